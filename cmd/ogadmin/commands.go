@@ -0,0 +1,741 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"oglike_server/internal/data"
+	"oglike_server/internal/game"
+	"oglike_server/internal/model"
+	"oglike_server/internal/snapshot"
+	"oglike_server/pkg/db"
+)
+
+// admin :
+// Regroups the proxies used by the commands to access
+// the data of the game.
+//
+// The `dryRun` defines whether the commands modifying
+// the DB should only describe the changes.
+//
+// The `dbase` defines the DB used by the commands which
+// can't be expressed through the proxies.
+//
+// The `og` defines the data model of the game.
+type admin struct {
+	dryRun   bool
+	dbase    *db.DB
+	universe data.UniverseProxy
+	players  data.PlayerProxy
+	planets  data.PlanetProxy
+	fleets   data.FleetProxy
+	admin    data.AdminProxy
+	og       game.Instance
+}
+
+// command :
+// Describes a command of the administration tool.
+//
+// The `help` defines a short description of the command.
+//
+// The `run` executes the command with its arguments.
+type command struct {
+	help string
+	run  func(a *admin, args []string) error
+}
+
+// ErrMissingArgument :
+// Used to indicate that a mandatory flag of a command was
+// not provided.
+var ErrMissingArgument = fmt.Errorf("missing mandatory argument")
+
+// ErrInvalidArgument :
+// Used to indicate that a flag of a command could not be
+// interpreted.
+var ErrInvalidArgument = fmt.Errorf("invalid argument")
+
+// commands :
+// Defines the commands available in the tool keyed by
+// their name.
+var commands = map[string]command{
+	"universes":        {"List the universes", listUniverses},
+	"create-universe":  {"Create a universe from a JSON file", createUniverse},
+	"update-universe":  {"Update the configuration of a universe from a JSON file", updateUniverse},
+	"players":          {"List the players of a universe", listPlayers},
+	"ban":              {"Ban a player or lift the ban", banPlayer},
+	"grant-resources":  {"Grant resources to a planet or a moon", grantResources},
+	"grant-ships":      {"Grant ships to a planet or a moon", grantShips},
+	"actions":          {"List the entries of the actions queue", listActions},
+	"complete-action":  {"Execute an entry of the actions queue right away", completeAction},
+	"purge-action":     {"Remove an entry from the actions queue", purgeAction},
+	"update-resources": {"Update the resources of all the planets of a universe", updateResources},
+	"export-player":    {"Export the full state of a player as JSON", exportPlayer},
+	"export-universe":  {"Export a universe to a JSON archive", exportUniverse},
+	"import-universe":  {"Import a universe from a JSON archive", importUniverse},
+}
+
+// playerState :
+// Describes the full state of a player as exported by
+// the `export-player` command.
+type playerState struct {
+	Player   game.Player    `json:"player"`
+	Planets  []game.Planet  `json:"planets"`
+	Moons    []game.Planet  `json:"moons"`
+	Fleets   []game.Fleet   `json:"fleets"`
+	Messages []game.Message `json:"messages"`
+}
+
+// display :
+// Used to display the input value as indented JSON on
+// the standard output.
+//
+// The `v` defines the value to display.
+//
+// Returns any error.
+func display(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+
+	return nil
+}
+
+// report :
+// Used to notify the outcome of a command modifying the
+// DB or the change that would be performed in case of a
+// dry run.
+//
+// The `a` defines the tool's data.
+//
+// The `format` defines the description of the change.
+//
+// The `args` defines the arguments of the description.
+func report(a *admin, format string, args ...interface{}) {
+	prefix := "Done:"
+	if a.dryRun {
+		prefix = "Dry run, would have done:"
+	}
+
+	fmt.Printf("%s %s\n", prefix, fmt.Sprintf(format, args...))
+}
+
+// required :
+// Used to verify that the input flag of a command has
+// been provided.
+//
+// The `name` defines the name of the flag.
+//
+// The `value` defines the value of the flag.
+//
+// Returns any error.
+func required(name string, value string) error {
+	if value == "" {
+		return fmt.Errorf("%v (flag: \"-%s\")", ErrMissingArgument, name)
+	}
+
+	return nil
+}
+
+// parseAmounts :
+// Used to interpret a list of amounts described as a
+// comma separated list of `name=amount` values.
+//
+// The `list` defines the list to interpret.
+//
+// Returns the amount associated to each name along with
+// any error.
+func parseAmounts(list string) (map[string]float64, error) {
+	amounts := make(map[string]float64)
+
+	for _, token := range strings.Split(list, ",") {
+		sep := strings.LastIndex(token, "=")
+		if sep < 0 {
+			return amounts, fmt.Errorf("%v (value: \"%s\")", ErrInvalidArgument, token)
+		}
+
+		name := strings.TrimSpace(token[:sep])
+		amount, err := strconv.ParseFloat(strings.TrimSpace(token[sep+1:]), 64)
+		if name == "" || err != nil {
+			return amounts, fmt.Errorf("%v (value: \"%s\")", ErrInvalidArgument, token)
+		}
+
+		amounts[name] += amount
+	}
+
+	return amounts, nil
+}
+
+// readUniverse :
+// Used to read the description of a universe from the
+// input file. The values are applied on top of the ones
+// already defined in the universe.
+//
+// The `file` defines the path to the file.
+//
+// The `uni` defines the universe to update.
+//
+// Returns any error.
+func readUniverse(file string, uni *game.Universe) error {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, uni)
+}
+
+// listUniverses :
+// Displays the universes registered in the DB.
+func listUniverses(a *admin, args []string) error {
+	fs := flag.NewFlagSet("universes", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	unis, err := a.universe.Universes([]db.Filter{})
+	if err != nil {
+		return err
+	}
+
+	return display(unis)
+}
+
+// createUniverse :
+// Creates a new universe from its JSON description.
+func createUniverse(a *admin, args []string) error {
+	fs := flag.NewFlagSet("create-universe", flag.ContinueOnError)
+	file := fs.String("file", "", "JSON file describing the universe")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("file", *file); err != nil {
+		return err
+	}
+
+	var uni game.Universe
+	if err := readUniverse(*file, &uni); err != nil {
+		return err
+	}
+
+	if a.dryRun {
+		report(a, "create universe \"%s\"", uni.Name)
+		return display(uni)
+	}
+
+	id, err := a.universe.Create(uni)
+	if err != nil {
+		return err
+	}
+
+	report(a, "created universe \"%s\" with id \"%s\"", uni.Name, id)
+
+	return nil
+}
+
+// updateUniverse :
+// Updates the configuration of a universe: the values
+// defined in the JSON file replace the current ones.
+func updateUniverse(a *admin, args []string) error {
+	fs := flag.NewFlagSet("update-universe", flag.ContinueOnError)
+	id := fs.String("universe", "", "Identifier of the universe")
+	file := fs.String("file", "", "JSON file with the values to update")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("universe", *id); err != nil {
+		return err
+	}
+	if err := required("file", *file); err != nil {
+		return err
+	}
+
+	uni, err := game.NewUniverseFromDB(*id, a.og)
+	if err != nil {
+		return err
+	}
+
+	if err := readUniverse(*file, &uni); err != nil {
+		return err
+	}
+	uni.ID = *id
+
+	if a.dryRun {
+		report(a, "update universe \"%s\"", uni.ID)
+		return display(uni)
+	}
+
+	_, err = a.universe.Update(uni)
+	if err != nil {
+		return err
+	}
+
+	report(a, "updated universe \"%s\"", uni.ID)
+
+	return nil
+}
+
+// listPlayers :
+// Displays the players of a universe.
+func listPlayers(a *admin, args []string) error {
+	fs := flag.NewFlagSet("players", flag.ContinueOnError)
+	uni := fs.String("universe", "", "Identifier of the universe")
+	name := fs.String("name", "", "Only display the player with this name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("universe", *uni); err != nil {
+		return err
+	}
+
+	filters := []db.Filter{
+		{
+			Key:    "universe",
+			Values: []interface{}{*uni},
+		},
+	}
+	if *name != "" {
+		filters = append(filters, db.Filter{Key: "name", Values: []interface{}{*name}})
+	}
+
+	players, err := a.players.Players(filters)
+	if err != nil {
+		return err
+	}
+
+	return display(players)
+}
+
+// banPlayer :
+// Bans a player from its universe or lifts the ban.
+func banPlayer(a *admin, args []string) error {
+	fs := flag.NewFlagSet("ban", flag.ContinueOnError)
+	id := fs.String("player", "", "Identifier of the player")
+	lift := fs.Bool("lift", false, "Lift the ban instead of banning the player")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("player", *id); err != nil {
+		return err
+	}
+
+	player, err := game.NewPlayerFromDB(*id, a.og)
+	if err != nil {
+		return err
+	}
+
+	action := "ban"
+	if *lift {
+		action = "lift ban of"
+	}
+
+	if !a.dryRun {
+		err = a.admin.Ban(player.ID, !*lift)
+		if err != nil {
+			return err
+		}
+	}
+
+	report(a, "%s player \"%s\" (%s)", action, player.Name, player.ID)
+
+	return nil
+}
+
+// grantResources :
+// Grants resources to a planet or a moon.
+func grantResources(a *admin, args []string) error {
+	fs := flag.NewFlagSet("grant-resources", flag.ContinueOnError)
+	planet := fs.String("planet", "", "Identifier of the planet or moon")
+	moon := fs.Bool("moon", false, "Whether the identifier refers to a moon")
+	list := fs.String("resources", "", "Comma separated list of resources as \"name=amount\"")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("planet", *planet); err != nil {
+		return err
+	}
+	if err := required("resources", *list); err != nil {
+		return err
+	}
+
+	amounts, err := parseAmounts(*list)
+	if err != nil {
+		return err
+	}
+
+	resources := make([]model.ResourceAmount, 0)
+	for name, amount := range amounts {
+		id, err := a.og.Resources.GetIDFromName(name)
+		if err != nil {
+			return fmt.Errorf("%v (resource: \"%s\")", err, name)
+		}
+
+		resources = append(resources, model.ResourceAmount{Resource: id, Amount: float32(amount)})
+	}
+
+	if !a.dryRun {
+		err = a.admin.GrantResources(*planet, *moon, resources)
+		if err != nil {
+			return err
+		}
+	}
+
+	report(a, "grant %s to %s \"%s\"", *list, kind(*moon), *planet)
+
+	return nil
+}
+
+// grantShips :
+// Grants ships to a planet or a moon.
+func grantShips(a *admin, args []string) error {
+	fs := flag.NewFlagSet("grant-ships", flag.ContinueOnError)
+	planet := fs.String("planet", "", "Identifier of the planet or moon")
+	moon := fs.Bool("moon", false, "Whether the identifier refers to a moon")
+	list := fs.String("ships", "", "Comma separated list of ships as \"name=amount\"")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("planet", *planet); err != nil {
+		return err
+	}
+	if err := required("ships", *list); err != nil {
+		return err
+	}
+
+	amounts, err := parseAmounts(*list)
+	if err != nil {
+		return err
+	}
+
+	ships := make([]data.ShipGrant, 0)
+	for name, amount := range amounts {
+		id, err := a.og.Ships.GetIDFromName(name)
+		if err != nil {
+			return fmt.Errorf("%v (ship: \"%s\")", err, name)
+		}
+
+		ships = append(ships, data.ShipGrant{Ship: id, Amount: int(amount)})
+	}
+
+	if !a.dryRun {
+		err = a.admin.GrantShips(*planet, *moon, ships)
+		if err != nil {
+			return err
+		}
+	}
+
+	report(a, "grant %s to %s \"%s\"", *list, kind(*moon), *planet)
+
+	return nil
+}
+
+// listActions :
+// Displays the entries of the actions queue.
+func listActions(a *admin, args []string) error {
+	fs := flag.NewFlagSet("actions", flag.ContinueOnError)
+	overdue := fs.Duration("overdue", 0, "Only display entries completed for at least this duration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	actions, err := a.admin.QueuedActions(*overdue)
+	if err != nil {
+		return err
+	}
+
+	return display(actions)
+}
+
+// queuedAction :
+// Used to retrieve the entry of the actions queue with
+// the input identifier.
+//
+// The `a` defines the tool's data.
+//
+// The `id` defines the identifier of the action.
+//
+// Returns the entry along with any error.
+func queuedAction(a *admin, id string) (game.QueuedAction, error) {
+	filters := []db.Filter{
+		{
+			Key:    "action",
+			Values: []interface{}{id},
+		},
+	}
+
+	actions, err := game.NewQueuedActionsFromDB(filters, a.og)
+	if err != nil {
+		return game.QueuedAction{}, err
+	}
+	if len(actions) == 0 {
+		return game.QueuedAction{}, game.ErrActionNotQueued
+	}
+
+	return actions[0], nil
+}
+
+// completeAction :
+// Executes an entry of the actions queue right away.
+func completeAction(a *admin, args []string) error {
+	fs := flag.NewFlagSet("complete-action", flag.ContinueOnError)
+	id := fs.String("action", "", "Identifier of the action")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("action", *id); err != nil {
+		return err
+	}
+
+	action, err := queuedAction(a, *id)
+	if err != nil {
+		return err
+	}
+
+	if !a.dryRun {
+		err = a.admin.CompleteAction(action.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	report(a, "complete %s action \"%s\" (due %s)", action.Kind, action.ID, action.CompletionTime.Format(time.RFC3339))
+
+	return nil
+}
+
+// purgeAction :
+// Removes an entry from the actions queue.
+func purgeAction(a *admin, args []string) error {
+	fs := flag.NewFlagSet("purge-action", flag.ContinueOnError)
+	id := fs.String("action", "", "Identifier of the action")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("action", *id); err != nil {
+		return err
+	}
+
+	action, err := queuedAction(a, *id)
+	if err != nil {
+		return err
+	}
+
+	if !a.dryRun {
+		err = a.admin.PurgeAction(action.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	report(a, "purge %s action \"%s\" (due %s)", action.Kind, action.ID, action.CompletionTime.Format(time.RFC3339))
+
+	return nil
+}
+
+// updateResources :
+// Updates the resources of all the planets of a universe.
+func updateResources(a *admin, args []string) error {
+	fs := flag.NewFlagSet("update-resources", flag.ContinueOnError)
+	uni := fs.String("universe", "", "Identifier of the universe")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("universe", *uni); err != nil {
+		return err
+	}
+
+	planets, err := a.admin.PlanetsOf(*uni)
+	if err != nil {
+		return err
+	}
+
+	count := len(planets)
+
+	if !a.dryRun {
+		count, err = a.admin.UpdateResources(planets)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Updated %d/%d planet(s)\n", count, len(planets))
+			return err
+		}
+	}
+
+	report(a, "update resources of %d planet(s) of universe \"%s\"", count, *uni)
+
+	return nil
+}
+
+// exportPlayer :
+// Displays the full state of a player as JSON.
+func exportPlayer(a *admin, args []string) error {
+	fs := flag.NewFlagSet("export-player", flag.ContinueOnError)
+	id := fs.String("player", "", "Identifier of the player")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("player", *id); err != nil {
+		return err
+	}
+
+	var state playerState
+	var err error
+
+	state.Player, err = game.NewPlayerFromDB(*id, a.og)
+	if err != nil {
+		return err
+	}
+
+	byPlayer := func(key string) []db.Filter {
+		return []db.Filter{
+			{
+				Key:    key,
+				Values: []interface{}{*id},
+			},
+		}
+	}
+
+	state.Planets, err = a.planets.Planets(byPlayer("p.player"))
+	if err != nil {
+		return err
+	}
+
+	state.Moons, err = a.planets.Moons(byPlayer("p.player"))
+	if err != nil {
+		return err
+	}
+
+	state.Fleets, err = a.fleets.Fleets(byPlayer("f.player"))
+	if err != nil {
+		return err
+	}
+
+	state.Messages, err = a.players.Messages(byPlayer("mp.player"))
+	if err != nil {
+		return err
+	}
+
+	return display(&state)
+}
+
+// exportUniverse :
+// Saves all the data of a universe to a JSON archive.
+func exportUniverse(a *admin, args []string) error {
+	fs := flag.NewFlagSet("export-universe", flag.ContinueOnError)
+	uni := fs.String("universe", "", "Identifier of the universe")
+	file := fs.String("output", "", "File to write the archive to (standard output if empty)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("universe", *uni); err != nil {
+		return err
+	}
+
+	archive, err := snapshot.Export(a.dbase, *uni)
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		return display(archive)
+	}
+
+	out, err := json.Marshal(archive)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(*file, out, 0644)
+	if err != nil {
+		return err
+	}
+
+	rows := 0
+	for _, count := range archive.Rows() {
+		rows += count
+	}
+
+	fmt.Printf("Exported universe \"%s\" (%d row(s)) to \"%s\"\n", *uni, rows, *file)
+
+	return nil
+}
+
+// importUniverse :
+// Restores a universe from a JSON archive.
+func importUniverse(a *admin, args []string) error {
+	fs := flag.NewFlagSet("import-universe", flag.ContinueOnError)
+	file := fs.String("file", "", "File containing the archive")
+	remap := fs.Bool("remap", false, "Import the universe with new identifiers")
+	name := fs.String("name", "", "Name of the imported universe (name of the archive if empty)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required("file", *file); err != nil {
+		return err
+	}
+
+	raw, err := ioutil.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+
+	// Keep numbers as they are to not lose precision.
+	var archive snapshot.Archive
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	err = dec.Decode(&archive)
+	if err != nil {
+		return err
+	}
+
+	rows := 0
+	for _, count := range archive.Rows() {
+		rows += count
+	}
+
+	opts := snapshot.Options{
+		Remap:  *remap,
+		Name:   *name,
+		DryRun: a.dryRun,
+	}
+
+	uni, err := snapshot.Import(a.dbase, archive, opts)
+	if err != nil {
+		return err
+	}
+
+	report(a, "import universe \"%s\" (%d row(s)) as \"%s\"", archive.Universe, rows, uni)
+
+	return nil
+}
+
+// kind :
+// Returns a description of the location targeted by a
+// command.
+func kind(moon bool) string {
+	if moon {
+		return "moon"
+	}
+
+	return "planet"
+}
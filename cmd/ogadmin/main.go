@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sort"
+
+	"oglike_server/internal/data"
+	"oglike_server/internal/game"
+	"oglike_server/pkg/arguments"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+
+	"github.com/spf13/viper"
+)
+
+// usage :
+// Displays the usage of the administration tool along with the
+// list of available commands.
+func usage() {
+	fmt.Println("Usage:")
+	fmt.Println("./ogadmin -config=[file] [-dry-run] [command] [flags]")
+	fmt.Println("Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("  %-18s %s\n", name, commands[name].help)
+	}
+
+	fmt.Println("Use ./ogadmin [command] -h to display the flags of a command")
+}
+
+// main :
+// Performs the requested administrative command on the DB.
+func main() {
+	// Define common flags.
+	help := flag.Bool("h", false, "Print usage")
+	conf := flag.String("config", "", "Configuration file to customize app behavior (development/production)")
+	dryRun := flag.Bool("dry-run", false, "Describe the changes without applying them")
+
+	// Parse flags.
+	flag.Parse()
+
+	cmd, ok := commands[flag.Arg(0)]
+	if *help || !ok {
+		usage()
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Parse configuration. Logs are written to the standard
+	// error so that the output of the commands can be piped.
+	metadata := arguments.Parse(*conf)
+	viper.Set("Logger.Output", "stderr")
+
+	log := logger.NewStdLogger(metadata.InstanceID, metadata.PublicIPv4)
+
+	err := run(cmd, flag.Args()[1:], *dryRun, log)
+
+	log.Release()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed (err: %v)\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+// run :
+// Used to load the data model from the DB and to execute
+// the input command.
+//
+// The `cmd` defines the command to execute.
+//
+// The `args` defines the arguments of the command.
+//
+// The `dryRun` defines whether the changes should only
+// be described.
+//
+// The `log` allows to notify information and errors.
+//
+// Returns any error.
+func run(cmd command, args []string, dryRun bool, log logger.Logger) (err error) {
+	// Convert crashes into errors so that the logger is
+	// released properly.
+	defer func() {
+		if r := recover(); r != nil {
+			log.Trace(logger.Fatal, "admin", fmt.Sprintf("Command crashed after error: %v (stack: %s)", r, string(debug.Stack())))
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	DB := db.NewPool(log)
+	proxy := db.NewProxy(DB)
+
+	// Load the data model: the rule set is not synchronized
+	// as this is the responsibility of the server.
+	m := game.NewModel(log)
+	for _, module := range m.Modules() {
+		err = module.Init(proxy, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	og := game.NewInstance(proxy, log)
+	og.Model = m
+	og.RuleSet = "classic"
+	if viper.IsSet("Server.RuleSet") {
+		og.RuleSet = viper.GetString("Server.RuleSet")
+	}
+
+	a := admin{
+		dryRun:   dryRun,
+		dbase:    DB,
+		universe: data.NewUniverseProxy(og, log),
+		players:  data.NewPlayerProxy(og, log),
+		planets:  data.NewPlanetProxy(og, log),
+		fleets:   data.NewFleetProxy(og, log),
+		admin:    data.NewAdminProxy(og, log),
+		og:       og,
+	}
+
+	return cmd.run(&a, args)
+}
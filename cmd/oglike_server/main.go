@@ -9,9 +9,11 @@ import (
 	// proved helpful when trying to determine which syntax to adopt to use packages defined locally.
 
 	"oglike_server/internal/routes"
+	ogdb "oglike_server/og_db"
 	"oglike_server/pkg/arguments"
 	"oglike_server/pkg/db"
 	"oglike_server/pkg/logger"
+	"oglike_server/pkg/migrate"
 )
 
 // TODO: Use the token mechanism to make sure that a client has access
@@ -24,6 +26,30 @@ import (
 func usage() {
 	fmt.Println("Usage:")
 	fmt.Println("./oglike_server -config=[file] for configuration file to use (development/production)")
+	fmt.Println("./oglike_server -migrate to apply the pending migrations to the DB before starting")
+	fmt.Println("./oglike_server -config=[file] migrate to only apply the pending migrations to the DB")
+}
+
+// migrateDB :
+// Applies the migrations embedded in the server which are
+// not yet applied to the DB. A panic is issued in case the
+// migrations can not be applied.
+//
+// The `dbase` defines the DB to migrate.
+//
+// The `log` allows to notify information and errors.
+func migrateDB(dbase *db.DB, log logger.Logger) {
+	migrations, err := migrate.Load(ogdb.Migrations, ogdb.MigrationsDir)
+	if err != nil {
+		panic(fmt.Errorf("Unable to load migrations (err: %v)", err))
+	}
+
+	count, err := migrate.NewMigrator(dbase, migrations, log).Up()
+	if err != nil {
+		panic(fmt.Errorf("Unable to migrate DB after applying %d migration(s) (err: %v)", count, err))
+	}
+
+	log.Trace(logger.Notice, "main", fmt.Sprintf("Applied %d migration(s), schema is at version %d", count, migrate.Latest(migrations)))
 }
 
 // main :
@@ -32,6 +58,7 @@ func main() {
 	// Define common flags.
 	help := flag.Bool("h", false, "Print usage")
 	conf := flag.String("config", "", "Configuration file to customize app behavior (development/production)")
+	migrateFirst := flag.Bool("migrate", false, "Apply pending migrations to the DB before starting")
 
 	// Parse flags.
 	flag.Parse()
@@ -62,13 +89,27 @@ func main() {
 		log.Release()
 	}()
 
-	// Create the server and set it up.
+	// Create the server and set it up. The `migrate`
+	// command only applies the migrations to the DB.
 	DB := db.NewPool(log)
+
+	migrateOnly := flag.Arg(0) == "migrate"
+	if *migrateFirst || migrateOnly {
+		migrateDB(DB, log)
+	}
+	if migrateOnly {
+		return
+	}
+
 	proxy := db.NewProxy(DB)
 
-	server := routes.NewServer(metadata.Port, proxy, log)
+	server, err := routes.NewServer(metadata.Port, proxy, log)
+	if err != nil {
+		log.Trace(logger.Fatal, "main", fmt.Sprintf("%v", err))
+		return
+	}
 
-	err := server.Serve()
+	err = server.Serve()
 	if err != nil {
 		panic(fmt.Errorf("Unexpected error while listening to port %d (err: %v)", metadata.Port, err))
 	}
@@ -1,6 +1,6 @@
 module oglike_server
 
-go 1.13
+go 1.16
 
 require (
 	github.com/cockroachdb/apd v1.1.0 // indirect
@@ -12,4 +12,5 @@ require (
 	github.com/lib/pq v1.3.0 // indirect
 	github.com/shopspring/decimal v0.0.0-20200227202807-02e2044944cc // indirect
 	github.com/spf13/viper v1.6.2
+	gopkg.in/yaml.v2 v2.2.4
 )
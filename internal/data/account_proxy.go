@@ -34,6 +34,21 @@ func NewAccountProxy(data game.Instance, log logger.Logger) AccountProxy {
 	}
 }
 
+// For :
+// Returns a copy of this proxy performing its logs with
+// the input logger. It allows to attach the logs of the
+// proxy to the request being served.
+//
+// The `log` defines the logger to use.
+//
+// Returns the copy of the proxy.
+func (p *AccountProxy) For(log logger.Logger) *AccountProxy {
+	c := *p
+	c.commonProxy = c.withLogger(log)
+
+	return &c
+}
+
 // Accounts :
 // Return a list of accounts registered so far in all the
 // values defined in the DB. The input filters might help
@@ -58,7 +73,7 @@ func (p *AccountProxy) Accounts(filters []db.Filter) ([]game.Account, error) {
 		Filters: filters,
 	}
 
-	dbRes, err := p.data.Proxy.FetchFromDB(query)
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
 
 	// Check for errors.
 	if err != nil {
@@ -92,7 +107,7 @@ func (p *AccountProxy) Accounts(filters []db.Filter) ([]game.Account, error) {
 	accounts := make([]game.Account, 0)
 
 	for _, ID = range IDs {
-		acc, err := game.NewAccountFromDB(ID, p.data)
+		acc, err := game.NewAccountFromDB(ID, p.data())
 
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch account \"%s\" data from DB (err: %v)", ID, err))
@@ -125,7 +140,7 @@ func (p *AccountProxy) Create(acc game.Account) (string, error) {
 		acc.ID = uuid.New().String()
 	}
 
-	err := acc.SaveToDB(p.data.Proxy)
+	err := acc.SaveToDB(p.data().Proxy)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not create account \"%s\" (err: %v)", acc.Name, err))
 		return acc.ID, err
@@ -149,7 +164,7 @@ func (p *AccountProxy) Create(acc game.Account) (string, error) {
 // any errors.
 func (p *AccountProxy) Update(acc game.Account) (string, error) {
 	// Update the account in the DB.
-	err := acc.UpdateInDB(p.data.Proxy)
+	err := acc.UpdateInDB(p.data().Proxy)
 
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not update account \"%s\" (err: %v)", acc.ID, err))
@@ -37,6 +37,21 @@ func NewActionProxy(data game.Instance, log logger.Logger) ActionProxy {
 	}
 }
 
+// For :
+// Returns a copy of this proxy performing its logs with
+// the input logger. It allows to attach the logs of the
+// proxy to the request being served.
+//
+// The `log` defines the logger to use.
+//
+// Returns the copy of the proxy.
+func (p *ActionProxy) For(log logger.Logger) *ActionProxy {
+	c := *p
+	c.commonProxy = c.withLogger(log)
+
+	return &c
+}
+
 // CreateBuildingAction :
 // Used to perform the creation of the building upgrade
 // action described by the input data to the DB. In case
@@ -52,6 +67,48 @@ func NewActionProxy(data game.Instance, log logger.Logger) ActionProxy {
 // `nil`. It also provides the identifier of the action
 // that was created by this method.
 func (p *ActionProxy) CreateBuildingAction(a game.BuildingAction) (string, error) {
+	err := p.validateBuildingAction(&a)
+	if err != nil {
+		return a.ID, err
+	}
+
+	err = a.SaveToDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not create building action on \"%s\" (err: %v)", a.Planet, err))
+		return a.ID, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Registered action to upgrade \"%s\" to level %d on \"%s\"", a.Element, a.DesiredLevel, a.Planet))
+
+	// All is well.
+	return a.ID, nil
+}
+
+// PreviewBuildingAction :
+// Used to perform the validation of the building
+// action described by the input data without
+// registering it in the DB. The result of the validation is returned
+// along with the costs and completion time computed
+// for the action.
+//
+// The `a` describes the action to validate.
+//
+// Returns the preview of the action.
+func (p *ActionProxy) PreviewBuildingAction(a game.BuildingAction) game.ActionPreview {
+	err := p.validateBuildingAction(&a)
+
+	return a.Preview(err)
+}
+
+// validateBuildingAction :
+// Used to perform the consolidation and validation of
+// the input building action against the data of its
+// parent planet. The action is directly modified.
+//
+// The `a` defines the action to validate.
+//
+// Returns any error.
+func (p *ActionProxy) validateBuildingAction(a *game.BuildingAction) error {
 	// Assign a valid identifier if this is not already the case.
 	if a.ID == "" {
 		a.ID = uuid.New().String()
@@ -59,51 +116,42 @@ func (p *ActionProxy) CreateBuildingAction(a game.BuildingAction) (string, error
 
 	// Fetch the planet related to this action and use it
 	// as read write access.
-	planet, err := game.NewPlanetFromDB(a.Planet, p.data)
+	planet, err := game.NewPlanetFromDB(a.Planet, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch planet related to building action (err: %v)", err))
-		return a.ID, game.ErrInvalidPlanetForAction
+		return game.ErrInvalidPlanetForAction
 	}
 
 	// Fetch multipliers to use when computing effects of
 	// actions.
-	uni, err := game.UniverseOfPlanet(planet.ID, p.data)
+	uni, err := game.UniverseOfPlanet(planet.ID, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch universe of planet \"%s\" for building action (err: %v)", planet.ID, err))
-		return a.ID, game.ErrInvalidPlanetForAction
+		return game.ErrInvalidPlanetForAction
 	}
 
-	mul, err := game.NewMultipliersFromDB(uni, p.data)
+	mul, err := game.NewMultipliersFromDB(uni, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch multipliers related to building action (err: %v)", err))
-		return a.ID, err
+		return err
 	}
 
 	// Consolidate the action (typically completion time
 	// and effects).
-	err = a.ConsolidateEffects(p.data, &planet, mul.EconomySpeedup)
+	err = a.ConsolidateEffects(p.data(), &planet, mul.EconomySpeedup)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not consolidate building action effects (err: %v)", err))
-		return a.ID, err
+		return err
 	}
 
 	// Validate the action's data against its parent planet
-	err = a.Validate(p.data, &planet, mul.Economy)
+	err = a.Validate(p.data(), &planet, mul.Economy)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Cannot perform building action for \"%s\" on \"%s\" (err: %v)", a.Element, planet.ID, err))
-		return a.ID, err
+		return err
 	}
 
-	err = a.SaveToDB(p.data.Proxy)
-	if err != nil {
-		p.trace(logger.Error, fmt.Sprintf("Could not create building action on \"%s\" (err: %v)", planet.ID, err))
-		return a.ID, err
-	}
-
-	p.trace(logger.Notice, fmt.Sprintf("Registered action to upgrade \"%s\" to level %d on \"%s\"", a.Element, a.DesiredLevel, a.Planet))
-
-	// All is well.
-	return a.ID, nil
+	return nil
 }
 
 // CreateTechnologyAction :
@@ -121,6 +169,47 @@ func (p *ActionProxy) CreateBuildingAction(a game.BuildingAction) (string, error
 // `nil`. It also indicates the identifier of the action
 // that was created.
 func (p *ActionProxy) CreateTechnologyAction(a game.TechnologyAction) (string, error) {
+	err := p.validateTechnologyAction(&a)
+	if err != nil {
+		return a.ID, err
+	}
+
+	err = a.SaveToDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not create technology action on \"%s\" (err: %v)", a.Planet, err))
+		return a.ID, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Registered action to upgrade \"%s\" to level %d on \"%s\"", a.Element, a.DesiredLevel, a.Planet))
+
+	return a.ID, err
+}
+
+// PreviewTechnologyAction :
+// Used to perform the validation of the technology
+// action described by the input data without
+// registering it in the DB. The result of the validation is returned
+// along with the costs and completion time computed
+// for the action.
+//
+// The `a` describes the action to validate.
+//
+// Returns the preview of the action.
+func (p *ActionProxy) PreviewTechnologyAction(a game.TechnologyAction) game.ActionPreview {
+	err := p.validateTechnologyAction(&a)
+
+	return a.Preview(err)
+}
+
+// validateTechnologyAction :
+// Used to perform the consolidation and validation of
+// the input technology action against the data of its
+// parent planet. The action is directly modified.
+//
+// The `a` defines the action to validate.
+//
+// Returns any error.
+func (p *ActionProxy) validateTechnologyAction(a *game.TechnologyAction) error {
 	// Assign a valid identifier if this is not already the case.
 	if a.ID == "" {
 		a.ID = uuid.New().String()
@@ -128,10 +217,10 @@ func (p *ActionProxy) CreateTechnologyAction(a game.TechnologyAction) (string, e
 
 	// Fetch the planet related to this action and use it
 	// as read write access.
-	planet, err := game.NewPlanetFromDB(a.Planet, p.data)
+	planet, err := game.NewPlanetFromDB(a.Planet, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch planet related to technology action (err: %v)", err))
-		return a.ID, game.ErrInvalidPlanetForAction
+		return game.ErrInvalidPlanetForAction
 	}
 
 	// Force the action to be associated to this player.
@@ -139,42 +228,34 @@ func (p *ActionProxy) CreateTechnologyAction(a game.TechnologyAction) (string, e
 
 	// Fetch multipliers to use when computing effects of
 	// actions.
-	uni, err := game.UniverseOfPlanet(planet.ID, p.data)
+	uni, err := game.UniverseOfPlanet(planet.ID, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch universe of planet \"%s\" for technology action (err: %v)", planet.ID, err))
-		return a.ID, game.ErrInvalidPlanetForAction
+		return game.ErrInvalidPlanetForAction
 	}
 
-	mul, err := game.NewMultipliersFromDB(uni, p.data)
+	mul, err := game.NewMultipliersFromDB(uni, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch multipliers related to technology action (err: %v)", err))
-		return a.ID, err
+		return err
 	}
 
 	// Consolidate the action (typically number of points that
 	// are granted upon completing this action).
-	err = a.ConsolidateEffects(p.data, &planet)
+	err = a.ConsolidateEffects(p.data(), &planet)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not consolidate building action effects (err: %v)", err))
-		return a.ID, err
+		return err
 	}
 
 	// Validate the action's data against its parent planet
-	err = a.Validate(p.data, &planet, mul.Research)
+	err = a.Validate(p.data(), &planet, mul.Research)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Cannot perform technology action for \"%s\" on \"%s\" (err: %v)", a.Element, planet.ID, err))
-		return a.ID, err
+		return err
 	}
 
-	err = a.SaveToDB(p.data.Proxy)
-	if err != nil {
-		p.trace(logger.Error, fmt.Sprintf("Could not create technology action on \"%s\" (err: %v)", planet.ID, err))
-		return a.ID, err
-	}
-
-	p.trace(logger.Notice, fmt.Sprintf("Registered action to upgrade \"%s\" to level %d on \"%s\"", a.Element, a.DesiredLevel, a.Planet))
-
-	return a.ID, err
+	return nil
 }
 
 // CreateShipAction :
@@ -192,6 +273,47 @@ func (p *ActionProxy) CreateTechnologyAction(a game.TechnologyAction) (string, e
 // `nil`. It also indicates the identifier of the action
 // that was created.
 func (p *ActionProxy) CreateShipAction(a game.ShipAction) (string, error) {
+	err := p.validateShipAction(&a)
+	if err != nil {
+		return a.ID, err
+	}
+
+	err = a.SaveToDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not create ship action on \"%s\" (err: %v)", a.Planet, err))
+		return a.ID, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Registered action to create %d \"%s\" on \"%s\"", a.Remaining, a.Element, a.Planet))
+
+	return a.ID, err
+}
+
+// PreviewShipAction :
+// Used to perform the validation of the ship
+// action described by the input data without
+// registering it in the DB. The result of the validation is returned
+// along with the costs and completion time computed
+// for the action.
+//
+// The `a` describes the action to validate.
+//
+// Returns the preview of the action.
+func (p *ActionProxy) PreviewShipAction(a game.ShipAction) game.ActionPreview {
+	err := p.validateShipAction(&a)
+
+	return a.Preview(err)
+}
+
+// validateShipAction :
+// Used to perform the consolidation and validation of
+// the input ship action against the data of its
+// parent planet. The action is directly modified.
+//
+// The `a` defines the action to validate.
+//
+// Returns any error.
+func (p *ActionProxy) validateShipAction(a *game.ShipAction) error {
 	// Assign a valid identifier if this is not already the case.
 	if a.ID == "" {
 		a.ID = uuid.New().String()
@@ -199,10 +321,10 @@ func (p *ActionProxy) CreateShipAction(a game.ShipAction) (string, error) {
 
 	// Fetch the planet related to this action and use it
 	// as read write access.
-	planet, err := game.NewPlanetFromDB(a.Planet, p.data)
+	planet, err := game.NewPlanetFromDB(a.Planet, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch planet related to ship action (err: %v)", err))
-		return a.ID, game.ErrInvalidPlanetForAction
+		return game.ErrInvalidPlanetForAction
 	}
 
 	// Consolidate the completion time for this action and
@@ -211,34 +333,26 @@ func (p *ActionProxy) CreateShipAction(a game.ShipAction) (string, error) {
 
 	// Fetch multipliers to use when computing effects of
 	// actions.
-	uni, err := game.UniverseOfPlanet(planet.ID, p.data)
+	uni, err := game.UniverseOfPlanet(planet.ID, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch universe of planet \"%s\" for ship action (err: %v)", planet.ID, err))
-		return a.ID, game.ErrInvalidPlanetForAction
+		return game.ErrInvalidPlanetForAction
 	}
 
-	mul, err := game.NewMultipliersFromDB(uni, p.data)
+	mul, err := game.NewMultipliersFromDB(uni, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch multipliers related to ship action (err: %v)", err))
-		return a.ID, err
+		return err
 	}
 
 	// Validate the action's data against its parent planet
-	err = a.Validate(p.data, &planet, mul.Economy)
+	err = a.Validate(p.data(), &planet, mul.Economy)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Cannot perform ship action for \"%s\" on \"%s\" (err: %v)", a.Element, planet.ID, err))
-		return a.ID, err
+		return err
 	}
 
-	err = a.SaveToDB(p.data.Proxy)
-	if err != nil {
-		p.trace(logger.Error, fmt.Sprintf("Could not create ship action on \"%s\" (err: %v)", planet.ID, err))
-		return a.ID, err
-	}
-
-	p.trace(logger.Notice, fmt.Sprintf("Registered action to create %d \"%s\" on \"%s\"", a.Remaining, a.Element, a.Planet))
-
-	return a.ID, err
+	return nil
 }
 
 // CreateDefenseAction :
@@ -256,6 +370,47 @@ func (p *ActionProxy) CreateShipAction(a game.ShipAction) (string, error) {
 // `nil`. It also indicates the identifier of the action
 // that was created.
 func (p *ActionProxy) CreateDefenseAction(a game.DefenseAction) (string, error) {
+	err := p.validateDefenseAction(&a)
+	if err != nil {
+		return a.ID, err
+	}
+
+	err = a.SaveToDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not create defense action on \"%s\" (err: %v)", a.Planet, err))
+		return a.ID, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Registered action to create %d \"%s\" on \"%s\"", a.Remaining, a.Element, a.Planet))
+
+	return a.ID, err
+}
+
+// PreviewDefenseAction :
+// Used to perform the validation of the defense
+// action described by the input data without
+// registering it in the DB. The result of the validation is returned
+// along with the costs and completion time computed
+// for the action.
+//
+// The `a` describes the action to validate.
+//
+// Returns the preview of the action.
+func (p *ActionProxy) PreviewDefenseAction(a game.DefenseAction) game.ActionPreview {
+	err := p.validateDefenseAction(&a)
+
+	return a.Preview(err)
+}
+
+// validateDefenseAction :
+// Used to perform the consolidation and validation of
+// the input defense action against the data of its
+// parent planet. The action is directly modified.
+//
+// The `a` defines the action to validate.
+//
+// Returns any error.
+func (p *ActionProxy) validateDefenseAction(a *game.DefenseAction) error {
 	// Assign a valid identifier if this is not already the case.
 	if a.ID == "" {
 		a.ID = uuid.New().String()
@@ -263,10 +418,10 @@ func (p *ActionProxy) CreateDefenseAction(a game.DefenseAction) (string, error)
 
 	// Fetch the planet related to this action and use it
 	// as read write access.
-	planet, err := game.NewPlanetFromDB(a.Planet, p.data)
+	planet, err := game.NewPlanetFromDB(a.Planet, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch planet related to defense action (err: %v)", err))
-		return a.ID, game.ErrInvalidPlanetForAction
+		return game.ErrInvalidPlanetForAction
 	}
 
 	// Consolidate the completion time for this action and
@@ -275,32 +430,24 @@ func (p *ActionProxy) CreateDefenseAction(a game.DefenseAction) (string, error)
 
 	// Fetch multipliers to use when computing effects of
 	// actions.
-	uni, err := game.UniverseOfPlanet(planet.ID, p.data)
+	uni, err := game.UniverseOfPlanet(planet.ID, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch universe of planet \"%s\" for defense action (err: %v)", planet.ID, err))
-		return a.ID, game.ErrInvalidPlanetForAction
+		return game.ErrInvalidPlanetForAction
 	}
 
-	mul, err := game.NewMultipliersFromDB(uni, p.data)
+	mul, err := game.NewMultipliersFromDB(uni, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not fetch multipliers related to defense action (err: %v)", err))
-		return a.ID, err
+		return err
 	}
 
 	// Validate the action's data against its parent planet
-	err = a.Validate(p.data, &planet, mul.Economy)
+	err = a.Validate(p.data(), &planet, mul.Economy)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Cannot perform defense action for \"%s\" on \"%s\" (err: %v)", a.Element, planet.ID, err))
-		return a.ID, err
+		return err
 	}
 
-	err = a.SaveToDB(p.data.Proxy)
-	if err != nil {
-		p.trace(logger.Error, fmt.Sprintf("Could not create defense action on \"%s\" (err: %v)", planet.ID, err))
-		return a.ID, err
-	}
-
-	p.trace(logger.Notice, fmt.Sprintf("Registered action to create %d \"%s\" on \"%s\"", a.Remaining, a.Element, a.Planet))
-
-	return a.ID, err
+	return nil
 }
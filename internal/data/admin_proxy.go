@@ -0,0 +1,342 @@
+package data
+
+import (
+	"fmt"
+	"oglike_server/internal/game"
+	"oglike_server/internal/model"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+	"time"
+)
+
+// AdminProxy :
+// Intended as a wrapper to perform administrative tasks
+// on the data of the game. Contrary to the other proxies
+// these operations are not meant to be triggered by the
+// players but by the administrators of the server, for
+// example to handle support cases or to fix some data
+// that got corrupted.
+type AdminProxy struct {
+	commonProxy
+}
+
+// NewAdminProxy :
+// Create a new proxy allowing to perform administrative
+// tasks on the data of the game.
+//
+// The `data` defines the data model to use to fetch
+// information and verify requests.
+//
+// The `log` allows to notify errors and information.
+//
+// Returns the created proxy.
+func NewAdminProxy(data game.Instance, log logger.Logger) AdminProxy {
+	return AdminProxy{
+		commonProxy: newCommonProxy(data, log, "admin"),
+	}
+}
+
+// Ban :
+// Used to ban the input player from its universe or to
+// lift the ban of the player. A banned player can not
+// register new actions nor send fleets.
+//
+// The `player` defines the identifier of the player.
+//
+// The `banned` defines whether the player is banned.
+//
+// Returns any error.
+func (p *AdminProxy) Ban(player string, banned bool) error {
+	query := db.InsertReq{
+		Script: "ban_player",
+		Args: []interface{}{
+			player,
+			banned,
+		},
+		SkipReturn: true,
+	}
+
+	err := p.data().Proxy.InsertToDB(query)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not update ban of player \"%s\" (err: %v)", player, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Updated ban of player \"%s\" to %t", player, banned))
+
+	return nil
+}
+
+// GrantResources :
+// Used to add resources to the input planet or moon.
+// Negative amounts allow to remove resources. The
+// production of the planet is accounted for before
+// the resources are granted.
+//
+// The `planet` defines the identifier of the planet
+// or moon.
+//
+// The `moon` defines whether the `planet` refers to
+// a moon.
+//
+// The `resources` defines the amount of each resource
+// to grant. Each resource is referenced by its id.
+//
+// Returns any error.
+func (p *AdminProxy) GrantResources(planet string, moon bool, resources []model.ResourceAmount) error {
+	for _, r := range resources {
+		_, err := p.data().Resources.GetResourceFromID(r.Resource)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := db.InsertReq{
+		Script: "grant_resources",
+		Args: []interface{}{
+			planet,
+			kindOf(moon),
+			resources,
+		},
+		SkipReturn: true,
+	}
+
+	err := p.data().Proxy.InsertToDB(query)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not grant resources to \"%s\" (err: %v)", planet, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Granted %d resource(s) to \"%s\"", len(resources), planet))
+
+	return nil
+}
+
+// ShipGrant :
+// Describes an amount of ships granted to a planet or a
+// moon by an administrator.
+//
+// The `Ship` defines the identifier of the ship.
+//
+// The `Amount` defines the number of ships granted. A
+// negative value allows to remove ships.
+type ShipGrant struct {
+	Ship   string `json:"ship"`
+	Amount int    `json:"amount"`
+}
+
+// GrantShips :
+// Used to add ships to the input planet or moon. Note
+// that the points of the player are not updated.
+//
+// The `planet` defines the identifier of the planet
+// or moon.
+//
+// The `moon` defines whether the `planet` refers to
+// a moon.
+//
+// The `ships` defines the number of each ship to grant.
+//
+// Returns any error.
+func (p *AdminProxy) GrantShips(planet string, moon bool, ships []ShipGrant) error {
+	for _, s := range ships {
+		_, err := p.data().Ships.GetShipFromID(s.Ship)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := db.InsertReq{
+		Script: "grant_ships",
+		Args: []interface{}{
+			planet,
+			kindOf(moon),
+			ships,
+		},
+		SkipReturn: true,
+	}
+
+	err := p.data().Proxy.InsertToDB(query)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not grant ships to \"%s\" (err: %v)", planet, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Granted %d ship(s) to \"%s\"", len(ships), planet))
+
+	return nil
+}
+
+// QueuedActions :
+// Used to fetch the entries of the actions queue. It
+// is possible to only retrieve the entries which are
+// overdue, i.e. whose completion time is older than
+// the input duration.
+//
+// The `overdue` defines the minimum delay since the
+// completion time of the entries to fetch. A value of
+// `0` fetches all the entries.
+//
+// Returns the entries along with any error.
+func (p *AdminProxy) QueuedActions(overdue time.Duration) ([]game.QueuedAction, error) {
+	filters := make([]db.Filter, 0)
+
+	if overdue > 0 {
+		filters = append(
+			filters,
+			db.Filter{
+				Key:      "completion_time",
+				Values:   []interface{}{time.Now().Add(-overdue)},
+				Operator: db.LessThan,
+			},
+		)
+	}
+
+	actions, err := game.NewQueuedActionsFromDB(filters, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not fetch actions queue (err: %v)", err))
+	}
+
+	return actions, err
+}
+
+// CompleteAction :
+// Used to execute the input action of the actions queue
+// right away no matter its completion time.
+//
+// The `action` defines the identifier of the action.
+//
+// Returns any error.
+func (p *AdminProxy) CompleteAction(action string) error {
+	err := p.data().ExecuteAction(action)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not complete action \"%s\" (err: %v)", action, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Completed action \"%s\"", action))
+
+	return nil
+}
+
+// PurgeAction :
+// Used to remove the input action from the actions queue
+// so that it is never executed.
+//
+// The `action` defines the identifier of the action.
+//
+// Returns any error.
+func (p *AdminProxy) PurgeAction(action string) error {
+	err := game.PurgeAction(action, p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not purge action \"%s\" (err: %v)", action, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Purged action \"%s\"", action))
+
+	return nil
+}
+
+// PlanetsOf :
+// Used to fetch the identifiers of all the planets that
+// belong to the input universe.
+//
+// The `universe` defines the identifier of the universe.
+//
+// Returns the identifiers of the planets along with any
+// error.
+func (p *AdminProxy) PlanetsOf(universe string) ([]string, error) {
+	IDs := make([]string, 0)
+
+	query := db.QueryDesc{
+		Props: []string{
+			"p.id",
+		},
+		Table: "planets p inner join players pl on p.player = pl.id",
+		Filters: []db.Filter{
+			{
+				Key:    "pl.universe",
+				Values: []interface{}{universe},
+			},
+		},
+	}
+
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not query DB to fetch planets (err: %v)", err))
+		return IDs, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Invalid query to fetch planets (err: %v)", dbRes.Err))
+		return IDs, dbRes.Err
+	}
+
+	var ID string
+
+	for dbRes.Next() {
+		err = dbRes.Scan(&ID)
+		if err != nil {
+			return IDs, err
+		}
+
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, nil
+}
+
+// UpdateResources :
+// Used to bring the resources of the input planets up
+// to date. Planets are processed independently: an
+// error does not prevent the others to be updated.
+//
+// The `planets` defines the identifiers of the planets
+// to update.
+//
+// Returns the number of planets updated along with the
+// last error if any.
+func (p *AdminProxy) UpdateResources(planets []string) (int, error) {
+	var err error
+	updated := 0
+
+	for _, planet := range planets {
+		query := db.InsertReq{
+			Script: "update_resources_for_planet",
+			Args: []interface{}{
+				planet,
+			},
+			SkipReturn: true,
+		}
+
+		uErr := p.data().Proxy.InsertToDB(query)
+		if uErr != nil {
+			p.trace(logger.Error, fmt.Sprintf("Could not update resources of planet \"%s\" (err: %v)", planet, uErr))
+			err = uErr
+			continue
+		}
+
+		updated++
+	}
+
+	return updated, err
+}
+
+// kindOf :
+// Used to retrieve the kind of location expected by the
+// DB scripts handling both planets and moons.
+//
+// The `moon` defines whether the location is a moon.
+//
+// Returns the kind of the location.
+func kindOf(moon bool) string {
+	if moon {
+		return "moon"
+	}
+
+	return "planet"
+}
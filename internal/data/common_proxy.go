@@ -47,17 +47,19 @@ import (
 // The `module` defines a string that will be used to
 // perform some logs with a qualified service.
 //
-// The `data` defines a convenience object which regroups
-// all the data fetched from the main DB in a easy-to-use
-// object: it can be used to fetch information of various
-// kind without needing to worry about how the data is
-// actually fetched. It can be used to verify specific
-// criteria when performing an action for example.
+// The `instance` defines a convenience object which
+// regroups all the data fetched from the main DB in a
+// easy-to-use object: it can be used to fetch info of
+// various kind without needing to worry about how the
+// data is actually fetched. It can be used to verify
+// specific criteria when performing an action for
+// example. It should be accessed through `data` so as
+// to use the latest data model.
 type commonProxy struct {
-	log    logger.Logger
-	lock   *locker.ConcurrentLocker
-	module string
-	data   game.Instance
+	log      logger.Logger
+	lock     *locker.ConcurrentLocker
+	module   string
+	instance game.Instance
 }
 
 // newCommonProxy :
@@ -80,13 +82,37 @@ type commonProxy struct {
 // not right when creating the proxy.
 func newCommonProxy(data game.Instance, log logger.Logger, module string) commonProxy {
 	return commonProxy{
-		log:    log,
-		lock:   locker.NewConcurrentLocker(log),
-		module: module,
-		data:   data,
+		log:      log,
+		lock:     locker.NewConcurrentLocker(log),
+		module:   module,
+		instance: data,
 	}
 }
 
+// withLogger :
+// Returns a copy of this proxy which performs its logs
+// through the input logger. The copy shares the locks
+// and the data model of this proxy. It is used to tag
+// the logs with the request being served.
+//
+// The `log` defines the logger to use for the copy.
+//
+// Returns the copy of the proxy.
+func (cp commonProxy) withLogger(log logger.Logger) commonProxy {
+	cp.log = log
+	return cp
+}
+
+// data :
+// Used to retrieve the data model of this proxy. The
+// data model can be reloaded while the server runs so
+// it is retrieved again for each use.
+//
+// Returns the up-to-date data model.
+func (cp *commonProxy) data() game.Instance {
+	return cp.instance.Current()
+}
+
 // trace :
 // Used as a wrapper around the internal logger object to
 // benefit from the module defined for this element along
@@ -100,3 +126,13 @@ func newCommonProxy(data game.Instance, log logger.Logger, module string) common
 func (cp *commonProxy) trace(level logger.Severity, msg string) {
 	cp.log.Trace(level, cp.module, msg)
 }
+
+// LockHolders :
+// Used to retrieve the resources currently locked by
+// this proxy. It allows to inspect the state of the
+// locks at runtime.
+//
+// Returns the description of the locked resources.
+func (cp *commonProxy) LockHolders() []locker.Holder {
+	return cp.lock.Holders()
+}
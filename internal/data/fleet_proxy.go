@@ -57,6 +57,21 @@ func NewFleetProxy(data game.Instance, log logger.Logger) FleetProxy {
 	}
 }
 
+// For :
+// Returns a copy of this proxy performing its logs with
+// the input logger. It allows to attach the logs of the
+// proxy to the request being served.
+//
+// The `log` defines the logger to use.
+//
+// Returns the copy of the proxy.
+func (p *FleetProxy) For(log logger.Logger) *FleetProxy {
+	c := *p
+	c.commonProxy = c.withLogger(log)
+
+	return &c
+}
+
 // Fleets :
 // Return a list of fleets registered so far in the DB.
 // The returned list take into account the filters that
@@ -82,7 +97,7 @@ func (p *FleetProxy) Fleets(filters []db.Filter) ([]game.Fleet, error) {
 		Filters: filters,
 	}
 
-	dbRes, err := p.data.Proxy.FetchFromDB(query)
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
 
 	// Check for errors.
 	if err != nil {
@@ -116,7 +131,7 @@ func (p *FleetProxy) Fleets(filters []db.Filter) ([]game.Fleet, error) {
 	fleets := make([]game.Fleet, 0)
 
 	for _, ID = range IDs {
-		f, err := game.NewFleetFromDB(ID, p.data)
+		f, err := game.NewFleetFromDB(ID, p.data())
 
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch fleet \"%s\" data from DB (err: %v)", ID, err))
@@ -149,7 +164,7 @@ func (p *FleetProxy) ACSFleets(filters []db.Filter) ([]game.ACSFleet, error) {
 		Filters: filters,
 	}
 
-	dbRes, err := p.data.Proxy.FetchFromDB(query)
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
 
 	// Check for errors.
 	if err != nil {
@@ -181,7 +196,7 @@ func (p *FleetProxy) ACSFleets(filters []db.Filter) ([]game.ACSFleet, error) {
 	fleets := make([]game.ACSFleet, 0)
 
 	for _, ID = range IDs {
-		acs, err := game.NewACSFleetFromDB(ID, p.data)
+		acs, err := game.NewACSFleetFromDB(ID, p.data())
 
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch ACS fleet \"%s\" data from DB (err: %v)", ID, err))
@@ -215,8 +230,13 @@ func (p *FleetProxy) CreateFleet(fleet game.Fleet) (string, error) {
 		return fleet.ID, err
 	}
 
+	// Fleets leaving at a later time are only scheduled.
+	if !fleet.DepartureTime.IsZero() {
+		return p.scheduleFleet(fleet)
+	}
+
 	// Import the fleet to the DB.
-	err = fleet.SaveToDB(p.data.Proxy)
+	err = fleet.SaveToDB(p.data().Proxy)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not create fleet for \"%s\" for \"%s\" (err: %v)", fleet.ID, fleet.Player, err))
 		return fleet.ID, err
@@ -224,6 +244,12 @@ func (p *FleetProxy) CreateFleet(fleet game.Fleet) (string, error) {
 
 	p.trace(logger.Notice, fmt.Sprintf("Created new fleet \"%s\" for \"%s\"", fleet.ID, fleet.Player))
 
+	// Notify the target of the fleet if needed.
+	err = fleet.WarnTarget(p.data())
+	if err != nil {
+		p.trace(logger.Warning, fmt.Sprintf("Could not warn target of fleet \"%s\" (err: %v)", fleet.ID, err))
+	}
+
 	return fleet.ID, nil
 }
 
@@ -263,13 +289,20 @@ func (p *FleetProxy) CreateACSFleet(fleet game.Fleet) (string, error) {
 
 	// Validate the arrival time expected by the
 	// new component of the fleet (if any).
-	err = acs.ValidateFleet(&fleet, source, p.data)
+	err = acs.ValidateFleet(&fleet, source, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Failed to validate ACS fleet's data (err: %v)", err))
 		return acs.ID, err
 	}
 
-	err = acs.SaveToDB(&fleet, p.data.Proxy)
+	// The fleet will join the ACS when it leaves in case
+	// it is scheduled for a later departure.
+	if !fleet.DepartureTime.IsZero() {
+		_, err = p.scheduleFleet(fleet)
+		return acs.ID, err
+	}
+
+	err = acs.SaveToDB(&fleet, p.data().Proxy)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not create ACS fleet for \"%s\" (err: %v)", fleet.Player, err))
 		return acs.ID, err
@@ -277,9 +310,185 @@ func (p *FleetProxy) CreateACSFleet(fleet game.Fleet) (string, error) {
 
 	p.trace(logger.Notice, fmt.Sprintf("Created new fleet \"%s\" for \"%s\" in ACS \"%s\"", fleet.ID, fleet.Player, acs.ID))
 
+	err = fleet.WarnTarget(p.data())
+	if err != nil {
+		p.trace(logger.Warning, fmt.Sprintf("Could not warn target of fleet \"%s\" (err: %v)", fleet.ID, err))
+	}
+
 	return acs.ID, nil
 }
 
+// ScheduledFleets :
+// Return a list of fleets waiting for their departure
+// and matching the input filters.
+//
+// The `filters` define some filtering properties that
+// can be applied to the SQL query to only select part
+// of the scheduled fleets.
+//
+// Returns the list of scheduled fleets along with any
+// error.
+func (p *FleetProxy) ScheduledFleets(filters []db.Filter) ([]game.Fleet, error) {
+	// Create the query and execute it.
+	query := db.QueryDesc{
+		Props: []string{
+			"id",
+		},
+		Table:    "fleets_scheduled",
+		Filters:  filters,
+		Ordering: "order by departure_time",
+	}
+
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not query DB to fetch scheduled fleets (err: %v)", err))
+		return []game.Fleet{}, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Invalid query to fetch scheduled fleets (err: %v)", dbRes.Err))
+		return []game.Fleet{}, dbRes.Err
+	}
+
+	var ID string
+	IDs := make([]string, 0)
+
+	for dbRes.Next() {
+		err = dbRes.Scan(&ID)
+
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Error while fetching scheduled fleet ID (err: %v)", err))
+			continue
+		}
+
+		IDs = append(IDs, ID)
+	}
+
+	fleets := make([]game.Fleet, 0)
+
+	for _, ID = range IDs {
+		f, err := game.NewScheduledFleetFromDB(ID, p.data())
+
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Unable to fetch scheduled fleet \"%s\" data from DB (err: %v)", ID, err))
+			continue
+		}
+
+		fleets = append(fleets, f)
+	}
+
+	return fleets, nil
+}
+
+// CancelScheduledFleet :
+// Used to cancel a fleet waiting for its departure.
+// The ships and resources reserved for the fleet are
+// restored on its source.
+//
+// The `fleet` defines the identifier of the fleet to
+// cancel.
+//
+// Returns any error.
+func (p *FleetProxy) CancelScheduledFleet(fleet string) error {
+	err := game.CancelScheduledFleet(fleet, false, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not cancel scheduled fleet \"%s\" (err: %v)", fleet, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Cancelled scheduled fleet \"%s\"", fleet))
+
+	return nil
+}
+
+// SupplyHoldingFleet :
+// Used to supply a fleet holding at one of the planets
+// of a player with fuel from the alliance depot. This
+// extends the hold time of the fleet.
+//
+// The `fleet` defines the identifier of the fleet.
+//
+// The `order` defines the player supplying the fleet
+// and the additional hold time.
+//
+// Returns any error.
+func (p *FleetProxy) SupplyHoldingFleet(fleet string, order game.HoldingOrder) error {
+	err := game.SupplyHoldingFleet(fleet, order, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not supply holding fleet \"%s\" (err: %v)", fleet, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Supplied holding fleet \"%s\" for %d hour(s)", fleet, order.Hours))
+
+	return nil
+}
+
+// ReleaseHoldingFleet :
+// Used to send a fleet holding at one of the planets
+// of a player back to its source before the end of
+// its hold time.
+//
+// The `fleet` defines the identifier of the fleet.
+//
+// The `order` defines the player releasing the fleet.
+//
+// Returns any error.
+func (p *FleetProxy) ReleaseHoldingFleet(fleet string, order game.HoldingOrder) error {
+	err := game.ReleaseHoldingFleet(fleet, order, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not release holding fleet \"%s\" (err: %v)", fleet, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Released holding fleet \"%s\"", fleet))
+
+	return nil
+}
+
+// PreviewFleet :
+// Used to perform the validation of the input fleet
+// without actually creating it. The flight times and
+// the consumption computed during the validation are
+// returned along with any validation error.
+//
+// The `fleet` defines the fleet to validate.
+//
+// Returns the preview of the fleet.
+func (p *FleetProxy) PreviewFleet(fleet game.Fleet) game.FleetPreview {
+	_, err := p.validateFleet(&fleet)
+
+	return fleet.Preview(err)
+}
+
+// PreviewACSFleet :
+// Similar to the `PreviewFleet` method but handles the
+// case of a fleet that should be part of an ACS. The
+// arrival time of the fleet is also validated against
+// the other components of the ACS if it exists.
+//
+// The `fleet` defines the fleet to validate.
+//
+// Returns the preview of the fleet.
+func (p *FleetProxy) PreviewACSFleet(fleet game.Fleet) game.FleetPreview {
+	acs, err := p.fetchOrCreateACS(&fleet)
+	if err != nil {
+		return fleet.Preview(err)
+	}
+
+	source, err := p.validateFleet(&fleet)
+	if err != nil {
+		return fleet.Preview(err)
+	}
+
+	err = acs.ValidateFleet(&fleet, source, p.data())
+
+	return fleet.Preview(err)
+}
+
 // validateFleet :
 // Used to perform the validation of the input fleet
 // against the data existing in the DB. The fleet is
@@ -305,7 +514,7 @@ func (p *FleetProxy) validateFleet(fleet *game.Fleet) (*game.Planet, error) {
 	// This might fail in case the universe's ID in the
 	// fleet is not valid. We will report this as an
 	// error of the fleet in this case.
-	uni, err := game.NewUniverseFromDB(fleet.Universe, p.data)
+	uni, err := game.NewUniverseFromDB(fleet.Universe, p.data())
 	if err == game.ErrInvalidElementID {
 		return nil, game.ErrInvalidUniverseForFleet
 	}
@@ -319,7 +528,7 @@ func (p *FleetProxy) validateFleet(fleet *game.Fleet) (*game.Planet, error) {
 	// In order to validate and import the fleet's data
 	// to the DB we need to retrieve the source planet
 	// or moon and the target element (if it exists).
-	source, err := game.NewPlanetFromDB(fleet.Source, p.data)
+	source, err := game.NewPlanetFromDB(fleet.Source, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Unable to fetch source for fleet (err: %v)", err))
 		return &source, game.ErrInvalidSourceForFleet
@@ -327,7 +536,7 @@ func (p *FleetProxy) validateFleet(fleet *game.Fleet) (*game.Planet, error) {
 
 	var target *game.Planet
 	if fleet.Target != "" {
-		vTarget, err := game.NewPlanetFromDB(fleet.Target, p.data)
+		vTarget, err := game.NewPlanetFromDB(fleet.Target, p.data())
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch target for fleet (err: %v)", err))
 			return &source, game.ErrInvalidTargetForFleet
@@ -350,7 +559,7 @@ func (p *FleetProxy) validateFleet(fleet *game.Fleet) (*game.Planet, error) {
 	// data. We will force the fleet's universe to match its
 	// source's player and check that the target is consistent
 	// with it.
-	player, err := game.NewPlayerFromDB(fleet.Player, p.data)
+	player, err := game.NewPlayerFromDB(fleet.Player, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Unable to fetch player for fleet (err: %v)", err))
 		return &source, game.ErrInvalidPlayerForFleet
@@ -361,7 +570,7 @@ func (p *FleetProxy) validateFleet(fleet *game.Fleet) (*game.Planet, error) {
 	}
 
 	if target != nil {
-		tPlayer, err := game.NewPlayerFromDB(target.Player, p.data)
+		tPlayer, err := game.NewPlayerFromDB(target.Player, p.data())
 
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch target player for fleet (err: %v)", err))
@@ -374,13 +583,13 @@ func (p *FleetProxy) validateFleet(fleet *game.Fleet) (*game.Planet, error) {
 		}
 	}
 
-	mul, err := game.NewMultipliersFromDB(fleet.Universe, p.data)
+	mul, err := game.NewMultipliersFromDB(fleet.Universe, p.data())
 	if err != nil {
 		return &source, err
 	}
 
 	// Make sure that the player can send a new fleet.
-	allowed, err := player.CanSendFleet(p.data, fleet.Objective)
+	allowed, err := player.CanSendFleet(p.data(), fleet.Objective)
 	if err != nil {
 		return &source, err
 	}
@@ -390,14 +599,14 @@ func (p *FleetProxy) validateFleet(fleet *game.Fleet) (*game.Planet, error) {
 	}
 
 	// Consolidate the arrival time for this fleet.
-	err = fleet.ConsolidateArrivalTime(p.data, &source, mul.Fleet)
+	err = fleet.ConsolidateArrivalTime(p.data(), &source, mul.Fleet)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not consolidate arrival time for fleet (err: %v)", err))
 		return &source, err
 	}
 
 	// Validate the fleet against planet's data.
-	err = fleet.Validate(p.data, &source, target)
+	err = fleet.Validate(p.data(), &source, target)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Unable to validate fleet's data for \"%s\" (err: %v)", fleet.Player, err))
 		return &source, err
@@ -406,6 +615,27 @@ func (p *FleetProxy) validateFleet(fleet *game.Fleet) (*game.Planet, error) {
 	return &source, nil
 }
 
+// scheduleFleet :
+// Used to register the input fleet so that it leaves
+// at its departure time. The fleet is assumed to be
+// validated already.
+//
+// The `fleet` defines the fleet to schedule.
+//
+// Returns the identifier of the fleet along with any
+// error.
+func (p *FleetProxy) scheduleFleet(fleet game.Fleet) (string, error) {
+	err := fleet.Schedule(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not schedule fleet \"%s\" for \"%s\" (err: %v)", fleet.ID, fleet.Player, err))
+		return fleet.ID, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Scheduled fleet \"%s\" for \"%s\" at %v", fleet.ID, fleet.Player, fleet.DepartureTime))
+
+	return fleet.ID, nil
+}
+
 // fetchOrCreateACS :
 // Used to perform the creation of the ACS fleet
 // associated to the input fleet if needed or to
@@ -430,6 +660,6 @@ func (p *FleetProxy) fetchOrCreateACS(fleet *game.Fleet) (*game.ACSFleet, error)
 	}
 
 	// Fetch the ACS from the DB.
-	acs, err := game.NewACSFleetFromDB(fleet.ACS, p.data)
+	acs, err := game.NewACSFleetFromDB(fleet.ACS, p.data())
 	return &acs, err
 }
@@ -0,0 +1,243 @@
+package data
+
+import (
+	"fmt"
+	"oglike_server/internal/game"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MarketProxy :
+// Intended as a wrapper to access the offers posted by
+// the players on the market of their universe. The goods
+// of an offer are taken from the planet of the seller
+// until another player accepts it, in which case fleets
+// are generated to deliver the goods and the price.
+type MarketProxy struct {
+	commonProxy
+}
+
+// NewMarketProxy :
+// Create a new proxy allowing to serve the requests
+// related to the market.
+//
+// The `data` defines the data model to use to fetch
+// information and verify requests.
+//
+// The `log` allows to notify errors and information.
+//
+// Returns the created proxy.
+func NewMarketProxy(data game.Instance, log logger.Logger) MarketProxy {
+	return MarketProxy{
+		commonProxy: newCommonProxy(data, log, "market"),
+	}
+}
+
+// For :
+// Returns a copy of this proxy performing its logs with
+// the input logger. It allows to attach the logs of the
+// proxy to the request being served.
+//
+// The `log` defines the logger to use.
+//
+// Returns the copy of the proxy.
+func (p *MarketProxy) For(log logger.Logger) *MarketProxy {
+	c := *p
+	c.commonProxy = c.withLogger(log)
+
+	return &c
+}
+
+// Offers :
+// Return a list of offers registered so far in the DB
+// and matching the input filters.
+//
+// The `filters` define some filtering properties that
+// can be applied to the SQL query to only select part
+// of the offers.
+//
+// Returns the list of offers along with any error.
+func (p *MarketProxy) Offers(filters []db.Filter) ([]game.MarketOffer, error) {
+	offers := make([]game.MarketOffer, 0)
+
+	IDs, err := p.fetchOffersIDs(filters)
+	if err != nil {
+		return offers, err
+	}
+
+	for _, ID := range IDs {
+		o, err := game.NewMarketOfferFromDB(ID, p.data())
+
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Unable to fetch offer \"%s\" data from DB (err: %v)", ID, err))
+			continue
+		}
+
+		offers = append(offers, o)
+	}
+
+	return offers, nil
+}
+
+// Create :
+// Used to post the input offer on the market. The goods
+// of the offer are taken from the planet of the seller.
+//
+// The `offer` defines the offer to create.
+//
+// Returns the identifier of the created offer along
+// with any error.
+func (p *MarketProxy) Create(offer game.MarketOffer) (string, error) {
+	// Assign a valid identifier if this is not already the case.
+	if offer.ID == "" {
+		offer.ID = uuid.New().String()
+	}
+
+	err := offer.Validate(p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Validation for offer failed (err: %v)", err))
+		return offer.ID, err
+	}
+
+	err = offer.SaveToDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not create offer \"%s\" for \"%s\" (err: %v)", offer.ID, offer.Player, err))
+		return offer.ID, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Created new offer \"%s\" for \"%s\"", offer.ID, offer.Player))
+
+	return offer.ID, nil
+}
+
+// Accept :
+// Used to accept an offer of the market. The fleets
+// delivering the goods and the price are created.
+//
+// The `purchase` defines the offer to accept and the
+// planet of the buyer.
+//
+// Returns the identifiers of the fleets created along
+// with any error.
+func (p *MarketProxy) Accept(purchase game.MarketPurchase) ([]string, error) {
+	err := purchase.Validate(p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Validation for purchase of \"%s\" failed (err: %v)", purchase.Offer, err))
+		return []string{}, err
+	}
+
+	err = purchase.SaveToDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not accept offer \"%s\" for \"%s\" (err: %v)", purchase.Offer, purchase.Player, err))
+		return []string{}, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Offer \"%s\" accepted by \"%s\"", purchase.Offer, purchase.Player))
+
+	return purchase.Fleets(), nil
+}
+
+// Cancel :
+// Used to withdraw an offer from the market. Its goods
+// are given back to the planet of the seller.
+//
+// The `offer` defines the identifier of the offer.
+//
+// Returns any error.
+func (p *MarketProxy) Cancel(offer string) error {
+	err := game.ReleaseMarketOffer(offer, "", p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not cancel offer \"%s\" (err: %v)", offer, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Cancelled offer \"%s\"", offer))
+
+	return nil
+}
+
+// Process :
+// Used to withdraw all the offers that expired. Their
+// goods are given back to the sellers which are also
+// notified.
+//
+// Returns any error.
+func (p *MarketProxy) Process() error {
+	IDs, err := p.fetchOffersIDs(
+		[]db.Filter{
+			{
+				Key:      "expiration_time",
+				Values:   []interface{}{time.Now()},
+				Operator: db.LessThan,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, ID := range IDs {
+		err = game.ReleaseMarketOffer(ID, "the offer expired", p.data())
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Could not release expired offer \"%s\" (err: %v)", ID, err))
+			continue
+		}
+
+		p.trace(logger.Notice, fmt.Sprintf("Released expired offer \"%s\"", ID))
+	}
+
+	return nil
+}
+
+// fetchOffersIDs :
+// Used to fetch the identifiers of the offers matching
+// the input filters.
+//
+// The `filters` define the filters to apply.
+//
+// Returns the identifiers along with any error.
+func (p *MarketProxy) fetchOffersIDs(filters []db.Filter) ([]string, error) {
+	IDs := make([]string, 0)
+
+	// Create the query and execute it.
+	query := db.QueryDesc{
+		Props: []string{
+			"id",
+		},
+		Table:    "market_offers",
+		Filters:  filters,
+		Ordering: "order by expiration_time",
+	}
+
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not query DB to fetch offers (err: %v)", err))
+		return IDs, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Invalid query to fetch offers (err: %v)", dbRes.Err))
+		return IDs, dbRes.Err
+	}
+
+	var ID string
+
+	for dbRes.Next() {
+		err = dbRes.Scan(&ID)
+
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Error while fetching offer ID (err: %v)", err))
+			continue
+		}
+
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, nil
+}
@@ -6,6 +6,9 @@ import (
 	"oglike_server/internal/game"
 	"oglike_server/pkg/db"
 	"oglike_server/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // PlanetProxy :
@@ -17,8 +20,13 @@ import (
 // it and some upgrade actions (meaning that a building
 // will have one more level soon or that there will be
 // more ships deployed on this planet).
+//
+// The `rng` defines the random source used to choose
+// the coordinates of the homeworld of new players.
 type PlanetProxy struct {
 	commonProxy
+
+	rng *rand.Rand
 }
 
 // planetGenerationMaxTrials :
@@ -47,9 +55,38 @@ var ErrTooManyTrials = fmt.Errorf("could not create planet after %d trial(s)", p
 func NewPlanetProxy(data game.Instance, log logger.Logger) PlanetProxy {
 	return PlanetProxy{
 		commonProxy: newCommonProxy(data, log, "planets"),
+		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
+// For :
+// Returns a copy of this proxy performing its logs with
+// the input logger. It allows to attach the logs of the
+// proxy to the request being served.
+//
+// The `log` defines the logger to use.
+//
+// Returns the copy of the proxy.
+func (p *PlanetProxy) For(log logger.Logger) *PlanetProxy {
+	c := *p
+	c.commonProxy = c.withLogger(log)
+
+	return &c
+}
+
+// WithRNG :
+// Used to replace the random source used to choose the
+// coordinates of the homeworld of new players. This is
+// mostly useful to get reproducible placements.
+//
+// The `rng` defines the random source to use.
+//
+// Returns this proxy to allow chain calling.
+func (p *PlanetProxy) WithRNG(rng *rand.Rand) *PlanetProxy {
+	p.rng = rng
+	return p
+}
+
 // Planets :
 // Return a list of planets registered so far in all the planets
 // defined in the DB. The input filters might help to narrow the
@@ -74,7 +111,7 @@ func (p *PlanetProxy) Planets(filters []db.Filter) ([]game.Planet, error) {
 		Filters: filters,
 	}
 
-	dbRes, err := p.data.Proxy.FetchFromDB(query)
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
 
 	// Check for errors.
 	if err != nil {
@@ -106,7 +143,7 @@ func (p *PlanetProxy) Planets(filters []db.Filter) ([]game.Planet, error) {
 	planets := make([]game.Planet, 0)
 
 	for _, ID = range IDs {
-		pla, err := game.NewPlanetFromDB(ID, p.data)
+		pla, err := game.NewPlanetFromDB(ID, p.data())
 
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch planet \"%s\" data from DB (err: %v)", ID, err))
@@ -144,7 +181,7 @@ func (p *PlanetProxy) Moons(filters []db.Filter) ([]game.Planet, error) {
 		Filters: filters,
 	}
 
-	dbRes, err := p.data.Proxy.FetchFromDB(query)
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
 
 	// Check for errors.
 	if err != nil {
@@ -176,7 +213,7 @@ func (p *PlanetProxy) Moons(filters []db.Filter) ([]game.Planet, error) {
 	moons := make([]game.Planet, 0)
 
 	for _, ID = range IDs {
-		m, err := game.NewMoonFromDB(ID, p.data)
+		m, err := game.NewMoonFromDB(ID, p.data())
 
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch moon \"%s\" data from DB (err: %v)", ID, err))
@@ -189,6 +226,36 @@ func (p *PlanetProxy) Moons(filters []db.Filter) ([]game.Planet, error) {
 	return moons, nil
 }
 
+// Fields :
+// Used to generate the report of the usage of fields
+// of the planet or moon described by the input ID.
+//
+// The `ID` defines the identifier of the planet or
+// moon.
+//
+// The `moon` defines whether the `ID` refers to a
+// moon or a planet.
+//
+// Returns the report of the fields along with any
+// error.
+func (p *PlanetProxy) Fields(ID string, moon bool) (game.FieldsReport, error) {
+	var pla game.Planet
+	var err error
+
+	if moon {
+		pla, err = game.NewMoonFromDB(ID, p.data())
+	} else {
+		pla, err = game.NewPlanetFromDB(ID, p.data())
+	}
+
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Unable to fetch \"%s\" to generate fields report (err: %v)", ID, err))
+		return game.FieldsReport{}, err
+	}
+
+	return pla.FieldsReport(), nil
+}
+
 // Debris :
 // Return a list of debris registered so far in the DB. A debris
 // field is linked to a position. The input filters might help
@@ -213,7 +280,7 @@ func (p *PlanetProxy) Debris(filters []db.Filter) ([]game.DebrisField, error) {
 		Filters: filters,
 	}
 
-	dbRes, err := p.data.Proxy.FetchFromDB(query)
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
 
 	// Check for errors.
 	if err != nil {
@@ -245,7 +312,7 @@ func (p *PlanetProxy) Debris(filters []db.Filter) ([]game.DebrisField, error) {
 	debris := make([]game.DebrisField, 0)
 
 	for _, ID = range IDs {
-		d, err := game.NewDebrisFieldFromDB(ID, p.data)
+		d, err := game.NewDebrisFieldFromDB(ID, p.data())
 
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch debris field \"%s\" data from DB (err: %v)", ID, err))
@@ -262,7 +329,8 @@ func (p *PlanetProxy) Debris(filters []db.Filter) ([]game.DebrisField, error) {
 // Used to handle the creation of a planet for the specified
 // player. This method is only used when a new player needs
 // to be registered in the universe so the coordinates of the
-// new planet to create are determine directly in this method.
+// new planet to create are determined directly in this method
+// using the placement strategy of the universe.
 //
 // The `player` represents the account for which the planet is
 // to be created. We assume that the universe and the player's
@@ -276,68 +344,48 @@ func (p *PlanetProxy) Debris(filters []db.Filter) ([]game.DebrisField, error) {
 func (p *PlanetProxy) CreateFor(player game.Player) (string, error) {
 	// First we need to fetch the universe related to the
 	// planet to create.
-	uni, err := game.NewUniverseFromDB(player.Universe, p.data)
+	uni, err := game.NewUniverseFromDB(player.Universe, p.data())
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Unable to fetch universe \"%s\" to create planet (err: %v)", player.Universe, err))
 		return "", err
 	}
 
-	// Retrieve the list of coordinates that are already
-	// used in the universe the player's in.
-	usedCoords, err := uni.UsedCoords(p.data.Proxy)
+	// Retrieve the strategy used by the universe to place
+	// the homeworld and the data it needs.
+	placer, err := game.NewHomeworldPlacer(game.PlacementStrategy(uni.HomeworldPlacement))
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Invalid placement strategy \"%s\" in \"%s\" (err: %v)", uni.HomeworldPlacement, player.Universe, err))
+		return "", err
+	}
+
+	pl, err := game.NewPlacement(&uni, player, p.data())
 	if err != nil {
-		p.trace(logger.Error, fmt.Sprintf("Failed to fetch used coordinates in \"%s\" (err: %v)", player.Universe, err))
+		p.trace(logger.Error, fmt.Sprintf("Failed to fetch placement data in \"%s\" (err: %v)", player.Universe, err))
 		return "", err
 	}
-	totalPlanets := uni.GalaxiesCount * uni.GalaxySize * uni.SolarSystemSize
 
-	// Try to insert the planet in the DB while we have some
-	// untested coordinates and we didn't suceed in inserting
-	// it.
+	// Try to insert the planet in the DB at each candidate
+	// coordinates in order until we succeed. In case the
+	// insertion fails (typically because another planet
+	// was created at the same coordinates in the meantime)
+	// we move on to the next candidate.
 	id := ""
 	inserted := false
-	trials := 0
-
-	for !inserted && len(usedCoords) < totalPlanets && trials < planetGenerationMaxTrials {
-		// Pick a random coordinate and check whether it belongs
-		// to the already used coordinates. If this is the case
-		// we will try to pick a new one. Otherwise we will try
-		// to perform the insertion of the planet in the DB.
-		// In case the insertion fails we will add the selected
-		// coordinates to the list of used one so as not to try
-		// to use it again.
-		coord := game.NewPlanetCoordinate(
-			rand.Int()%uni.GalaxiesCount,
-			rand.Int()%uni.GalaxySize,
-			rand.Int()%uni.SolarSystemSize,
-		)
-
-		exists := true
-		for exists {
-			key := coord.Linearize(uni.GalaxySize, uni.SolarSystemSize)
-
-			if _, ok := usedCoords[key]; !ok {
-				// We found a not yet used coordinate.
-				exists = false
-			} else {
-				// Pick some new coordinates.
-				coord = game.NewPlanetCoordinate(
-					rand.Int()%uni.GalaxiesCount,
-					rand.Int()%uni.GalaxySize,
-					rand.Int()%uni.SolarSystemSize,
-				)
-			}
-		}
+
+	candidates := placer.Candidates(pl, p.rng, planetGenerationMaxTrials)
+
+	for i := 0; i < len(candidates) && !inserted; i++ {
+		coord := candidates[i]
 
 		// Generate a new planet. We also need to associate
 		// some resources to it.
-		planet, err := game.NewPlanet(player.ID, coord, true, p.data)
+		planet, err := game.NewPlanet(player.ID, coord, true, p.data())
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to generate resources for planet at %s for \"%s\" (err: %v)", coord, player.ID, err))
 		}
 
 		// Try to create the planet at the specified coordinates.
-		err = planet.SaveToDB(p.data.Proxy)
+		err = planet.SaveToDB(p.data().Proxy)
 
 		// Check for errors.
 		if err == nil {
@@ -346,13 +394,7 @@ func (p *PlanetProxy) CreateFor(player game.Player) (string, error) {
 			id = planet.ID
 		} else {
 			p.trace(logger.Warning, fmt.Sprintf("Could not import planet at %s for \"%s\" (err: %v)", coord, player.ID, err))
-
-			// Register this coordinate as being used as we can't
-			// successfully use it to create the planet anyways.
-			usedCoords[coord.Linearize(uni.GalaxySize, uni.SolarSystemSize)] = coord
 		}
-
-		trials++
 	}
 
 	// Check whether we could insert the element in the DB: if
@@ -377,7 +419,7 @@ func (p *PlanetProxy) CreateFor(player game.Player) (string, error) {
 // with any errors.
 func (p *PlanetProxy) Update(planet game.Planet) (string, error) {
 	// Update the planet in the DB.
-	err := planet.UpdateInDB(p.data.Proxy)
+	err := planet.UpdateInDB(p.data().Proxy)
 
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not update planet \"%s\" (err: %v)", planet.ID, err))
@@ -404,7 +446,7 @@ func (p *PlanetProxy) UpdateProduction(planetID string, production []game.Buildi
 	}
 
 	// Update the planet in the DB.
-	err := planet.UpdateProduction(production, p.data.Proxy)
+	err := planet.UpdateProduction(production, p.data().Proxy)
 
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not update planet \"%s\" (err: %v)", planet.ID, err))
@@ -424,11 +466,157 @@ func (p *PlanetProxy) UpdateProduction(planetID string, production []game.Buildi
 // Returns any error that occurred during the deletion.
 func (p *PlanetProxy) Delete(planet string) error {
 	// Retrieve the planet from the DB.
-	pl, err := game.NewPlanetFromDB(planet, p.data)
+	pl, err := game.NewPlanetFromDB(planet, p.data())
 	if err != nil {
 		return err
 	}
 
 	// We could fetch the planet, attempt to delete it.
-	return pl.DeleteFromDB(p.data)
+	return pl.DeleteFromDB(p.data())
+}
+
+// Relocations :
+// Return a list of the relocations of planets that
+// are waiting to be performed and matching the input
+// filters.
+//
+// The `filters` define some filtering properties that
+// can be applied to the SQL query to only select part
+// of the relocations.
+//
+// Returns the list of relocations along with any error.
+func (p *PlanetProxy) Relocations(filters []db.Filter) ([]game.Relocation, error) {
+	// Create the query and execute it.
+	query := db.QueryDesc{
+		Props: []string{
+			"id",
+		},
+		Table:    "planets_relocations",
+		Filters:  filters,
+		Ordering: "order by completion_time",
+	}
+
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not query DB to fetch relocations (err: %v)", err))
+		return []game.Relocation{}, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Invalid query to fetch relocations (err: %v)", dbRes.Err))
+		return []game.Relocation{}, dbRes.Err
+	}
+
+	var ID string
+	IDs := make([]string, 0)
+
+	for dbRes.Next() {
+		err = dbRes.Scan(&ID)
+
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Error while fetching relocation ID (err: %v)", err))
+			continue
+		}
+
+		IDs = append(IDs, ID)
+	}
+
+	relocations := make([]game.Relocation, 0)
+
+	for _, ID = range IDs {
+		r, err := game.NewRelocationFromDB(ID, p.data())
+
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Unable to fetch relocation \"%s\" data from DB (err: %v)", ID, err))
+			continue
+		}
+
+		relocations = append(relocations, r)
+	}
+
+	return relocations, nil
+}
+
+// Relocate :
+// Used to reserve the target of the input relocation
+// and to start the countdown after which the planet
+// will be moved.
+//
+// The `relocation` defines the planet to move and its
+// target.
+//
+// Returns the identifier of the relocation along with
+// any error.
+func (p *PlanetProxy) Relocate(relocation game.Relocation) (string, error) {
+	// Assign a valid identifier if this is not already the case.
+	if relocation.ID == "" {
+		relocation.ID = uuid.New().String()
+	}
+
+	err := relocation.Validate(p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Validation for relocation of \"%s\" failed (err: %v)", relocation.Planet, err))
+		return relocation.ID, err
+	}
+
+	err = relocation.SaveToDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not create relocation of \"%s\" (err: %v)", relocation.Planet, err))
+		return relocation.ID, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Planet \"%s\" will be relocated to %s at %v", relocation.Planet, relocation.Target, relocation.CompletionTime))
+
+	return relocation.ID, nil
+}
+
+// CancelRelocation :
+// Used to cancel a relocation that is not performed
+// yet. The target slot is released and the cost is
+// restored on the planet.
+//
+// The `relocation` defines the identifier of the
+// relocation to cancel.
+//
+// Returns any error.
+func (p *PlanetProxy) CancelRelocation(relocation string) error {
+	err := game.CancelRelocation(relocation, "", p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not cancel relocation \"%s\" (err: %v)", relocation, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Cancelled relocation \"%s\"", relocation))
+
+	return nil
+}
+
+// Trade :
+// Used to exchange some resources of a planet with the
+// merchant of its universe. The rate of the trade is
+// computed from the rates of the universe.
+//
+// The `trade` defines the trade to perform.
+//
+// Returns the identifier of the planet along with any
+// error.
+func (p *PlanetProxy) Trade(trade game.Trade) (string, error) {
+	err := trade.Validate(p.data(), p.rng)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Validation for trade on \"%s\" failed (err: %v)", trade.Planet, err))
+		return trade.Planet, err
+	}
+
+	err = trade.SaveToDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not perform trade on \"%s\" (err: %v)", trade.Planet, err))
+		return trade.Planet, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Traded %f of \"%s\" for %f of \"%s\" on \"%s\"", trade.Amount, trade.Sold, trade.Received, trade.Bought, trade.Planet))
+
+	return trade.Planet, nil
 }
@@ -5,6 +5,7 @@ import (
 	"oglike_server/internal/game"
 	"oglike_server/pkg/db"
 	"oglike_server/pkg/logger"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,6 +13,9 @@ import (
 // maxNameTrials : Number of trials to perform to generate a name.
 var maxNameTrials = 50
 
+// eventsRetention : Duration during which the events of players are kept.
+var eventsRetention = 7 * 24 * time.Hour
+
 // PlayerProxy :
 // Intended as a wrapper to access properties of players
 // and retrieve data from the database. In most cases we
@@ -40,6 +44,21 @@ func NewPlayerProxy(data game.Instance, log logger.Logger) PlayerProxy {
 	}
 }
 
+// For :
+// Returns a copy of this proxy performing its logs with
+// the input logger. It allows to attach the logs of the
+// proxy to the request being served.
+//
+// The `log` defines the logger to use.
+//
+// Returns the copy of the proxy.
+func (p *PlayerProxy) For(log logger.Logger) *PlayerProxy {
+	c := *p
+	c.commonProxy = c.withLogger(log)
+
+	return &c
+}
+
 // Players :
 // Return a list of players registered so far in all the
 // players defined in the DB. The input filters might help
@@ -64,7 +83,7 @@ func (p *PlayerProxy) Players(filters []db.Filter) ([]game.Player, error) {
 		Filters: filters,
 	}
 
-	dbRes, err := p.data.Proxy.FetchFromDB(query)
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
 
 	// Check for errors.
 	if err != nil {
@@ -98,7 +117,7 @@ func (p *PlayerProxy) Players(filters []db.Filter) ([]game.Player, error) {
 	players := make([]game.Player, 0)
 
 	for _, ID = range IDs {
-		pla, err := game.NewPlayerFromDB(ID, p.data)
+		pla, err := game.NewPlayerFromDB(ID, p.data())
 
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch player \"%s\" data from DB (err: %v)", ID, err))
@@ -131,7 +150,7 @@ func (p *PlayerProxy) Messages(filters []db.Filter) ([]game.Message, error) {
 		Filters: filters,
 	}
 
-	dbRes, err := p.data.Proxy.FetchFromDB(query)
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
 
 	// Check for errors.
 	if err != nil {
@@ -165,7 +184,7 @@ func (p *PlayerProxy) Messages(filters []db.Filter) ([]game.Message, error) {
 	messages := make([]game.Message, 0)
 
 	for _, ID = range IDs {
-		msg, err := game.NewMessageFromDB(ID, p.data)
+		msg, err := game.NewMessageFromDB(ID, p.data())
 
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch message \"%s\" data from DB (err: %v)", ID, err))
@@ -178,6 +197,46 @@ func (p *PlayerProxy) Messages(filters []db.Filter) ([]game.Message, error) {
 	return messages, nil
 }
 
+// RankingsHistory :
+// Return the evolution of the rankings of the players
+// matching the input filters as registered in all the
+// snapshots of the rankings.
+//
+// The `filters` define some filtering properties to be
+// applied when querying the snapshots.
+//
+// The `category` defines the category used to compute
+// the rank of the player in each snapshot.
+//
+// Returns the history of the rankings along with any
+// error.
+func (p *PlayerProxy) RankingsHistory(filters []db.Filter, category string) ([]game.RankingHistoryEntry, error) {
+	history, err := game.NewRankingHistoryFromDB(filters, category, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not fetch rankings history (err: %v)", err))
+	}
+
+	return history, err
+}
+
+// FleetsMovements :
+// Return the movements of fleets related to the input
+// player. This includes the fleets of the player and
+// the foreign fleets heading towards its planets or
+// moons.
+//
+// The `player` defines the identifier of the player.
+//
+// Returns the movements of fleets along with any error.
+func (p *PlayerProxy) FleetsMovements(player string) ([]game.FleetMovement, error) {
+	movements, err := game.NewFleetMovementsFromDB(player, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not fetch fleets movements for \"%s\" (err: %v)", player, err))
+	}
+
+	return movements, err
+}
+
 // Create :
 // Used to perform the creation of the player described
 // by the input structure in the DB. The player is both
@@ -203,18 +262,18 @@ func (p *PlayerProxy) Create(player game.Player) (string, error) {
 	// Assign a valid name in case it's not already the
 	// case.
 	if player.Name == "" && player.Universe != "" {
-		u, err := game.NewUniverseFromDB(player.Universe, p.data)
+		u, err := game.NewUniverseFromDB(player.Universe, p.data())
 		if err != nil {
 			return player.ID, err
 		}
 
-		player.Name, err = u.GenerateName(p.data.Proxy, maxNameTrials)
+		player.Name, err = u.GenerateName(p.data().Proxy, maxNameTrials)
 		if err != nil {
 			return player.ID, err
 		}
 	}
 
-	err := player.SaveToDB(p.data.Proxy)
+	err := player.SaveToDB(p.data().Proxy)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not create player \"%s\" (err: %v)", player.Name, err))
 		return player.ID, err
@@ -237,8 +296,21 @@ func (p *PlayerProxy) Create(player game.Player) (string, error) {
 // be updated (should match the `player.ID`) along
 // with any errors.
 func (p *PlayerProxy) Update(player game.Player) (string, error) {
+	// Fetch the current state of the player to make sure
+	// that the vacation mode can be changed if needed.
+	current, err := game.NewPlayerFromDB(player.ID, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Unable to fetch player \"%s\" to update (err: %v)", player.ID, err))
+		return player.ID, err
+	}
+
+	if current.VacationMode != player.VacationMode && !current.CanChangeVacationMode() {
+		p.trace(logger.Error, fmt.Sprintf("Could not update player \"%s\" (err: %v)", player.ID, game.ErrFleetsInFlight))
+		return player.ID, game.ErrFleetsInFlight
+	}
+
 	// Update the player in the DB.
-	err := player.UpdateInDB(p.data.Proxy)
+	err = player.UpdateInDB(p.data().Proxy)
 
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not update player \"%s\" (err: %v)", player.ID, err))
@@ -247,6 +319,93 @@ func (p *PlayerProxy) Update(player game.Player) (string, error) {
 	return player.ID, err
 }
 
+// UpdateActivity :
+// Used to refresh the inactivity flags of all the players
+// registered in the DB based on the last activity on any
+// of their planets. This is meant to be called regularly
+// by a background process.
+//
+// Returns any error.
+func (p *PlayerProxy) UpdateActivity() error {
+	query := db.InsertReq{
+		Script: "update_players_activity",
+		Args:   []interface{}{},
+	}
+
+	err := p.data().Proxy.InsertToDB(query)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not update players activity (err: %v)", err))
+	}
+
+	return err
+}
+
+// Events :
+// Used to fetch the events produced for the input player
+// after the specified event.
+//
+// The `player` defines the identifier of the player.
+//
+// The `after` defines the identifier of the last event
+// already known: only more recent events are returned.
+//
+// The `count` defines the maximum number of events to
+// return.
+//
+// Returns the events along with any error.
+func (p *PlayerProxy) Events(player string, after int64, count int) ([]game.Event, error) {
+	filters := []db.Filter{
+		{
+			Key:    "player",
+			Values: []interface{}{player},
+		},
+		{
+			Key:      "id",
+			Values:   []interface{}{after},
+			Operator: db.GreaterThan,
+		},
+	}
+
+	events, err := game.NewEventsFromDB(filters, count, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not fetch events for player \"%s\" (err: %v)", player, err))
+	}
+
+	return events, err
+}
+
+// LastEventID :
+// Used to fetch the identifier of the most recent event
+// produced for the input player.
+//
+// The `player` defines the identifier of the player.
+//
+// Returns the identifier of the event along with any
+// error.
+func (p *PlayerProxy) LastEventID(player string) (int64, error) {
+	ID, err := game.LastEventID(player, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not fetch last event for player \"%s\" (err: %v)", player, err))
+	}
+
+	return ID, err
+}
+
+// PurgeEvents :
+// Used to remove the events of players that are older
+// than the retention duration. This is meant to be
+// called regularly by a background process.
+//
+// Returns any error.
+func (p *PlayerProxy) PurgeEvents() error {
+	err := game.DeleteEvents(time.Now().Add(-eventsRetention), p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not purge players events (err: %v)", err))
+	}
+
+	return err
+}
+
 // Delete :
 // Used to perform the deletion of the player specified
 // by the input identifier. Checks are performed to make
@@ -258,11 +417,11 @@ func (p *PlayerProxy) Update(player game.Player) (string, error) {
 // Returns any error that occurred during the deletion.
 func (p *PlayerProxy) Delete(player string) error {
 	// Retrieve the player from the DB.
-	pl, err := game.NewPlayerFromDB(player, p.data)
+	pl, err := game.NewPlayerFromDB(player, p.data())
 	if err != nil {
 		return err
 	}
 
 	// We could fetch the player, attempt to delete it.
-	return pl.DeleteFromDB(p.data)
+	return pl.DeleteFromDB(p.data())
 }
@@ -0,0 +1,318 @@
+package data
+
+import (
+	"fmt"
+	"oglike_server/internal/game"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransportRouteProxy :
+// Intended as a wrapper to access properties of the
+// transport routes defined by players. A route is used
+// to regularly send a transport fleet between two of
+// the planets or moons of a player. The fleets created
+// by the routes go through the same validation process
+// as the ones created by the players.
+type TransportRouteProxy struct {
+	commonProxy
+
+	fleets FleetProxy
+}
+
+// NewTransportRouteProxy :
+// Create a new proxy allowing to serve the requests
+// related to transport routes.
+//
+// The `data` defines the data model to use to fetch
+// information and verify requests.
+//
+// The `log` allows to notify errors and information.
+//
+// Returns the created proxy.
+func NewTransportRouteProxy(data game.Instance, log logger.Logger) TransportRouteProxy {
+	return TransportRouteProxy{
+		commonProxy: newCommonProxy(data, log, "logistics"),
+		fleets:      NewFleetProxy(data, log),
+	}
+}
+
+// For :
+// Returns a copy of this proxy performing its logs with
+// the input logger. It allows to attach the logs of the
+// proxy to the request being served.
+//
+// The `log` defines the logger to use.
+//
+// Returns the copy of the proxy.
+func (p *TransportRouteProxy) For(log logger.Logger) *TransportRouteProxy {
+	c := *p
+	c.commonProxy = c.withLogger(log)
+	c.fleets.commonProxy = c.fleets.withLogger(log)
+
+	return &c
+}
+
+// Routes :
+// Return a list of transport routes registered so far
+// in the DB and matching the input filters.
+//
+// The `filters` define some filtering properties that
+// can be applied to the SQL query to only select part
+// of the routes.
+//
+// Returns the list of routes along with any error.
+func (p *TransportRouteProxy) Routes(filters []db.Filter) ([]game.TransportRoute, error) {
+	routes := make([]game.TransportRoute, 0)
+
+	IDs, err := p.fetchRoutesIDs(filters)
+	if err != nil {
+		return routes, err
+	}
+
+	for _, ID := range IDs {
+		tr, err := game.NewTransportRouteFromDB(ID, p.data())
+
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Unable to fetch transport route \"%s\" data from DB (err: %v)", ID, err))
+			continue
+		}
+
+		routes = append(routes, tr)
+	}
+
+	return routes, nil
+}
+
+// Create :
+// Used to perform the creation of the input transport
+// route. The source and target of the route should both
+// belong to the player of the route. The first fleet is
+// sent at the next run of the background process.
+//
+// The `route` defines the route to create.
+//
+// Returns the identifier of the created route along
+// with any error.
+func (p *TransportRouteProxy) Create(route game.TransportRoute) (string, error) {
+	// Assign a valid identifier if this is not already the case.
+	if route.ID == "" {
+		route.ID = uuid.New().String()
+	}
+
+	route.NextRun = time.Now()
+
+	err := route.Validate(p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Validation for transport route failed (err: %v)", err))
+		return route.ID, err
+	}
+
+	err = route.SaveToDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not create transport route \"%s\" for \"%s\" (err: %v)", route.ID, route.Player, err))
+		return route.ID, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Created new transport route \"%s\" for \"%s\"", route.ID, route.Player))
+
+	return route.ID, nil
+}
+
+// Delete :
+// Used to remove the transport route described by the
+// input identifier. The fleets already sent by the route
+// are not affected.
+//
+// The `route` defines the identifier of the route.
+//
+// Returns any error.
+func (p *TransportRouteProxy) Delete(route string) error {
+	tr, err := game.NewTransportRouteFromDB(route, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not fetch transport route \"%s\" (err: %v)", route, err))
+		return err
+	}
+
+	err = tr.DeleteFromDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not delete transport route \"%s\" (err: %v)", route, err))
+		return err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Deleted transport route \"%s\"", route))
+
+	return nil
+}
+
+// Process :
+// Used to send the fleets of all the transport routes
+// that are due. In case a route cannot send its fleet
+// the owner is notified with the reason. Routes whose
+// source or target does not exist anymore are deleted.
+//
+// Returns any error.
+func (p *TransportRouteProxy) Process() error {
+	now := time.Now()
+
+	IDs, err := p.fetchRoutesIDs(
+		[]db.Filter{
+			{
+				Key:      "next_run",
+				Values:   []interface{}{now},
+				Operator: db.LessThan,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, ID := range IDs {
+		tr, err := game.NewTransportRouteFromDB(ID, p.data())
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Unable to fetch transport route \"%s\" (err: %v)", ID, err))
+			continue
+		}
+
+		p.processRoute(&tr)
+
+		err = tr.Schedule(now, p.data().Proxy)
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Could not schedule transport route \"%s\" (err: %v)", tr.ID, err))
+		}
+	}
+
+	return nil
+}
+
+// processRoute :
+// Used to send the fleet of the input route. Any error
+// is reported to the owner of the route.
+//
+// The `tr` defines the route to process.
+func (p *TransportRouteProxy) processRoute(tr *game.TransportRoute) {
+	// Make sure that the route is still consistent.
+	err := tr.Validate(p.data())
+	if err == game.ErrInvalidSourceForFleet || err == game.ErrInvalidTargetForFleet {
+		p.trace(logger.Warning, fmt.Sprintf("Deleting transport route \"%s\" with invalid source or target", tr.ID))
+
+		err = tr.DeleteFromDB(p.data().Proxy)
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Could not delete transport route \"%s\" (err: %v)", tr.ID, err))
+		}
+
+		return
+	}
+
+	f, err := tr.NewFleet(p.data())
+
+	// Validate the fleet without any cargo to compute
+	// its consumption and fetch the resources of the
+	// source.
+	var source *game.Planet
+	if err == nil {
+		source, err = p.fleets.validateFleet(&f)
+	}
+	if err == nil {
+		err = tr.FillCargo(&f, source, p.data())
+	}
+
+	// Routes with nothing to move are skipped silently.
+	if err == nil && len(f.Cargo) == 0 {
+		p.trace(logger.Verbose, fmt.Sprintf("Nothing to move for transport route \"%s\"", tr.ID))
+		return
+	}
+
+	if err == nil {
+		_, err = p.fleets.CreateFleet(f)
+	}
+
+	if err == nil {
+		p.trace(logger.Notice, fmt.Sprintf("Transport route \"%s\" sent fleet \"%s\"", tr.ID, f.ID))
+		return
+	}
+
+	p.trace(logger.Warning, fmt.Sprintf("Transport route \"%s\" could not send fleet (err: %v)", tr.ID, err))
+
+	err = tr.Skip(skipReason(err), p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not notify skipped transport route \"%s\" (err: %v)", tr.ID, err))
+	}
+}
+
+// skipReason :
+// Used to convert the error preventing a transport
+// route to send its fleet into a message for the
+// owner of the route.
+//
+// The `err` defines the error to convert.
+//
+// Returns the reason to notify.
+func skipReason(err error) string {
+	switch err {
+	case ErrTooManyFleets:
+		return "no fleet slot is available"
+	case game.ErrNotEnoughShips:
+		return "not enough ships are available"
+	case game.ErrNotEnoughFuel:
+		return "not enough fuel is available"
+	case game.ErrPlayerInVacationMode:
+		return "the player is in vacation mode"
+	}
+
+	return err.Error()
+}
+
+// fetchRoutesIDs :
+// Used to fetch the identifiers of the transport routes
+// matching the input filters.
+//
+// The `filters` define the filters to apply.
+//
+// Returns the identifiers along with any error.
+func (p *TransportRouteProxy) fetchRoutesIDs(filters []db.Filter) ([]string, error) {
+	IDs := make([]string, 0)
+
+	// Create the query and execute it.
+	query := db.QueryDesc{
+		Props: []string{
+			"id",
+		},
+		Table:    "transport_routes",
+		Filters:  filters,
+		Ordering: "order by next_run",
+	}
+
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not query DB to fetch transport routes (err: %v)", err))
+		return IDs, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Invalid query to fetch transport routes (err: %v)", dbRes.Err))
+		return IDs, dbRes.Err
+	}
+
+	var ID string
+
+	for dbRes.Next() {
+		err = dbRes.Scan(&ID)
+
+		if err != nil {
+			p.trace(logger.Error, fmt.Sprintf("Error while fetching transport route ID (err: %v)", err))
+			continue
+		}
+
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, nil
+}
@@ -5,6 +5,7 @@ import (
 	"oglike_server/internal/game"
 	"oglike_server/pkg/db"
 	"oglike_server/pkg/logger"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -34,6 +35,21 @@ func NewUniverseProxy(data game.Instance, log logger.Logger) UniverseProxy {
 	}
 }
 
+// For :
+// Returns a copy of this proxy performing its logs with
+// the input logger. It allows to attach the logs of the
+// proxy to the request being served.
+//
+// The `log` defines the logger to use.
+//
+// Returns the copy of the proxy.
+func (p *UniverseProxy) For(log logger.Logger) *UniverseProxy {
+	c := *p
+	c.commonProxy = c.withLogger(log)
+
+	return &c
+}
+
 // Universes :
 // Return a list of universes registered so far in all the
 // values defined in the DB. The input filters might help
@@ -58,7 +74,7 @@ func (p *UniverseProxy) Universes(filters []db.Filter) ([]game.Universe, error)
 		Filters: filters,
 	}
 
-	dbRes, err := p.data.Proxy.FetchFromDB(query)
+	dbRes, err := p.data().Proxy.FetchFromDB(query)
 
 	// Check for errors.
 	if err != nil {
@@ -92,7 +108,7 @@ func (p *UniverseProxy) Universes(filters []db.Filter) ([]game.Universe, error)
 	universes := make([]game.Universe, 0)
 
 	for _, ID = range IDs {
-		uni, err := game.NewUniverseFromDB(ID, p.data)
+		uni, err := game.NewUniverseFromDB(ID, p.data())
 
 		if err != nil {
 			p.trace(logger.Error, fmt.Sprintf("Unable to fetch universe \"%s\" data from DB (err: %v)", ID, err))
@@ -106,76 +122,59 @@ func (p *UniverseProxy) Universes(filters []db.Filter) ([]game.Universe, error)
 }
 
 // Rankings :
-// Return a list of the players registered in the universe
-// sorted by order and with the points associated to each
-// one.
+// Return a page of the rankings of the players registered
+// in a universe as defined by the most recent snapshot of
+// the rankings matching the input options.
 //
 // The `filters` define some filtering properties that can
-// be applied to the SQL query to only select part of all
-// the players. Each one is appended `as-is` to the query.
+// be applied to the SQL query to select the snapshot. Each
+// one is appended `as-is` to the query.
 //
-// Returns the list of rankings for the universes as fetched
+// The `opts` define the category, time and page of the
+// rankings to fetch.
+//
+// Returns the page of rankings for the universe as fetched
 // in the DB along any errors.
-func (p *UniverseProxy) Rankings(filters []db.Filter) ([]game.Ranking, error) {
-	// Create the query and execute it.
-	query := db.QueryDesc{
-		Props: []string{
-			"p.id",
-			"pp.economy_points",
-			"pp.military_points",
-			"pp.military_points_built",
-			"pp.military_points_lost",
-			"pp.military_points_destroyed",
-			"(pp.research_points + pp.military_points + pp.economy_points) as points",
-		},
-		Table:    "players p inner join players_points pp on p.id = pp.player",
-		Filters:  filters,
-		Ordering: "order by points desc",
+func (p *UniverseProxy) Rankings(filters []db.Filter, opts game.RankingOptions) (game.RankingPage, error) {
+	rp, err := game.NewRankingPageFromDB(filters, opts, p.data())
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not fetch rankings (err: %v)", err))
 	}
 
-	dbRes, err := p.data.Proxy.FetchFromDB(query)
+	return rp, err
+}
 
-	// Check for errors.
+// CreateRankingSnapshots :
+// Used to register a snapshot of the current rankings of
+// all the universes. This is meant to be called regularly
+// by a background process.
+//
+// Returns any error.
+func (p *UniverseProxy) CreateRankingSnapshots() error {
+	err := game.CreateRankingSnapshots(time.Now(), p.data().Proxy)
 	if err != nil {
-		p.trace(logger.Error, fmt.Sprintf("Could not query DB to fetch rankings (err: %v)", err))
-		return []game.Ranking{}, err
+		p.trace(logger.Error, fmt.Sprintf("Could not create rankings snapshots (err: %v)", err))
 	}
-	defer dbRes.Close()
-
-	if dbRes.Err != nil {
-		p.trace(logger.Error, fmt.Sprintf("Invalid query to fetch rankings (err: %v)", dbRes.Err))
-		return []game.Ranking{}, dbRes.Err
-	}
-
-	// Traverse the fetched data and build the output array.
-	rankings := []game.Ranking{}
-	rankNum := 0
-	var pts float32
-
-	for dbRes.Next() {
-		var rank game.Ranking
-		err = dbRes.Scan(
-			&rank.Player,
-			&rank.Economy,
-			&rank.Research,
-			&rank.MilitaryBuilt,
-			&rank.MilitaryLost,
-			&rank.MilitaryDestroyed,
-			&pts,
-		)
 
-		if err != nil {
-			p.trace(logger.Error, fmt.Sprintf("Error while fetching rank %d (err: %v)", rankNum, err))
-			continue
-		}
-
-		rank.Rank = rankNum
-		rankNum++
+	return err
+}
 
-		rankings = append(rankings, rank)
+// PurgeRankingSnapshots :
+// Used to remove the snapshots of the rankings that are
+// older than the input retention. This is meant to be
+// called regularly by a background process.
+//
+// The `retention` defines the duration during which the
+// snapshots are kept. A value of `0` keeps them forever.
+//
+// Returns any error.
+func (p *UniverseProxy) PurgeRankingSnapshots(retention time.Duration) error {
+	err := game.DeleteRankingSnapshots(time.Now(), retention, p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not purge rankings snapshots (err: %v)", err))
 	}
 
-	return rankings, nil
+	return err
 }
 
 // Create :
@@ -198,7 +197,18 @@ func (p *UniverseProxy) Create(uni game.Universe) (string, error) {
 		uni.ID = uuid.New().String()
 	}
 
-	err := uni.SaveToDB(p.data.Proxy)
+	// Universes use the rule set of the server unless
+	// specified otherwise: in which case it should be
+	// the same as the one used by the server.
+	if uni.RuleSet == "" {
+		uni.RuleSet = p.data().RuleSet
+	}
+	if uni.RuleSet != p.data().RuleSet {
+		p.trace(logger.Error, fmt.Sprintf("Could not create universe \"%s\" with rule set \"%s\"", uni.Name, uni.RuleSet))
+		return uni.ID, game.ErrUnsupportedRuleSet
+	}
+
+	err := uni.SaveToDB(p.data().Proxy)
 	if err != nil {
 		p.trace(logger.Error, fmt.Sprintf("Could not create universe \"%s\" (err: %v)", uni.Name, err))
 		return uni.ID, err
@@ -208,3 +218,26 @@ func (p *UniverseProxy) Create(uni game.Universe) (string, error) {
 
 	return uni.ID, nil
 }
+
+// Update :
+// Used to perform the update of the configuration of the
+// universe described by the input data. The dimensions of
+// the universe, its country and its rule set can't be
+// changed.
+//
+// The `uni` defines the data to use to update the DB
+// version of the universe.
+//
+// Returns the identifier of the universe that has been
+// updated along with any errors.
+func (p *UniverseProxy) Update(uni game.Universe) (string, error) {
+	err := uni.UpdateInDB(p.data().Proxy)
+	if err != nil {
+		p.trace(logger.Error, fmt.Sprintf("Could not update universe \"%s\" (err: %v)", uni.ID, err))
+		return uni.ID, err
+	}
+
+	p.trace(logger.Notice, fmt.Sprintf("Updated universe \"%s\"", uni.ID))
+
+	return uni.ID, nil
+}
@@ -589,6 +589,8 @@ func (acs *ACSFleet) simulate(p *Planet, data Instance) error {
 		return ErrFleetFightSimulationFailure
 	}
 
+	fights.Inc(objectiveName(acs.Objective, data), result.outcome.String())
+
 	// Just like regular fleets we need to
 	// handle the resources already carried
 	// by the fleets composing the ACS.
@@ -653,6 +655,13 @@ func (acs *ACSFleet) simulate(p *Planet, data Instance) error {
 		return ErrFleetFightSimulationFailure
 	}
 
+	// Keep track of the attack for all the
+	// participants of the ACS.
+	err = registerAttack(a, p, result, data)
+	if err != nil {
+		return ErrFleetFightSimulationFailure
+	}
+
 	// Update each fleet's data in the DB.
 	for _, f := range fleets {
 		// Execute the query to update the fleet with
@@ -0,0 +1,170 @@
+package game
+
+import (
+	"fmt"
+	"oglike_server/pkg/db"
+	"time"
+)
+
+// QueuedAction :
+// Describes an entry of the actions queue: each action
+// that should be executed at some point in the future
+// (upgrade of a building, arrival of a fleet, etc.) is
+// registered in it until it is executed.
+type QueuedAction struct {
+	// The `ID` defines the identifier of the action.
+	ID string `json:"id"`
+
+	// The `Kind` defines the type of the action. It is
+	// used to determine how it should be executed.
+	Kind string `json:"kind"`
+
+	// The `CompletionTime` defines the time at which the
+	// action should be executed.
+	CompletionTime time.Time `json:"completion_time"`
+}
+
+// ErrActionNotQueued : Indicates that the action is not registered in the actions queue.
+var ErrActionNotQueued = fmt.Errorf("action is not registered in the actions queue")
+
+// NewQueuedActionsFromDB :
+// Used to fetch the entries of the actions queue which
+// match the input filters. The entries are returned in
+// increasing order of completion time.
+//
+// The `filters` define the filters to apply to select
+// the entries.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the entries along with any error.
+func NewQueuedActionsFromDB(filters []db.Filter, data Instance) ([]QueuedAction, error) {
+	actions := make([]QueuedAction, 0)
+
+	query := db.QueryDesc{
+		Props: []string{
+			"action",
+			"type",
+			"completion_time",
+		},
+		Table:    "actions_queue",
+		Filters:  filters,
+		Ordering: "order by completion_time",
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return actions, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return actions, dbRes.Err
+	}
+
+	var a QueuedAction
+
+	for dbRes.Next() {
+		err = dbRes.Scan(
+			&a.ID,
+			&a.Kind,
+			&a.CompletionTime,
+		)
+
+		if err != nil {
+			return actions, err
+		}
+
+		actions = append(actions, a)
+	}
+
+	return actions, nil
+}
+
+// ExecuteAction :
+// Used to execute the input action right away no matter
+// its completion time. This is meant for actions which
+// are stuck in the actions queue: the execution follows
+// the same process as the one used when the action is
+// completed.
+// The lock on the actions is acquired in the DB so that
+// a running server does not process the action at the
+// same time.
+//
+// The `action` defines the identifier of the action to
+// execute.
+//
+// Returns any error.
+func (i Instance) ExecuteAction(action string) error {
+	release, err := i.Proxy.AdvisoryLock(actionsLockKey)
+	defer release()
+
+	if err != nil {
+		return err
+	}
+
+	filters := []db.Filter{
+		{
+			Key:    "action",
+			Values: []interface{}{action},
+		},
+	}
+
+	// The action might have been executed while waiting
+	// for the lock.
+	actions, err := NewQueuedActionsFromDB(filters, i)
+	if err != nil {
+		return err
+	}
+
+	if len(actions) == 0 {
+		return ErrActionNotQueued
+	}
+
+	err = i.executeAction(action, actionKind(actions[0].Kind))
+	if err != nil {
+		return err
+	}
+
+	if i.Events != nil {
+		i.Events.notifyAll()
+	}
+
+	return nil
+}
+
+// PurgeAction :
+// Used to remove the input action from the actions queue
+// so that it is never executed. In case the action is a
+// construction action it is removed along with all its
+// effects. Other actions (such as fleets) are only
+// removed from the queue.
+// Similarly to `ExecuteAction` the lock on the actions is
+// acquired in the DB during the process.
+//
+// The `action` defines the identifier of the action to
+// remove.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func PurgeAction(action string, proxy db.Proxy) error {
+	release, err := proxy.AdvisoryLock(actionsLockKey)
+	defer release()
+
+	if err != nil {
+		return err
+	}
+
+	query := db.InsertReq{
+		Script: "purge_action",
+		Args: []interface{}{
+			action,
+		},
+		SkipReturn: true,
+	}
+
+	return proxy.InsertToDB(query)
+}
@@ -563,8 +563,9 @@ func (a *BuildingAction) ConsolidateEffects(data Instance, p *Planet, ratio floa
 		a.Storage = append(a.Storage, e)
 	}
 
-	// And fields effects.
-	a.Fields.Additional = bd.Fields.ComputeFields(a.DesiredLevel)
+	// And fields effects: destroying a level of the
+	// building removes the fields it provided.
+	a.Fields.Additional = bd.Fields.TotalFields(a.DesiredLevel) - bd.Fields.TotalFields(a.CurrentLevel)
 
 	// Finally compute the additional points that will
 	// be brought by this action upon completing it.
@@ -734,13 +735,13 @@ func (a *BuildingAction) Validate(data Instance, p *Planet, ratio float32) error
 		return ErrBuildingCannotBeBuilt
 	}
 
-	// Make sure that if the action requires to use one
-	// more field there is at least one available.
-	if p.RemainingFields() == 0 && a.DesiredLevel > a.CurrentLevel {
-		return ErrNoFieldsLeft
+	// Validate against planet's data. Upgrading the
+	// building requires one more field.
+	fields := 0
+	if a.DesiredLevel > a.CurrentLevel {
+		fields = 1
 	}
 
-	// Validate against planet's data.
 	var costs map[string]int
 
 	if a.CurrentLevel < a.DesiredLevel {
@@ -749,5 +750,5 @@ func (a *BuildingAction) Validate(data Instance, p *Planet, ratio float32) error
 		costs = bd.Cost.ComputeCost(a.DesiredLevel)
 	}
 
-	return p.validateAction(costs, bd.UpgradableDesc, data)
+	return p.validateAction(costs, bd.UpgradableDesc, fields, data)
 }
@@ -257,5 +257,5 @@ func (a *DefenseAction) Validate(data Instance, p *Planet, ratio float32) error
 
 	costs := dd.Cost.ComputeCost(a.Remaining)
 
-	return p.validateAction(costs, dd.UpgradableDesc, data)
+	return p.validateAction(costs, dd.UpgradableDesc, 0, data)
 }
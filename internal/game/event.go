@@ -0,0 +1,578 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"oglike_server/pkg/db"
+	"sync"
+	"time"
+)
+
+// EventKind :
+// Defines the possible kinds of events that can be
+// produced for a player.
+type EventKind string
+
+// Define the possible kinds of events.
+const (
+	ActionCompleted EventKind = "action_completed"
+	FleetArrived    EventKind = "fleet_arrived"
+	FleetReturned   EventKind = "fleet_returned"
+	HostileFleet    EventKind = "hostile_fleet"
+	NewMessage      EventKind = "message"
+)
+
+// Event :
+// Defines an event that happened in the game and is
+// of interest for a player. Events are registered in
+// the DB so that a client can resume the stream from
+// the last event it received.
+//
+// The `ID` defines the identifier of the event. It is
+// increasing with each new event.
+//
+// The `Player` defines the identifier of the player to
+// which the event is directed.
+//
+// The `Kind` defines the type of the event.
+//
+// The `Data` defines the payload of the event. Its
+// content depends on the kind of the event.
+//
+// The `CreatedAt` defines when the event was created.
+type Event struct {
+	ID        int64       `json:"id"`
+	Player    string      `json:"player"`
+	Kind      EventKind   `json:"kind"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// EventsBroker :
+// Used to notify the listeners of the events of a
+// player that new events are available. It does not
+// carry the events themselves which should be read
+// from the DB.
+//
+// The `lock` protects the listeners from concurrent
+// accesses.
+//
+// The `listeners` defines the channels to notify for
+// each player.
+type EventsBroker struct {
+	lock      sync.Mutex
+	listeners map[string][]chan struct{}
+}
+
+// NewEventsBroker :
+// Used to create a new broker with no listeners.
+//
+// Returns the created broker.
+func NewEventsBroker() *EventsBroker {
+	return &EventsBroker{
+		listeners: make(map[string][]chan struct{}),
+	}
+}
+
+// Subscribe :
+// Used to register a new listener for the events of
+// the input player. The returned channel receives a
+// value whenever new events may be available.
+//
+// The `player` defines the identifier of the player.
+//
+// Returns the channel to listen to and a function to
+// call to unregister the listener.
+func (eb *EventsBroker) Subscribe(player string) (<-chan struct{}, func()) {
+	c := make(chan struct{}, 1)
+
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+
+	eb.listeners[player] = append(eb.listeners[player], c)
+
+	return c, func() {
+		eb.lock.Lock()
+		defer eb.lock.Unlock()
+
+		l := eb.listeners[player]
+		for id := range l {
+			if l[id] == c {
+				eb.listeners[player] = append(l[:id], l[id+1:]...)
+				break
+			}
+		}
+
+		if len(eb.listeners[player]) == 0 {
+			delete(eb.listeners, player)
+		}
+	}
+}
+
+// notify :
+// Used to wake up the listeners of the input player.
+// Listeners that were already notified are skipped.
+//
+// The `player` defines the player to notify.
+func (eb *EventsBroker) notify(player string) {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+
+	for _, c := range eb.listeners[player] {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// notifyAll :
+// Used to wake up all the listeners. This is useful
+// when events were created by the DB itself and we
+// don't know which players are concerned.
+func (eb *EventsBroker) notifyAll() {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+
+	for _, listeners := range eb.listeners {
+		for _, c := range listeners {
+			select {
+			case c <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// NewEventsFromDB :
+// Used to fetch the events matching the input filters
+// from the DB. Events are returned in increasing order
+// of their identifiers.
+//
+// The `filters` define the filters to apply to select
+// the events.
+//
+// The `count` defines the maximum number of events to
+// fetch.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the events along with any error.
+func NewEventsFromDB(filters []db.Filter, count int, data Instance) ([]Event, error) {
+	events := make([]Event, 0)
+
+	query := db.QueryDesc{
+		Props: []string{
+			"id",
+			"player",
+			"kind",
+			"data::text",
+			"created_at",
+		},
+		Table:    "players_events",
+		Filters:  filters,
+		Ordering: fmt.Sprintf("order by id limit %d", count),
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return events, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return events, dbRes.Err
+	}
+
+	var e Event
+	var payload string
+
+	for dbRes.Next() {
+		err = dbRes.Scan(
+			&e.ID,
+			&e.Player,
+			&e.Kind,
+			&payload,
+			&e.CreatedAt,
+		)
+
+		if err != nil {
+			return events, err
+		}
+
+		e.Data = json.RawMessage(payload)
+
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// LastEventID :
+// Used to fetch the identifier of the last event that
+// was produced for the input player.
+//
+// The `player` defines the identifier of the player.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the identifier of the last event or `0` if
+// no events exist along with any error.
+func LastEventID(player string, data Instance) (int64, error) {
+	query := db.QueryDesc{
+		Props: []string{
+			"coalesce(max(id), 0)",
+		},
+		Table: "players_events",
+		Filters: []db.Filter{
+			{
+				Key:    "player",
+				Values: []interface{}{player},
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return 0, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return 0, dbRes.Err
+	}
+
+	var ID int64
+	if dbRes.Next() {
+		err = dbRes.Scan(&ID)
+	}
+
+	return ID, err
+}
+
+// DeleteEvents :
+// Used to remove the events that are older than the
+// input date from the DB.
+//
+// The `moment` defines the date before which events
+// are deleted.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func DeleteEvents(moment time.Time, proxy db.Proxy) error {
+	query := db.InsertReq{
+		Script: "delete_players_events",
+		Args: []interface{}{
+			moment.Format(time.RFC3339),
+		},
+		SkipReturn: true,
+	}
+
+	return proxy.InsertToDB(query)
+}
+
+// registerEvent :
+// Used to save a new event for the input player and
+// to notify the listeners of this player.
+//
+// The `player` defines the player concerned by the
+// event.
+//
+// The `kind` defines the kind of the event.
+//
+// The `payload` defines the data of the event.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func registerEvent(player string, kind EventKind, payload interface{}, data Instance) error {
+	query := db.InsertReq{
+		Script: "create_player_event",
+		Args: []interface{}{
+			struct {
+				Player string      `json:"player"`
+				Kind   EventKind   `json:"kind"`
+				Data   interface{} `json:"data"`
+			}{
+				Player: player,
+				Kind:   kind,
+				Data:   payload,
+			},
+		},
+		SkipReturn: true,
+	}
+
+	err := data.Proxy.InsertToDB(query)
+	if err == nil && data.Events != nil {
+		data.Events.notify(player)
+	}
+
+	return err
+}
+
+// actionEventDesc :
+// Describes how to fetch the information needed to
+// produce the event associated to an action.
+//
+// The `category` defines the category of the element
+// concerned by the action.
+//
+// The `table` defines the table to query.
+//
+// The `location` defines the column describing where
+// the action takes place.
+//
+// The `level` defines the column describing the level
+// reached by the action if any.
+type actionEventDesc struct {
+	category string
+	table    string
+	location string
+	level    string
+}
+
+// actionEvents :
+// Defines the way to fetch information about each kind
+// of action. The player is always aliased to `p` in the
+// tables.
+var actionEvents = map[actionKind]actionEventDesc{
+	planetBuilding: {
+		category: "building",
+		table:    "construction_actions_buildings a inner join planets p on a.planet = p.id",
+		location: "a.planet",
+		level:    "a.desired_level",
+	},
+	moonBuilding: {
+		category: "building",
+		table:    "construction_actions_buildings_moon a inner join moons m on a.moon = m.id inner join planets p on m.planet = p.id",
+		location: "a.moon",
+		level:    "a.desired_level",
+	},
+	technology: {
+		category: "technology",
+		table:    "construction_actions_technologies a inner join planets p on a.planet = p.id",
+		location: "a.planet",
+		level:    "a.desired_level",
+	},
+	planetShip: {
+		category: "ship",
+		table:    "construction_actions_ships a inner join planets p on a.planet = p.id",
+		location: "a.planet",
+	},
+	moonShip: {
+		category: "ship",
+		table:    "construction_actions_ships_moon a inner join moons m on a.moon = m.id inner join planets p on m.planet = p.id",
+		location: "a.moon",
+	},
+	planetDefense: {
+		category: "defense",
+		table:    "construction_actions_defenses a inner join planets p on a.planet = p.id",
+		location: "a.planet",
+	},
+	moonDefense: {
+		category: "defense",
+		table:    "construction_actions_defenses_moon a inner join moons m on a.moon = m.id inner join planets p on m.planet = p.id",
+		location: "a.moon",
+	},
+}
+
+// actionEvent :
+// Defines the payload of an event produced when an
+// action is completed.
+type actionEvent struct {
+	Action   string `json:"action"`
+	Category string `json:"category"`
+	Location string `json:"location"`
+	Element  string `json:"element"`
+	Level    int    `json:"level,omitempty"`
+}
+
+// fetchActionEvent :
+// Used to fetch the information about the input action
+// so that an event can be produced once it is executed.
+// This should be called before the action is executed
+// as it might be removed from the DB in the process.
+//
+// The `action` defines the identifier of the action.
+//
+// The `kind` defines the kind of the action.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the player concerned by the action and the
+// payload of the event along with any error.
+func fetchActionEvent(action string, kind actionKind, data Instance) (string, actionEvent, error) {
+	ae := actionEvent{
+		Action: action,
+	}
+
+	desc, ok := actionEvents[kind]
+	if !ok {
+		return "", ae, ErrInvalidElementID
+	}
+
+	ae.Category = desc.category
+
+	props := []string{
+		"p.player",
+		desc.location,
+		"a.element",
+	}
+	if desc.level != "" {
+		props = append(props, desc.level)
+	}
+
+	query := db.QueryDesc{
+		Props: props,
+		Table: desc.table,
+		Filters: []db.Filter{
+			{
+				Key:    "a.id",
+				Values: []interface{}{action},
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return "", ae, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return "", ae, dbRes.Err
+	}
+
+	if !dbRes.Next() {
+		return "", ae, ErrElementNotFound
+	}
+
+	var player string
+	dest := []interface{}{
+		&player,
+		&ae.Location,
+		&ae.Element,
+	}
+	if desc.level != "" {
+		dest = append(dest, &ae.Level)
+	}
+
+	err = dbRes.Scan(dest...)
+
+	return player, ae, err
+}
+
+// fleetEvent :
+// Defines the payload of an event related to a fleet.
+type fleetEvent struct {
+	Fleet     string     `json:"fleet"`
+	Objective string     `json:"objective"`
+	Source    string     `json:"source"`
+	Target    Coordinate `json:"target"`
+	Time      time.Time  `json:"time"`
+}
+
+// WarnTarget :
+// Used to notify the owner of the target of the fleet
+// that a hostile fleet is heading towards one of its
+// planets or moons. This both posts a message to the
+// player and registers an event. Nothing happens in
+// case the fleet is not hostile or does not target a
+// player.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (f *Fleet) WarnTarget(data Instance) error {
+	obj, err := data.Objectives.GetObjectiveFromID(f.Objective)
+	if err != nil {
+		return err
+	}
+
+	if !obj.Hostile || f.Target == "" {
+		return nil
+	}
+
+	var target Planet
+
+	switch f.TargetCoords.Type {
+	case World:
+		target, err = NewPlanetFromDB(f.Target, data)
+	case Moon:
+		target, err = NewMoonFromDB(f.Target, data)
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if target.Player == f.Player {
+		return nil
+	}
+
+	query := db.InsertReq{
+		Script: "create_hostile_fleet_message",
+		Args: []interface{}{
+			f.ID,
+		},
+		SkipReturn: true,
+	}
+
+	err = data.Proxy.InsertToDB(query)
+	if err != nil {
+		return err
+	}
+
+	fe := fleetEvent{
+		Fleet:     f.ID,
+		Objective: obj.Name,
+		Source:    f.Source,
+		Target:    f.TargetCoords,
+		Time:      f.ArrivalTime,
+	}
+
+	return registerEvent(target.Player, HostileFleet, fe, data)
+}
+
+// registerFleetEvent :
+// Used to notify the owner of the fleet that it has
+// either arrived at its destination or returned to
+// its source.
+//
+// The `returning` defines whether the fleet was on
+// its way back when it was processed.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (f *Fleet) registerFleetEvent(returning bool, data Instance) error {
+	obj, err := data.Objectives.GetObjectiveFromID(f.Objective)
+	if err != nil {
+		return err
+	}
+
+	fe := fleetEvent{
+		Fleet:     f.ID,
+		Objective: obj.Name,
+		Source:    f.Source,
+		Target:    f.TargetCoords,
+		Time:      f.ArrivalTime,
+	}
+
+	kind := FleetArrived
+	if returning {
+		kind = FleetReturned
+		fe.Time = f.ReturnTime
+	}
+
+	return registerEvent(f.Player, kind, fe, data)
+}
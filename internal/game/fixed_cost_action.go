@@ -280,6 +280,28 @@ func (a *FixedAction) computeCompletionTime(data Instance, cost model.FixedCost,
 	return nil
 }
 
+// fixedActionOutput :
+// Describes the JSON representation of a fixed cost
+// action as it is sent to clients.
+type fixedActionOutput struct {
+	ID      string `json:"id"`
+	Planet  string `json:"planet,omitempty"`
+	Element string `json:"element"`
+
+	Amount         int               `json:"amount"`
+	Remaining      int               `json:"remaining"`
+	CompletionTime duration.Duration `json:"completion_time"`
+	CreationTime   time.Time         `json:"created_at"`
+}
+
+// JSONModel :
+// Returns a value having the same JSON representation
+// as the action. It is used to describe the action in
+// the specification of the API.
+func (a *FixedAction) JSONModel() interface{} {
+	return fixedActionOutput{}
+}
+
 // MarshalJSON :
 // Used to marshal the content defined by this fixed
 // cost action in order to make it available to other
@@ -288,16 +310,7 @@ func (a *FixedAction) computeCompletionTime(data Instance, cost model.FixedCost,
 //
 // Returns the marshalled content and an error.
 func (a *FixedAction) MarshalJSON() ([]byte, error) {
-	o := struct {
-		ID      string `json:"id"`
-		Planet  string `json:"planet,omitempty"`
-		Element string `json:"element"`
-
-		Amount         int               `json:"amount"`
-		Remaining      int               `json:"remaining"`
-		CompletionTime duration.Duration `json:"completion_time"`
-		CreationTime   time.Time         `json:"created_at"`
-	}{
+	o := fixedActionOutput{
 		ID:      a.ID,
 		Planet:  a.Planet,
 		Element: a.Element,
@@ -76,6 +76,13 @@ type Fleet struct {
 	// fleet was created. It is the launch time.
 	CreatedAt time.Time `json:"created_at"`
 
+	// The `DepartureTime` defines an optional time in
+	// the future at which the fleet should be launched.
+	// If it is not provided the fleet leaves as soon as
+	// it is created. Otherwise the ships, fuel and cargo
+	// are reserved on the source until the departure.
+	DepartureTime time.Time `json:"departure_time"`
+
 	// The `ArrivalTime` represents the time at which
 	// the fleet should arrive at its destination. This
 	// value is computed in the server and any data
@@ -309,6 +316,9 @@ var ErrUnableToSimulateFleet = fmt.Errorf("error occurred while simulating fleet
 // parent universe of the fleet.
 var ErrMultipliersError = fmt.Errorf("error occurred while fetching universe's multipliers")
 
+// ErrInvalidDepartureTime : Indicates that the departure time of a fleet is not in the future.
+var ErrInvalidDepartureTime = fmt.Errorf("departure time for fleet is not valid")
+
 // Valid :
 // Determines whether the fleet is valid. By valid we only
 // mean obvious syntax errors.
@@ -345,6 +355,9 @@ func (f *Fleet) Valid(uni Universe) error {
 	if f.Speed <= 0.0 || f.Speed > 1.0 {
 		return ErrInvalidSpeedForFleet
 	}
+	if !f.DepartureTime.IsZero() && f.DepartureTime.Before(time.Now()) {
+		return ErrInvalidDepartureTime
+	}
 	if err := f.Ships.valid(); err != nil {
 		return err
 	}
@@ -815,6 +828,37 @@ func (f *Fleet) SaveToDB(proxy db.Proxy) error {
 	return dbe
 }
 
+// fleetOutput :
+// Describes the JSON representation of a fleet as it
+// is sent to clients.
+type fleetOutput struct {
+	ID             string                 `json:"id"`
+	Universe       string                 `json:"universe"`
+	Objective      string                 `json:"objective"`
+	Player         string                 `json:"player"`
+	Source         string                 `json:"source"`
+	SourceType     Location               `json:"source_type"`
+	TargetCoords   Coordinate             `json:"target_coordinates"`
+	Target         string                 `json:"target"`
+	ACS            string                 `json:"acs"`
+	Speed          float32                `json:"speed"`
+	CreatedAt      time.Time              `json:"created_at"`
+	DepartureTime  *time.Time             `json:"departure_time,omitempty"`
+	ArrivalTime    time.Time              `json:"arrival_time"`
+	DeploymentTime int                    `json:"deployment_time"`
+	ReturnTime     time.Time              `json:"return_time"`
+	Ships          []ShipInFleet          `json:"ships"`
+	Cargo          []model.ResourceAmount `json:"cargo,omitempty"`
+}
+
+// JSONModel :
+// Returns a value having the same JSON representation
+// as the fleet. It is used to describe the fleet in the
+// specification of the API.
+func (f *Fleet) JSONModel() interface{} {
+	return fleetOutput{}
+}
+
 // MarshalJSON :
 // Implementation of the `json.Marshaler` interface
 // in order to convert some of the internal props to
@@ -823,27 +867,8 @@ func (f *Fleet) SaveToDB(proxy db.Proxy) error {
 // Returns the marshalled bytes for this planet along
 // with any error.
 func (f *Fleet) MarshalJSON() ([]byte, error) {
-	type outFleet struct {
-		ID             string                 `json:"id"`
-		Universe       string                 `json:"universe"`
-		Objective      string                 `json:"objective"`
-		Player         string                 `json:"player"`
-		Source         string                 `json:"source"`
-		SourceType     Location               `json:"source_type"`
-		TargetCoords   Coordinate             `json:"target_coordinates"`
-		Target         string                 `json:"target"`
-		ACS            string                 `json:"acs"`
-		Speed          float32                `json:"speed"`
-		CreatedAt      time.Time              `json:"created_at"`
-		ArrivalTime    time.Time              `json:"arrival_time"`
-		DeploymentTime int                    `json:"deployment_time"`
-		ReturnTime     time.Time              `json:"return_time"`
-		Ships          []ShipInFleet          `json:"ships"`
-		Cargo          []model.ResourceAmount `json:"cargo,omitempty"`
-	}
-
 	// Copy the fleet's data.
-	of := outFleet{
+	of := fleetOutput{
 		ID:             f.ID,
 		Universe:       f.Universe,
 		Objective:      f.Objective,
@@ -861,6 +886,11 @@ func (f *Fleet) MarshalJSON() ([]byte, error) {
 		Ships:          f.Ships.convert(),
 	}
 
+	if !f.DepartureTime.IsZero() {
+		dt := f.DepartureTime
+		of.DepartureTime = &dt
+	}
+
 	for _, r := range f.Cargo {
 		of.Cargo = append(of.Cargo, r)
 	}
@@ -893,6 +923,7 @@ func (f *Fleet) UnmarshalJSON(raw []byte) error {
 		ACS            string                 `json:"acs"`
 		Speed          float32                `json:"speed"`
 		CreatedAt      time.Time              `json:"created_at"`
+		DepartureTime  time.Time              `json:"departure_time"`
 		ArrivalTime    time.Time              `json:"arrival_time"`
 		DeploymentTime int                    `json:"deployment_time"`
 		ReturnTime     time.Time              `json:"return_time"`
@@ -919,6 +950,7 @@ func (f *Fleet) UnmarshalJSON(raw []byte) error {
 	f.ACS = in.ACS
 	f.Speed = in.Speed
 	f.CreatedAt = in.CreatedAt
+	f.DepartureTime = in.DepartureTime
 	f.ArrivalTime = in.ArrivalTime
 	f.DeploymentTime = in.DeploymentTime
 	f.ReturnTime = in.ReturnTime
@@ -1033,6 +1065,85 @@ func (f *Fleet) Validate(data Instance, source *Planet, target *Planet) error {
 		return err
 	}
 
+	// Check the objective against the source and the
+	// target of the fleet.
+	err = f.validateObjective(data, source, target)
+	if err != nil {
+		return err
+	}
+
+	// Make sure that the cargo defined for this fleet
+	// component can be stored in the ships.
+	totCargo, err := f.cargoSpace(data)
+	if err != nil {
+		return err
+	}
+
+	if f.Cargo == nil {
+		f.Cargo = make(map[string]model.ResourceAmount)
+	}
+
+	var totNeeded float32
+	for _, res := range f.Cargo {
+		rDesc, err := data.Resources.GetResourceFromID(res.Resource)
+		if err != nil {
+			return err
+		}
+		if !rDesc.Movable {
+			return ErrCargoNotMovable
+		}
+
+		totNeeded += res.Amount
+	}
+
+	if totNeeded > float32(totCargo) {
+		return ErrInsufficientCargoForFleet
+	}
+
+	// Make sure that the amount of fuel needed for
+	// this fleet can be stored in the tanks. We use
+	// a separate tank to store the fuel.
+	totNeeded = 0
+	for _, res := range f.Consumption {
+		rDesc, err := data.Resources.GetResourceFromID(res.Resource)
+		if err != nil {
+			return err
+		}
+		if !rDesc.Movable {
+			return ErrFuelNotMovable
+		}
+
+		totNeeded += res.Amount
+	}
+
+	if totNeeded > float32(totCargo) {
+		return ErrInsufficientTankForFleet
+	}
+
+	// Validate the amount of fuel available on the
+	// planet compared to the amount required and
+	// that there are enough resources to be taken
+	// from the planet.
+	return source.validateFleet(f.Consumption, f.Cargo, f.Ships, data)
+}
+
+// validateObjective :
+// Used to make sure that the objective of the fleet can
+// be performed by its ships against its target. This
+// includes the protection of the target by the rules
+// of the universe (vacation mode, noob protection and
+// bashing limit) which may change over time.
+//
+// The `data` allows to access to the DB.
+//
+// The `source` defines the planet from where the fleet
+// is launched.
+//
+// The `target` defines the planet targeted by the fleet
+// if any.
+//
+// Returns any error.
+func (f *Fleet) validateObjective(data Instance, source *Planet, target *Planet) error {
 	// Retrieve this fleet's objective's description
 	// and check that at least a ship is able to be
 	// used to perform the objective.
@@ -1060,6 +1171,14 @@ func (f *Fleet) Validate(data Instance, source *Planet, target *Planet) error {
 	if purpose(obj.Name) != "ACS defend" && purpose(obj.Name) != "expedition" {
 		f.DeploymentTime = 0
 	}
+	// The hold time of an ACS defend operation should
+	// be one of the durations allowed by the universe.
+	if purpose(obj.Name) == acsDefend {
+		err = f.validateHoldTime(data)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Make sure that the location of the target
 	// is consistent with the objective.
@@ -1084,6 +1203,17 @@ func (f *Fleet) Validate(data Instance, source *Planet, target *Planet) error {
 	if obj.Hostile && source.Player == target.Player {
 		return ErrInvalidTargetForObjective
 	}
+	// Make sure that the target of hostile fleets is
+	// not protected by the rules of the universe.
+	if obj.Hostile && target.vacation {
+		return ErrTargetInVacationMode
+	}
+	if obj.Hostile {
+		err = f.validateProtection(data, purpose(obj.Name), source, target)
+		if err != nil {
+			return err
+		}
+	}
 	// Prevent ACS defend operation where the fleet
 	// carries some resources.
 	if purpose(obj.Name) == acsDefend && f.usedCargoSpace() > 0.0 {
@@ -1119,59 +1249,7 @@ func (f *Fleet) Validate(data Instance, source *Planet, target *Planet) error {
 		}
 	}
 
-	// Make sure that the cargo defined for this fleet
-	// component can be stored in the ships.
-	totCargo, err := f.cargoSpace(data)
-	if err != nil {
-		return err
-	}
-
-	if f.Cargo == nil {
-		f.Cargo = make(map[string]model.ResourceAmount)
-	}
-
-	var totNeeded float32
-	for _, res := range f.Cargo {
-		rDesc, err := data.Resources.GetResourceFromID(res.Resource)
-		if err != nil {
-			return err
-		}
-		if !rDesc.Movable {
-			return ErrCargoNotMovable
-		}
-
-		totNeeded += res.Amount
-	}
-
-	if totNeeded > float32(totCargo) {
-		return ErrInsufficientCargoForFleet
-	}
-
-	// Make sure that the amount of fuel needed for
-	// this fleet can be stored in the tanks. We use
-	// a separate tank to store the fuel.
-	totNeeded = 0
-	for _, res := range f.Consumption {
-		rDesc, err := data.Resources.GetResourceFromID(res.Resource)
-		if err != nil {
-			return err
-		}
-		if !rDesc.Movable {
-			return ErrFuelNotMovable
-		}
-
-		totNeeded += res.Amount
-	}
-
-	if totNeeded > float32(totCargo) {
-		return ErrInsufficientTankForFleet
-	}
-
-	// Validate the amount of fuel available on the
-	// planet compared to the amount required and
-	// that there are enough resources to be taken
-	// from the planet.
-	return source.validateFleet(f.Consumption, f.Cargo, f.Ships, data)
+	return nil
 }
 
 // consolidateConsumption :
@@ -1290,8 +1368,12 @@ func (f *Fleet) consolidateConsumption(data Instance, p *Planet, mul Multipliers
 // Returns any error.
 func (f *Fleet) ConsolidateArrivalTime(data Instance, p *Planet, ratio float32) error {
 	// Update the time at which this component joined
-	// the fleet.
+	// the fleet: in case the fleet is scheduled to leave
+	// at a later time, this is its departure time.
 	f.CreatedAt = time.Now()
+	if !f.DepartureTime.IsZero() {
+		f.CreatedAt = f.DepartureTime
+	}
 
 	// Compute the time of arrival for this component. It
 	// is function of the percentage of the maximum speed
@@ -1378,7 +1460,7 @@ func (f *Fleet) ConsolidateArrivalTime(data Instance, p *Planet, ratio float32)
 // DB if needed.
 //
 // Returns any error.
-func (f *Fleet) simulate(p *Planet, data Instance) error {
+func (f *Fleet) simulate(p *Planet, data Instance) (err error) {
 	// We need to check the objective of this fleet
 	// and perform the adequate processing.
 	obj, err := data.Objectives.GetObjectiveFromID(f.Objective)
@@ -1386,6 +1468,10 @@ func (f *Fleet) simulate(p *Planet, data Instance) error {
 		return err
 	}
 
+	defer func() {
+		fleetSimulations.Inc(obj.Name, simulationStatus(err))
+	}()
+
 	// In order to process the fleet we need to know
 	// whether some server side processing should be
 	// done and the script to use to update the data
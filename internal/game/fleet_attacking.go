@@ -51,6 +51,8 @@ func (f *Fleet) attack(p *Planet, data Instance) (string, error) {
 		return "", ErrFleetFightSimulationFailure
 	}
 
+	fights.Inc(objectiveName(f.Objective, data), result.outcome.String())
+
 	// Before handling the pillage we need to make
 	// sure that any resources transported by the
 	// fleet can still be transported. Indeed in
@@ -153,6 +155,13 @@ func (f *Fleet) attack(p *Planet, data Instance) (string, error) {
 		return "", ErrFleetFightSimulationFailure
 	}
 
+	// Keep track of the attack to enforce the
+	// bashing limit.
+	err = registerAttack(a, p, result, data)
+	if err != nil {
+		return "", ErrFleetFightSimulationFailure
+	}
+
 	// Update the reinforcements' data in the DB.
 	// As we know that the reinforcements can't
 	// be carrying resources, we will provide an
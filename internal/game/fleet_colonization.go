@@ -56,6 +56,18 @@ func (f *Fleet) colonizationAuthrozied(data Instance) (*Planet, error) {
 		allowed = (err == ErrPlanetNotFound)
 	}
 
+	// The slot might also be reserved for the relocation
+	// of a planet.
+	if allowed {
+		used, err := u.UsedCoords(data.Proxy)
+		if err != nil {
+			return planet, ErrColonizationStatusUnknown
+		}
+
+		_, reserved := used[f.TargetCoords.Linearize(u.GalaxySize, u.SolarSystemSize)]
+		allowed = !reserved
+	}
+
 	if allowed {
 		// In case the colonization can be performed we
 		// will use the parent universe to create the
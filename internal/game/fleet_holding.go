@@ -0,0 +1,254 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"oglike_server/internal/model"
+	"oglike_server/pkg/db"
+	"time"
+)
+
+// HoldingOrder :
+// Describes a request of the defended player on a fleet
+// holding at one of its planets for an ACS defend. It
+// can be used to supply the fleet with fuel from the
+// alliance depot or to send it back home.
+//
+// The `Player` defines the player issuing the order. It
+// should own the planet defended by the fleet.
+//
+// The `Hours` defines the additional hold time to give
+// to the fleet when supplying it.
+type HoldingOrder struct {
+	Player string `json:"player"`
+	Hours  int    `json:"hours"`
+}
+
+// allianceDepotCapacity :
+// Defines the amount of each resource that can be provided
+// by each level of the alliance depot to holding fleets in
+// a single supply.
+var allianceDepotCapacity = float32(10000.0)
+
+// ErrInvalidHoldTime : Indicates that the hold time is not allowed for an ACS defend.
+var ErrInvalidHoldTime = fmt.Errorf("hold time is not allowed for fleet")
+
+// ErrFleetNotHolding : Indicates that the fleet is not holding at its target.
+var ErrFleetNotHolding = fmt.Errorf("fleet is not holding at its target")
+
+// ErrNotDefendedPlayer : Indicates that the player does not own the target of the fleet.
+var ErrNotDefendedPlayer = fmt.Errorf("player is not defended by fleet")
+
+// ErrAllianceDepotCapacity : Indicates that the alliance depot cannot provide the fuel.
+var ErrAllianceDepotCapacity = fmt.Errorf("alliance depot cannot provide the fuel for fleet")
+
+// validateHoldTime :
+// Used to make sure that the deployment time of this
+// fleet is one of the hold times allowed in its uni
+// for an ACS defend operation.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (f *Fleet) validateHoldTime(data Instance) error {
+	uni, err := NewUniverseFromDB(f.Universe, data)
+	if err != nil {
+		return err
+	}
+
+	hold := time.Duration(f.DeploymentTime) * time.Second
+	if hold%time.Hour != 0 || !uni.canHoldFor(int(hold.Hours())) {
+		return ErrInvalidHoldTime
+	}
+
+	return nil
+}
+
+// holdingCost :
+// Used to compute the fuel needed to keep this fleet
+// at its target for the input duration.
+//
+// The `hours` defines the hold time.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the fuel needed along with any error.
+func (f *Fleet) holdingCost(hours int, data Instance) ([]model.ResourceAmount, error) {
+	cost := make([]model.ResourceAmount, 0)
+
+	mul, err := NewMultipliersFromDB(f.Universe, data)
+	if err != nil {
+		return cost, ErrMultipliersError
+	}
+
+	fuels := make(map[string]float64)
+
+	for _, ship := range f.Ships {
+		sd, err := data.Ships.GetShipFromID(ship.ID)
+		if err != nil {
+			return cost, err
+		}
+
+		for _, fuel := range sd.Deployment {
+			fuels[fuel.Resource] += float64(fuel.Amount) * float64(hours) * float64(ship.Count)
+		}
+	}
+
+	for res, amount := range fuels {
+		cost = append(
+			cost,
+			model.ResourceAmount{
+				Resource: res,
+				Amount:   float32(math.Ceil(amount * float64(mul.Consumption))),
+			},
+		)
+	}
+
+	return cost, nil
+}
+
+// fetchHoldingFleet :
+// Used to fetch the fleet described by the input ID
+// and make sure that it is currently holding at the
+// target for an ACS defend operation which belongs
+// to the input player.
+//
+// The `ID` defines the identifier of the fleet.
+//
+// The `player` defines the player owning the target.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the fleet and its target along with any
+// error.
+func fetchHoldingFleet(ID string, player string, data Instance) (Fleet, Planet, error) {
+	var target Planet
+
+	f, err := NewFleetFromDB(ID, data)
+	if err != nil {
+		return f, target, err
+	}
+
+	obj, err := data.Objectives.GetObjectiveFromID(f.Objective)
+	if err != nil {
+		return f, target, err
+	}
+
+	if purpose(obj.Name) != acsDefend || !f.deployed || f.returning {
+		return f, target, ErrFleetNotHolding
+	}
+
+	if f.TargetCoords.Type == Moon {
+		target, err = NewMoonFromDB(f.Target, data)
+	} else {
+		target, err = NewPlanetFromDB(f.Target, data)
+	}
+	if err != nil {
+		return f, target, err
+	}
+
+	if target.Player != player {
+		return f, target, ErrNotDefendedPlayer
+	}
+
+	return f, target, nil
+}
+
+// SupplyHoldingFleet :
+// Used to supply the fleet described by the input ID
+// with fuel from the alliance depot of the planet it
+// is defending. This extends the hold time of the
+// fleet by the duration described in the order. The
+// total hold time cannot exceed the longest one that
+// is allowed in the universe.
+//
+// The `ID` defines the identifier of the fleet.
+//
+// The `order` defines the supply requested.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func SupplyHoldingFleet(ID string, order HoldingOrder, data Instance) error {
+	f, target, err := fetchHoldingFleet(ID, order.Player, data)
+	if err != nil {
+		return err
+	}
+
+	uni, err := NewUniverseFromDB(f.Universe, data)
+	if err != nil {
+		return err
+	}
+
+	hold := time.Duration(f.DeploymentTime)*time.Second + time.Duration(order.Hours)*time.Hour
+	if order.Hours <= 0 || hold > time.Duration(uni.maxHoldTime())*time.Hour {
+		return ErrInvalidHoldTime
+	}
+
+	cost, err := f.holdingCost(order.Hours, data)
+	if err != nil {
+		return err
+	}
+
+	// Make sure that the alliance depot of the target is
+	// able to provide the fuel.
+	depot, err := data.Buildings.GetIDFromName("alliance depot")
+	if err != nil {
+		return err
+	}
+
+	capacity := float32(target.Buildings[depot].Level) * allianceDepotCapacity
+
+	for _, fuel := range cost {
+		if fuel.Amount > capacity {
+			return ErrAllianceDepotCapacity
+		}
+
+		res, ok := target.Resources[fuel.Resource]
+		if !ok || res.Amount < fuel.Amount {
+			return ErrNotEnoughFuel
+		}
+	}
+
+	query := db.InsertReq{
+		Script: "fleet_acs_defend_supply",
+		Args: []interface{}{
+			f.ID,
+			order.Hours * 3600,
+			cost,
+		},
+		SkipReturn: true,
+	}
+
+	return data.Proxy.InsertToDB(query)
+}
+
+// ReleaseHoldingFleet :
+// Used to send the fleet described by the input ID
+// back to its source before the end of its hold time.
+// The fuel consumed for the hold is not refunded.
+//
+// The `ID` defines the identifier of the fleet.
+//
+// The `order` defines the player releasing the fleet.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func ReleaseHoldingFleet(ID string, order HoldingOrder, data Instance) error {
+	f, _, err := fetchHoldingFleet(ID, order.Player, data)
+	if err != nil {
+		return err
+	}
+
+	query := db.InsertReq{
+		Script: "fleet_acs_defend_release",
+		Args: []interface{}{
+			f.ID,
+			time.Now().Format(time.RFC3339Nano),
+		},
+		SkipReturn: true,
+	}
+
+	return data.Proxy.InsertToDB(query)
+}
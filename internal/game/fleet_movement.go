@@ -0,0 +1,307 @@
+package game
+
+import (
+	"oglike_server/internal/model"
+	"oglike_server/pkg/db"
+	"sort"
+	"time"
+)
+
+// FleetMovement :
+// Describes a fleet as seen by a player in the overview
+// of the fleets movements. It can either be a fleet of
+// the player or a foreign fleet heading towards one of
+// its planets or moons.
+//
+// The `ID` defines the identifier of the fleet.
+//
+// The `Player` defines the owner of the fleet.
+//
+// The `Own` defines whether the fleet belongs to the
+// player requesting the movements.
+//
+// The `Objective` defines the objective of the fleet.
+//
+// The `Hostile` defines whether the objective of the
+// fleet is hostile.
+//
+// The `Source` and `SourceType` define the origin of
+// the fleet.
+//
+// The `Target` and `TargetCoords` define the target of
+// the fleet.
+//
+// The `ArrivalTime` defines when the fleet reaches its
+// target.
+//
+// The `ReturnTime` defines when the fleet is back to
+// its source. It is only provided for the fleets of
+// the player.
+//
+// The `Returning` defines whether the fleet is on its
+// way back to its source.
+//
+// The `Hidden` indicates that the composition of the
+// fleet cannot be determined by the player.
+//
+// The `Ships` defines the ships of the fleet. It is
+// empty in case the fleet is hidden.
+//
+// The `Cargo` defines the resources carried by the
+// fleet. It is only provided for the fleets of the
+// player.
+type FleetMovement struct {
+	ID           string                 `json:"id"`
+	Player       string                 `json:"player"`
+	Own          bool                   `json:"own"`
+	Objective    string                 `json:"objective"`
+	Hostile      bool                   `json:"hostile"`
+	Source       string                 `json:"source"`
+	SourceType   Location               `json:"source_type"`
+	Target       string                 `json:"target"`
+	TargetCoords Coordinate             `json:"target_coordinates"`
+	ArrivalTime  time.Time              `json:"arrival_time"`
+	ReturnTime   *time.Time             `json:"return_time,omitempty"`
+	Returning    bool                   `json:"is_returning"`
+	Hidden       bool                   `json:"hidden"`
+	Ships        []ShipInFleet          `json:"ships,omitempty"`
+	Cargo        []model.ResourceAmount `json:"cargo,omitempty"`
+}
+
+// NewFleetMovementsFromDB :
+// Used to fetch the movements of fleets related to the
+// input player: this includes all its fleets and the
+// foreign fleets heading towards any of its planets
+// or moons. The composition of foreign fleets is only
+// visible if the espionage technology of the player is
+// strictly higher than the one of the owner.
+//
+// The `player` defines the identifier of the player.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the movements sorted by arrival time along
+// with any error.
+func NewFleetMovementsFromDB(player string, data Instance) ([]FleetMovement, error) {
+	movements := make([]FleetMovement, 0)
+
+	p, err := NewPlayerFromDB(player, data)
+	if err != nil {
+		return movements, err
+	}
+
+	techID, err := data.Technologies.GetIDFromName("espionage")
+	if err != nil {
+		return movements, err
+	}
+
+	espionage := p.Technologies[techID].Level
+
+	// Fetch the fleets of the player and the ones
+	// targeting its planets and moons.
+	own, err := fetchFleetsIDs(
+		"fleets f",
+		[]db.Filter{
+			{
+				Key:    "f.player",
+				Values: []interface{}{player},
+			},
+		},
+		data,
+	)
+	if err != nil {
+		return movements, err
+	}
+
+	toPlanets, err := fetchFleetsIDs(
+		"fleets f inner join planets p on f.target = p.id",
+		[]db.Filter{
+			{
+				Key:    "p.player",
+				Values: []interface{}{player},
+			},
+			{
+				Key:    "f.target_type",
+				Values: []interface{}{"planet"},
+			},
+			{
+				Key:    "f.is_returning",
+				Values: []interface{}{"false"},
+			},
+		},
+		data,
+	)
+	if err != nil {
+		return movements, err
+	}
+
+	toMoons, err := fetchFleetsIDs(
+		"fleets f inner join moons m on f.target = m.id inner join planets p on m.planet = p.id",
+		[]db.Filter{
+			{
+				Key:    "p.player",
+				Values: []interface{}{player},
+			},
+			{
+				Key:    "f.target_type",
+				Values: []interface{}{"moon"},
+			},
+			{
+				Key:    "f.is_returning",
+				Values: []interface{}{"false"},
+			},
+		},
+		data,
+	)
+	if err != nil {
+		return movements, err
+	}
+
+	// Build the movements: own fleets heading towards
+	// one of the planets of the player are only listed
+	// once.
+	seen := make(map[string]bool)
+	IDs := append(own, append(toPlanets, toMoons...)...)
+
+	owners := make(map[string]int)
+
+	for _, ID := range IDs {
+		if seen[ID] {
+			continue
+		}
+		seen[ID] = true
+
+		f, err := NewFleetFromDB(ID, data)
+		if err != nil {
+			return movements, err
+		}
+
+		obj, err := data.Objectives.GetObjectiveFromID(f.Objective)
+		if err != nil {
+			return movements, err
+		}
+
+		fm := FleetMovement{
+			ID:           f.ID,
+			Player:       f.Player,
+			Own:          f.Player == player,
+			Objective:    f.Objective,
+			Hostile:      obj.Hostile,
+			Source:       f.Source,
+			SourceType:   f.SourceType,
+			Target:       f.Target,
+			TargetCoords: f.TargetCoords,
+			ArrivalTime:  f.ArrivalTime,
+			Returning:    f.returning,
+		}
+
+		if fm.Own {
+			rt := f.ReturnTime
+			fm.ReturnTime = &rt
+			fm.Ships = f.Ships.convert()
+
+			for _, r := range f.Cargo {
+				fm.Cargo = append(fm.Cargo, r)
+			}
+
+			movements = append(movements, fm)
+			continue
+		}
+
+		// Determine whether the composition of the fleet
+		// can be seen by the player.
+		level, ok := owners[f.Player]
+		if !ok {
+			owner, err := NewPlayerFromDB(f.Player, data)
+			if err != nil {
+				return movements, err
+			}
+
+			level = owner.Technologies[techID].Level
+			owners[f.Player] = level
+		}
+
+		fm.Hidden = espionage <= level
+		if !fm.Hidden {
+			fm.Ships = f.Ships.convert()
+		}
+
+		movements = append(movements, fm)
+	}
+
+	sortFleetMovements(movements)
+
+	return movements, nil
+}
+
+// fetchFleetsIDs :
+// Used to fetch the identifiers of the fleets that are
+// matching the input filters.
+//
+// The `table` defines the table to query: the fleets
+// should be aliased to `f`.
+//
+// The `filters` define the filters to apply.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the identifiers of the fleets along with any
+// error.
+func fetchFleetsIDs(table string, filters []db.Filter, data Instance) ([]string, error) {
+	IDs := make([]string, 0)
+
+	query := db.QueryDesc{
+		Props: []string{
+			"f.id",
+		},
+		Table:   table,
+		Filters: filters,
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return IDs, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return IDs, dbRes.Err
+	}
+
+	var ID string
+
+	for dbRes.Next() {
+		err = dbRes.Scan(&ID)
+
+		if err != nil {
+			return IDs, err
+		}
+
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, nil
+}
+
+// sortFleetMovements :
+// Used to sort the input movements by increasing time
+// of the next event: the arrival time for the fleets
+// heading to their target and the return time for the
+// ones going back to their source.
+//
+// The `movements` define the list to sort in place.
+func sortFleetMovements(movements []FleetMovement) {
+	next := func(fm FleetMovement) time.Time {
+		if fm.Returning && fm.ReturnTime != nil {
+			return *fm.ReturnTime
+		}
+
+		return fm.ArrivalTime
+	}
+
+	sort.SliceStable(movements, func(i, j int) bool {
+		return next(movements[i]).Before(next(movements[j]))
+	})
+}
@@ -0,0 +1,255 @@
+package game
+
+import (
+	"fmt"
+	"oglike_server/pkg/db"
+	"time"
+)
+
+// ErrTargetUnderNoobProtection : Indicates that the target of a hostile fleet is
+// protected because of its weakness compared to the attacker.
+var ErrTargetUnderNoobProtection = fmt.Errorf("target is under noob protection")
+
+// ErrBashingLimitReached : Indicates that the attacker already performed too
+// many successful attacks on the target in the last day.
+var ErrBashingLimitReached = fmt.Errorf("bashing limit reached for target")
+
+// ErrTargetInVacationMode : Indicates that the target of a hostile fleet belongs
+// to a player in vacation mode.
+var ErrTargetInVacationMode = fmt.Errorf("target is in vacation mode")
+
+// bashingWindow :
+// Defines the rolling window over which successful
+// attacks are counted to enforce the bashing limit.
+var bashingWindow = 24 * time.Hour
+
+// protectedObjective :
+// Used to determine whether the input objective is
+// subject to the noob protection.
+//
+// The `p` defines the objective to check.
+//
+// Returns `true` if the objective is covered by the
+// noob protection.
+func protectedObjective(p purpose) bool {
+	return p == attacking || p == acsAttack || p == destroy || p == espionage
+}
+
+// bashedObjective :
+// Used to determine whether the input objective is
+// subject to the bashing limit. Espionage is not a
+// concern as it does not involve any fight.
+//
+// The `p` defines the objective to check.
+//
+// Returns `true` if the objective is covered by the
+// bashing limit.
+func bashedObjective(p purpose) bool {
+	return p == attacking || p == acsAttack || p == destroy
+}
+
+// validateProtection :
+// Used to make sure that the hostile objective of
+// this fleet does not break the noob protection or
+// the bashing limit defined by the parent universe
+// of the fleet.
+//
+// The `data` allows to access to the DB.
+//
+// The `obj` defines the objective of the fleet.
+//
+// The `source` defines the planet from where the
+// fleet is launched.
+//
+// The `target` defines the planet targeted by the
+// fleet. It is assumed to be valid.
+//
+// Returns any error.
+func (f *Fleet) validateProtection(data Instance, obj purpose, source *Planet, target *Planet) error {
+	if !protectedObjective(obj) && !bashedObjective(obj) {
+		return nil
+	}
+
+	uni, err := NewUniverseFromDB(f.Universe, data)
+	if err != nil {
+		return err
+	}
+
+	if protectedObjective(obj) {
+		err = f.validateNoobProtection(data, uni, source, target)
+		if err != nil {
+			return err
+		}
+	}
+
+	if bashedObjective(obj) && uni.BashingLimit > 0 {
+		count, err := target.attacksFrom(data, source.Player, time.Now().Add(-bashingWindow))
+		if err != nil {
+			return err
+		}
+
+		if count >= uni.BashingLimit {
+			return ErrBashingLimitReached
+		}
+	}
+
+	return nil
+}
+
+// validateNoobProtection :
+// Used to verify that the target of the fleet is not
+// protected by the noob protection. A target is said
+// to be protected if its points are below the value
+// defined by the universe or if they are too small
+// compared to the points of the attacker. Inactive
+// players are never protected: this relies on the
+// flag maintained by the activity process.
+//
+// The `data` allows to access to the DB.
+//
+// The `uni` defines the universe of the fleet.
+//
+// The `source` defines the source of the fleet.
+//
+// The `target` defines the target of the fleet.
+//
+// Returns any error.
+func (f *Fleet) validateNoobProtection(data Instance, uni Universe, source *Planet, target *Planet) error {
+	if uni.NoobProtectionPoints == 0 && uni.NoobProtectionRatio == 0.0 {
+		return nil
+	}
+
+	attacker, err := NewPlayerFromDB(source.Player, data)
+	if err != nil {
+		return err
+	}
+
+	defender, err := NewPlayerFromDB(target.Player, data)
+	if err != nil {
+		return err
+	}
+
+	if defender.Inactive {
+		return nil
+	}
+
+	points := defender.TotalPoints()
+
+	if uni.NoobProtectionPoints > 0 && points < float32(uni.NoobProtectionPoints) {
+		return ErrTargetUnderNoobProtection
+	}
+	if uni.NoobProtectionRatio > 0.0 && points*uni.NoobProtectionRatio < attacker.TotalPoints() {
+		return ErrTargetUnderNoobProtection
+	}
+
+	return nil
+}
+
+// attacksFrom :
+// Used to count the number of successful attacks that
+// were performed by the input player on this planet
+// since the specified time.
+//
+// The `data` allows to access to the DB.
+//
+// The `player` defines the attacker.
+//
+// The `since` defines the time from which attacks are
+// counted.
+//
+// Returns the number of attacks along with any error.
+func (p *Planet) attacksFrom(data Instance, player string, since time.Time) (int, error) {
+	query := db.QueryDesc{
+		Props: []string{
+			"count(*)",
+		},
+		Table: "planets_attacks",
+		Filters: []db.Filter{
+			{
+				Key:    "player",
+				Values: []interface{}{player},
+			},
+			{
+				Key:    "target",
+				Values: []interface{}{p.ID},
+			},
+			{
+				Key:      "created_at",
+				Values:   []interface{}{since},
+				Operator: db.GreaterThan,
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return 0, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return 0, dbRes.Err
+	}
+
+	var count int
+
+	if dbRes.Next() {
+		err = dbRes.Scan(&count)
+	}
+
+	return count, err
+}
+
+// successfulAttack :
+// Used to determine whether the input fight was won by
+// the attacker. The outcome is expressed from the point
+// of view of the defender so only a `Loss` qualifies: a
+// draw is not a successful attack.
+//
+// The `result` defines the outcome of the fight.
+//
+// Returns `true` if the attacker won the fight.
+func successfulAttack(result fightResult) bool {
+	return result.outcome == Loss
+}
+
+// registerAttack :
+// Used to keep track of the attack of the input planet
+// by the participants of the attacker in case it was a
+// success. This allows to enforce the bashing limit.
+//
+// The `a` defines the attacker.
+//
+// The `p` defines the planet that was attacked.
+//
+// The `result` defines the outcome of the fight.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func registerAttack(a attacker, p *Planet, result fightResult, data Instance) error {
+	if !successfulAttack(result) {
+		return nil
+	}
+
+	for _, player := range a.participants {
+		query := db.InsertReq{
+			Script: "register_planet_attack",
+			Args: []interface{}{
+				player,
+				p.ID,
+				string(p.Coordinates.Type),
+				result.date,
+			},
+		}
+
+		err := data.Proxy.InsertToDB(query)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
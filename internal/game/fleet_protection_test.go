@@ -0,0 +1,26 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestOnlyAttackerWinsCountTowardsBashing(t *testing.T) {
+	cases := []struct {
+		outcome  FightOutcome
+		expected bool
+	}{
+		{outcome: Victory, expected: false},
+		{outcome: Draw, expected: false},
+		{outcome: Loss, expected: true},
+	}
+
+	for _, c := range cases {
+		result := fightResult{
+			outcome: c.outcome,
+		}
+
+		if got := successfulAttack(result); got != c.expected {
+			t.Errorf("%s: expected successful attack to be %t, got %t", c.outcome, c.expected, got)
+		}
+	}
+}
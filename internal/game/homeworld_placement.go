@@ -0,0 +1,508 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"oglike_server/pkg/db"
+	"sort"
+)
+
+// PlacementStrategy :
+// Describes the possible strategies to choose the
+// coordinates of the homeworld of a new player in
+// a universe.
+type PlacementStrategy string
+
+// Define the possible placement strategies.
+const (
+	RandomPlacement PlacementStrategy = "random"
+	FillPlacement   PlacementStrategy = "fill"
+	SparsePlacement PlacementStrategy = "sparse"
+	BufferPlacement PlacementStrategy = "buffer"
+	GalaxyPlacement PlacementStrategy = "galaxy"
+)
+
+// Placement :
+// Gathers the information needed by the strategies
+// to choose the coordinates of a new homeworld. It
+// does not require any access to the DB so that a
+// strategy always produces the same coordinates for
+// the same input data and random source.
+//
+// The `GalaxiesCount`, `GalaxySize` and the field
+// `SolarSystemSize` define the dimensions of the
+// universe.
+//
+// The `Density` defines the number of planets that
+// should be reached in a solar system before moving
+// on to the next one.
+//
+// The `Buffer` defines the number of solar systems
+// to keep between a new homeworld and the planets
+// of the top players.
+//
+// The `Used` defines the coordinates that are not
+// available anymore, as returned by `UsedCoords`.
+//
+// The `Top` defines the coordinates of the planets
+// of the top players of the universe.
+//
+// The `Galaxy` defines the galaxy picked by the new
+// player if any.
+type Placement struct {
+	GalaxiesCount   int
+	GalaxySize      int
+	SolarSystemSize int
+	Density         int
+	Buffer          int
+	Used            map[int]Coordinate
+	Top             []Coordinate
+	Galaxy          *int
+}
+
+// HomeworldPlacer :
+// Common interface for the strategies choosing the
+// coordinates of the homeworld of a new player.
+type HomeworldPlacer interface {
+	// Candidates :
+	// Used to produce a list of free coordinates where
+	// the homeworld can be created, ordered from the
+	// most to the least preferred.
+	//
+	// The `pl` defines the state of the universe.
+	//
+	// The `rng` defines the random source to use.
+	//
+	// The `count` defines the maximum number of coords
+	// to return.
+	//
+	// Returns the candidate coordinates.
+	Candidates(pl Placement, rng *rand.Rand, count int) []Coordinate
+}
+
+// placementTopPlayers :
+// Defines the number of players considered as the
+// top players of a universe when keeping a buffer
+// around their planets.
+var placementTopPlayers = 10
+
+// sparseRegionRadius :
+// Defines the number of solar systems on each side
+// of a system that are considered to evaluate how
+// populated its region is.
+var sparseRegionRadius = 5
+
+// defaultHomeworldDensity :
+// Defines the number of planets per system that the
+// fill strategy tries to reach when the universe does
+// not define any.
+var defaultHomeworldDensity = 4
+
+// ErrInvalidPlacementStrategy : Indicates that the placement strategy does not exist.
+var ErrInvalidPlacementStrategy = fmt.Errorf("invalid homeworld placement strategy")
+
+// placers :
+// Defines the strategies that can be used by the
+// universes to place homeworlds.
+var placers = map[PlacementStrategy]HomeworldPlacer{
+	RandomPlacement: randomPlacer{},
+	FillPlacement:   fillPlacer{},
+	SparsePlacement: sparsePlacer{},
+	BufferPlacement: bufferPlacer{},
+	GalaxyPlacement: galaxyPlacer{},
+}
+
+// NewHomeworldPlacer :
+// Used to retrieve the placement strategy with the
+// input name.
+//
+// The `strategy` defines the name of the strategy.
+//
+// Returns the strategy along with any error.
+func NewHomeworldPlacer(strategy PlacementStrategy) (HomeworldPlacer, error) {
+	p, ok := placers[strategy]
+	if !ok {
+		return nil, ErrInvalidPlacementStrategy
+	}
+
+	return p, nil
+}
+
+// NewPlacement :
+// Used to gather the information needed to place the
+// homeworld of the input player in its universe.
+//
+// The `uni` defines the universe of the player.
+//
+// The `player` defines the player to place.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the placement data along with any error.
+func NewPlacement(uni *Universe, player Player, data Instance) (Placement, error) {
+	pl := Placement{
+		GalaxiesCount:   uni.GalaxiesCount,
+		GalaxySize:      uni.GalaxySize,
+		SolarSystemSize: uni.SolarSystemSize,
+		Density:         uni.HomeworldDensity,
+		Buffer:          uni.HomeworldBuffer,
+		Top:             make([]Coordinate, 0),
+		Galaxy:          player.Galaxy,
+	}
+
+	var err error
+
+	pl.Used, err = uni.UsedCoords(data.Proxy)
+	if err != nil {
+		return pl, err
+	}
+
+	if PlacementStrategy(uni.HomeworldPlacement) == BufferPlacement {
+		pl.Top, err = uni.topPlayersCoords(placementTopPlayers, data.Proxy)
+	}
+
+	return pl, err
+}
+
+// topPlayersCoords :
+// Used to fetch the coordinates of all the planets of
+// the players with the most points in this universe.
+//
+// The `count` defines the number of players to consider.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns the coordinates along with any error.
+func (u *Universe) topPlayersCoords(count int, proxy db.Proxy) ([]Coordinate, error) {
+	coords := make([]Coordinate, 0)
+
+	// Fetch the top players first.
+	query := db.QueryDesc{
+		Props: []string{
+			"pl.id",
+		},
+		Table: "players pl inner join players_points pp on pp.player = pl.id",
+		Filters: []db.Filter{
+			{
+				Key:    "pl.universe",
+				Values: []interface{}{u.ID},
+			},
+		},
+		Ordering: fmt.Sprintf("order by pp.economy_points + pp.research_points + pp.military_points desc limit %d", count),
+	}
+
+	dbRes, err := proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return coords, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return coords, dbRes.Err
+	}
+
+	var ID string
+	players := make([]interface{}, 0)
+
+	for dbRes.Next() {
+		err = dbRes.Scan(&ID)
+		if err != nil {
+			return coords, db.ErrInvalidScan
+		}
+
+		players = append(players, ID)
+	}
+
+	if len(players) == 0 {
+		return coords, nil
+	}
+
+	// And then their planets.
+	query = db.QueryDesc{
+		Props: []string{
+			"galaxy",
+			"solar_system",
+			"position",
+		},
+		Table: "planets",
+		Filters: []db.Filter{
+			{
+				Key:    "player",
+				Values: players,
+			},
+		},
+	}
+
+	pRes, err := proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return coords, err
+	}
+	defer pRes.Close()
+
+	if pRes.Err != nil {
+		return coords, pRes.Err
+	}
+
+	var galaxy, system, position int
+
+	for pRes.Next() {
+		err = pRes.Scan(
+			&galaxy,
+			&system,
+			&position,
+		)
+
+		if err != nil {
+			return coords, db.ErrInvalidScan
+		}
+
+		coords = append(coords, NewPlanetCoordinate(galaxy, system, position))
+	}
+
+	return coords, nil
+}
+
+// system :
+// Convenience structure to reference a solar system
+// of a universe.
+type system struct {
+	galaxy int
+	index  int
+}
+
+// systems :
+// Used to list all the solar systems of the universe.
+//
+// Returns the systems ordered by galaxy and index.
+func (pl Placement) systems() []system {
+	out := make([]system, 0, pl.GalaxiesCount*pl.GalaxySize)
+
+	for g := 0; g < pl.GalaxiesCount; g++ {
+		for s := 0; s < pl.GalaxySize; s++ {
+			out = append(out, system{galaxy: g, index: s})
+		}
+	}
+
+	return out
+}
+
+// population :
+// Used to count the used coordinates of each solar
+// system of the universe.
+//
+// Returns the number of used coordinates per system.
+func (pl Placement) population() map[system]int {
+	pop := make(map[system]int)
+
+	for _, c := range pl.Used {
+		pop[system{galaxy: c.Galaxy, index: c.System}]++
+	}
+
+	return pop
+}
+
+// free :
+// Used to gather the free coordinates of the input
+// systems. Systems are considered in order while the
+// positions of a system are shuffled.
+//
+// The `systems` defines the systems to consider.
+//
+// The `rng` defines the random source to use.
+//
+// The `count` defines the maximum number of coords
+// to return.
+//
+// Returns the free coordinates.
+func (pl Placement) free(systems []system, rng *rand.Rand, count int) []Coordinate {
+	out := make([]Coordinate, 0, count)
+
+	for _, s := range systems {
+		for _, pos := range rng.Perm(pl.SolarSystemSize) {
+			if len(out) >= count {
+				return out
+			}
+
+			c := NewPlanetCoordinate(s.galaxy, s.index, pos)
+			if _, ok := pl.Used[c.Linearize(pl.GalaxySize, pl.SolarSystemSize)]; !ok {
+				out = append(out, c)
+			}
+		}
+	}
+
+	return out
+}
+
+// shuffled :
+// Used to randomize the order of the input systems.
+//
+// The `systems` defines the systems to shuffle.
+//
+// The `rng` defines the random source to use.
+//
+// Returns the shuffled systems.
+func shuffled(systems []system, rng *rand.Rand) []system {
+	rng.Shuffle(len(systems), func(i, j int) {
+		systems[i], systems[j] = systems[j], systems[i]
+	})
+
+	return systems
+}
+
+// randomPlacer :
+// Picks the homeworld uniformly among the solar systems
+// of the universe that still have a free slot.
+type randomPlacer struct{}
+
+// Candidates :
+// Implementation of the `HomeworldPlacer` interface.
+func (rp randomPlacer) Candidates(pl Placement, rng *rand.Rand, count int) []Coordinate {
+	out := make([]Coordinate, 0, count)
+
+	// Only a single position is picked in each system so
+	// that the candidates are spread in the universe.
+	for _, s := range shuffled(pl.systems(), rng) {
+		if len(out) >= count {
+			break
+		}
+
+		out = append(out, pl.free([]system{s}, rng, 1)...)
+	}
+
+	return out
+}
+
+// fillPlacer :
+// Fills the galaxies in order: a system is used until
+// it reaches the target density before moving to the
+// next one.
+type fillPlacer struct{}
+
+// Candidates :
+// Implementation of the `HomeworldPlacer` interface.
+func (fp fillPlacer) Candidates(pl Placement, rng *rand.Rand, count int) []Coordinate {
+	pop := pl.population()
+
+	systems := make([]system, 0)
+	for _, s := range pl.systems() {
+		if pop[s] < pl.Density {
+			systems = append(systems, s)
+		}
+	}
+
+	out := pl.free(systems, rng, count)
+	if len(out) == 0 {
+		return randomPlacer{}.Candidates(pl, rng, count)
+	}
+
+	return out
+}
+
+// sparsePlacer :
+// Places the homeworld in the least populated region
+// of the universe. The region of a system gathers the
+// systems within `sparseRegionRadius` of it.
+type sparsePlacer struct{}
+
+// Candidates :
+// Implementation of the `HomeworldPlacer` interface.
+func (sp sparsePlacer) Candidates(pl Placement, rng *rand.Rand, count int) []Coordinate {
+	pop := pl.population()
+
+	region := func(s system) int {
+		total := 0
+		for i := s.index - sparseRegionRadius; i <= s.index+sparseRegionRadius; i++ {
+			total += pop[system{galaxy: s.galaxy, index: i}]
+		}
+		return total
+	}
+
+	// Shuffle the systems first so that the ties are
+	// broken randomly.
+	systems := shuffled(pl.systems(), rng)
+	regions := make(map[system]int)
+	for _, s := range systems {
+		regions[s] = region(s)
+	}
+
+	sort.SliceStable(systems, func(i, j int) bool {
+		return regions[systems[i]] < regions[systems[j]]
+	})
+
+	return pl.free(systems, rng, count)
+}
+
+// bufferPlacer :
+// Places the homeworld randomly while keeping a buffer
+// of solar systems around the planets of the players
+// with the most points.
+type bufferPlacer struct{}
+
+// Candidates :
+// Implementation of the `HomeworldPlacer` interface.
+func (bp bufferPlacer) Candidates(pl Placement, rng *rand.Rand, count int) []Coordinate {
+	systems := make([]system, 0)
+
+	for _, s := range pl.systems() {
+		near := false
+
+		for _, c := range pl.Top {
+			d := c.System - s.index
+			if d < 0 {
+				d = -d
+			}
+
+			if c.Galaxy == s.galaxy && d <= pl.Buffer {
+				near = true
+				break
+			}
+		}
+
+		if !near {
+			systems = append(systems, s)
+		}
+	}
+
+	out := pl.free(shuffled(systems, rng), rng, count)
+	if len(out) == 0 {
+		return randomPlacer{}.Candidates(pl, rng, count)
+	}
+
+	return out
+}
+
+// galaxyPlacer :
+// Places the homeworld in the galaxy picked by the
+// player. The galaxy is filled in the same way as
+// for the `fillPlacer`. In case the player did not
+// pick any galaxy the homeworld is placed randomly.
+type galaxyPlacer struct{}
+
+// Candidates :
+// Implementation of the `HomeworldPlacer` interface.
+func (gp galaxyPlacer) Candidates(pl Placement, rng *rand.Rand, count int) []Coordinate {
+	if pl.Galaxy == nil || *pl.Galaxy < 0 || *pl.Galaxy >= pl.GalaxiesCount {
+		return randomPlacer{}.Candidates(pl, rng, count)
+	}
+
+	pop := pl.population()
+
+	full := make([]system, 0)
+	systems := make([]system, 0)
+
+	for s := 0; s < pl.GalaxySize; s++ {
+		sys := system{galaxy: *pl.Galaxy, index: s}
+
+		if pop[sys] < pl.Density {
+			systems = append(systems, sys)
+		} else {
+			full = append(full, sys)
+		}
+	}
+
+	return pl.free(append(systems, full...), rng, count)
+}
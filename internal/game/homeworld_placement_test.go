@@ -0,0 +1,207 @@
+package game
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// newTestPlacement :
+// Builds the placement data of a small universe with two
+// galaxies of ten systems of six positions. The input
+// coordinates are registered as used.
+func newTestPlacement(used ...Coordinate) Placement {
+	pl := Placement{
+		GalaxiesCount:   2,
+		GalaxySize:      10,
+		SolarSystemSize: 6,
+		Density:         2,
+		Buffer:          2,
+		Used:            make(map[int]Coordinate),
+		Top:             make([]Coordinate, 0),
+	}
+
+	for _, c := range used {
+		pl.Used[c.Linearize(pl.GalaxySize, pl.SolarSystemSize)] = c
+	}
+
+	return pl
+}
+
+// occupied :
+// Returns the coordinates of the first `count` positions
+// of the input system.
+func occupied(galaxy int, system int, count int) []Coordinate {
+	out := make([]Coordinate, 0, count)
+
+	for p := 0; p < count; p++ {
+		out = append(out, NewPlanetCoordinate(galaxy, system, p))
+	}
+
+	return out
+}
+
+func TestHomeworldPlacement(t *testing.T) {
+	galaxy := 1
+	invalidGalaxy := 5
+
+	// Fill: the first two systems reach the density.
+	fill := newTestPlacement(append(append(occupied(0, 0, 2), occupied(0, 1, 2)...), occupied(0, 2, 1)...)...)
+
+	// Sparse: the first galaxy is crowded and the second
+	// one only has a planet in its first system.
+	crowded := make([]Coordinate, 0)
+	for s := 0; s < 10; s++ {
+		crowded = append(crowded, occupied(0, s, 3)...)
+	}
+	sparse := newTestPlacement(append(crowded, occupied(1, 0, 1)...)...)
+
+	// Buffer: the top players are in the middle of both
+	// galaxies.
+	buffer := newTestPlacement()
+	buffer.Top = []Coordinate{
+		NewPlanetCoordinate(0, 4, 1),
+		NewPlanetCoordinate(1, 4, 1),
+	}
+
+	// Buffer: the top players cover the whole universe.
+	surrounded := newTestPlacement()
+	surrounded.Buffer = 10
+	surrounded.Top = []Coordinate{
+		NewPlanetCoordinate(0, 0, 1),
+		NewPlanetCoordinate(1, 0, 1),
+	}
+
+	// Galaxy: the first system of the picked galaxy is
+	// full.
+	picked := newTestPlacement(occupied(1, 0, 2)...)
+	picked.Galaxy = &galaxy
+
+	unpicked := newTestPlacement()
+
+	outOfRange := newTestPlacement()
+	outOfRange.Galaxy = &invalidGalaxy
+
+	cases := []struct {
+		name     string
+		strategy PlacementStrategy
+		pl       Placement
+		count    int
+		check    func(c Coordinate) bool
+	}{
+		{
+			name:     "fill uses the first system below the density",
+			strategy: FillPlacement,
+			pl:       fill,
+			count:    5,
+			check: func(c Coordinate) bool {
+				return c.Galaxy == 0 && c.System == 2
+			},
+		},
+		{
+			name:     "fill moves to the next system once full",
+			strategy: FillPlacement,
+			pl:       fill,
+			count:    10,
+			check: func(c Coordinate) bool {
+				return c.Galaxy == 0 && (c.System == 2 || c.System == 3)
+			},
+		},
+		{
+			name:     "sparse uses the least populated region",
+			strategy: SparsePlacement,
+			pl:       sparse,
+			count:    12,
+			check: func(c Coordinate) bool {
+				return c.Galaxy == 1 && c.System > sparseRegionRadius
+			},
+		},
+		{
+			name:     "buffer keeps away from the top players",
+			strategy: BufferPlacement,
+			pl:       buffer,
+			count:    30,
+			check: func(c Coordinate) bool {
+				return c.System < 2 || c.System > 6
+			},
+		},
+		{
+			name:     "buffer falls back to random placement",
+			strategy: BufferPlacement,
+			pl:       surrounded,
+			count:    20,
+			check: func(c Coordinate) bool {
+				return true
+			},
+		},
+		{
+			name:     "galaxy uses the picked galaxy",
+			strategy: GalaxyPlacement,
+			pl:       picked,
+			count:    6,
+			check: func(c Coordinate) bool {
+				return c.Galaxy == galaxy && c.System == 1
+			},
+		},
+		{
+			name:     "galaxy without pick is random",
+			strategy: GalaxyPlacement,
+			pl:       unpicked,
+			count:    20,
+			check: func(c Coordinate) bool {
+				return true
+			},
+		},
+		{
+			name:     "galaxy out of range is random",
+			strategy: GalaxyPlacement,
+			pl:       outOfRange,
+			count:    20,
+			check: func(c Coordinate) bool {
+				return c.Galaxy < outOfRange.GalaxiesCount
+			},
+		},
+	}
+
+	for _, c := range cases {
+		placer, err := NewHomeworldPlacer(c.strategy)
+		if err != nil {
+			t.Fatalf("%s: unexpected error (err: %v)", c.name, err)
+		}
+
+		out := placer.Candidates(c.pl, rand.New(rand.NewSource(42)), c.count)
+		if len(out) != c.count {
+			t.Errorf("%s: expected %d candidates, got %d", c.name, c.count, len(out))
+		}
+
+		seen := make(map[int]bool)
+
+		for _, coord := range out {
+			key := coord.Linearize(c.pl.GalaxySize, c.pl.SolarSystemSize)
+
+			if _, ok := c.pl.Used[key]; ok {
+				t.Errorf("%s: candidate %v is already used", c.name, coord)
+			}
+			if seen[key] {
+				t.Errorf("%s: candidate %v is duplicated", c.name, coord)
+			}
+			if !c.check(coord) {
+				t.Errorf("%s: unexpected candidate %v", c.name, coord)
+			}
+
+			seen[key] = true
+		}
+
+		// The same seed produces the same placement.
+		again := placer.Candidates(c.pl, rand.New(rand.NewSource(42)), c.count)
+		if !reflect.DeepEqual(out, again) {
+			t.Errorf("%s: placement is not deterministic (first: %v, second: %v)", c.name, out, again)
+		}
+	}
+}
+
+func TestInvalidPlacementStrategy(t *testing.T) {
+	if _, err := NewHomeworldPlacer("unknown"); err != ErrInvalidPlacementStrategy {
+		t.Errorf("expected %v, got %v", ErrInvalidPlacementStrategy, err)
+	}
+}
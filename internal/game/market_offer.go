@@ -0,0 +1,744 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"oglike_server/internal/model"
+	"oglike_server/pkg/db"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MarketOffer :
+// Describes an offer posted by a player on the market
+// of its universe. The goods of the offer (resources
+// and ships) are taken from the planet of the seller
+// until the offer is accepted by another player or it
+// expires.
+//
+// The `ID` defines the identifier of the offer.
+//
+// The `Universe` defines the universe of the offer.
+//
+// The `Player` defines the seller. It should own the
+// planet of the offer.
+//
+// The `Planet` defines the planet from where the goods
+// are taken and delivered.
+//
+// The `Resources` defines the resources sold.
+//
+// The `Ships` defines the ships sold. They are given
+// to the buyer when they reach its planet.
+//
+// The `Carriers` defines the ships used to deliver the
+// resources sold. They come back to the planet of the
+// seller once the delivery is done.
+//
+// The `Price` defines the resources asked in exchange.
+//
+// The `Duration` defines for how many hours the offer
+// is available. It is only used when the offer is
+// created.
+//
+// The `ExpirationTime` defines the time at which the
+// offer is withdrawn and its goods given back to the
+// seller.
+type MarketOffer struct {
+	ID             string                 `json:"id"`
+	Universe       string                 `json:"universe"`
+	Player         string                 `json:"player"`
+	Planet         string                 `json:"planet"`
+	Resources      []model.ResourceAmount `json:"resources"`
+	Ships          []ShipInFleet          `json:"ships"`
+	Carriers       []ShipInFleet          `json:"carriers"`
+	Price          []model.ResourceAmount `json:"price"`
+	Duration       int                    `json:"duration,omitempty"`
+	ExpirationTime time.Time              `json:"expiration_time"`
+}
+
+// MarketPurchase :
+// Describes the acceptance of an offer of the market.
+// The buyer pays the price from one of its planets
+// and the goods are delivered to this planet.
+//
+// The `Offer` defines the identifier of the offer.
+//
+// The `Player` defines the buyer. It should own the
+// planet of the purchase.
+//
+// The `Planet` defines the planet paying the price and
+// receiving the goods.
+//
+// The `Carriers` defines the ships used to deliver the
+// price to the seller. They come back to the planet of
+// the buyer once the delivery is done.
+//
+// The `fleets` defines the fleets generated to deliver
+// the goods and the price. They are computed when the
+// purchase is validated.
+type MarketPurchase struct {
+	Offer    string        `json:"offer"`
+	Player   string        `json:"player"`
+	Planet   string        `json:"planet"`
+	Carriers []ShipInFleet `json:"carriers"`
+
+	fleets []Fleet
+}
+
+// defaultMarketOfferDuration :
+// Defines the duration in hours of an offer when the
+// seller does not define any.
+var defaultMarketOfferDuration = 48
+
+// maxMarketOfferDuration :
+// Defines the maximum duration in hours of an offer.
+var maxMarketOfferDuration = 168
+
+// ErrInvalidPlayerForOffer : Indicates that the player of an offer is not valid.
+var ErrInvalidPlayerForOffer = fmt.Errorf("no valid player for offer")
+
+// ErrInvalidPlanetForOffer : Indicates that the planet of an offer is not valid.
+var ErrInvalidPlanetForOffer = fmt.Errorf("no valid planet for offer")
+
+// ErrEmptyOffer : Indicates that an offer does not sell anything.
+var ErrEmptyOffer = fmt.Errorf("offer does not sell any goods")
+
+// ErrInvalidOfferGoods : Indicates that the goods of an offer are not valid.
+var ErrInvalidOfferGoods = fmt.Errorf("invalid goods for offer")
+
+// ErrInvalidOfferPrice : Indicates that the price of an offer is not valid.
+var ErrInvalidOfferPrice = fmt.Errorf("invalid price for offer")
+
+// ErrInvalidOfferDuration : Indicates that the duration of an offer is not valid.
+var ErrInvalidOfferDuration = fmt.Errorf("invalid duration for offer")
+
+// ErrInvalidCarriers : Indicates that the carriers cannot deliver the resources.
+var ErrInvalidCarriers = fmt.Errorf("invalid carriers to deliver resources")
+
+// ErrOfferExpired : Indicates that the offer is not available anymore.
+var ErrOfferExpired = fmt.Errorf("offer has expired")
+
+// ErrInvalidPlayerForPurchase : Indicates that the buyer of an offer is not valid.
+var ErrInvalidPlayerForPurchase = fmt.Errorf("no valid player for purchase")
+
+// ErrInvalidPlanetForPurchase : Indicates that the planet of the buyer is not valid.
+var ErrInvalidPlanetForPurchase = fmt.Errorf("no valid planet for purchase")
+
+// valid :
+// Determines whether the offer is valid. By valid we
+// only mean obvious syntax errors.
+//
+// Returns any error or `nil` if the offer seems valid.
+func (o *MarketOffer) valid() error {
+	if !validUUID(o.ID) {
+		return ErrInvalidElementID
+	}
+	if !validUUID(o.Player) {
+		return ErrInvalidPlayerForOffer
+	}
+	if !validUUID(o.Planet) {
+		return ErrInvalidPlanetForOffer
+	}
+	if len(o.Resources) == 0 && len(o.Ships) == 0 {
+		return ErrEmptyOffer
+	}
+	if !validAmounts(o.Resources) || !validShips(o.Ships) {
+		return ErrInvalidOfferGoods
+	}
+	if len(o.Price) == 0 || !validAmounts(o.Price) {
+		return ErrInvalidOfferPrice
+	}
+	if !validShips(o.Carriers) || (len(o.Resources) > 0) != (len(o.Carriers) > 0) {
+		return ErrInvalidCarriers
+	}
+	if o.Duration <= 0 || o.Duration > maxMarketOfferDuration {
+		return ErrInvalidOfferDuration
+	}
+
+	return nil
+}
+
+// validAmounts :
+// Used to make sure that the input resources are
+// all distinct and have a positive amount.
+//
+// The `resources` defines the resources to check.
+//
+// Returns `true` if the resources are valid.
+func validAmounts(resources []model.ResourceAmount) bool {
+	seen := make(map[string]bool)
+
+	for _, r := range resources {
+		if !validUUID(r.Resource) || r.Amount <= 0.0 || seen[r.Resource] {
+			return false
+		}
+		seen[r.Resource] = true
+	}
+
+	return true
+}
+
+// validShips :
+// Similar to `validAmounts` but for ships.
+//
+// The `ships` defines the ships to check.
+//
+// Returns `true` if the ships are valid.
+func validShips(ships []ShipInFleet) bool {
+	seen := make(map[string]bool)
+
+	for _, s := range ships {
+		if s.valid() != nil || seen[s.ID] {
+			return false
+		}
+		seen[s.ID] = true
+	}
+
+	return true
+}
+
+// mergeShips :
+// Used to gather the input lists of ships in a single
+// set where the ships of the same type are summed.
+//
+// The `lists` defines the lists of ships to merge.
+//
+// Returns the merged ships.
+func mergeShips(lists ...[]ShipInFleet) ShipsInFleet {
+	ships := make(ShipsInFleet)
+
+	for _, list := range lists {
+		for _, s := range list {
+			ex := ships[s.ID]
+			ex.ID = s.ID
+			ex.Count += s.Count
+			ships[s.ID] = ex
+		}
+	}
+
+	return ships
+}
+
+// NewMarketOfferFromDB :
+// Used to fetch the content of the offer from the
+// input identifier.
+//
+// The `ID` defines the identifier of the offer.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the offer as fetched from the DB along
+// with any error.
+func NewMarketOfferFromDB(ID string, data Instance) (MarketOffer, error) {
+	o := MarketOffer{
+		ID: ID,
+	}
+
+	// Consistency.
+	if !validUUID(o.ID) {
+		return o, ErrInvalidElementID
+	}
+
+	// Create the query and execute it.
+	query := db.QueryDesc{
+		Props: []string{
+			"universe",
+			"player",
+			"planet",
+			"resources::text",
+			"ships::text",
+			"carriers::text",
+			"price::text",
+			"expiration_time",
+		},
+		Table: "market_offers",
+		Filters: []db.Filter{
+			{
+				Key:    "id",
+				Values: []interface{}{o.ID},
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return o, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return o, dbRes.Err
+	}
+
+	// Scan the offer's data.
+	atLeastOne := dbRes.Next()
+	if !atLeastOne {
+		return o, ErrElementNotFound
+	}
+
+	var resources, ships, carriers, price string
+
+	err = dbRes.Scan(
+		&o.Universe,
+		&o.Player,
+		&o.Planet,
+		&resources,
+		&ships,
+		&carriers,
+		&price,
+		&o.ExpirationTime,
+	)
+	if err != nil {
+		return o, err
+	}
+
+	// Make sure that it's the only offer.
+	if dbRes.Next() {
+		return o, ErrDuplicatedElement
+	}
+
+	err = json.Unmarshal([]byte(resources), &o.Resources)
+	if err == nil {
+		err = json.Unmarshal([]byte(ships), &o.Ships)
+	}
+	if err == nil {
+		err = json.Unmarshal([]byte(carriers), &o.Carriers)
+	}
+	if err == nil {
+		err = json.Unmarshal([]byte(price), &o.Price)
+	}
+
+	return o, err
+}
+
+// Validate :
+// Used to make sure that the offer can be posted. The
+// planet should belong to the seller and hold all the
+// goods and the carriers. The carriers should be able
+// to deliver the resources sold.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (o *MarketOffer) Validate(data Instance) error {
+	if o.Duration == 0 {
+		o.Duration = defaultMarketOfferDuration
+	}
+
+	// Avoid `null` values in the DB.
+	if o.Resources == nil {
+		o.Resources = make([]model.ResourceAmount, 0)
+	}
+	if o.Ships == nil {
+		o.Ships = make([]ShipInFleet, 0)
+	}
+	if o.Carriers == nil {
+		o.Carriers = make([]ShipInFleet, 0)
+	}
+
+	if err := o.valid(); err != nil {
+		return err
+	}
+
+	p, err := NewPlanetFromDB(o.Planet, data)
+	if err != nil || p.Player != o.Player {
+		return ErrInvalidPlanetForOffer
+	}
+
+	o.Universe, err = UniverseOfPlanet(o.Planet, data)
+	if err != nil {
+		return err
+	}
+
+	// The goods and the price should be movable.
+	for _, r := range append(o.Resources, o.Price...) {
+		rDesc, err := data.Resources.GetResourceFromID(r.Resource)
+		if err != nil {
+			return err
+		}
+		if !rDesc.Movable {
+			return ErrCargoNotMovable
+		}
+	}
+
+	// The ships sold should be able to fly to the buyer
+	// and the carriers to deliver the resources.
+	err = canPerform(deployment, o.Ships, data)
+	if err != nil {
+		return ErrInvalidOfferGoods
+	}
+
+	err = canPerform(transport, o.Carriers, data)
+	if err != nil {
+		return ErrInvalidCarriers
+	}
+
+	carriers := Fleet{
+		Ships: mergeShips(o.Carriers),
+	}
+
+	space, err := carriers.cargoSpace(data)
+	if err != nil {
+		return err
+	}
+
+	cargo := make(map[string]model.ResourceAmount)
+	total := float32(0.0)
+
+	for _, r := range o.Resources {
+		cargo[r.Resource] = r
+		total += r.Amount
+	}
+
+	if total > float32(space) {
+		return ErrInsufficientCargoForFleet
+	}
+
+	// Make sure that the planet holds the goods.
+	err = p.validateFleet(nil, cargo, mergeShips(o.Ships, o.Carriers), data)
+	if err != nil {
+		return err
+	}
+
+	o.ExpirationTime = time.Now().Add(time.Duration(o.Duration) * time.Hour)
+
+	return nil
+}
+
+// canPerform :
+// Used to make sure that all the input ships can be
+// used for the objective.
+//
+// The `obj` defines the objective to perform.
+//
+// The `ships` defines the ships to check.
+//
+// The `data` allows to access to the objectives.
+//
+// Returns any error.
+func canPerform(obj purpose, ships []ShipInFleet, data Instance) error {
+	desc, err := data.Objectives.GetObjectiveFromName(string(obj))
+	if err != nil {
+		return err
+	}
+
+	for _, s := range ships {
+		if !desc.CanBePerformedBy(s.ID) {
+			return ErrNoShipToPerformObjective
+		}
+	}
+
+	return nil
+}
+
+// SaveToDB :
+// Used to register the offer and to take its goods
+// from the planet of the seller.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func (o *MarketOffer) SaveToDB(proxy db.Proxy) error {
+	query := db.InsertReq{
+		Script: "create_market_offer",
+		Args: []interface{}{
+			o,
+		},
+		SkipReturn: true,
+	}
+
+	err := proxy.InsertToDB(query)
+
+	// Analyze the error in order to provide some
+	// comprehensive message.
+	dbe, ok := err.(db.Error)
+	if !ok {
+		return err
+	}
+
+	dee, ok := dbe.Err.(db.DuplicatedElementError)
+	if ok {
+		switch dee.Constraint {
+		case "market_offers_pkey":
+			return ErrDuplicatedElement
+		}
+
+		return dee
+	}
+
+	fkve, ok := dbe.Err.(db.ForeignKeyViolationError)
+	if ok {
+		switch fkve.ForeignKey {
+		case "universe":
+			return ErrNonExistingUniverse
+		case "player":
+			return ErrNonExistingPlayer
+		}
+
+		return fkve
+	}
+
+	return dbe
+}
+
+// ReleaseMarketOffer :
+// Used to withdraw the offer described by the input
+// identifier. Its goods are given back to the planet
+// of the seller.
+//
+// The `ID` defines the identifier of the offer.
+//
+// The `reason` defines why the offer is withdrawn. The
+// seller is notified in case it is not empty.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func ReleaseMarketOffer(ID string, reason string, data Instance) error {
+	// Make sure that the offer exists.
+	_, err := NewMarketOfferFromDB(ID, data)
+	if err != nil {
+		return err
+	}
+
+	query := db.InsertReq{
+		Script: "release_market_offer",
+		Args: []interface{}{
+			ID,
+			reason,
+		},
+		SkipReturn: true,
+	}
+
+	return data.Proxy.InsertToDB(query)
+}
+
+// Validate :
+// Used to make sure that the offer can be accepted by
+// the buyer. The fleets delivering the goods and the
+// price are generated and validated like any other
+// fleet: the fuel they need is taken from the planet
+// they start from.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (mp *MarketPurchase) Validate(data Instance) error {
+	o, err := NewMarketOfferFromDB(mp.Offer, data)
+	if err != nil {
+		return err
+	}
+
+	if o.ExpirationTime.Before(time.Now()) {
+		return ErrOfferExpired
+	}
+	if !validUUID(mp.Player) || mp.Player == o.Player {
+		return ErrInvalidPlayerForPurchase
+	}
+	if !validShips(mp.Carriers) {
+		return ErrInvalidCarriers
+	}
+
+	buyer, err := NewPlanetFromDB(mp.Planet, data)
+	if err != nil || buyer.Player != mp.Player {
+		return ErrInvalidPlanetForPurchase
+	}
+
+	uni, err := UniverseOfPlanet(mp.Planet, data)
+	if err != nil || uni != o.Universe {
+		return ErrInvalidPlanetForPurchase
+	}
+
+	seller, err := NewPlanetFromDB(o.Planet, data)
+	if err != nil {
+		return err
+	}
+
+	// The goods of the offer are given back to the seller
+	// before the fleets leave.
+	seller.adjust(o.Resources, mergeShips(o.Ships, o.Carriers), 1.0)
+
+	mp.fleets = make([]Fleet, 0)
+
+	if len(o.Ships) > 0 {
+		err = mp.addFleet(o.Universe, o.Player, deployment, &seller, &buyer, o.Ships, nil, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(o.Resources) > 0 {
+		err = mp.addFleet(o.Universe, o.Player, transport, &seller, &buyer, o.Carriers, o.Resources, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return mp.addFleet(o.Universe, mp.Player, transport, &buyer, &seller, mp.Carriers, o.Price, data)
+}
+
+// addFleet :
+// Used to generate a fleet delivering some goods for
+// this purchase. The fleet is validated against the
+// source and its ships, cargo and fuel are removed
+// from it so that the next fleets are validated with
+// what remains.
+//
+// The `uni` defines the universe of the offer.
+//
+// The `player` defines the owner of the fleet.
+//
+// The `obj` defines the objective of the fleet.
+//
+// The `source` defines the planet sending the fleet.
+//
+// The `target` defines the planet receiving the goods.
+//
+// The `ships` defines the ships of the fleet.
+//
+// The `cargo` defines the resources carried.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (mp *MarketPurchase) addFleet(uni string, player string, obj purpose, source *Planet, target *Planet, ships []ShipInFleet, cargo []model.ResourceAmount, data Instance) error {
+	objID, err := data.Objectives.GetIDFromName(string(obj))
+	if err != nil {
+		return err
+	}
+
+	f := Fleet{
+		ID:           uuid.New().String(),
+		Universe:     uni,
+		Objective:    objID,
+		Player:       player,
+		Source:       source.ID,
+		SourceType:   World,
+		Target:       target.ID,
+		TargetCoords: target.Coordinates,
+		Speed:        1.0,
+		Ships:        mergeShips(ships),
+		Cargo:        make(map[string]model.ResourceAmount),
+	}
+
+	for _, r := range cargo {
+		f.Cargo[r.Resource] = r
+	}
+
+	u, err := NewUniverseFromDB(uni, data)
+	if err != nil {
+		return err
+	}
+
+	err = f.Valid(u)
+	if err != nil {
+		return err
+	}
+
+	mul, err := NewMultipliersFromDB(uni, data)
+	if err != nil {
+		return ErrMultipliersError
+	}
+
+	err = f.ConsolidateArrivalTime(data, source, mul.Fleet)
+	if err != nil {
+		return err
+	}
+
+	err = f.Validate(data, source, target)
+	if err != nil {
+		return err
+	}
+
+	source.adjust(append(cargo, f.Consumption...), f.Ships, -1.0)
+
+	mp.fleets = append(mp.fleets, f)
+
+	return nil
+}
+
+// adjust :
+// Used to update the resources and ships of the planet
+// with the input values. This only affects the local
+// copy of the planet and not the DB.
+//
+// The `resources` defines the resources to update.
+//
+// The `ships` defines the ships to update.
+//
+// The `factor` defines whether the values are added
+// (`1`) or removed (`-1`).
+func (p *Planet) adjust(resources []model.ResourceAmount, ships ShipsInFleet, factor float32) {
+	for _, r := range resources {
+		desc := p.Resources[r.Resource]
+		desc.Resource = r.Resource
+		desc.Amount += factor * r.Amount
+		p.Resources[r.Resource] = desc
+	}
+
+	for _, s := range ships {
+		desc := p.Ships[s.ID]
+		desc.Amount += int(factor) * s.Count
+		p.Ships[s.ID] = desc
+	}
+}
+
+// SaveToDB :
+// Used to accept the offer: the goods of the offer are
+// given back to the seller and the fleets delivering
+// them and the price are created at once.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func (mp *MarketPurchase) SaveToDB(proxy db.Proxy) error {
+	fleets := make([]interface{}, 0)
+
+	for id := range mp.fleets {
+		f := &mp.fleets[id]
+
+		cargo := make([]model.ResourceAmount, 0)
+		for _, r := range f.Cargo {
+			cargo = append(cargo, r)
+		}
+
+		fleets = append(
+			fleets,
+			map[string]interface{}{
+				"fleet":       f,
+				"ships":       f.Ships.convert(),
+				"resources":   cargo,
+				"consumption": f.Consumption,
+			},
+		)
+	}
+
+	query := db.InsertReq{
+		Script: "accept_market_offer",
+		Args: []interface{}{
+			mp.Offer,
+			mp.Planet,
+			fleets,
+		},
+		SkipReturn: true,
+	}
+
+	return proxy.InsertToDB(query)
+}
+
+// Fleets :
+// Returns the identifiers of the fleets generated to
+// deliver the goods and the price of the purchase.
+func (mp *MarketPurchase) Fleets() []string {
+	IDs := make([]string, 0)
+
+	for _, f := range mp.fleets {
+		IDs = append(IDs, f.ID)
+	}
+
+	return IDs
+}
@@ -0,0 +1,176 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"oglike_server/pkg/db"
+)
+
+// Trade :
+// Describes the exchange of some resources of a planet
+// with the merchant. The player sells an amount of some
+// resource and receives another resource in exchange at
+// the rates defined by the universe.
+//
+// The `Planet` defines the identifier of the planet on
+// which the trade is performed.
+//
+// The `Sold` defines the identifier of the resource sold
+// to the merchant.
+//
+// The `Amount` defines the amount of the `Sold` resource
+// given to the merchant.
+//
+// The `Bought` defines the identifier of the resource
+// received in exchange.
+//
+// The `Rate` defines the number of units of the `Bought`
+// resource received for each unit of the `Sold` one. It
+// is computed when the trade is validated.
+//
+// The `Received` defines the amount of the `Bought` res
+// added to the planet. It is computed when the trade is
+// validated.
+type Trade struct {
+	Planet   string  `json:"planet"`
+	Sold     string  `json:"sold"`
+	Amount   float32 `json:"amount"`
+	Bought   string  `json:"bought"`
+	Rate     float32 `json:"rate"`
+	Received float32 `json:"received"`
+}
+
+// ErrInvalidTradeAmount : Indicates that the amount to trade is not valid.
+var ErrInvalidTradeAmount = fmt.Errorf("invalid amount to trade")
+
+// ErrInvalidTrade : Indicates that a resource cannot be traded against itself.
+var ErrInvalidTrade = fmt.Errorf("cannot trade a resource against itself")
+
+// ErrResourceNotTradable : Indicates that the merchant does not trade the resource.
+var ErrResourceNotTradable = fmt.Errorf("resource cannot be traded with the merchant")
+
+// ErrNotEnoughStorage : Indicates that the planet cannot store the traded resources.
+var ErrNotEnoughStorage = fmt.Errorf("not enough storage available")
+
+// Validate :
+// Used to make sure that the trade can be performed on
+// the planet. Both resources should be traded by the
+// merchant of the universe, the planet should have the
+// resources to sell and be able to store the bought
+// ones. The rate of the trade is also computed.
+//
+// The `data` allows to access to the DB.
+//
+// The `rng` defines the random source used to pick the
+// variation of the rate of the merchant.
+//
+// Returns any error.
+func (t *Trade) Validate(data Instance, rng *rand.Rand) error {
+	if t.Amount <= 0.0 {
+		return ErrInvalidTradeAmount
+	}
+	if t.Sold == t.Bought {
+		return ErrInvalidTrade
+	}
+
+	p, err := NewPlanetFromDB(t.Planet, data)
+	if err != nil {
+		return err
+	}
+
+	if p.vacation {
+		return ErrPlayerInVacationMode
+	}
+	if p.banned {
+		return ErrPlayerBanned
+	}
+
+	uniID, err := UniverseOfPlanet(t.Planet, data)
+	if err != nil {
+		return err
+	}
+
+	uni, err := NewUniverseFromDB(uniID, data)
+	if err != nil {
+		return err
+	}
+
+	sold, err := uni.merchantRate(t.Sold, data)
+	if err != nil {
+		return err
+	}
+
+	bought, err := uni.merchantRate(t.Bought, data)
+	if err != nil {
+		return err
+	}
+
+	// The variance is uniformly distributed around the
+	// base rate.
+	variance := 1.0 + uni.MerchantVariance*(2.0*rng.Float32()-1.0)
+
+	t.Rate = bought / sold * variance
+	t.Received = t.Amount * t.Rate
+
+	// Make sure that the planet can afford the trade.
+	desc, ok := p.Resources[t.Sold]
+	if !ok || desc.Amount < t.Amount {
+		return ErrNotEnoughResources
+	}
+
+	desc, ok = p.Resources[t.Bought]
+	if !ok || desc.Amount+t.Received > desc.Storage {
+		return ErrNotEnoughStorage
+	}
+
+	return nil
+}
+
+// merchantRate :
+// Used to retrieve the rate of the merchant for the
+// input resource in this universe. Only the movable
+// and storable resources can be traded.
+//
+// The `res` defines the identifier of the resource.
+//
+// The `data` allows to access to the resources.
+//
+// Returns the rate along with any error.
+func (u *Universe) merchantRate(res string, data Instance) (float32, error) {
+	desc, err := data.Resources.GetResourceFromID(res)
+	if err != nil {
+		return 0.0, err
+	}
+
+	if !desc.Movable || !desc.Storable {
+		return 0.0, ErrResourceNotTradable
+	}
+
+	rate, ok := u.MerchantRates[desc.Name]
+	if !ok {
+		return 0.0, ErrResourceNotTradable
+	}
+
+	return rate, nil
+}
+
+// SaveToDB :
+// Used to apply the trade to the resources of the
+// planet. Both resources are updated at once and
+// the owner of the planet is notified.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func (t *Trade) SaveToDB(proxy db.Proxy) error {
+	query := db.InsertReq{
+		Script: "perform_merchant_trade",
+		Args: []interface{}{
+			t.Planet,
+			t,
+		},
+		SkipReturn: true,
+	}
+
+	return proxy.InsertToDB(query)
+}
@@ -0,0 +1,102 @@
+package game
+
+import "oglike_server/pkg/metrics"
+
+// lockWait :
+// Measures the time spent waiting to acquire the lock
+// on the data model.
+var lockWait = metrics.NewHistogram(
+	"oglike_lock_wait_seconds",
+	"Time spent waiting to acquire the lock on the data model.",
+	metrics.DefaultDurationBuckets,
+)
+
+// lockHold :
+// Measures the time during which the lock on the data
+// model is held.
+var lockHold = metrics.NewHistogram(
+	"oglike_lock_hold_seconds",
+	"Time during which the lock on the data model is held.",
+	metrics.DefaultDurationBuckets,
+)
+
+// actionsProcessed :
+// Counts the actions successfully executed for each
+// kind of action.
+var actionsProcessed = metrics.NewCounter(
+	"oglike_actions_processed_total",
+	"Number of actions executed.",
+	"kind",
+)
+
+// actionsFailed :
+// Counts the actions that could not be executed for
+// each kind of action.
+var actionsFailed = metrics.NewCounter(
+	"oglike_actions_failed_total",
+	"Number of actions that failed to execute.",
+	"kind",
+)
+
+// actionsPerRun :
+// Measures the number of actions executed each time
+// the outstanding actions are scheduled.
+var actionsPerRun = metrics.NewHistogram(
+	"oglike_actions_per_run",
+	"Number of actions executed each time outstanding actions are scheduled.",
+	[]float64{0, 1, 2, 5, 10, 25, 50, 100, 250},
+)
+
+// fleetSimulations :
+// Counts the simulations of fleets for each objective
+// and status.
+var fleetSimulations = metrics.NewCounter(
+	"oglike_fleet_simulations_total",
+	"Number of fleets simulated.",
+	"objective",
+	"status",
+)
+
+// fights :
+// Counts the fights simulated for each objective and
+// outcome.
+var fights = metrics.NewCounter(
+	"oglike_fights_total",
+	"Number of fights simulated.",
+	"objective",
+	"outcome",
+)
+
+// simulationStatus :
+// Used to convert the input error into a status that
+// can be used as the value of a label.
+//
+// The `err` defines the error to convert.
+//
+// Returns the status.
+func simulationStatus(err error) string {
+	if err != nil {
+		return "failure"
+	}
+
+	return "success"
+}
+
+// objectiveName :
+// Used to retrieve the name of the input objective to
+// use it as the value of a label. In case the name is
+// not known the identifier is used.
+//
+// The `ID` defines the identifier of the objective.
+//
+// The `data` allows to access to the objectives.
+//
+// Returns the name of the objective.
+func objectiveName(ID string, data Instance) string {
+	obj, err := data.Objectives.GetObjectiveFromID(ID)
+	if err != nil {
+		return ID
+	}
+
+	return obj.Name
+}
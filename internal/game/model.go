@@ -1,10 +1,13 @@
 package game
 
 import (
+	"context"
 	"fmt"
 	"oglike_server/internal/model"
 	"oglike_server/pkg/db"
 	"oglike_server/pkg/logger"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,6 +21,59 @@ import (
 // case the information present in this element does
 // not cover all the needs.
 //
+// The `Model` defines the modules describing the data
+// model of the game. It might be outdated in case the
+// data model was reloaded: `Current` should be used
+// to retrieve an instance using the latest one.
+//
+// The `RuleSet` defines the name of the rule set used
+// to describe the data model of the game. As the data
+// is shared by all the universes, only those using the
+// same rule set can be served.
+//
+// The `Events` allows to notify the listeners of the
+// events of players whenever new events are produced.
+//
+// The `RelocationCost` defines the amount of resources
+// needed to relocate a planet. Each resource is keyed
+// by its name.
+//
+// The `log` defines a logger object to use to notify
+// information or errors to the user.
+//
+// The `waiter` allows to lock this instance which will
+// prevent any unauthorized use of the DB.
+//
+// The `models` holds the latest data model installed
+// in this instance. It is shared by all the copies of
+// the instance.
+//
+// The `epoch` counts the data models installed in the
+// instance. It is shared by all the copies as well.
+type Instance struct {
+	Model
+
+	Proxy   db.Proxy
+	RuleSet string
+	Events  *EventsBroker
+
+	RelocationCost map[string]int
+
+	log    logger.Logger
+	waiter *locker
+	models *atomic.Value
+	epoch  *uint32
+}
+
+// Model :
+// Regroups the modules describing the data model of the
+// game. These modules are loaded from the DB and do not
+// change afterwards: updating the data model consists in
+// loading a new set of modules.
+//
+// The `Countries` defines the countries available for
+// the players.
+//
 // The `Buildings` defines the object to use to access
 // to the buildings information for the game.
 //
@@ -37,14 +93,7 @@ import (
 //
 // The `Messages` defines the module to access to all
 // the messages defined in the game.
-//
-// The `log` defines a logger object to use to notify
-// information or errors to the user.
-//
-// The `waiter` allows to lock this instance which will
-// prevent any unauthorized use of the DB.
-type Instance struct {
-	Proxy        db.Proxy
+type Model struct {
 	Countries    *model.CountriesModule
 	Buildings    *model.BuildingsModule
 	Technologies *model.TechnologiesModule
@@ -53,9 +102,6 @@ type Instance struct {
 	Resources    *model.ResourcesModule
 	Objectives   *model.FleetObjectivesModule
 	Messages     *model.MessagesModule
-
-	log    logger.Logger
-	waiter *locker
 }
 
 // actionKind :
@@ -66,22 +112,60 @@ type actionKind string
 
 // Define the possible kind of actions.
 const (
-	planetBuilding actionKind = "building_upgrade"
-	moonBuilding   actionKind = "building_upgrade_moon"
-	technology     actionKind = "technology_upgrade"
-	planetShip     actionKind = "ship_upgrade"
-	moonShip       actionKind = "ship_upgrade_moon"
-	planetDefense  actionKind = "defense_upgrade"
-	moonDefense    actionKind = "defense_upgrade_moon"
-	fleet          actionKind = "fleet"
-	acsFleet       actionKind = "acs_fleet"
+	planetBuilding   actionKind = "building_upgrade"
+	moonBuilding     actionKind = "building_upgrade_moon"
+	technology       actionKind = "technology_upgrade"
+	planetShip       actionKind = "ship_upgrade"
+	moonShip         actionKind = "ship_upgrade_moon"
+	planetDefense    actionKind = "defense_upgrade"
+	moonDefense      actionKind = "defense_upgrade_moon"
+	fleet            actionKind = "fleet"
+	acsFleet         actionKind = "acs_fleet"
+	scheduledFleet   actionKind = "scheduled_fleet"
+	planetRelocation actionKind = "planet_relocation"
 )
 
 // locker :
 // Defines a common locker that can be used to protect
 // from concurrent accesses in a single-user fashion.
+//
+// The `state` describes the current holder of the lock
+// so that it can be inspected at runtime.
+//
+// The `status` protects the `state` from concurrent use.
+//
+// The `release` allows the holder of the lock to release
+// the lock on the actions acquired in the DB.
 type locker struct {
-	waiter chan struct{}
+	waiter  chan struct{}
+	state   LockState
+	status  sync.Mutex
+	release func()
+}
+
+// actionsLockKey :
+// Defines the key of the advisory lock acquired in the DB
+// while executing actions. It is shared by all the servers
+// and by the admin tool so that an action is never run by
+// several of them at once.
+const actionsLockKey int64 = 0x6f67616374
+
+// LockState :
+// Describes the state of the lock on the data model. It
+// is used to inspect which process holds the lock.
+//
+// The `Held` defines whether the lock is held.
+//
+// The `Since` defines the moment at which the lock was
+// acquired by its current holder.
+//
+// The `Holder` defines the identifier of the request
+// holding the lock. It is empty if the lock is held by
+// an internal process.
+type LockState struct {
+	Held   bool       `json:"held"`
+	Since  *time.Time `json:"since,omitempty"`
+	Holder string     `json:"holder,omitempty"`
 }
 
 // newLocker :
@@ -91,7 +175,8 @@ type locker struct {
 // Returns the created locker.
 func newLocker() *locker {
 	l := locker{
-		make(chan struct{}, 1),
+		waiter:  make(chan struct{}, 1),
+		release: func() {},
 	}
 
 	l.waiter <- struct{}{}
@@ -102,16 +187,52 @@ func newLocker() *locker {
 // lock :
 // Used to perform the lock of the resource managed
 // by this element.
-func (l *locker) lock() {
+//
+// The `holder` describes the element acquiring the
+// lock.
+func (l *locker) lock(holder string) {
+	start := time.Now()
 	<-l.waiter
+
+	acquired := time.Now()
+	lockWait.Observe(acquired.Sub(start).Seconds())
+
+	l.status.Lock()
+	defer l.status.Unlock()
+
+	l.state = LockState{
+		Held:   true,
+		Since:  &acquired,
+		Holder: holder,
+	}
 }
 
 // unlock :
 // Used to release the resource managed by this lock.
 func (l *locker) unlock() {
+	func() {
+		l.status.Lock()
+		defer l.status.Unlock()
+
+		if l.state.Since != nil {
+			lockHold.Observe(time.Since(*l.state.Since).Seconds())
+		}
+
+		l.state = LockState{}
+	}()
+
 	l.waiter <- struct{}{}
 }
 
+// current :
+// Returns the current state of the lock.
+func (l *locker) current() LockState {
+	l.status.Lock()
+	defer l.status.Unlock()
+
+	return l.state
+}
+
 // NewInstance :
 // Used to create a default instance of a data model
 // with a valid waiter object. Nothing else is set
@@ -126,15 +247,116 @@ func (l *locker) unlock() {
 // Returns the created instance.
 func NewInstance(proxy db.Proxy, log logger.Logger) Instance {
 	i := Instance{
-		Proxy: proxy,
+		Proxy:  proxy,
+		Events: NewEventsBroker(),
+
+		RelocationCost: make(map[string]int),
 
 		log:    log,
 		waiter: newLocker(),
+		models: &atomic.Value{},
+		epoch:  new(uint32),
+	}
+
+	return i
+}
+
+// NewModel :
+// Used to create the modules of a data model. None of
+// them is loaded: the `Init` method of each module is
+// expected to be called before using it.
+//
+// The `log` defines a way to notify information and
+// errors if needed.
+//
+// Returns the created model.
+func NewModel(log logger.Logger) Model {
+	return Model{
+		Countries:    model.NewCountriesModule(log),
+		Buildings:    model.NewBuildingsModule(log),
+		Technologies: model.NewTechnologiesModule(log),
+		Ships:        model.NewShipsModule(log),
+		Defenses:     model.NewDefensesModule(log),
+		Resources:    model.NewResourcesModule(log),
+		Objectives:   model.NewFleetObjectivesModule(log),
+		Messages:     model.NewMessagesModule(log),
+	}
+}
+
+// Modules :
+// Returns the modules of the data model in the order in
+// which they should be loaded.
+func (m Model) Modules() []model.DBModule {
+	return []model.DBModule{
+		m.Countries,
+		m.Buildings,
+		m.Technologies,
+		m.Ships,
+		m.Defenses,
+		m.Resources,
+		m.Objectives,
+		m.Messages,
+	}
+}
+
+// Validate :
+// Used to make sure that the modules of the data model
+// are consistent with each other: all the elements that
+// they reference should exist.
+//
+// Returns any error.
+func (m Model) Validate() error {
+	return model.CheckConsistency(
+		m.Buildings,
+		m.Technologies,
+		m.Ships,
+		m.Defenses,
+		m.Resources,
+		m.Objectives,
+	)
+}
+
+// Current :
+// Used to retrieve a copy of this instance using the
+// latest data model installed with `Swap`. The model
+// of the copy does not change afterwards so it should
+// be retrieved again for each operation.
+//
+// Returns the up-to-date instance.
+func (i Instance) Current() Instance {
+	if m, ok := i.models.Load().(Model); ok {
+		i.Model = m
 	}
 
 	return i
 }
 
+// Swap :
+// Used to install the input data model in this instance
+// and all its copies. The lock on the instance is held
+// during the process so that the operations in progress
+// complete with the previous model while the following
+// ones use the new one.
+//
+// The `m` defines the data model to install. It should
+// be loaded and validated.
+func (i Instance) Swap(m Model) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.models.Store(m)
+	atomic.AddUint32(i.epoch, 1)
+}
+
+// Epoch :
+// Returns the number of data models installed in this
+// instance with `Swap`. It changes with each reload of
+// the data model so anything computed from a previous
+// value should be discarded.
+func (i Instance) Epoch() uint32 {
+	return atomic.LoadUint32(i.epoch)
+}
+
 // trace :
 // Wrapper around the internal logger method to be
 // able to provide always the same `module` string.
@@ -153,13 +375,66 @@ func (i Instance) trace(level logger.Severity, msg string) {
 // It will also perform an update of the actions
 // that are outstanding when the lock is acquired.
 func (i Instance) Lock() {
-	i.trace(logger.Verbose, "Acquiring lock on DB")
-	i.waiter.lock()
+	i.lock(logger.Fields{})
+}
+
+// LockFor :
+// Similar to the `Lock` method but indicates that
+// the lock is acquired to serve a request. All the
+// logs produced while the lock is held will carry
+// the identifier of the request if the logger of
+// this instance supports it.
+//
+// The `ctx` defines the context of the request.
+func (i Instance) LockFor(ctx context.Context) {
+	fields := logger.Fields{}
+
+	id := logger.RequestID(ctx)
+	if id != "" {
+		fields[logger.RequestIDField] = id
+	}
+
+	i.lock(fields)
+}
+
+// lock :
+// Used to acquire the lock on this object and to
+// attach the input fields to the logs produced by
+// the holder of the lock.
+//
+// The `fields` defines the fields to attach.
+func (i Instance) lock(fields logger.Fields) {
+	holder, _ := fields[logger.RequestIDField].(string)
+
+	// The scope describes the current holder of the lock
+	// so it does not apply while waiting for it.
+	waiting := i.log
+	if sl, ok := i.log.(*logger.ScopedLogger); ok {
+		waiting = logger.WithFields(sl.Unscoped(), fields)
+	}
+
+	waiting.Trace(logger.Verbose, "lock", "Acquiring lock on DB")
+	i.waiter.lock(holder)
+
+	if sl, ok := i.log.(*logger.ScopedLogger); ok {
+		sl.Enter(fields)
+	}
+
 	i.trace(logger.Verbose, "Acquired lock on DB")
 
+	// The actions can also be executed by other servers
+	// or by the admin tool: they share a lock in the DB.
+	release, err := i.Proxy.AdvisoryLock(actionsLockKey)
+	i.waiter.release = release
+
+	if err != nil {
+		i.trace(logger.Error, fmt.Sprintf("Unable to acquire lock on actions, skipping outstanding actions (err: %v)", err))
+		return
+	}
+
 	// Schedule the execution of the outstanding actions
 	// now that the lock is acquired.
-	err := i.scheduleActions()
+	err = i.Current().scheduleActions()
 	if err != nil {
 		i.trace(logger.Error, fmt.Sprintf("Unable to execute outstanding actions (err: %v)", err))
 	}
@@ -170,10 +445,73 @@ func (i Instance) Lock() {
 // on this object.
 func (i Instance) Unlock() {
 	i.trace(logger.Verbose, "Releasing lock on DB")
+
+	i.waiter.release()
+	i.waiter.release = func() {}
+
+	if sl, ok := i.log.(*logger.ScopedLogger); ok {
+		sl.Leave()
+	}
+
 	i.waiter.unlock()
 	i.trace(logger.Verbose, "Released lock on DB")
 }
 
+// LockState :
+// Used to retrieve the state of the lock on this
+// object without acquiring it.
+//
+// Returns the state of the lock.
+func (i Instance) LockState() LockState {
+	return i.waiter.current()
+}
+
+// ActionsBacklog :
+// Used to count the actions that have completed but
+// that were not yet executed. This does not acquire
+// the lock on this object.
+//
+// Returns the number of pending actions along with
+// any error.
+func (i Instance) ActionsBacklog() (int, error) {
+	query := db.QueryDesc{
+		Props: []string{
+			"count(*)",
+		},
+		Table: "actions_queue",
+		Filters: []db.Filter{
+			{
+				Key:      "completion_time",
+				Values:   []interface{}{time.Now()},
+				Operator: db.LessThan,
+			},
+		},
+	}
+
+	dbRes, err := i.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return 0, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return 0, dbRes.Err
+	}
+
+	count := 0
+
+	for dbRes.Next() {
+		err = dbRes.Scan(&count)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return count, nil
+}
+
 // scheduleActions :
 // Used to perform the execution of all the pending
 // actions in the actions queue. It will fetch all
@@ -222,6 +560,8 @@ func (i Instance) scheduleActions() error {
 	var kind actionKind
 	var completion time.Time
 
+	processed := 0
+
 	for dbRes.Next() {
 		err = dbRes.Scan(
 			&action,
@@ -233,31 +573,91 @@ func (i Instance) scheduleActions() error {
 			return err
 		}
 
-		switch kind {
-		case planetBuilding:
-			err = i.performBuildingAction(action, "planet")
-		case moonBuilding:
-			err = i.performBuildingAction(action, "moon")
-		case technology:
-			err = i.performTechnologyAction(action)
-		case planetShip:
-			err = i.performShipAction(action, "planet")
-		case moonShip:
-			err = i.performShipAction(action, "moon")
-		case planetDefense:
-			err = i.performDefenseAction(action, "planet")
-		case moonDefense:
-			err = i.performDefenseAction(action, "moon")
-		case fleet:
-			err = i.performFleetAction(action)
-		case acsFleet:
-			err = i.performACSFleetAction(action)
-		default:
-			i.trace(logger.Error, fmt.Sprintf("Unknown action \"%s\" with kind \"%s\" not processed", action, kind))
+		err = i.executeAction(action, kind)
+		if err != nil {
+			continue
 		}
 
-		if err != nil {
-			i.trace(logger.Error, fmt.Sprintf("Failed to perform action \"%s\" (err: %v)", action, err))
+		processed++
+	}
+
+	actionsPerRun.Observe(float64(processed))
+
+	// Some events might have been produced by the DB
+	// itself while processing the actions (typically
+	// messages): notify all listeners in this case.
+	if processed > 0 && i.Events != nil {
+		i.Events.notifyAll()
+	}
+
+	return nil
+}
+
+// executeAction :
+// Used to execute the input action and to register the
+// event notifying its completion when needed. Failures
+// are traced and counted.
+//
+// The `action` defines the identifier of the action.
+//
+// The `kind` defines the kind of the action.
+//
+// Returns any error.
+func (i Instance) executeAction(action string, kind actionKind) error {
+	// Fetch the description of the event to produce
+	// for construction actions before executing them
+	// as they are removed in the process.
+	var player string
+	var ae actionEvent
+	var eErr error
+
+	if _, ok := actionEvents[kind]; ok {
+		player, ae, eErr = fetchActionEvent(action, kind, i)
+	}
+
+	var err error
+
+	switch kind {
+	case planetBuilding:
+		err = i.performBuildingAction(action, "planet")
+	case moonBuilding:
+		err = i.performBuildingAction(action, "moon")
+	case technology:
+		err = i.performTechnologyAction(action)
+	case planetShip:
+		err = i.performShipAction(action, "planet")
+	case moonShip:
+		err = i.performShipAction(action, "moon")
+	case planetDefense:
+		err = i.performDefenseAction(action, "planet")
+	case moonDefense:
+		err = i.performDefenseAction(action, "moon")
+	case fleet:
+		err = i.performFleetAction(action)
+	case acsFleet:
+		err = i.performACSFleetAction(action)
+	case scheduledFleet:
+		err = i.performScheduledFleetAction(action)
+	case planetRelocation:
+		err = i.performRelocationAction(action)
+	default:
+		i.trace(logger.Error, fmt.Sprintf("Unknown action \"%s\" with kind \"%s\" not processed", action, kind))
+	}
+
+	if err != nil {
+		actionsFailed.Inc(string(kind))
+		i.trace(logger.Error, fmt.Sprintf("Failed to perform action \"%s\" (err: %v)", action, err))
+		return err
+	}
+
+	actionsProcessed.Inc(string(kind))
+
+	if _, ok := actionEvents[kind]; ok {
+		if eErr == nil {
+			eErr = registerEvent(player, ActionCompleted, ae, i)
+		}
+		if eErr != nil {
+			i.trace(logger.Warning, fmt.Sprintf("Failed to register event for action \"%s\" (err: %v)", action, eErr))
 		}
 	}
 
@@ -445,7 +845,21 @@ func (i Instance) performFleetAction(ID string) error {
 		}
 	}
 
-	return f.simulate(p, i)
+	// Keep track of whether the fleet is returning
+	// as the simulation changes it.
+	returning := f.returning
+
+	err = f.simulate(p, i)
+	if err != nil {
+		return err
+	}
+
+	err = f.registerFleetEvent(returning, i)
+	if err != nil {
+		i.trace(logger.Warning, fmt.Sprintf("Failed to register event for fleet \"%s\" (err: %v)", f.ID, err))
+	}
+
+	return nil
 }
 
 // performACSFleetAction :
@@ -496,5 +910,25 @@ func (i Instance) performACSFleetAction(ID string) error {
 		}
 	}
 
-	return acs.simulate(p, i)
+	err = acs.simulate(p, i)
+	fleetSimulations.Inc(objectiveName(acs.Objective, i), simulationStatus(err))
+
+	if err != nil {
+		return err
+	}
+
+	// Notify the arrival of each component of the
+	// ACS operation.
+	for _, ID := range acs.Fleets {
+		f, fErr := NewFleetFromDB(ID, i)
+		if fErr == nil {
+			fErr = f.registerFleetEvent(false, i)
+		}
+
+		if fErr != nil {
+			i.trace(logger.Warning, fmt.Sprintf("Failed to register event for fleet \"%s\" (err: %v)", ID, fErr))
+		}
+	}
+
+	return nil
 }
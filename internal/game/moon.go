@@ -153,8 +153,10 @@ func NewMoon(p *Planet, diameter int) *Planet {
 
 		technologies: p.technologies,
 
-		Moon:   true,
-		planet: p.ID,
+		Moon:     true,
+		planet:   p.ID,
+		vacation: p.vacation,
+		banned:   p.banned,
 	}
 
 	m.Coordinates.Type = Moon
@@ -186,8 +188,11 @@ func (p *Planet) fetchMoonInfo(data Instance) error {
 			"m.diameter",
 			"m.created_at",
 			"m.last_activity",
+			"pl.vacation_mode",
+			"pl.banned",
+			"r.id is not null",
 		},
-		Table: "moons m inner join planets p on m.planet=p.id",
+		Table: "moons m inner join planets p on m.planet=p.id inner join players pl on p.player=pl.id left join planets_relocations r on r.planet=p.id",
 		Filters: []db.Filter{
 			{
 				Key:    "m.id",
@@ -225,6 +230,9 @@ func (p *Planet) fetchMoonInfo(data Instance) error {
 			&p.Diameter,
 			&p.CreatedAt,
 			&p.LastActivity,
+			&p.vacation,
+			&p.banned,
+			&p.relocating,
 		)
 
 		if err != nil {
@@ -155,6 +155,19 @@ type Planet struct {
 	// associated to the moon. In the case of a planet
 	// we also populate it with the `ID` of the planet.
 	planet string
+
+	// The `vacation` defines whether the player owning
+	// this planet is currently in vacation mode.
+	vacation bool
+
+	// The `banned` defines whether the player owning this
+	// planet is banned from the universe.
+	banned bool
+
+	// The `relocating` defines whether the planet (or the
+	// parent planet in the case of a moon) is waiting to
+	// be relocated to new coordinates.
+	relocating bool
 }
 
 // ResourceInfo :
@@ -264,6 +277,13 @@ var ErrNotEnoughFuel = fmt.Errorf("not enough fuel for fleet")
 // ErrNotEnoughShips : Indicates that there's not enough ships for the fleet.
 var ErrNotEnoughShips = fmt.Errorf("not enough ships for fleet")
 
+// ErrPlayerInVacationMode : Indicates that the player owning the planet is in
+// vacation mode.
+var ErrPlayerInVacationMode = fmt.Errorf("player is in vacation mode")
+
+// ErrPlayerBanned : Indicates that the player owning the planet is banned.
+var ErrPlayerBanned = fmt.Errorf("player is banned")
+
 // getDefaultPlanetName :
 // Used to retrieve a default name for a planet. The
 // generated name will be different based on whether
@@ -613,9 +633,17 @@ func (p *Planet) AverageTemperature() float32 {
 
 // RemainingFields :
 // Returns the number of remaining fields on the planet
-// given the current buildings on it. Note that it does
-// not include the potential upgrade actions.
+// given the current buildings on it. The upgrade actions
+// that are still pending also consume a field.
 func (p *Planet) RemainingFields() int {
+	return p.Fields - p.UsedFields()
+}
+
+// UsedFields :
+// Returns the number of fields used on the planet: each
+// level of a building uses a field, including the ones
+// that are still being built.
+func (p *Planet) UsedFields() int {
 	// Accumulate the total used fields.
 	used := 0
 
@@ -623,15 +651,19 @@ func (p *Planet) RemainingFields() int {
 		used += b.Level
 	}
 
-	return p.Fields - used
+	for _, a := range p.BuildingsUpgrade {
+		if a.DesiredLevel > a.CurrentLevel {
+			used++
+		}
+	}
+
+	return used
 }
 
 // generateData :
-// Used to generate the size associated to a planet. The size
-// is a general notion including both its actual diameter and
-// also the temperature on the surface of the planet. Both
-// values depend on the actual position of the planet in the
-// parent solar system.
+// Used to generate the size associated to a planet along
+// with the resources that are initially present on it.
+// See the `generateProperties` method for more details.
 //
 // The `data ` will help generating the resources that are
 // present on the planet upon starting it. It corresponds to
@@ -640,6 +672,37 @@ func (p *Planet) RemainingFields() int {
 //
 // Returns any error.
 func (p *Planet) generateData(data Instance) error {
+	p.generateProperties()
+
+	// Generate default resources for this planet.
+	p.Resources = make(map[string]ResourceInfo)
+
+	allRes, err := data.Resources.Resources(data.Proxy, []db.Filter{})
+	if err != nil {
+		return err
+	}
+
+	for _, res := range allRes {
+		desc := ResourceInfo{
+			Resource:   res.ID,
+			Amount:     float32(res.BaseAmount),
+			Storage:    float32(res.BaseStorage),
+			Production: float32(res.BaseProd),
+		}
+
+		p.Resources[res.ID] = desc
+	}
+
+	return nil
+}
+
+// generateProperties :
+// Used to generate the size associated to a planet. The size
+// is a general notion including both its actual diameter and
+// also the temperature on the surface of the planet. Both
+// values depend on the actual position of the planet in the
+// parent solar system.
+func (p *Planet) generateProperties() {
 	// Create a random source to be used for the generation of
 	// the planet's properties. We will use a procedural algo
 	// which will be based on the position of the planet in its
@@ -825,27 +888,6 @@ func (p *Planet) generateData(data Instance) error {
 	clFMaxTemp := math.Max(float64(min), math.Min(float64(max), fMaxTemp))
 	p.MaxTemp = int(math.Round(clFMaxTemp))
 	p.MinTemp = p.MaxTemp - 50
-
-	// Generate default resources for this planet.
-	p.Resources = make(map[string]ResourceInfo)
-
-	allRes, err := data.Resources.Resources(data.Proxy, []db.Filter{})
-	if err != nil {
-		return err
-	}
-
-	for _, res := range allRes {
-		desc := ResourceInfo{
-			Resource:   res.ID,
-			Amount:     float32(res.BaseAmount),
-			Storage:    float32(res.BaseStorage),
-			Production: float32(res.BaseProd),
-		}
-
-		p.Resources[res.ID] = desc
-	}
-
-	return nil
 }
 
 // fetchGeneralInfo :
@@ -872,8 +914,11 @@ func (p *Planet) fetchGeneralInfo(data Instance) error {
 			"p.diameter",
 			"p.created_at",
 			"p.last_activity",
+			"pl.vacation_mode",
+			"pl.banned",
+			"r.id is not null",
 		},
-		Table: "planets p inner join players pl on p.player = pl.id",
+		Table: "planets p inner join players pl on p.player = pl.id left join planets_relocations r on r.planet = p.id",
 		Filters: []db.Filter{
 			{
 				Key:    "p.id",
@@ -911,6 +956,9 @@ func (p *Planet) fetchGeneralInfo(data Instance) error {
 			&p.Diameter,
 			&p.CreatedAt,
 			&p.LastActivity,
+			&p.vacation,
+			&p.banned,
+			&p.relocating,
 		)
 
 		if err != nil {
@@ -1896,12 +1944,36 @@ func (p *Planet) fetchDefenses(data Instance) error {
 // need to be met for the element to be built on
 // this planet.
 //
+// The `fields` defines the number of fields that
+// are needed by the action.
+//
 // The `data` allows to access to the DB if needed.
 //
 // Returns any error. In case the return value is
 // `nil` it means that the action can be performed
 // on this planet.
-func (p *Planet) validateAction(costs map[string]int, desc model.UpgradableDesc, data Instance) error {
+func (p *Planet) validateAction(costs map[string]int, desc model.UpgradableDesc, fields int, data Instance) error {
+	// No action can be performed while the player is in
+	// vacation mode.
+	if p.vacation {
+		return ErrPlayerInVacationMode
+	}
+
+	// Banned players can't do anything either.
+	if p.banned {
+		return ErrPlayerBanned
+	}
+
+	// Nor while the planet is waiting to be relocated.
+	if p.relocating {
+		return ErrPlanetRelocating
+	}
+
+	// Make sure that there are enough fields left.
+	if fields > 0 && p.RemainingFields() < fields {
+		return ErrNoFieldsLeft
+	}
+
 	// Make sure that there are enough resources on the planet.
 	if len(costs) == 0 {
 		return ErrNoCost
@@ -1969,6 +2041,22 @@ func (p *Planet) validateAction(costs map[string]int, desc model.UpgradableDesc,
 // meant to indicate that the component is a
 // valid one compared to the planet's data.
 func (p *Planet) validateFleet(fuels []model.ResourceAmount, cargos map[string]model.ResourceAmount, ships map[string]ShipInFleet, data Instance) error {
+	// No fleet can be sent while the player is in vacation
+	// mode.
+	if p.vacation {
+		return ErrPlayerInVacationMode
+	}
+
+	// Banned players can't send fleets either.
+	if p.banned {
+		return ErrPlayerBanned
+	}
+
+	// Nor from a planet waiting to be relocated.
+	if p.relocating {
+		return ErrPlanetRelocating
+	}
+
 	// Gather existing resources.
 	available := make(map[string]float32)
 
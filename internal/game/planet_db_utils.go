@@ -140,6 +140,26 @@ func (p *Planet) UpdateProduction(production []BuildingInfo, proxy db.Proxy) err
 // there are no obvious obstacles to the deletion
 // of the planet.
 func (p *Planet) isEligibleForDeletion() error {
+	if err := p.isIdle(); err != nil {
+		return err
+	}
+
+	// Finally make sure that we're not deleting a moon.
+	if p.Moon {
+		return ErrCannotDeleteMoon
+	}
+
+	return nil
+}
+
+// isIdle :
+// Defines whether this planet does not have any
+// upgrade action in progress and is not involved
+// in any fleet, either as a source or a target.
+//
+// Returns `nil` in case the planet is idle and an
+// error describing the first activity found.
+func (p *Planet) isIdle() error {
 	// Make sure that there are no upgrade actions.
 	if len(p.BuildingsUpgrade) > 0 {
 		return ErrActionStillInProgress
@@ -163,11 +183,6 @@ func (p *Planet) isEligibleForDeletion() error {
 		return ErrFleetNotYetArrived
 	}
 
-	// Finally make sure that we're not deleting a moon.
-	if p.Moon {
-		return ErrCannotDeleteMoon
-	}
-
 	return nil
 }
 
@@ -310,6 +325,61 @@ func (p *Planet) Convert() interface{} {
 	}
 }
 
+// planetBuildingOutput :
+// Describes the JSON representation of a building of a
+// planet as it is sent to clients.
+type planetBuildingOutput struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	Level            int     `json:"level"`
+	ProductionFactor float32 `json:"production_factor"`
+	EnergyFactor     float32 `json:"energy_factor"`
+}
+
+// planetUnitOutput :
+// Describes the JSON representation of the ships or the
+// defenses of a planet as they are sent to clients.
+type planetUnitOutput struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Amount int    `json:"amount"`
+}
+
+// planetOutput :
+// Describes the JSON representation of a planet as it
+// is sent to clients.
+type planetOutput struct {
+	ID                   string                 `json:"id"`
+	Player               string                 `json:"player"`
+	PlayerName           string                 `json:"player_name"`
+	Coordinates          Coordinate             `json:"coordinate"`
+	Name                 string                 `json:"name"`
+	Fields               int                    `json:"fields"`
+	MinTemp              int                    `json:"min_temperature"`
+	MaxTemp              int                    `json:"max_temperature"`
+	Diameter             int                    `json:"diameter"`
+	Resources            []ResourceInfo         `json:"resources"`
+	Buildings            []planetBuildingOutput `json:"buildings"`
+	Ships                []planetUnitOutput     `json:"ships"`
+	Defenses             []planetUnitOutput     `json:"defenses"`
+	BuildingsUpgrade     []BuildingAction       `json:"buildings_upgrade"`
+	TechnologiesUpgrade  []TechnologyAction     `json:"technologies_upgrade"`
+	ShipsConstruction    []ShipAction           `json:"ships_construction"`
+	DefensesConstruction []DefenseAction        `json:"defenses_construction"`
+	SourceFleets         []string               `json:"source_fleets"`
+	IncomingFleets       []string               `json:"incoming_fleets"`
+	CreatedAt            time.Time              `json:"created_at"`
+	LastActivity         time.Time              `json:"last_activity"`
+}
+
+// JSONModel :
+// Returns a value having the same JSON representation
+// as the planet. It is used to describe the planet in
+// the specification of the API.
+func (p *Planet) JSONModel() interface{} {
+	return planetOutput{}
+}
+
 // MarshalJSON :
 // Implementation of the `Marshaler` interface to allow
 // only specific information to be marshalled when the
@@ -326,46 +396,8 @@ func (p *Planet) Convert() interface{} {
 // Returns the marshalled bytes for this planet along
 // with any error.
 func (p *Planet) MarshalJSON() ([]byte, error) {
-	type lightInfo struct {
-		ID               string  `json:"id"`
-		Name             string  `json:"name"`
-		Level            int     `json:"level"`
-		ProductionFactor float32 `json:"production_factor"`
-		EnergyFactor     float32 `json:"energy_factor"`
-	}
-
-	type lightCount struct {
-		ID     string `json:"id"`
-		Name   string `json:"name"`
-		Amount int    `json:"amount"`
-	}
-
-	type lightPlanet struct {
-		ID                   string             `json:"id"`
-		Player               string             `json:"player"`
-		PlayerName           string             `json:"player_name"`
-		Coordinates          Coordinate         `json:"coordinate"`
-		Name                 string             `json:"name"`
-		Fields               int                `json:"fields"`
-		MinTemp              int                `json:"min_temperature"`
-		MaxTemp              int                `json:"max_temperature"`
-		Diameter             int                `json:"diameter"`
-		Resources            []ResourceInfo     `json:"resources"`
-		Buildings            []lightInfo        `json:"buildings"`
-		Ships                []lightCount       `json:"ships"`
-		Defenses             []lightCount       `json:"defenses"`
-		BuildingsUpgrade     []BuildingAction   `json:"buildings_upgrade"`
-		TechnologiesUpgrade  []TechnologyAction `json:"technologies_upgrade"`
-		ShipsConstruction    []ShipAction       `json:"ships_construction"`
-		DefensesConstruction []DefenseAction    `json:"defenses_construction"`
-		SourceFleets         []string           `json:"source_fleets"`
-		IncomingFleets       []string           `json:"incoming_fleets"`
-		CreatedAt            time.Time          `json:"created_at"`
-		LastActivity         time.Time          `json:"last_activity"`
-	}
-
 	// Copy the planet's data.
-	lp := lightPlanet{
+	lp := planetOutput{
 		ID:                   p.ID,
 		Player:               p.Player,
 		PlayerName:           p.PlayerName,
@@ -393,7 +425,7 @@ func (p *Planet) MarshalJSON() ([]byte, error) {
 	// Make shallow copy of the buildings, ships and
 	// defenses without including the tech deps.
 	for _, b := range p.Buildings {
-		lb := lightInfo{
+		lb := planetBuildingOutput{
 			ID:               b.ID,
 			Name:             b.Name,
 			Level:            b.Level,
@@ -405,7 +437,7 @@ func (p *Planet) MarshalJSON() ([]byte, error) {
 	}
 
 	for _, s := range p.Ships {
-		ls := lightCount{
+		ls := planetUnitOutput{
 			ID:     s.ID,
 			Name:   s.Name,
 			Amount: s.Amount,
@@ -415,7 +447,7 @@ func (p *Planet) MarshalJSON() ([]byte, error) {
 	}
 
 	for _, d := range p.Defenses {
-		ld := lightCount{
+		ld := planetUnitOutput{
 			ID:     d.ID,
 			Name:   d.Name,
 			Amount: d.Amount,
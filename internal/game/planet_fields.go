@@ -0,0 +1,106 @@
+package game
+
+import "sort"
+
+// FieldsReport :
+// Describes the usage of the fields of a planet or a
+// moon. It details how the maximum number of fields
+// is reached and which buildings use them.
+//
+// The `Planet` defines the identifier of the planet
+// or moon described by this report.
+//
+// The `Base` defines the number of fields of the body
+// without the contribution of any building.
+//
+// The `Maximum` defines the total number of fields of
+// the body including the contribution of buildings.
+//
+// The `Used` defines the number of fields used by the
+// buildings of the body, including the ones that are
+// still being upgraded.
+//
+// The `Free` defines the number of fields that are
+// still available.
+//
+// The `Buildings` defines the contribution of each
+// building to the usage of the fields.
+type FieldsReport struct {
+	Planet    string               `json:"planet"`
+	Base      int                  `json:"base"`
+	Maximum   int                  `json:"maximum"`
+	Used      int                  `json:"used"`
+	Free      int                  `json:"free"`
+	Buildings []BuildingFieldsInfo `json:"buildings"`
+}
+
+// BuildingFieldsInfo :
+// Describes the contribution of a building to the
+// fields of a planet or moon.
+//
+// The `Building` defines the identifier of the
+// building.
+//
+// The `Level` defines the current level of the
+// building.
+//
+// The `Used` defines the number of fields used by
+// the building.
+//
+// The `Additional` defines the number of fields
+// provided by the building.
+type BuildingFieldsInfo struct {
+	Building   string `json:"building"`
+	Level      int    `json:"level"`
+	Used       int    `json:"used"`
+	Additional int    `json:"additional"`
+}
+
+// FieldsReport :
+// Used to generate the report of the usage of the
+// fields of this planet. Only the buildings using
+// or providing fields are included.
+//
+// Returns the report of the fields of the planet.
+func (p *Planet) FieldsReport() FieldsReport {
+	fr := FieldsReport{
+		Planet:    p.ID,
+		Maximum:   p.Fields,
+		Used:      p.UsedFields(),
+		Free:      p.RemainingFields(),
+		Buildings: make([]BuildingFieldsInfo, 0),
+	}
+
+	pending := make(map[string]int)
+	for _, a := range p.BuildingsUpgrade {
+		if a.DesiredLevel > a.CurrentLevel {
+			pending[a.Element]++
+		}
+	}
+
+	additional := 0
+
+	for id, b := range p.Buildings {
+		bfi := BuildingFieldsInfo{
+			Building:   id,
+			Level:      b.Level,
+			Used:       b.Level + pending[id],
+			Additional: b.Fields.TotalFields(b.Level),
+		}
+
+		if bfi.Used == 0 && bfi.Additional == 0 {
+			continue
+		}
+
+		additional += bfi.Additional
+		fr.Buildings = append(fr.Buildings, bfi)
+	}
+
+	fr.Base = fr.Maximum - additional
+
+	sort.Slice(fr.Buildings, func(i, j int) bool {
+		return fr.Buildings[i].Building < fr.Buildings[j].Building
+	})
+
+	return fr
+}
@@ -0,0 +1,439 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"oglike_server/internal/model"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+	"time"
+)
+
+// Relocation :
+// Describes the request of a player to move one of its
+// planets to new coordinates in the same universe. The
+// target slot is reserved for the duration of the move
+// and the planet is moved along with its moon once the
+// countdown is over.
+//
+// The `ID` defines the identifier of the relocation.
+//
+// The `Universe` defines the universe of the planet.
+//
+// The `Player` defines the player requesting the move.
+// It should own the planet.
+//
+// The `Planet` defines the identifier of the planet to
+// relocate.
+//
+// The `Target` defines the coordinates where the planet
+// should be moved.
+//
+// The `MinTemp` defines the minimum temperature of the
+// planet once it is moved to its new position.
+//
+// The `MaxTemp` defines the maximum temperature of the
+// planet once it is moved to its new position.
+//
+// The `Cost` defines the resources taken from the planet
+// to pay for the relocation.
+//
+// The `CompletionTime` defines the time at which the
+// planet will be moved.
+type Relocation struct {
+	ID             string                 `json:"id"`
+	Universe       string                 `json:"universe"`
+	Player         string                 `json:"player"`
+	Planet         string                 `json:"planet"`
+	Target         Coordinate             `json:"target"`
+	MinTemp        int                    `json:"min_temperature"`
+	MaxTemp        int                    `json:"max_temperature"`
+	Cost           []model.ResourceAmount `json:"cost"`
+	CompletionTime time.Time              `json:"completion_time"`
+}
+
+// relocationDuration :
+// Defines the time needed to relocate a planet once the
+// target slot has been reserved.
+var relocationDuration = 24 * time.Hour
+
+// ErrPlanetRelocating : Indicates that the planet is waiting to be relocated.
+var ErrPlanetRelocating = fmt.Errorf("planet is waiting to be relocated")
+
+// ErrPlanetNotIdle : Indicates that the planet has actions or fleets in progress.
+var ErrPlanetNotIdle = fmt.Errorf("planet has actions or fleets in progress")
+
+// ErrInvalidPlanetForRelocation : Indicates that the planet cannot be relocated by the player.
+var ErrInvalidPlanetForRelocation = fmt.Errorf("invalid planet for relocation")
+
+// ErrRelocationTargetUsed : Indicates that the target of the relocation is not free.
+var ErrRelocationTargetUsed = fmt.Errorf("relocation target is already used")
+
+// NewRelocationFromDB :
+// Used to fetch the content of the relocation from
+// the input identifier.
+//
+// The `ID` defines the identifier of the relocation.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the relocation as fetched from the DB
+// along with any error.
+func NewRelocationFromDB(ID string, data Instance) (Relocation, error) {
+	r := Relocation{
+		ID: ID,
+	}
+
+	// Consistency.
+	if !validUUID(r.ID) {
+		return r, ErrInvalidElementID
+	}
+
+	// Create the query and execute it.
+	query := db.QueryDesc{
+		Props: []string{
+			"universe",
+			"player",
+			"planet",
+			"galaxy",
+			"solar_system",
+			"position",
+			"min_temperature",
+			"max_temperature",
+			"cost::text",
+			"completion_time",
+		},
+		Table: "planets_relocations",
+		Filters: []db.Filter{
+			{
+				Key:    "id",
+				Values: []interface{}{r.ID},
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return r, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return r, dbRes.Err
+	}
+
+	// Scan the relocation's data.
+	atLeastOne := dbRes.Next()
+	if !atLeastOne {
+		return r, ErrElementNotFound
+	}
+
+	var galaxy, system, position int
+	var rawCost string
+
+	err = dbRes.Scan(
+		&r.Universe,
+		&r.Player,
+		&r.Planet,
+		&galaxy,
+		&system,
+		&position,
+		&r.MinTemp,
+		&r.MaxTemp,
+		&rawCost,
+		&r.CompletionTime,
+	)
+	if err != nil {
+		return r, err
+	}
+
+	// Make sure that it's the only relocation.
+	if dbRes.Next() {
+		return r, ErrDuplicatedElement
+	}
+
+	r.Target = NewPlanetCoordinate(galaxy, system, position)
+
+	r.Cost = make([]model.ResourceAmount, 0)
+	err = json.Unmarshal([]byte(rawCost), &r.Cost)
+
+	return r, err
+}
+
+// Validate :
+// Used to make sure that the relocation can be done.
+// The planet should belong to the player and both the
+// planet and its moon should be idle. The target slot
+// should be free and the planet should have enough
+// resources to pay for the move. The temperature at
+// the target and the cost of the relocation are also
+// computed.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (r *Relocation) Validate(data Instance) error {
+	p, err := NewPlanetFromDB(r.Planet, data)
+	if err != nil || p.Player != r.Player {
+		return ErrInvalidPlanetForRelocation
+	}
+
+	if p.vacation {
+		return ErrPlayerInVacationMode
+	}
+	if p.banned {
+		return ErrPlayerBanned
+	}
+	if p.relocating {
+		return ErrPlanetRelocating
+	}
+
+	err = p.isRelocatable(data)
+	if err != nil {
+		return err
+	}
+
+	r.Universe, err = UniverseOfPlanet(r.Planet, data)
+	if err != nil {
+		return err
+	}
+
+	uni, err := NewUniverseFromDB(r.Universe, data)
+	if err != nil {
+		return err
+	}
+
+	// Make sure that the target is free: this includes
+	// the slots reserved by other relocations.
+	r.Target.Type = World
+	if !r.Target.valid(uni.GalaxiesCount, uni.GalaxySize, uni.SolarSystemSize) {
+		return ErrInvalidCoordinates
+	}
+
+	used, err := uni.UsedCoords(data.Proxy)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := used[r.Target.Linearize(uni.GalaxySize, uni.SolarSystemSize)]; ok {
+		return ErrRelocationTargetUsed
+	}
+
+	// Generate the temperature at the target using the
+	// same rules as for a new planet.
+	target := Planet{
+		Coordinates: r.Target,
+	}
+	target.generateProperties()
+
+	r.MinTemp = target.MinTemp
+	r.MaxTemp = target.MaxTemp
+
+	// Compute the cost and make sure the planet is able
+	// to pay for it.
+	r.Cost = make([]model.ResourceAmount, 0)
+
+	for name, amount := range data.RelocationCost {
+		res, err := data.Resources.GetIDFromName(name)
+		if err != nil {
+			return err
+		}
+
+		desc, ok := p.Resources[res]
+		if !ok || desc.Amount < float32(amount) {
+			return ErrNotEnoughResources
+		}
+
+		r.Cost = append(
+			r.Cost,
+			model.ResourceAmount{
+				Resource: res,
+				Amount:   float32(amount),
+			},
+		)
+	}
+
+	r.CompletionTime = time.Now().Add(relocationDuration)
+
+	return nil
+}
+
+// isRelocatable :
+// Used to make sure that this planet and its moon if
+// any do not have any action or fleet in progress.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (p *Planet) isRelocatable(data Instance) error {
+	if p.isIdle() != nil {
+		return ErrPlanetNotIdle
+	}
+
+	query := db.QueryDesc{
+		Props: []string{
+			"id",
+		},
+		Table: "moons",
+		Filters: []db.Filter{
+			{
+				Key:    "planet",
+				Values: []interface{}{p.ID},
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return dbRes.Err
+	}
+
+	// The planet might not have a moon.
+	if !dbRes.Next() {
+		return nil
+	}
+
+	var ID string
+
+	err = dbRes.Scan(&ID)
+	if err != nil {
+		return err
+	}
+
+	m, err := NewMoonFromDB(ID, data)
+	if err != nil {
+		return err
+	}
+
+	if m.isIdle() != nil {
+		return ErrPlanetNotIdle
+	}
+
+	return nil
+}
+
+// SaveToDB :
+// Used to reserve the target slot of the relocation
+// and to register the countdown after which the move
+// will happen. The cost is taken from the planet.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func (r *Relocation) SaveToDB(proxy db.Proxy) error {
+	query := db.InsertReq{
+		Script: "create_planet_relocation",
+		Args: []interface{}{
+			r,
+			r.Cost,
+		},
+		SkipReturn: true,
+	}
+
+	err := proxy.InsertToDB(query)
+
+	// Analyze the error in order to provide some
+	// comprehensive message.
+	dbe, ok := err.(db.Error)
+	if !ok {
+		return err
+	}
+
+	dee, ok := dbe.Err.(db.DuplicatedElementError)
+	if ok {
+		switch dee.Constraint {
+		case "planets_relocations_pkey":
+			return ErrDuplicatedElement
+		case "planets_relocations_planet_key":
+			return ErrPlanetRelocating
+		case "planets_relocations_universe_galaxy_solar_system_position_key":
+			return ErrRelocationTargetUsed
+		}
+
+		return dee
+	}
+
+	return dbe
+}
+
+// CancelRelocation :
+// Used to cancel the relocation described by the input
+// identifier. The target slot is released and the cost
+// is given back to the planet.
+//
+// The `ID` defines the identifier of the relocation.
+//
+// The `reason` defines why the relocation is cancelled.
+// The owner of the planet is notified in case it is not
+// empty.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func CancelRelocation(ID string, reason string, data Instance) error {
+	// Make sure that the relocation exists.
+	_, err := NewRelocationFromDB(ID, data)
+	if err != nil {
+		return err
+	}
+
+	query := db.InsertReq{
+		Script: "cancel_planet_relocation",
+		Args: []interface{}{
+			ID,
+			reason,
+		},
+		SkipReturn: true,
+	}
+
+	return data.Proxy.InsertToDB(query)
+}
+
+// performRelocationAction :
+// Used to move the planet described by the relocation
+// now that the countdown is over. In case the planet
+// or its moon are not idle anymore (typically because
+// of a fleet targeting them) the relocation is simply
+// cancelled and the owner is notified.
+//
+// The `ID` defines the identifier of the relocation.
+//
+// Returns any error.
+func (i Instance) performRelocationAction(ID string) error {
+	i.trace(logger.Verbose, fmt.Sprintf("Executing relocation %s", ID))
+
+	r, err := NewRelocationFromDB(ID, i)
+	if err != nil {
+		return err
+	}
+
+	p, err := NewPlanetFromDB(r.Planet, i)
+	if err != nil {
+		return err
+	}
+
+	err = p.isRelocatable(i)
+	if err != nil {
+		i.trace(logger.Warning, fmt.Sprintf("Cancelling relocation \"%s\" (err: %v)", r.ID, err))
+		return CancelRelocation(r.ID, "fleets or actions are still in progress", i)
+	}
+
+	query := db.InsertReq{
+		Script: "perform_planet_relocation",
+		Args: []interface{}{
+			r.ID,
+		},
+		SkipReturn: true,
+	}
+
+	return i.Proxy.InsertToDB(query)
+}
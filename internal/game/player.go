@@ -56,6 +56,35 @@ type Player struct {
 	// The `Points` defines the accumulated score of the
 	// player.
 	Score Points `json:"score"`
+
+	// The `VacationMode` defines whether the player is
+	// currently in vacation mode. In this case nothing
+	// is produced on its planets and they can't be the
+	// target of hostile fleets. The player is also not
+	// able to register actions or fleets.
+	VacationMode bool `json:"vacation_mode"`
+
+	// The `Inactive` defines whether the player did not
+	// show any activity on its planets for at least a
+	// week.
+	Inactive bool `json:"inactive"`
+
+	// The `LongInactive` defines whether the player did
+	// not show any activity on its planets for at least
+	// four weeks.
+	LongInactive bool `json:"long_inactive"`
+
+	// The `Banned` defines whether the player has been
+	// banned from the universe by an administrator. A
+	// banned player is not able to register actions or
+	// fleets anymore.
+	Banned bool `json:"banned"`
+
+	// The `Galaxy` defines the galaxy picked by the player
+	// when it is created. It is only used when the homeworld
+	// placement strategy of the universe lets the player
+	// choose its galaxy.
+	Galaxy *int `json:"galaxy,omitempty"`
 }
 
 // Points :
@@ -124,6 +153,10 @@ var ErrNonExistingUniverse = fmt.Errorf("inexisting parent universe")
 // planets of a player.
 var ErrInconsistentPlanetFound = fmt.Errorf("inconsistencies found for planets of a player")
 
+// ErrFleetsInFlight : Indicates that the vacation mode of a player can't be changed
+// as some of its fleets are still in flight.
+var ErrFleetsInFlight = fmt.Errorf("cannot change vacation mode while fleets are in flight")
+
 // valid :
 // Determines whether the player is valid. By valid we only mean
 // obvious syntax errors.
@@ -217,6 +250,10 @@ func (p *Player) fetchGeneralInfo(data Instance) error {
 			"pl.military_points_built",
 			"pl.military_points_lost",
 			"pl.military_points_destroyed",
+			"p.vacation_mode",
+			"p.inactive",
+			"p.long_inactive",
+			"p.banned",
 		},
 		Table: "players p inner join players_points pl on p.id = pl.player",
 		Filters: []db.Filter{
@@ -256,6 +293,10 @@ func (p *Player) fetchGeneralInfo(data Instance) error {
 		&p.Score.MilitaryBuilt,
 		&p.Score.MilitaryLost,
 		&p.Score.MilitaryDestroyed,
+		&p.VacationMode,
+		&p.Inactive,
+		&p.LongInactive,
+		&p.Banned,
 	)
 
 	// Make sure that it's the only player.
@@ -268,7 +309,9 @@ func (p *Player) fetchGeneralInfo(data Instance) error {
 
 // fetchFleetsCount :
 // Simialr to the `fetchGeneralInfo` but handles
-// the retrieval of the player's fleets count.
+// the retrieval of the player's fleets count. The
+// fleets waiting for their departure are counted
+// as they already use a slot.
 //
 // The `data` defines the object to access the
 // DB.
@@ -284,7 +327,7 @@ func (p *Player) fetchFleetsCount(data Instance) error {
 			Props: []string{
 				"count(*)",
 			},
-			Table: "fleets",
+			Table: "(select player from fleets union all select player from fleets_scheduled) f",
 			Filters: []db.Filter{
 				{
 					Key:    "player",
@@ -335,7 +378,7 @@ func (p *Player) fetchFleetsCount(data Instance) error {
 		Props: []string{
 			"count(*)",
 		},
-		Table: "fleets f inner join fleets_objectives fo on f.objective = fo.id",
+		Table: "(select player, objective from fleets union all select player, objective from fleets_scheduled) f inner join fleets_objectives fo on f.objective = fo.id",
 		Filters: []db.Filter{
 			{
 				Key:    "f.player",
@@ -505,6 +548,27 @@ func (p *Player) fetchPlanets(data Instance) error {
 	return nil
 }
 
+// TotalPoints :
+// Returns the total number of points of this player
+// which is the sum of the economy, research and the
+// military points. It is consistent with the value
+// used to rank players.
+//
+// Returns the total points.
+func (p *Player) TotalPoints() float32 {
+	return p.Score.Economy + p.Score.Research + p.Score.Military
+}
+
+// CanChangeVacationMode :
+// Used to determine whether the vacation mode of this
+// player can be toggled. This is only possible when
+// no fleets of the player are in flight.
+//
+// Returns `true` if the vacation mode can be changed.
+func (p *Player) CanChangeVacationMode() bool {
+	return p.fleetsCount == 0
+}
+
 // GetTechnology :
 // Retrieves the technology from the input identifier.
 //
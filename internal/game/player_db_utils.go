@@ -56,9 +56,11 @@ func (p *Player) UpdateInDB(proxy db.Proxy) error {
 		Args: []interface{}{
 			p.ID,
 			struct {
-				Name string `json:"name"`
+				Name         string `json:"name"`
+				VacationMode bool   `json:"vacation_mode"`
 			}{
-				Name: p.Name,
+				Name:         p.Name,
+				VacationMode: p.VacationMode,
 			},
 		},
 	}
@@ -155,6 +157,40 @@ func (p *Player) analyzeDBError(err error) error {
 	return dbe
 }
 
+// playerTechnologyOutput :
+// Describes the JSON representation of a technology of
+// a player as it is sent to clients.
+type playerTechnologyOutput struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Level int    `json:"level"`
+}
+
+// playerOutput :
+// Describes the JSON representation of a player as it
+// is sent to clients.
+type playerOutput struct {
+	ID           string                   `json:"id"`
+	Account      string                   `json:"account"`
+	Universe     string                   `json:"universe"`
+	Name         string                   `json:"name"`
+	Technologies []playerTechnologyOutput `json:"technologies"`
+	Planets      []string                 `json:"planets"`
+	Score        Points                   `json:"score"`
+	VacationMode bool                     `json:"vacation_mode"`
+	Inactive     bool                     `json:"inactive"`
+	LongInactive bool                     `json:"long_inactive"`
+	Banned       bool                     `json:"banned"`
+}
+
+// JSONModel :
+// Returns a value having the same JSON representation
+// as the player. It is used to describe the player in
+// the specification of the API.
+func (p *Player) JSONModel() interface{} {
+	return playerOutput{}
+}
+
 // MarshalJSON :
 // Implementation of the `Marshaler` interface to allow
 // only specific information to be marshalled when the
@@ -166,36 +202,25 @@ func (p *Player) analyzeDBError(err error) error {
 // Returns the marshalled bytes for this player along
 // with any error.
 func (p *Player) MarshalJSON() ([]byte, error) {
-	type lightInfo struct {
-		ID    string `json:"id"`
-		Name  string `json:"name"`
-		Level int    `json:"level"`
-	}
-
-	type lightPlayer struct {
-		ID           string      `json:"id"`
-		Account      string      `json:"account"`
-		Universe     string      `json:"universe"`
-		Name         string      `json:"name"`
-		Technologies []lightInfo `json:"technologies"`
-		Planets      []string    `json:"planets"`
-		Score        Points      `json:"score"`
-	}
-
 	// Copy the planet's data.
-	lp := lightPlayer{
+	lp := playerOutput{
 		ID:       p.ID,
 		Account:  p.Account,
 		Universe: p.Universe,
 		Name:     p.Name,
 		Planets:  p.Planets,
 		Score:    p.Score,
+
+		VacationMode: p.VacationMode,
+		Inactive:     p.Inactive,
+		LongInactive: p.LongInactive,
+		Banned:       p.Banned,
 	}
 
 	// Make shallow copy of the buildings, ships and
 	// defenses without including the tech deps.
 	for _, t := range p.Technologies {
-		lt := lightInfo{
+		lt := playerTechnologyOutput{
 			ID:    t.ID,
 			Name:  t.Name,
 			Level: t.Level,
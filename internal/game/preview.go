@@ -0,0 +1,168 @@
+package game
+
+import (
+	"oglike_server/internal/model"
+	"time"
+)
+
+// ActionPreview :
+// Describes the outcome of the validation of an action
+// when it is not meant to be registered in the DB. It
+// allows a client to know in advance how much a given
+// action would cost and when it would be completed or
+// why it would be refused.
+//
+// The `Planet` defines the planet where the action is
+// to be performed.
+//
+// The `Element` defines the identifier of the element
+// concerned by the action.
+//
+// The `Costs` defines the resources needed to perform
+// the action as computed by the validation process.
+//
+// The `CreatedAt` defines the time at which the action
+// would start: it might be in the future in case some
+// other actions are already queued on the planet.
+//
+// The `CompletionTime` defines the time at which the
+// action would be completed.
+//
+// The `Valid` defines whether the action could have
+// been registered.
+//
+// The `Error` describes the reason why the action is
+// not valid. It is empty if `Valid` is `true`.
+type ActionPreview struct {
+	Planet         string    `json:"planet"`
+	Element        string    `json:"element"`
+	Costs          []Cost    `json:"costs"`
+	CreatedAt      time.Time `json:"created_at"`
+	CompletionTime time.Time `json:"completion_time"`
+	Valid          bool      `json:"valid"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// FleetPreview :
+// Similar to the `ActionPreview` but describes the
+// outcome of the validation of a fleet. It contains
+// the flight times and the fuel consumption needed
+// to launch the fleet.
+//
+// The `Source` defines the planet or moon from where
+// the fleet would be launched.
+//
+// The `Objective` defines the objective of the fleet.
+//
+// The `CreatedAt` defines the launch time.
+//
+// The `ArrivalTime` defines the time at which the fleet
+// would reach its destination.
+//
+// The `ReturnTime` defines the time at which the fleet
+// would be back to its source.
+//
+// The `Consumption` defines the fuel needed to launch
+// the fleet.
+//
+// The `Valid` defines whether the fleet could have
+// been created.
+//
+// The `Error` describes the reason why the fleet is not
+// valid. It is empty if `Valid` is `true`.
+type FleetPreview struct {
+	Source      string                 `json:"source"`
+	Objective   string                 `json:"objective"`
+	CreatedAt   time.Time              `json:"created_at"`
+	ArrivalTime time.Time              `json:"arrival_time"`
+	ReturnTime  time.Time              `json:"return_time"`
+	Consumption []model.ResourceAmount `json:"consumption"`
+	Valid       bool                   `json:"valid"`
+	Error       string                 `json:"error,omitempty"`
+}
+
+// newActionPreview :
+// Used to create a preview from the base properties
+// of the input action.
+//
+// The `a` defines the action to preview.
+//
+// The `completion` defines the completion time of the
+// action as computed by the specialized action.
+//
+// The `err` defines the result of the validation of
+// the action.
+//
+// Returns the created preview.
+func newActionPreview(a action, completion time.Time, err error) ActionPreview {
+	ap := ActionPreview{
+		Planet:         a.Planet,
+		Element:        a.Element,
+		Costs:          a.Costs,
+		CreatedAt:      a.creationTime,
+		CompletionTime: completion,
+		Valid:          err == nil,
+	}
+
+	if ap.Costs == nil {
+		ap.Costs = make([]Cost, 0)
+	}
+	if err != nil {
+		ap.Error = err.Error()
+	}
+
+	return ap
+}
+
+// Preview :
+// Used to generate a preview of this action from the
+// result of its validation.
+//
+// The `err` defines the result of the validation.
+//
+// Returns the corresponding preview.
+func (a *ProgressAction) Preview(err error) ActionPreview {
+	return newActionPreview(a.action, a.CompletionTime, err)
+}
+
+// Preview :
+// Used to generate a preview of this action from the
+// result of its validation. The completion time is
+// computed from the time needed to build each unit.
+//
+// The `err` defines the result of the validation.
+//
+// Returns the corresponding preview.
+func (a *FixedAction) Preview(err error) ActionPreview {
+	completion := a.creationTime.Add(time.Duration(a.Remaining) * a.CompletionTime.Duration)
+
+	return newActionPreview(a.action, completion, err)
+}
+
+// Preview :
+// Used to generate a preview of this fleet from the
+// result of its validation.
+//
+// The `err` defines the result of the validation.
+//
+// Returns the corresponding preview.
+func (f *Fleet) Preview(err error) FleetPreview {
+	fp := FleetPreview{
+		Source:      f.Source,
+		Objective:   f.Objective,
+		CreatedAt:   f.CreatedAt,
+		ArrivalTime: f.ArrivalTime,
+		ReturnTime:  f.ReturnTime,
+		Consumption: f.Consumption,
+		Valid:       err == nil,
+	}
+
+	if fp.Consumption == nil {
+		fp.Consumption = make([]model.ResourceAmount, 0)
+	}
+	if err != nil {
+		fp.Error = err.Error()
+	}
+
+	return fp
+}
@@ -0,0 +1,785 @@
+package game
+
+import (
+	"database/sql"
+	"fmt"
+	"oglike_server/pkg/db"
+	"time"
+)
+
+// Ranking :
+// Defines the needed information to represent the rank of
+// a player within a universe as registered in a snapshot of
+// the rankings.
+type Ranking struct {
+	// Player defines the identifier of the player associated to
+	// the ranking.
+	Player string `json:"player"`
+
+	// Rank defines the ranking of this player in the universe
+	// for the requested category. The best player has a rank
+	// of `1`.
+	Rank int `json:"rank"`
+
+	// Delta defines the evolution of the rank of the player in
+	// the requested category since the previous snapshot. A
+	// positive value indicates that the player climbed in the
+	// rankings.
+	Delta int `json:"delta"`
+
+	// Ranks defines the rank of this player in each category.
+	Ranks map[string]int `json:"ranks"`
+
+	// Points defines the total number of points of the player.
+	Points float32 `json:"points"`
+
+	// Economy defines how many economy points this player has.
+	Economy float32 `json:"economy"`
+
+	// Research defines how many research points this player has.
+	Research float32 `json:"research"`
+
+	// Military defines how many military points this player has.
+	Military float32 `json:"military"`
+
+	// MilitaryBuilt defines how many military points this player
+	// has built.
+	MilitaryBuilt float32 `json:"military_built"`
+
+	// MilitaryLost defines how many military points this player
+	// has lost.
+	MilitaryLost float32 `json:"military_lost"`
+
+	// MilitaryDestroyed defines how many military points this
+	// player has destroyed.
+	MilitaryDestroyed float32 `json:"military_destroyed"`
+
+	// VacationMode defines whether the player is currently in
+	// vacation mode.
+	VacationMode bool `json:"vacation_mode"`
+
+	// Inactive defines whether the player has been inactive for
+	// at least a week.
+	Inactive bool `json:"inactive"`
+
+	// LongInactive defines whether the player has been inactive
+	// for at least four weeks.
+	LongInactive bool `json:"long_inactive"`
+}
+
+// RankingOptions :
+// Defines the options that can be used to select a page
+// of the rankings of a universe.
+//
+// The `Category` defines the category used to sort the
+// players. If it is empty the total points are used.
+//
+// The `At` defines the time at which the rankings should
+// be fetched: the most recent snapshot before this time
+// is used.
+//
+// The `Page` defines the index of the page to fetch. The
+// first page has an index of `0`.
+//
+// The `Count` defines the number of players in a page.
+type RankingOptions struct {
+	Category string
+	At       time.Time
+	Page     int
+	Count    int
+}
+
+// RankingPage :
+// Defines a page of the rankings of a universe as they
+// were registered in a given snapshot.
+//
+// The `Universe` defines the universe of the rankings.
+//
+// The `Category` defines the category used to sort the
+// players.
+//
+// The `Snapshot` defines the time at which the rankings
+// were computed.
+//
+// The `Page` defines the index of this page.
+//
+// The `Count` defines the maximum number of players in
+// a page.
+//
+// The `Total` defines the total number of players in
+// the snapshot.
+//
+// The `Rankings` defines the rankings of the players in
+// this page.
+type RankingPage struct {
+	Universe string    `json:"universe"`
+	Category string    `json:"category"`
+	Snapshot time.Time `json:"snapshot"`
+	Page     int       `json:"page"`
+	Count    int       `json:"count"`
+	Total    int       `json:"total"`
+	Rankings []Ranking `json:"rankings"`
+}
+
+// RankingHistoryEntry :
+// Defines the ranking of a player at a given point in
+// time. It is used to track the progress of a player.
+//
+// The `CreatedAt` defines the time of the snapshot.
+type RankingHistoryEntry struct {
+	CreatedAt time.Time `json:"created_at"`
+	Ranking
+}
+
+// DefaultRankingCategory : The category used when none is provided.
+var DefaultRankingCategory = "points"
+
+// DefaultRankingPageSize : The number of players in a page by default.
+var DefaultRankingPageSize = 50
+
+// MaxRankingPageSize : The maximum number of players in a page.
+var MaxRankingPageSize = 200
+
+// rankingCategories :
+// Defines the categories available to sort players in
+// the rankings along with the column holding the rank
+// of a player in the snapshots.
+var rankingCategories = map[string]string{
+	"points":             "points_rank",
+	"economy":            "economy_rank",
+	"research":           "research_rank",
+	"military":           "military_rank",
+	"military_built":     "military_built_rank",
+	"military_lost":      "military_lost_rank",
+	"military_destroyed": "military_destroyed_rank",
+}
+
+// ErrInvalidRankingCategory : Indicates that the category of rankings does not exist.
+var ErrInvalidRankingCategory = fmt.Errorf("invalid ranking category")
+
+// ErrInvalidRankingPage : Indicates that the page of rankings is not valid.
+var ErrInvalidRankingPage = fmt.Errorf("invalid ranking page")
+
+// ValidRankingCategory :
+// Used to determine whether the input category can be
+// used to sort the rankings.
+//
+// The `category` defines the name of the category.
+//
+// Returns `true` if the category exists.
+func ValidRankingCategory(category string) bool {
+	_, ok := rankingCategories[category]
+	return ok
+}
+
+// CreateRankingSnapshots :
+// Used to register a snapshot of the rankings for all
+// the universes at the specified time.
+//
+// The `moment` defines the time of the snapshot.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func CreateRankingSnapshots(moment time.Time, proxy db.Proxy) error {
+	query := db.InsertReq{
+		Script: "create_rankings_snapshots",
+		Args: []interface{}{
+			moment.Truncate(time.Second),
+		},
+	}
+
+	return proxy.InsertToDB(query)
+}
+
+// snapshotsCutoff :
+// Used to compute the date before which the snapshots of
+// the rankings should be removed given the retention. As
+// the snapshots are registered with a precision of one
+// second the cutoff is truncated in the same way.
+//
+// The `moment` defines the current time.
+//
+// The `retention` defines the duration during which the
+// snapshots are kept. A value of `0` or below disables
+// the removal of snapshots.
+//
+// Returns the cutoff date along with `false` in case no
+// snapshots should be removed.
+func snapshotsCutoff(moment time.Time, retention time.Duration) (time.Time, bool) {
+	if retention <= 0 {
+		return time.Time{}, false
+	}
+
+	return moment.Add(-retention).Truncate(time.Second), true
+}
+
+// DeleteRankingSnapshots :
+// Used to remove the snapshots of the rankings that are
+// older than the retention from the DB. The most recent
+// snapshot of each universe is always kept.
+//
+// The `moment` defines the current time.
+//
+// The `retention` defines the duration during which the
+// snapshots are kept. A value of `0` or below keeps the
+// snapshots forever.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func DeleteRankingSnapshots(moment time.Time, retention time.Duration, proxy db.Proxy) error {
+	cutoff, ok := snapshotsCutoff(moment, retention)
+	if !ok {
+		return nil
+	}
+
+	query := db.InsertReq{
+		Script: "delete_rankings_snapshots",
+		Args: []interface{}{
+			cutoff.Format(time.RFC3339),
+		},
+		SkipReturn: true,
+	}
+
+	return proxy.InsertToDB(query)
+}
+
+// NewRankingPageFromDB :
+// Used to fetch a page of the rankings from the most
+// recent snapshot matching the input filters and the
+// requested options. The delta of each player is the
+// evolution of its rank since the previous snapshot.
+//
+// The `filters` define the filters to apply to select
+// the snapshot. They typically target the universe.
+//
+// The `opts` define the category, time and page of the
+// rankings to fetch.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the page of rankings along with any error.
+func NewRankingPageFromDB(filters []db.Filter, opts RankingOptions, data Instance) (RankingPage, error) {
+	rp := RankingPage{
+		Category: opts.Category,
+		Page:     opts.Page,
+		Count:    opts.Count,
+		Rankings: make([]Ranking, 0),
+	}
+
+	if rp.Category == "" {
+		rp.Category = DefaultRankingCategory
+	}
+	if rp.Count == 0 {
+		rp.Count = DefaultRankingPageSize
+	}
+
+	rank, ok := rankingCategories[rp.Category]
+	if !ok {
+		return rp, ErrInvalidRankingCategory
+	}
+	if rp.Page < 0 || rp.Count < 0 || rp.Count > MaxRankingPageSize {
+		return rp, ErrInvalidRankingPage
+	}
+
+	at := opts.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	// Fetch the snapshot to use and the previous one to
+	// compute the evolution of ranks. Note that as the
+	// time filter is strict we add a second to include
+	// the snapshots created at exactly the input time.
+	// The input filters are copied so that the slice of
+	// the caller is not modified.
+	snapshotFilters := make([]db.Filter, 0, len(filters)+1)
+	snapshotFilters = append(snapshotFilters, filters...)
+	snapshotFilters = append(
+		snapshotFilters,
+		db.Filter{
+			Key:      "created_at",
+			Values:   []interface{}{at.Add(time.Second)},
+			Operator: db.LessThan,
+		},
+	)
+
+	snapshot, found, err := fetchRankingSnapshot(snapshotFilters, data)
+	if err != nil {
+		return rp, err
+	}
+	if !found && opts.At.IsZero() {
+		// No snapshot was registered yet (typically for
+		// a new universe): compute the rankings from the
+		// current points of the players.
+		return newLiveRankingPage(filters, rp, rank, data)
+	}
+	if !found {
+		return rp, ErrElementNotFound
+	}
+
+	rp.Universe = snapshot.universe
+	rp.Snapshot = snapshot.createdAt
+
+	previousFilters := []db.Filter{
+		{
+			Key:    "universe",
+			Values: []interface{}{snapshot.universe},
+		},
+		{
+			Key:      "created_at",
+			Values:   []interface{}{snapshot.createdAt},
+			Operator: db.LessThan,
+		},
+	}
+
+	previous, hasPrevious, err := fetchRankingSnapshot(previousFilters, data)
+	if err != nil {
+		return rp, err
+	}
+
+	rp.Total, err = countRankings(snapshot.id, data)
+	if err != nil {
+		return rp, err
+	}
+
+	// Fetch the rankings of the page.
+	table := "rankings_snapshots_players rsp left join players p on rsp.player = p.id"
+	previousRank := "null::integer"
+
+	if hasPrevious {
+		table += fmt.Sprintf(" left join rankings_snapshots_players prev on prev.player = rsp.player and prev.snapshot = '%s'", previous.id)
+		previousRank = "prev." + rank
+	}
+
+	query := db.QueryDesc{
+		Props: append(rankingProps(), previousRank),
+		Table: table,
+		Filters: []db.Filter{
+			{
+				Key:    "rsp.snapshot",
+				Values: []interface{}{snapshot.id},
+			},
+		},
+		Ordering: fmt.Sprintf("order by rsp.%s, rsp.player limit %d offset %d", rank, rp.Count, rp.Page*rp.Count),
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return rp, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return rp, dbRes.Err
+	}
+
+	for dbRes.Next() {
+		var prev sql.NullInt64
+
+		r, err := scanRanking(dbRes, rp.Category, &prev)
+		if err != nil {
+			return rp, err
+		}
+
+		if prev.Valid {
+			r.Delta = int(prev.Int64) - r.Rank
+		}
+
+		rp.Rankings = append(rp.Rankings, r)
+	}
+
+	return rp, nil
+}
+
+// liveRankings :
+// Describes the rankings computed from the current points
+// of the players. The columns are the same as the ones of
+// the `rankings_snapshots_players` table so that they can
+// be fetched in the same way.
+const liveRankings = "(select" +
+	" pp.player," +
+	" p.universe," +
+	" pp.economy_points," +
+	" pp.research_points," +
+	" pp.military_points," +
+	" pp.military_points_built," +
+	" pp.military_points_lost," +
+	" pp.military_points_destroyed," +
+	" pp.economy_points + pp.research_points + pp.military_points as points," +
+	" rank() over (partition by p.universe order by pp.economy_points desc) as economy_rank," +
+	" rank() over (partition by p.universe order by pp.research_points desc) as research_rank," +
+	" rank() over (partition by p.universe order by pp.military_points desc) as military_rank," +
+	" rank() over (partition by p.universe order by pp.military_points_built desc) as military_built_rank," +
+	" rank() over (partition by p.universe order by pp.military_points_lost desc) as military_lost_rank," +
+	" rank() over (partition by p.universe order by pp.military_points_destroyed desc) as military_destroyed_rank," +
+	" rank() over (partition by p.universe order by pp.economy_points + pp.research_points + pp.military_points desc) as points_rank" +
+	" from players_points pp inner join players p on pp.player = p.id) rsp"
+
+// newLiveRankingPage :
+// Used to fetch a page of the rankings computed from the
+// current points of the players rather than a snapshot.
+// This is used until a first snapshot is registered for
+// the universe. The delta of the players is always `0`
+// and the time of the snapshot is the current time. The
+// universe is expected to be provided in the filters.
+//
+// The `filters` define the filters to apply to select
+// the players. They typically target the universe.
+//
+// The `rp` defines the page to fill.
+//
+// The `rank` defines the column holding the rank of the
+// players in the requested category.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the page of rankings along with any error.
+func newLiveRankingPage(filters []db.Filter, rp RankingPage, rank string, data Instance) (RankingPage, error) {
+	rp.Snapshot = time.Now().Truncate(time.Second)
+
+	// The filters target the columns of the rankings and
+	// not the ones of the players joined to them.
+	liveFilters := make([]db.Filter, 0, len(filters))
+	for _, f := range filters {
+		if f.Key == "universe" && len(f.Values) == 1 {
+			rp.Universe = fmt.Sprintf("%v", f.Values[0])
+		}
+
+		f.Key = "rsp." + f.Key
+		liveFilters = append(liveFilters, f)
+	}
+
+	query := db.QueryDesc{
+		Props: []string{
+			"count(*)",
+		},
+		Table:   liveRankings,
+		Filters: liveFilters,
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return rp, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return rp, dbRes.Err
+	}
+
+	if dbRes.Next() {
+		err = dbRes.Scan(&rp.Total)
+		if err != nil {
+			return rp, err
+		}
+	}
+
+	// Unknown universes don't have any player: make sure
+	// that the universe exists in this case.
+	if rp.Total == 0 {
+		if _, err := NewUniverseFromDB(rp.Universe, data); err != nil {
+			return rp, err
+		}
+	}
+
+	// Fetch the rankings of the page.
+	query = db.QueryDesc{
+		Props:    append(rankingProps(), "null::integer"),
+		Table:    liveRankings + " left join players p on rsp.player = p.id",
+		Filters:  liveFilters,
+		Ordering: fmt.Sprintf("order by rsp.%s, rsp.player limit %d offset %d", rank, rp.Count, rp.Page*rp.Count),
+	}
+
+	pageRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return rp, err
+	}
+	defer pageRes.Close()
+
+	if pageRes.Err != nil {
+		return rp, pageRes.Err
+	}
+
+	for pageRes.Next() {
+		var prev sql.NullInt64
+
+		r, err := scanRanking(pageRes, rp.Category, &prev)
+		if err != nil {
+			return rp, err
+		}
+
+		rp.Rankings = append(rp.Rankings, r)
+	}
+
+	return rp, nil
+}
+
+// NewRankingHistoryFromDB :
+// Used to fetch the evolution of the rankings of the
+// players matching the input filters. The entries are
+// sorted by ascending creation time and the delta is
+// computed from the previous entry of the history.
+//
+// The `filters` define the filters to apply to select
+// the entries. They typically target a player.
+//
+// The `category` defines the category used to compute
+// the rank and delta of each entry.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the history along with any error.
+func NewRankingHistoryFromDB(filters []db.Filter, category string, data Instance) ([]RankingHistoryEntry, error) {
+	history := make([]RankingHistoryEntry, 0)
+
+	if category == "" {
+		category = DefaultRankingCategory
+	}
+	if !ValidRankingCategory(category) {
+		return history, ErrInvalidRankingCategory
+	}
+
+	query := db.QueryDesc{
+		Props:    append([]string{"rs.created_at"}, append(rankingProps(), "null::integer")...),
+		Table:    "rankings_snapshots_players rsp inner join rankings_snapshots rs on rsp.snapshot = rs.id left join players p on rsp.player = p.id",
+		Filters:  filters,
+		Ordering: "order by rsp.player, rs.created_at",
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return history, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return history, dbRes.Err
+	}
+
+	var entry RankingHistoryEntry
+	var prev sql.NullInt64
+
+	for dbRes.Next() {
+		previous := entry
+		hasPrevious := len(history) > 0
+
+		entry = RankingHistoryEntry{}
+
+		r, err := scanRanking(dbRes, category, &prev, &entry.CreatedAt)
+		if err != nil {
+			return history, err
+		}
+
+		entry.Ranking = r
+		if hasPrevious && previous.Player == r.Player {
+			entry.Delta = previous.Rank - r.Rank
+		}
+
+		history = append(history, entry)
+	}
+
+	return history, nil
+}
+
+// rankingSnapshot :
+// Convenience structure describing a snapshot of the
+// rankings of a universe.
+type rankingSnapshot struct {
+	id        string
+	universe  string
+	createdAt time.Time
+}
+
+// fetchRankingSnapshot :
+// Used to fetch the most recent snapshot matching the
+// input filters.
+//
+// The `filters` define the filters to select snapshots.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the snapshot, whether it was found and any
+// error.
+func fetchRankingSnapshot(filters []db.Filter, data Instance) (rankingSnapshot, bool, error) {
+	var rs rankingSnapshot
+
+	query := db.QueryDesc{
+		Props: []string{
+			"id",
+			"universe",
+			"created_at",
+		},
+		Table:    "rankings_snapshots",
+		Filters:  filters,
+		Ordering: "order by created_at desc limit 1",
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return rs, false, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return rs, false, dbRes.Err
+	}
+
+	if !dbRes.Next() {
+		return rs, false, nil
+	}
+
+	err = dbRes.Scan(
+		&rs.id,
+		&rs.universe,
+		&rs.createdAt,
+	)
+
+	return rs, err == nil, err
+}
+
+// countRankings :
+// Used to count the number of players registered in
+// the input snapshot.
+//
+// The `snapshot` defines the identifier of the snapshot.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the number of players along with any error.
+func countRankings(snapshot string, data Instance) (int, error) {
+	query := db.QueryDesc{
+		Props: []string{
+			"count(*)",
+		},
+		Table: "rankings_snapshots_players",
+		Filters: []db.Filter{
+			{
+				Key:    "snapshot",
+				Values: []interface{}{snapshot},
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return 0, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return 0, dbRes.Err
+	}
+
+	var count int
+
+	if dbRes.Next() {
+		err = dbRes.Scan(&count)
+	}
+
+	return count, err
+}
+
+// rankingProps :
+// Returns the list of properties to select to build a
+// ranking from the snapshots table.
+func rankingProps() []string {
+	return []string{
+		"rsp.player",
+		"rsp.points",
+		"rsp.economy_points",
+		"rsp.research_points",
+		"rsp.military_points",
+		"rsp.military_points_built",
+		"rsp.military_points_lost",
+		"rsp.military_points_destroyed",
+		"rsp.points_rank",
+		"rsp.economy_rank",
+		"rsp.research_rank",
+		"rsp.military_rank",
+		"rsp.military_built_rank",
+		"rsp.military_lost_rank",
+		"rsp.military_destroyed_rank",
+		"coalesce(p.vacation_mode, false)",
+		"coalesce(p.inactive, false)",
+		"coalesce(p.long_inactive, false)",
+	}
+}
+
+// scanRanking :
+// Used to scan a ranking from the current row of the
+// input result. The row is expected to contain the
+// props defined by `rankingProps` followed by the
+// previous rank of the player.
+//
+// The `dbRes` defines the result to scan.
+//
+// The `category` defines the category used to set the
+// rank of the player.
+//
+// The `prev` defines where to scan the previous rank.
+//
+// The `before` defines additional values to scan that
+// are placed before the ranking props in the row.
+//
+// Returns the scanned ranking along with any error.
+func scanRanking(dbRes db.QueryResult, category string, prev *sql.NullInt64, before ...interface{}) (Ranking, error) {
+	r := Ranking{
+		Ranks: make(map[string]int),
+	}
+
+	var points, economy, research, military, built, lost, destroyed int
+
+	dest := append(
+		before,
+		&r.Player,
+		&r.Points,
+		&r.Economy,
+		&r.Research,
+		&r.Military,
+		&r.MilitaryBuilt,
+		&r.MilitaryLost,
+		&r.MilitaryDestroyed,
+		&points,
+		&economy,
+		&research,
+		&military,
+		&built,
+		&lost,
+		&destroyed,
+		&r.VacationMode,
+		&r.Inactive,
+		&r.LongInactive,
+		prev,
+	)
+
+	err := dbRes.Scan(dest...)
+	if err != nil {
+		return r, err
+	}
+
+	r.Ranks["points"] = points
+	r.Ranks["economy"] = economy
+	r.Ranks["research"] = research
+	r.Ranks["military"] = military
+	r.Ranks["military_built"] = built
+	r.Ranks["military_lost"] = lost
+	r.Ranks["military_destroyed"] = destroyed
+
+	r.Rank = r.Ranks[category]
+
+	return r, nil
+}
@@ -0,0 +1,51 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRankingSnapshotsRetention(t *testing.T) {
+	moment := time.Date(2021, time.March, 10, 12, 30, 45, 500000000, time.UTC)
+
+	cases := []struct {
+		name      string
+		retention time.Duration
+		expected  time.Time
+		purge     bool
+	}{
+		{
+			name:      "snapshots older than the retention are removed",
+			retention: 30 * 24 * time.Hour,
+			expected:  time.Date(2021, time.February, 8, 12, 30, 45, 0, time.UTC),
+			purge:     true,
+		},
+		{
+			name:      "cutoff is truncated to the second",
+			retention: time.Hour,
+			expected:  time.Date(2021, time.March, 10, 11, 30, 45, 0, time.UTC),
+			purge:     true,
+		},
+		{
+			name:      "no retention keeps the snapshots",
+			retention: 0,
+			purge:     false,
+		},
+		{
+			name:      "negative retention keeps the snapshots",
+			retention: -time.Hour,
+			purge:     false,
+		},
+	}
+
+	for _, c := range cases {
+		cutoff, purge := snapshotsCutoff(moment, c.retention)
+
+		if purge != c.purge {
+			t.Errorf("%s: expected purge to be %t, got %t", c.name, c.purge, purge)
+		}
+		if purge && !cutoff.Equal(c.expected) {
+			t.Errorf("%s: expected cutoff %v, got %v", c.name, c.expected, cutoff)
+		}
+	}
+}
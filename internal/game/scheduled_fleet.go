@@ -0,0 +1,342 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"oglike_server/internal/model"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+	"time"
+)
+
+// ErrNotScheduledFleet : Indicates that the fleet is not waiting for its departure.
+var ErrNotScheduledFleet = fmt.Errorf("fleet is not scheduled for a later departure")
+
+// NewScheduledFleetFromDB :
+// Used to fetch the content of a fleet waiting for its
+// departure from the DB. The fleet is described as it
+// was provided when it was scheduled along with the
+// fuel reserved on its source.
+//
+// The `ID` defines the identifier of the fleet.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the fleet as fetched from the DB along with
+// any error.
+func NewScheduledFleetFromDB(ID string, data Instance) (Fleet, error) {
+	f := Fleet{
+		ID: ID,
+	}
+
+	// Consistency.
+	if !validUUID(f.ID) {
+		return f, ErrInvalidElementID
+	}
+
+	// Create the query and execute it.
+	query := db.QueryDesc{
+		Props: []string{
+			"fleet::text",
+			"consumption::text",
+		},
+		Table: "fleets_scheduled",
+		Filters: []db.Filter{
+			{
+				Key:    "id",
+				Values: []interface{}{f.ID},
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return f, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return f, dbRes.Err
+	}
+
+	// Scan the fleet's data.
+	atLeastOne := dbRes.Next()
+	if !atLeastOne {
+		return f, ErrElementNotFound
+	}
+
+	var rawFleet, rawConsumption string
+
+	err = dbRes.Scan(
+		&rawFleet,
+		&rawConsumption,
+	)
+	if err != nil {
+		return f, err
+	}
+
+	// Make sure that it's the only fleet.
+	if dbRes.Next() {
+		return f, ErrDuplicatedElement
+	}
+
+	err = json.Unmarshal([]byte(rawFleet), &f)
+	if err != nil {
+		return f, err
+	}
+
+	f.Consumption = make([]model.ResourceAmount, 0)
+	err = json.Unmarshal([]byte(rawConsumption), &f.Consumption)
+
+	return f, err
+}
+
+// Schedule :
+// Used to save the content of this fleet to the DB
+// so that it is launched at its departure time. The
+// ships, cargo and fuel needed by the fleet are all
+// reserved on the source in the meantime.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func (f *Fleet) Schedule(proxy db.Proxy) error {
+	if f.DepartureTime.IsZero() {
+		return ErrNotScheduledFleet
+	}
+
+	// The fleet is saved in the same format as the one
+	// used by the players to describe it.
+	raw, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	// Convert the cargo to a marshallable slice.
+	resources := make([]model.ResourceAmount, 0)
+	for _, res := range f.Cargo {
+		resources = append(resources, res)
+	}
+
+	query := db.InsertReq{
+		Script: "create_scheduled_fleet",
+		Args: []interface{}{
+			string(raw),
+			f.Ships.convert(),
+			resources,
+			f.Consumption,
+		},
+		SkipReturn: true,
+	}
+
+	err = proxy.InsertToDB(query)
+
+	// Analyze the error in order to provide some
+	// comprehensive message.
+	dbe, ok := err.(db.Error)
+	if !ok {
+		return err
+	}
+
+	dee, ok := dbe.Err.(db.DuplicatedElementError)
+	if ok {
+		switch dee.Constraint {
+		case "fleets_scheduled_pkey":
+			return ErrDuplicatedElement
+		}
+
+		return dee
+	}
+
+	fkve, ok := dbe.Err.(db.ForeignKeyViolationError)
+	if ok {
+		switch fkve.ForeignKey {
+		case "universe":
+			return ErrNonExistingUniverse
+		case "objective":
+			return ErrNonExistingObjective
+		case "player":
+			return ErrNonExistingPlayer
+		}
+
+		return fkve
+	}
+
+	return dbe
+}
+
+// CancelScheduledFleet :
+// Used to cancel the departure of the input fleet.
+// All the ships and resources reserved for it are
+// restored on its source.
+//
+// The `ID` defines the identifier of the fleet to
+// cancel.
+//
+// The `notify` defines whether the owner of the
+// fleet should receive a message.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func CancelScheduledFleet(ID string, notify bool, data Instance) error {
+	// Make sure that the fleet exists.
+	_, err := NewScheduledFleetFromDB(ID, data)
+	if err != nil {
+		return err
+	}
+
+	query := db.InsertReq{
+		Script: "cancel_scheduled_fleet",
+		Args: []interface{}{
+			ID,
+			notify,
+		},
+		SkipReturn: true,
+	}
+
+	return data.Proxy.InsertToDB(query)
+}
+
+// performScheduledFleetAction :
+// Used to launch the fleet described by the input
+// ID now that its departure time is reached. The
+// arrival time is computed again from the source
+// of the fleet. In case the fleet cannot be sent
+// anymore (for example because its target is now
+// protected or the ACS operation it should join
+// would be delayed too much), it is cancelled in
+// the same way as a manual cancellation and its
+// owner is notified.
+//
+// The `ID` defines the identifier of the fleet to
+// launch.
+//
+// Returns any error.
+func (i Instance) performScheduledFleetAction(ID string) error {
+	i.trace(logger.Verbose, fmt.Sprintf("Launching scheduled fleet %s", ID))
+
+	f, err := NewScheduledFleetFromDB(ID, i)
+	if err != nil {
+		return err
+	}
+
+	err = i.launchScheduledFleet(&f)
+	if err != nil {
+		i.trace(logger.Warning, fmt.Sprintf("Cancelling scheduled fleet \"%s\" (err: %v)", f.ID, err))
+		return CancelScheduledFleet(f.ID, true, i)
+	}
+
+	err = f.WarnTarget(i)
+	if err != nil {
+		i.trace(logger.Warning, fmt.Sprintf("Could not warn target of fleet \"%s\" (err: %v)", f.ID, err))
+	}
+
+	return nil
+}
+
+// launchScheduledFleet :
+// Used to check the target of the input fleet again,
+// compute its flight data from its departure time and
+// register it as a regular fleet. The fuel reserved
+// when the fleet was scheduled is used for the flight.
+//
+// The `f` defines the fleet to launch.
+//
+// Returns any error.
+func (i Instance) launchScheduledFleet(f *Fleet) error {
+	var source Planet
+	var err error
+
+	switch f.SourceType {
+	case World:
+		source, err = NewPlanetFromDB(f.Source, i)
+	case Moon:
+		source, err = NewMoonFromDB(f.Source, i)
+	default:
+		err = ErrInvalidSourceTypeForFleet
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// The target might have changed since the fleet was
+	// scheduled: it may have been destroyed or relocated
+	// or be protected by the rules of the universe.
+	var target *Planet
+	if f.Target != "" {
+		var vTarget Planet
+
+		switch f.TargetCoords.Type {
+		case World:
+			vTarget, err = NewPlanetFromDB(f.Target, i)
+		case Moon:
+			vTarget, err = NewMoonFromDB(f.Target, i)
+		default:
+			err = ErrInvalidTargetForFleet
+		}
+
+		if err == ErrElementNotFound {
+			return ErrInvalidTargetForFleet
+		}
+		if err != nil {
+			return err
+		}
+		if vTarget.Coordinates != f.TargetCoords {
+			return ErrInvalidTargetForFleet
+		}
+
+		target = &vTarget
+	}
+
+	err = f.validateObjective(i, &source, target)
+	if err != nil {
+		return err
+	}
+
+	mul, err := NewMultipliersFromDB(f.Universe, i)
+	if err != nil {
+		return ErrMultipliersError
+	}
+
+	err = f.ConsolidateArrivalTime(i, &source, mul.Fleet)
+	if err != nil {
+		return err
+	}
+
+	// In case the fleet should join an ACS operation,
+	// make sure that it is still possible. Note that
+	// the ACS might not exist yet if this fleet is the
+	// first component.
+	if f.ACS != "" {
+		acs, err := NewACSFleetFromDB(f.ACS, i)
+		if err == ErrElementNotFound {
+			ID := f.ACS
+			acs = NewACSFleet(f)
+			acs.ID = ID
+			f.ACS = ID
+		} else if err != nil {
+			return err
+		}
+
+		err = acs.ValidateFleet(f, &source, i)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := db.InsertReq{
+		Script: "launch_scheduled_fleet",
+		Args: []interface{}{
+			f.ID,
+			f,
+			f.CreatedAt.Format(time.RFC3339Nano),
+		},
+		SkipReturn: true,
+	}
+
+	return i.Proxy.InsertToDB(query)
+}
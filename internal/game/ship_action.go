@@ -258,5 +258,5 @@ func (a *ShipAction) Validate(data Instance, p *Planet, ratio float32) error {
 
 	costs := sd.Cost.ComputeCost(a.Remaining)
 
-	return p.validateAction(costs, sd.UpgradableDesc, data)
+	return p.validateAction(costs, sd.UpgradableDesc, 0, data)
 }
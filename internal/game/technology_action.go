@@ -461,5 +461,5 @@ func (a *TechnologyAction) Validate(data Instance, p *Planet, ratio float32) err
 	// Validate against planet's data.
 	costs := td.Cost.ComputeCost(a.CurrentLevel)
 
-	return p.validateAction(costs, td.UpgradableDesc, data)
+	return p.validateAction(costs, td.UpgradableDesc, 0, data)
 }
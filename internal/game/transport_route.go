@@ -0,0 +1,718 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"oglike_server/internal/model"
+	"oglike_server/pkg/db"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransportRule :
+// Describes the way the resources to move are computed
+// for a transport route.
+type TransportRule string
+
+// Define the possible rules of a transport route.
+const (
+	// StorageRule : moves all the resources exceeding a
+	// percentage of the storage of the source.
+	StorageRule TransportRule = "storage"
+
+	// FixedRule : moves fixed amounts of resources.
+	FixedRule TransportRule = "fixed"
+)
+
+// minTransportRoutePeriod :
+// Defines the minimum interval between two fleets sent
+// by a transport route.
+var minTransportRoutePeriod = time.Hour
+
+// TransportRoute :
+// Defines a recurring transport of resources between
+// two planets or moons of a player. Each time the route
+// runs a regular `transport` fleet is created from the
+// template of ships and the rule defined by the route.
+//
+// The `ID` defines the identifier of the route.
+//
+// The `Universe` defines the universe of the route.
+//
+// The `Player` defines the owner of the route. Both
+// the source and the target should belong to it.
+//
+// The `Source` and `SourceType` define the location
+// from where the fleets are sent.
+//
+// The `Target` and `TargetType` define the location
+// where the resources are delivered.
+//
+// The `Speed` defines the speed of the fleets in the
+// range `]0; 1]`.
+//
+// The `Period` defines the interval in seconds between
+// two fleets sent by the route.
+//
+// The `Rule` defines how the resources to move are
+// computed.
+//
+// The `Threshold` defines the percentage of the storage
+// to keep on the source for the `storage` rule.
+//
+// The `Ships` defines the ships to use for each fleet.
+//
+// The `Resources` defines the amounts to move for the
+// `fixed` rule.
+//
+// The `NextRun` defines the next time the route will
+// send a fleet.
+type TransportRoute struct {
+	ID         string                 `json:"id"`
+	Universe   string                 `json:"universe"`
+	Player     string                 `json:"player"`
+	Source     string                 `json:"source"`
+	SourceType Location               `json:"source_type"`
+	Target     string                 `json:"target"`
+	TargetType Location               `json:"target_type"`
+	Speed      float32                `json:"speed"`
+	Period     int                    `json:"period"`
+	Rule       TransportRule          `json:"rule"`
+	Threshold  float32                `json:"threshold"`
+	Ships      []ShipInFleet          `json:"ships"`
+	Resources  []model.ResourceAmount `json:"resources,omitempty"`
+	NextRun    time.Time              `json:"next_run"`
+}
+
+// ErrInvalidTransportRule : Indicates that the rule of a transport route is not valid.
+var ErrInvalidTransportRule = fmt.Errorf("invalid rule for transport route")
+
+// ErrInvalidTransportPeriod : Indicates that the period of a transport route is too short.
+var ErrInvalidTransportPeriod = fmt.Errorf("invalid period for transport route")
+
+// ErrInvalidTransportThreshold : Indicates that the threshold of a transport route is not valid.
+var ErrInvalidTransportThreshold = fmt.Errorf("invalid threshold for transport route")
+
+// ErrNoResourcesForTransportRoute : Indicates that a fixed transport route does not move anything.
+var ErrNoResourcesForTransportRoute = fmt.Errorf("no resources defined for transport route")
+
+// valid :
+// Determines whether the route is valid. By valid we
+// only mean obvious syntax errors.
+//
+// Returns any error or `nil` if the route seems valid.
+func (tr *TransportRoute) valid() error {
+	if !validUUID(tr.ID) {
+		return ErrInvalidElementID
+	}
+	if !validUUID(tr.Universe) {
+		return ErrInvalidUniverseForFleet
+	}
+	if !validUUID(tr.Player) {
+		return ErrInvalidPlayerForFleet
+	}
+	if !validUUID(tr.Source) {
+		return ErrInvalidSourceForFleet
+	}
+	if tr.SourceType != World && tr.SourceType != Moon {
+		return ErrInvalidSourceTypeForFleet
+	}
+	if !validUUID(tr.Target) || tr.Target == tr.Source {
+		return ErrInvalidTargetForFleet
+	}
+	if tr.TargetType != World && tr.TargetType != Moon {
+		return ErrInvalidTargetTypeForFleet
+	}
+	if tr.Speed <= 0.0 || tr.Speed > 1.0 {
+		return ErrInvalidSpeedForFleet
+	}
+	if time.Duration(tr.Period)*time.Second < minTransportRoutePeriod {
+		return ErrInvalidTransportPeriod
+	}
+
+	ships := make(ShipsInFleet)
+	for _, s := range tr.Ships {
+		ships[s.ID] = s
+	}
+	if len(ships) != len(tr.Ships) {
+		return ErrInvalidShipCount
+	}
+	if err := ships.valid(); err != nil {
+		return err
+	}
+
+	switch tr.Rule {
+	case StorageRule:
+		if tr.Threshold < 0.0 || tr.Threshold >= 1.0 {
+			return ErrInvalidTransportThreshold
+		}
+		if len(tr.Resources) > 0 {
+			return ErrInvalidCargoForFleet
+		}
+	case FixedRule:
+		if len(tr.Resources) == 0 {
+			return ErrNoResourcesForTransportRoute
+		}
+	default:
+		return ErrInvalidTransportRule
+	}
+
+	for _, r := range tr.Resources {
+		if !validUUID(r.Resource) || r.Amount <= 0.0 {
+			return ErrInvalidCargoForFleet
+		}
+	}
+
+	return nil
+}
+
+// NewTransportRouteFromDB :
+// Used to fetch the content of the transport route
+// from the input DB and populate all internal fields
+// from it.
+//
+// The `ID` defines the identifier of the route.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the route as fetched from the DB along with
+// any error.
+func NewTransportRouteFromDB(ID string, data Instance) (TransportRoute, error) {
+	tr := TransportRoute{
+		ID: ID,
+	}
+
+	// Consistency.
+	if !validUUID(tr.ID) {
+		return tr, ErrInvalidElementID
+	}
+
+	err := tr.fetchGeneralInfo(data)
+	if err != nil {
+		return tr, err
+	}
+
+	err = tr.fetchShips(data)
+	if err != nil {
+		return tr, err
+	}
+
+	err = tr.fetchResources(data)
+	if err != nil {
+		return tr, err
+	}
+
+	return tr, nil
+}
+
+// fetchGeneralInfo :
+// Used internally when building a route from the
+// DB to retrieve general information such as the
+// source and target of the route.
+//
+// The `data` defines the object to access the DB.
+//
+// Returns any error.
+func (tr *TransportRoute) fetchGeneralInfo(data Instance) error {
+	// Create the query and execute it.
+	query := db.QueryDesc{
+		Props: []string{
+			"universe",
+			"player",
+			"source",
+			"source_type",
+			"target",
+			"target_type",
+			"speed",
+			"period",
+			"rule",
+			"threshold",
+			"next_run",
+		},
+		Table: "transport_routes",
+		Filters: []db.Filter{
+			{
+				Key:    "id",
+				Values: []interface{}{tr.ID},
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return dbRes.Err
+	}
+
+	// Scan the route's data.
+	atLeastOne := dbRes.Next()
+	if !atLeastOne {
+		return ErrElementNotFound
+	}
+
+	err = dbRes.Scan(
+		&tr.Universe,
+		&tr.Player,
+		&tr.Source,
+		&tr.SourceType,
+		&tr.Target,
+		&tr.TargetType,
+		&tr.Speed,
+		&tr.Period,
+		&tr.Rule,
+		&tr.Threshold,
+		&tr.NextRun,
+	)
+
+	// Make sure that it's the only route.
+	if dbRes.Next() {
+		return ErrDuplicatedElement
+	}
+
+	return err
+}
+
+// fetchShips :
+// Similar to `fetchGeneralInfo` but allows to
+// fetch the ships used by the route.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (tr *TransportRoute) fetchShips(data Instance) error {
+	tr.Ships = make([]ShipInFleet, 0)
+
+	// Create the query and execute it.
+	query := db.QueryDesc{
+		Props: []string{
+			"ship",
+			"count",
+		},
+		Table: "transport_routes_ships",
+		Filters: []db.Filter{
+			{
+				Key:    "route",
+				Values: []interface{}{tr.ID},
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return dbRes.Err
+	}
+
+	// Populate the return value.
+	var sif ShipInFleet
+
+	for dbRes.Next() {
+		err = dbRes.Scan(
+			&sif.ID,
+			&sif.Count,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		tr.Ships = append(tr.Ships, sif)
+	}
+
+	return nil
+}
+
+// fetchResources :
+// Similar to `fetchGeneralInfo` but allows to
+// fetch the fixed amounts moved by the route.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (tr *TransportRoute) fetchResources(data Instance) error {
+	tr.Resources = make([]model.ResourceAmount, 0)
+
+	// Create the query and execute it.
+	query := db.QueryDesc{
+		Props: []string{
+			"resource",
+			"amount",
+		},
+		Table: "transport_routes_resources",
+		Filters: []db.Filter{
+			{
+				Key:    "route",
+				Values: []interface{}{tr.ID},
+			},
+		},
+	}
+
+	dbRes, err := data.Proxy.FetchFromDB(query)
+
+	// Check for errors.
+	if err != nil {
+		return err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return dbRes.Err
+	}
+
+	// Populate the return value.
+	var ra model.ResourceAmount
+
+	for dbRes.Next() {
+		err = dbRes.Scan(
+			&ra.Resource,
+			&ra.Amount,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		tr.Resources = append(tr.Resources, ra)
+	}
+
+	return nil
+}
+
+// Validate :
+// Used to make sure that the route is consistent with
+// the data of the DB: both the source and the target
+// should belong to the player of the route.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (tr *TransportRoute) Validate(data Instance) error {
+	if err := tr.valid(); err != nil {
+		return err
+	}
+
+	player, err := NewPlayerFromDB(tr.Player, data)
+	if err != nil {
+		return ErrInvalidPlayerForFleet
+	}
+	if player.Universe != tr.Universe {
+		return ErrInvalidUniverseForFleet
+	}
+
+	source, err := tr.fetchLocation(tr.Source, tr.SourceType, data)
+	if err != nil || source.Player != tr.Player {
+		return ErrInvalidSourceForFleet
+	}
+
+	target, err := tr.fetchLocation(tr.Target, tr.TargetType, data)
+	if err != nil || target.Player != tr.Player {
+		return ErrInvalidTargetForFleet
+	}
+
+	for _, s := range tr.Ships {
+		if _, err := data.Ships.GetShipFromID(s.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range tr.Resources {
+		rDesc, err := data.Resources.GetResourceFromID(r.Resource)
+		if err != nil {
+			return err
+		}
+		if !rDesc.Movable {
+			return ErrCargoNotMovable
+		}
+	}
+
+	return nil
+}
+
+// fetchLocation :
+// Used to fetch the planet or moon described by the
+// input identifier and type.
+//
+// The `ID` defines the identifier of the location.
+//
+// The `loc` defines whether it is a planet or a moon.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the location along with any error.
+func (tr *TransportRoute) fetchLocation(ID string, loc Location, data Instance) (Planet, error) {
+	if loc == Moon {
+		return NewMoonFromDB(ID, data)
+	}
+
+	return NewPlanetFromDB(ID, data)
+}
+
+// NewFleet :
+// Used to create the transport fleet that should be
+// sent by this route. The fleet does not carry any
+// resources yet: they can be computed once the fuel
+// needed by the fleet is known through the method
+// `FillCargo`.
+//
+// The `data` allows to access to the DB.
+//
+// Returns the fleet along with any error.
+func (tr *TransportRoute) NewFleet(data Instance) (Fleet, error) {
+	f := Fleet{
+		ID:         uuid.New().String(),
+		Universe:   tr.Universe,
+		Player:     tr.Player,
+		Source:     tr.Source,
+		SourceType: tr.SourceType,
+		Target:     tr.Target,
+		Speed:      tr.Speed,
+		Ships:      make(ShipsInFleet),
+		Cargo:      make(map[string]model.ResourceAmount),
+	}
+
+	obj, err := data.Objectives.GetIDFromName(string(transport))
+	if err != nil {
+		return f, err
+	}
+	f.Objective = obj
+
+	target, err := tr.fetchLocation(tr.Target, tr.TargetType, data)
+	if err != nil {
+		return f, err
+	}
+	f.TargetCoords = target.Coordinates
+
+	for _, s := range tr.Ships {
+		f.Ships[s.ID] = s
+	}
+
+	return f, nil
+}
+
+// FillCargo :
+// Used to compute the resources carried by the input
+// fleet based on the rule of the route. The fuel of
+// the fleet is assumed to be computed already so that
+// it is not included in the cargo. The resources are
+// reduced to fit in the cargo space of the fleet for
+// the `storage` rule.
+//
+// The `f` defines the fleet to update.
+//
+// The `source` defines the location of the resources.
+//
+// The `data` allows to access to the DB.
+//
+// Returns any error.
+func (tr *TransportRoute) FillCargo(f *Fleet, source *Planet, data Instance) error {
+	f.Cargo = make(map[string]model.ResourceAmount)
+
+	if tr.Rule == FixedRule {
+		for _, r := range tr.Resources {
+			f.Cargo[r.Resource] = r
+		}
+
+		return nil
+	}
+
+	fuels := make(map[string]float32)
+	for _, fuel := range f.Consumption {
+		fuels[fuel.Resource] += fuel.Amount
+	}
+
+	total := float32(0.0)
+
+	for _, res := range source.Resources {
+		rDesc, err := data.Resources.GetResourceFromID(res.Resource)
+		if err != nil {
+			return err
+		}
+		if !rDesc.Movable || res.Storage <= 0.0 {
+			continue
+		}
+
+		excess := res.Amount - tr.Threshold*res.Storage
+		available := res.Amount - fuels[res.Resource]
+
+		if available < excess {
+			excess = available
+		}
+		if excess < 1.0 {
+			continue
+		}
+
+		f.Cargo[res.Resource] = model.ResourceAmount{
+			Resource: res.Resource,
+			Amount:   excess,
+		}
+		total += excess
+	}
+
+	// Scale down the resources to fit in the ships.
+	space, err := f.cargoSpace(data)
+	if err != nil {
+		return err
+	}
+
+	ratio := float32(1.0)
+	if total > float32(space) {
+		ratio = float32(space) / total
+	}
+
+	for id, r := range f.Cargo {
+		r.Amount = float32(math.Floor(float64(r.Amount * ratio)))
+
+		if r.Amount <= 0.0 {
+			delete(f.Cargo, id)
+			continue
+		}
+
+		f.Cargo[id] = r
+	}
+
+	return nil
+}
+
+// SaveToDB :
+// Used to save the content of this route to the DB.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func (tr *TransportRoute) SaveToDB(proxy db.Proxy) error {
+	// Check consistency.
+	if err := tr.valid(); err != nil {
+		return err
+	}
+
+	resources := tr.Resources
+	if resources == nil {
+		resources = make([]model.ResourceAmount, 0)
+	}
+
+	// Create the query and execute it.
+	query := db.InsertReq{
+		Script: "create_transport_route",
+		Args: []interface{}{
+			tr,
+			tr.Ships,
+			resources,
+		},
+		SkipReturn: true,
+	}
+
+	err := proxy.InsertToDB(query)
+
+	// Analyze the error in order to provide some
+	// comprehensive message.
+	dbe, ok := err.(db.Error)
+	if !ok {
+		return err
+	}
+
+	dee, ok := dbe.Err.(db.DuplicatedElementError)
+	if ok {
+		switch dee.Constraint {
+		case "transport_routes_pkey":
+			return ErrDuplicatedElement
+		}
+
+		return dee
+	}
+
+	fkve, ok := dbe.Err.(db.ForeignKeyViolationError)
+	if ok {
+		switch fkve.ForeignKey {
+		case "universe":
+			return ErrNonExistingUniverse
+		case "player":
+			return ErrNonExistingPlayer
+		}
+
+		return fkve
+	}
+
+	return dbe
+}
+
+// Schedule :
+// Used to update the next time this route should
+// send a fleet. It is always in the future even if
+// some runs were missed.
+//
+// The `moment` defines the time of the last run.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func (tr *TransportRoute) Schedule(moment time.Time, proxy db.Proxy) error {
+	period := time.Duration(tr.Period) * time.Second
+
+	for !tr.NextRun.After(moment) {
+		tr.NextRun = tr.NextRun.Add(period)
+	}
+
+	query := db.InsertReq{
+		Script: "update_transport_route",
+		Args: []interface{}{
+			tr.ID,
+			tr.NextRun.Format(time.RFC3339Nano),
+		},
+		SkipReturn: true,
+	}
+
+	return proxy.InsertToDB(query)
+}
+
+// Skip :
+// Used to notify the owner of the route that no fleet
+// could be sent.
+//
+// The `reason` describes why the fleet was not sent.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func (tr *TransportRoute) Skip(reason string, proxy db.Proxy) error {
+	query := db.InsertReq{
+		Script: "create_transport_route_message",
+		Args: []interface{}{
+			tr.ID,
+			reason,
+		},
+		SkipReturn: true,
+	}
+
+	return proxy.InsertToDB(query)
+}
+
+// DeleteFromDB :
+// Used to remove the route from the DB.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func (tr *TransportRoute) DeleteFromDB(proxy db.Proxy) error {
+	query := db.InsertReq{
+		Script: "delete_transport_route",
+		Args: []interface{}{
+			tr.ID,
+		},
+		SkipReturn: true,
+	}
+
+	return proxy.InsertToDB(query)
+}
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"oglike_server/pkg/db"
@@ -66,6 +67,59 @@ type Universe struct {
 	// SolarSystemSize defines the number of planets in each
 	// solar system of each galaxy.
 	SolarSystemSize int `json:"solar_system_size"`
+
+	// NoobProtectionPoints defines the number of points
+	// below which a player cannot be the target of any
+	// hostile fleet. A value of `0` disables the check.
+	NoobProtectionPoints int `json:"noob_protection_points"`
+
+	// NoobProtectionRatio defines the ratio between the
+	// points of the attacker and the points of the target
+	// above which the target is protected from hostile
+	// fleets. A value of `0` disables the check.
+	NoobProtectionRatio float32 `json:"noob_protection_ratio"`
+
+	// BashingLimit defines the maximum number of attacks
+	// that a player can successfully perform on a single
+	// planet or moon over a rolling day. A value of `0`
+	// disables the check.
+	BashingLimit int `json:"bashing_limit"`
+
+	// ACSDefendHoldTimes defines the durations in hours
+	// that are allowed for fleets holding at a planet in
+	// an ACS defend operation.
+	ACSDefendHoldTimes []int `json:"acs_defend_hold_times"`
+
+	// HomeworldPlacement defines the name of the strategy
+	// used to choose the coordinates of the homeworld of
+	// new players.
+	HomeworldPlacement string `json:"homeworld_placement"`
+
+	// HomeworldDensity defines the number of planets that
+	// a solar system should reach before the strategies
+	// filling the universe move on to the next one.
+	HomeworldDensity int `json:"homeworld_density"`
+
+	// HomeworldBuffer defines the number of solar systems
+	// kept between new homeworlds and the planets of the
+	// top players of the universe.
+	HomeworldBuffer int `json:"homeworld_buffer"`
+
+	// MerchantRates defines the relative value of each
+	// resource that can be traded with the merchant. It
+	// is indexed by the name of the resource: a rate of
+	// `3:2:1` for metal, crystal and deuterium means that
+	// `3` units of metal are worth `1` unit of deuterium.
+	MerchantRates map[string]float32 `json:"merchant_rates"`
+
+	// MerchantVariance is a value in the range `[0; 1[`
+	// defining how much the rates of the merchant can
+	// randomly vary for each trade.
+	MerchantVariance float32 `json:"merchant_variance"`
+
+	// RuleSet defines the name of the rule set describing
+	// the data model used by this universe.
+	RuleSet string `json:"rule_set"`
 }
 
 // Multipliers :
@@ -106,36 +160,6 @@ type Multipliers struct {
 	Consumption float32
 }
 
-// Ranking :
-// Defines the needed information to represent the rank of
-// a player within a universe.
-type Ranking struct {
-	// Player defines the identifier of the player associated to
-	// the ranking.
-	Player string `json:"player"`
-
-	// Rank defines the ranking of this player in the universe.
-	Rank int `json:"rank"`
-
-	// Economy defines how many economy points this player has.
-	Economy float32 `json:"economy"`
-
-	// Research defines how many research points this player has.
-	Research float32 `json:"research"`
-
-	// MilitaryBuilt defines how many military points this player
-	// has built.
-	MilitaryBuilt float32 `json:"military_built"`
-
-	// MilitaryLost defines how many military points this player
-	// has lost.
-	MilitaryLost float32 `json:"military_lost"`
-
-	// MilitaryDestroyed defines how many military points this
-	// player has destroyed.
-	MilitaryDestroyed float32 `json:"military_destroyed"`
-}
-
 // ErrDuplicatedCoordinates : Indicates that some coordinates appeared twice.
 var ErrDuplicatedCoordinates = fmt.Errorf("invalid duplicated coordinates")
 
@@ -184,6 +208,53 @@ var ErrGalaxySize = fmt.Errorf("galaxy size is not within admissible range")
 // ErrSolarSystemSize : The size of a solar system is not within admissible range.
 var ErrSolarSystemSize = fmt.Errorf("solar system size is not within admissible range")
 
+// ErrNoobProtectionPoints : The noob protection points are not within admissible range.
+var ErrNoobProtectionPoints = fmt.Errorf("noob protection points are not within admissible range")
+
+// ErrNoobProtectionRatio : The noob protection ratio is not within admissible range.
+var ErrNoobProtectionRatio = fmt.Errorf("noob protection ratio is not within admissible range")
+
+// ErrBashingLimit : The bashing limit is not within admissible range.
+var ErrBashingLimit = fmt.Errorf("bashing limit is not within admissible range")
+
+// ErrACSDefendHoldTimes : The hold times for ACS defend operations are not within admissible range.
+var ErrACSDefendHoldTimes = fmt.Errorf("acs defend hold times are not within admissible range")
+
+// ErrHomeworldDensity : The homeworld density is not within admissible range.
+var ErrHomeworldDensity = fmt.Errorf("homeworld density is not within admissible range")
+
+// ErrHomeworldBuffer : The homeworld buffer is not within admissible range.
+var ErrHomeworldBuffer = fmt.Errorf("homeworld buffer is not within admissible range")
+
+// ErrMerchantRates : The rates of the merchant are not within admissible range.
+var ErrMerchantRates = fmt.Errorf("merchant rates are not within admissible range")
+
+// ErrMerchantVariance : The variance of the merchant rates is not within admissible range.
+var ErrMerchantVariance = fmt.Errorf("merchant variance is not within admissible range")
+
+// ErrUnsupportedRuleSet : The rule set is not the one used by the server.
+var ErrUnsupportedRuleSet = fmt.Errorf("rule set is not supported by the server")
+
+// defaultACSDefendHoldTimes :
+// Defines the hold times in hours allowed for the ACS
+// defend operations when the universe does not define
+// any.
+var defaultACSDefendHoldTimes = []int{0, 1, 2, 4, 8, 16, 32}
+
+// maxACSDefendHoldTime :
+// Defines the maximum hold time in hours that can be
+// allowed by a universe for ACS defend operations.
+var maxACSDefendHoldTime = 32
+
+// defaultMerchantRates :
+// Defines the rates of the merchant when the universe
+// does not define any.
+var defaultMerchantRates = map[string]float32{
+	"metal":     3.0,
+	"crystal":   2.0,
+	"deuterium": 1.0,
+}
+
 // valid :
 // Determines whether the universe is valid. By valid we only
 // mean obvious syntax errors.
@@ -226,6 +297,42 @@ func (u *Universe) valid() error {
 	if u.SolarSystemSize <= 0 {
 		return ErrSolarSystemSize
 	}
+	if u.NoobProtectionPoints < 0 {
+		return ErrNoobProtectionPoints
+	}
+	if u.NoobProtectionRatio < 0.0 {
+		return ErrNoobProtectionRatio
+	}
+	if u.BashingLimit < 0 {
+		return ErrBashingLimit
+	}
+	holds := make(map[int]bool)
+	for _, h := range u.ACSDefendHoldTimes {
+		if h < 0 || h > maxACSDefendHoldTime || holds[h] {
+			return ErrACSDefendHoldTimes
+		}
+		holds[h] = true
+	}
+	if _, ok := placers[PlacementStrategy(u.HomeworldPlacement)]; !ok {
+		return ErrInvalidPlacementStrategy
+	}
+	if u.HomeworldDensity <= 0 || u.HomeworldDensity > u.SolarSystemSize {
+		return ErrHomeworldDensity
+	}
+	if u.HomeworldBuffer < 0 {
+		return ErrHomeworldBuffer
+	}
+	for _, rate := range u.MerchantRates {
+		if rate <= 0.0 {
+			return ErrMerchantRates
+		}
+	}
+	if u.MerchantVariance < 0.0 || u.MerchantVariance >= 1.0 {
+		return ErrMerchantVariance
+	}
+	if u.RuleSet == "" {
+		return ErrUnsupportedRuleSet
+	}
 
 	return nil
 }
@@ -271,6 +378,16 @@ func NewUniverseFromDB(ID string, data Instance) (Universe, error) {
 			"u.galaxies_count",
 			"u.galaxy_size",
 			"u.solar_system_size",
+			"u.noob_protection_points",
+			"u.noob_protection_ratio",
+			"u.bashing_limit",
+			"u.acs_defend_hold_times",
+			"u.homeworld_placement",
+			"u.homeworld_density",
+			"u.homeworld_buffer",
+			"u.merchant_rates::text",
+			"u.merchant_variance",
+			"u.rule_set",
 			"u.created_at",
 		},
 		Table: "universes u inner join countries c on u.country = c.id",
@@ -301,6 +418,8 @@ func NewUniverseFromDB(ID string, data Instance) (Universe, error) {
 	}
 
 	var creationTime time.Time
+	var holds []int32
+	var rates string
 
 	err = dbRes.Scan(
 		&u.Name,
@@ -314,9 +433,34 @@ func NewUniverseFromDB(ID string, data Instance) (Universe, error) {
 		&u.GalaxiesCount,
 		&u.GalaxySize,
 		&u.SolarSystemSize,
+		&u.NoobProtectionPoints,
+		&u.NoobProtectionRatio,
+		&u.BashingLimit,
+		&holds,
+		&u.HomeworldPlacement,
+		&u.HomeworldDensity,
+		&u.HomeworldBuffer,
+		&rates,
+		&u.MerchantVariance,
+		&u.RuleSet,
 		&creationTime,
 	)
 
+	u.ACSDefendHoldTimes = make([]int, 0)
+	for _, h := range holds {
+		u.ACSDefendHoldTimes = append(u.ACSDefendHoldTimes, int(h))
+	}
+
+	if err != nil {
+		return u, err
+	}
+
+	u.MerchantRates = make(map[string]float32)
+	err = json.Unmarshal([]byte(rates), &u.MerchantRates)
+	if err != nil {
+		return u, err
+	}
+
 	// Convert the age in days.
 	u.Age = int(time.Since(creationTime).Hours() / 24.0)
 
@@ -328,6 +472,39 @@ func NewUniverseFromDB(ID string, data Instance) (Universe, error) {
 	return u, err
 }
 
+// canHoldFor :
+// Used to determine whether the input duration is one of
+// the hold times allowed for ACS defend operations in
+// this universe.
+//
+// The `hours` defines the duration to check.
+//
+// Returns `true` if the duration is allowed.
+func (u *Universe) canHoldFor(hours int) bool {
+	for _, h := range u.ACSDefendHoldTimes {
+		if h == hours {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maxHoldTime :
+// Returns the longest hold time in hours allowed for
+// ACS defend operations in this universe.
+func (u *Universe) maxHoldTime() int {
+	max := 0
+
+	for _, h := range u.ACSDefendHoldTimes {
+		if h > max {
+			max = h
+		}
+	}
+
+	return max
+}
+
 // NewMultipliersFromDB :
 // Used to fetch the multipliers related to a
 // universe from the DB.
@@ -421,6 +598,24 @@ func NewMultipliersFromDB(uni string, data Instance) (Multipliers, error) {
 //
 // Returns any error.
 func (u *Universe) SaveToDB(proxy db.Proxy) error {
+	// Use the default hold times if none are provided.
+	if len(u.ACSDefendHoldTimes) == 0 {
+		u.ACSDefendHoldTimes = defaultACSDefendHoldTimes
+	}
+
+	// Same for the homeworld placement.
+	if u.HomeworldPlacement == "" {
+		u.HomeworldPlacement = string(RandomPlacement)
+	}
+	if u.HomeworldDensity == 0 {
+		u.HomeworldDensity = defaultHomeworldDensity
+	}
+
+	// And for the merchant.
+	if len(u.MerchantRates) == 0 {
+		u.MerchantRates = defaultMerchantRates
+	}
+
 	// Check consistency.
 	if err := u.valid(); err != nil {
 		return err
@@ -454,6 +649,47 @@ func (u *Universe) SaveToDB(proxy db.Proxy) error {
 	return dbe
 }
 
+// UpdateInDB :
+// Used to update the configuration of this universe in
+// the DB. The dimensions of the universe along with its
+// country and rule set are not updated as planets might
+// already rely on them.
+//
+// The `proxy` allows to access to the DB.
+//
+// Returns any error.
+func (u *Universe) UpdateInDB(proxy db.Proxy) error {
+	// Check consistency.
+	if err := u.valid(); err != nil {
+		return err
+	}
+
+	// Create the query and execute it.
+	query := db.InsertReq{
+		Script: "update_universe",
+		Args: []interface{}{
+			u.ID,
+			u,
+		},
+	}
+
+	err := proxy.InsertToDB(query)
+
+	// Analyze the error in order to provide some
+	// comprehensive message.
+	dbe, ok := err.(db.Error)
+	if !ok {
+		return err
+	}
+
+	dee, ok := dbe.Err.(db.DuplicatedElementError)
+	if ok && dee.Constraint == "universes_name_key" {
+		return ErrInvalidName
+	}
+
+	return dbe
+}
+
 // UsedCoords :
 // Used to find and generate a list of the used coordinates
 // in this universe. Note that the list is only some snapshot
@@ -462,6 +698,8 @@ func (u *Universe) SaveToDB(proxy db.Proxy) error {
 // are outstanding or some fleets are registered which will
 // ultimately lead to the colonization/destruction of some
 // planet this list will change.
+// The slots reserved for the relocation of a planet are also
+// considered as used.
 // In order to be practical the list of used coordinates is
 // returned using a map. The keys correspond to the coords
 // where the `Linearize` method with this universe as param
@@ -473,18 +711,19 @@ func (u *Universe) SaveToDB(proxy db.Proxy) error {
 // Returns the list of used coordinates along with any error.
 func (u *Universe) UsedCoords(proxy db.Proxy) (map[int]Coordinate, error) {
 	// Create the query allowing to fetch all the planets of
-	// a specific universe. This will consistute the list of
-	// used planets for this universe.
+	// a specific universe along with the slots reserved for
+	// relocations. This will consistute the list of used
+	// planets for this universe.
 	query := db.QueryDesc{
 		Props: []string{
-			"p.galaxy",
-			"p.solar_system",
-			"p.position",
+			"c.galaxy",
+			"c.solar_system",
+			"c.position",
 		},
-		Table: "planets p inner join players pl on p.player=pl.id",
+		Table: "(select p.galaxy, p.solar_system, p.position, pl.universe from planets p inner join players pl on p.player=pl.id union all select r.galaxy, r.solar_system, r.position, r.universe from planets_relocations r) c",
 		Filters: []db.Filter{
 			{
-				Key:    "pl.universe",
+				Key:    "c.universe",
 				Values: []interface{}{u.ID},
 			},
 		},
@@ -3,7 +3,9 @@ package locker
 import (
 	"fmt"
 	"oglike_server/pkg/logger"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -126,12 +128,34 @@ var ErrLock = fmt.Errorf("error while using resource lock")
 // lock is released without the user needing to
 // manually call the `Release` method on the parent
 // object. It helps providing a simple-to-use lock.
+//
+// The `since` defines the moment at which the lock was
+// assigned to the current resource.
 type Lock struct {
 	id     int
 	res    string
 	use    int
 	waiter chan struct{}
 	cl     *ConcurrentLocker
+	since  time.Time
+}
+
+// Holder :
+// Describes a resource currently protected by a lock
+// of a `ConcurrentLocker`. It is used to inspect the
+// state of the locks at runtime.
+//
+// The `Resource` defines the name of the resource.
+//
+// The `Users` defines the number of clients using or
+// waiting for the lock.
+//
+// The `Since` defines the moment at which the lock was
+// assigned to the resource.
+type Holder struct {
+	Resource string    `json:"resource"`
+	Users    int       `json:"users"`
+	Since    time.Time `json:"since"`
 }
 
 // configuration :
@@ -299,6 +323,7 @@ func (cl *ConcurrentLocker) Acquire(resource string) (*Lock, error) {
 		l.id = id
 		l.res = resource
 		l.use++
+		l.since = time.Now()
 
 		cl.cout.Trace(logger.Debug, getModuleName(), fmt.Sprintf("Creating locker on \"%s\" (id: %d, available: %d)", l.res, l.id, len(cl.availableLocks)))
 	}()
@@ -307,6 +332,38 @@ func (cl *ConcurrentLocker) Acquire(resource string) (*Lock, error) {
 	return l, nil
 }
 
+// Holders :
+// Used to retrieve the resources currently protected by
+// one of the locks of this element. The resources are
+// sorted by name.
+//
+// Returns the description of the resources.
+func (cl *ConcurrentLocker) Holders() []Holder {
+	cl.locker.Lock()
+	defer cl.locker.Unlock()
+
+	holders := make([]Holder, 0, len(cl.registered))
+
+	for res, id := range cl.registered {
+		l := cl.locks[id]
+
+		holders = append(
+			holders,
+			Holder{
+				Resource: res,
+				Users:    l.use,
+				Since:    l.since,
+			},
+		)
+	}
+
+	sort.Slice(holders, func(i, j int) bool {
+		return holders[i].Resource < holders[j].Resource
+	})
+
+	return holders
+}
+
 // Release :
 // Used to perform the release of the lock provided in input
 // and handle the necessary verifications to see whether it
@@ -262,6 +262,25 @@ func newFieldsRule() FieldsRule {
 	}
 }
 
+// TotalFields :
+// Used to perform the computation of the total number
+// of fields provided by the building when it reaches
+// the input level.
+//
+// The `level` for which the fields need to be computed.
+//
+// Returns the number of fields provided by the building
+// at the input level.
+func (fr FieldsRule) TotalFields(level int) int {
+	if level <= 0 {
+		return 0
+	}
+
+	fFields := float64(fr.Multiplier)*float64(level) + float64(fr.Constant)
+
+	return int(math.Floor(fFields))
+}
+
 // ComputeFields :
 // Used to perform the computation of the additional
 // fields provided by the level of the building in
@@ -273,14 +292,7 @@ func newFieldsRule() FieldsRule {
 // Returns the number of fields added by building the
 // input level of the building.
 func (fr FieldsRule) ComputeFields(level int) int {
-	prevFields := float64(0.0)
-	if level > 0 {
-		prevFields = float64(fr.Multiplier)*float64(level-1) + float64(fr.Constant)
-	}
-
-	fFields := float64(fr.Multiplier)*float64(level) + float64(fr.Constant)
-
-	return int(math.Floor(fFields - prevFields))
+	return fr.TotalFields(level) - fr.TotalFields(level-1)
 }
 
 // NewBuildingsModule :
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"fmt"
+)
+
+// ErrInconsistentModel :
+// Used to indicate that some modules reference elements
+// which are not defined by the other modules.
+var ErrInconsistentModel = fmt.Errorf("detected dangling references in data model")
+
+// CheckConsistency :
+// Used to verify that the input modules are consistent
+// with each other. This includes the dependencies of the
+// upgradable elements which should reference existing
+// buildings and technologies, their costs which should
+// reference existing resources, the rapid fires of the
+// ships and the ships allowed for each fleet objective.
+// All the modules are expected to be initialized.
+//
+// The `bm` defines the buildings module.
+//
+// The `tm` defines the technologies module.
+//
+// The `sm` defines the ships module.
+//
+// The `dm` defines the defenses module.
+//
+// The `rm` defines the resources module.
+//
+// The `om` defines the fleet objectives module.
+//
+// Returns any error.
+func CheckConsistency(bm *BuildingsModule, tm *TechnologiesModule, sm *ShipsModule, dm *DefensesModule, rm *ResourcesModule, om *FleetObjectivesModule) error {
+	upgradables := []*upgradablesModule{
+		&bm.upgradablesModule,
+		&tm.upgradablesModule,
+		&sm.upgradablesModule,
+		&dm.upgradablesModule,
+	}
+
+	for _, um := range upgradables {
+		err := checkDependencies(um, bm, tm)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check the costs of all upgradable elements.
+	costs := map[upgradable]map[string]map[string]int{
+		Building:   make(map[string]map[string]int),
+		Technology: make(map[string]map[string]int),
+		Ship:       make(map[string]map[string]int),
+		Defense:    make(map[string]map[string]int),
+	}
+
+	for id, c := range bm.progressCostsModule.costs {
+		costs[Building][id] = c.InitCosts
+	}
+	for id, c := range tm.progressCostsModule.costs {
+		costs[Technology][id] = c.InitCosts
+	}
+	for id, c := range sm.fixedCostsModule.costs {
+		costs[Ship][id] = c.InitCosts
+	}
+	for id, c := range dm.fixedCostsModule.costs {
+		costs[Defense][id] = c.InitCosts
+	}
+
+	for kind, elems := range costs {
+		for id, cost := range elems {
+			for res := range cost {
+				if !rm.existsID(res) {
+					return inconsistency(kind.String(), fmt.Sprintf("cost of \"%s\" references unknown resource \"%s\"", id, res))
+				}
+			}
+		}
+	}
+
+	// Check the resources produced and stored by buildings.
+	for id, rules := range bm.production {
+		for _, rule := range rules {
+			if !rm.existsID(rule.Resource) {
+				return inconsistency(Building.String(), fmt.Sprintf("production of \"%s\" references unknown resource \"%s\"", id, rule.Resource))
+			}
+		}
+	}
+	for id, rules := range bm.storage {
+		for _, rule := range rules {
+			if !rm.existsID(rule.Resource) {
+				return inconsistency(Building.String(), fmt.Sprintf("storage of \"%s\" references unknown resource \"%s\"", id, rule.Resource))
+			}
+		}
+	}
+
+	// Check the rapid fires of ships.
+	for id, rfs := range sm.rfVSShips {
+		for _, rf := range rfs {
+			if !sm.existsID(rf.Receiver) {
+				return inconsistency(Ship.String(), fmt.Sprintf("rapid fire of \"%s\" references unknown ship \"%s\"", id, rf.Receiver))
+			}
+		}
+	}
+	for id, rfs := range sm.rfVSDefenses {
+		for _, rf := range rfs {
+			if !dm.existsID(rf.Receiver) {
+				return inconsistency(Ship.String(), fmt.Sprintf("rapid fire of \"%s\" references unknown defense \"%s\"", id, rf.Receiver))
+			}
+		}
+	}
+
+	// Check the ships allowed for fleet objectives.
+	for obj, ships := range om.allowedShips {
+		for ship := range ships {
+			if !sm.existsID(ship) {
+				return inconsistency("objectives", fmt.Sprintf("objective \"%s\" references unknown ship \"%s\"", obj, ship))
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkDependencies :
+// Used to verify that the dependencies registered in the
+// input module reference elements that exist, both for
+// the elements themselves and for their requirements.
+//
+// The `um` defines the module to check.
+//
+// The `bm` defines the buildings module.
+//
+// The `tm` defines the technologies module.
+//
+// Returns any error.
+func checkDependencies(um *upgradablesModule, bm *BuildingsModule, tm *TechnologiesModule) error {
+	deps := []struct {
+		deps     map[string][]Dependency
+		registry *associationTable
+		kind     upgradable
+	}{
+		{um.buildingsDeps, &bm.associationTable, Building},
+		{um.techDeps, &tm.associationTable, Technology},
+	}
+
+	for _, d := range deps {
+		for id, reqs := range d.deps {
+			if !um.existsID(id) {
+				return inconsistency(um.uType.String(), fmt.Sprintf("dependencies registered for unknown element \"%s\"", id))
+			}
+
+			for _, req := range reqs {
+				if !d.registry.existsID(req.ID) {
+					return inconsistency(um.uType.String(), fmt.Sprintf("\"%s\" depends on \"%s\" which is not one of the %s", id, req.ID, d.kind))
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// inconsistency :
+// Used to build the error describing an inconsistency
+// detected in a module.
+//
+// The `module` defines the name of the module.
+//
+// The `detail` describes the inconsistency.
+//
+// Returns the corresponding error.
+func inconsistency(module string, detail string) error {
+	return fmt.Errorf("%v (module: %s, err: %s)", ErrInconsistentModel, module, detail)
+}
@@ -72,6 +72,21 @@ func (fc FixedCost) ComputeCost(count int) map[string]int {
 	return costs
 }
 
+// fixedCostOutput :
+// Describes the JSON representation of a fixed cost as it
+// is sent to clients.
+type fixedCostOutput struct {
+	Resources []ResourceAmount `json:"init_costs"`
+}
+
+// JSONModel :
+// Returns a value having the same JSON representation
+// as the cost. It is used to describe the costs in the
+// specification of the API.
+func (fc FixedCost) JSONModel() interface{} {
+	return fixedCostOutput{}
+}
+
 // MarshalJSON :
 // Used to marshal the content defined by this fixed
 // cost in order to make it available to other tools.
@@ -91,9 +106,7 @@ func (fc FixedCost) MarshalJSON() ([]byte, error) {
 		)
 	}
 
-	o := struct {
-		Resources []ResourceAmount `json:"init_costs"`
-	}{
+	o := fixedCostOutput{
 		Resources: costs,
 	}
 
@@ -71,6 +71,25 @@ type Objective struct {
 	Allowed  map[string]bool `json:"-"`
 }
 
+// objectiveOutput :
+// Describes the JSON representation of an objective as
+// it is sent to clients.
+type objectiveOutput struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Hostile  bool     `json:"hostile"`
+	Directed bool     `json:"directed"`
+	Allowed  []string `json:"allowed_ships"`
+}
+
+// JSONModel :
+// Returns a value having the same JSON representation
+// as the objective. It is used to describe objectives
+// in the specification of the API.
+func (o *Objective) JSONModel() interface{} {
+	return objectiveOutput{}
+}
+
 // MarshalJSON :
 // Implementation of the `Marshaler` interface to allow
 // only specific information to be marshalled when the
@@ -81,16 +100,8 @@ type Objective struct {
 // Returns the marshalled bytes for this objective along
 // with any error.
 func (o *Objective) MarshalJSON() ([]byte, error) {
-	type outObjective struct {
-		ID       string   `json:"id"`
-		Name     string   `json:"name"`
-		Hostile  bool     `json:"hostile"`
-		Directed bool     `json:"directed"`
-		Allowed  []string `json:"allowed_ships"`
-	}
-
 	// Copy the planet's data.
-	oo := outObjective{
+	oo := objectiveOutput{
 		ID:       o.ID,
 		Name:     o.Name,
 		Hostile:  o.Hostile,
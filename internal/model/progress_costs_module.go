@@ -93,6 +93,22 @@ func (pc ProgressCost) ComputeCost(level int) map[string]int {
 	return costs
 }
 
+// progressCostOutput :
+// Describes the JSON representation of a progress cost as it
+// is sent to clients.
+type progressCostOutput struct {
+	Resources   []ResourceAmount `json:"init_costs"`
+	Progression float32          `json:"progression"`
+}
+
+// JSONModel :
+// Returns a value having the same JSON representation
+// as the cost. It is used to describe the costs in the
+// specification of the API.
+func (pc ProgressCost) JSONModel() interface{} {
+	return progressCostOutput{}
+}
+
 // MarshalJSON :
 // Used to marshal the content defined by this progress
 // cost in order to make it available to other tools.
@@ -112,10 +128,7 @@ func (pc ProgressCost) MarshalJSON() ([]byte, error) {
 		)
 	}
 
-	o := struct {
-		Resources   []ResourceAmount `json:"init_costs"`
-		Progression float32          `json:"progression"`
-	}{
+	o := progressCostOutput{
 		Resources:   costs,
 		Progression: pc.ProgressionRule,
 	}
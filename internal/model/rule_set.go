@@ -0,0 +1,469 @@
+package model
+
+import (
+	"fmt"
+)
+
+// RuleSet :
+// Defines a declarative description of the data model
+// of the game. It gathers all the resources, buildings,
+// technologies, ships and defenses along with their
+// costs and dependencies. This is used to populate the
+// DB from versioned files rather than from migrations.
+//
+// The `Name` defines the name of the rule set which is
+// used by universes to reference it.
+//
+// The `Version` defines the version of the rule set. It
+// is used to determine whether the data in the DB is up
+// to date with the description.
+//
+// The `Extends` defines the name of a rule set that is
+// used as a base for this one: each element defined in
+// the current rule set overrides the element with the
+// same name in the base rule set.
+//
+// The `Resources` defines the resources of the game.
+//
+// The `Buildings` defines the buildings of the game.
+//
+// The `Technologies` defines the technologies that can
+// be researched.
+//
+// The `Ships` defines the ships that can be built.
+//
+// The `Defenses` defines the defenses that can be built.
+//
+// The `PropulsionIncrease` defines the speed increase in
+// percentage brought by each level of the propulsion
+// technologies.
+type RuleSet struct {
+	Name               string           `yaml:"name" json:"name"`
+	Version            int              `yaml:"version" json:"version"`
+	Extends            string           `yaml:"extends" json:"-"`
+	Resources          []ResourceRule   `yaml:"resources" json:"resources,omitempty"`
+	Buildings          []BuildingRule   `yaml:"buildings" json:"buildings,omitempty"`
+	Technologies       []TechnologyRule `yaml:"technologies" json:"technologies,omitempty"`
+	Ships              []ShipRule       `yaml:"ships" json:"ships,omitempty"`
+	Defenses           []DefenseRule    `yaml:"defenses" json:"defenses,omitempty"`
+	PropulsionIncrease map[string]int   `yaml:"propulsion_increase" json:"propulsion_increase,omitempty"`
+}
+
+// ResourceRule :
+// Describes a resource in a rule set. The properties
+// are similar to the ones defined in `ResourceDesc`.
+type ResourceRule struct {
+	Name            string `yaml:"name" json:"name"`
+	BaseProduction  int    `yaml:"base_production" json:"base_production"`
+	BaseStorage     int    `yaml:"base_storage" json:"base_storage"`
+	BaseAmount      int    `yaml:"base_amount" json:"base_amount"`
+	Movable         bool   `yaml:"movable" json:"movable"`
+	Storable        bool   `yaml:"storable" json:"storable"`
+	Dispersable     bool   `yaml:"dispersable" json:"dispersable"`
+	EconomyScalable bool   `yaml:"economy_scalable" json:"economy_scalable"`
+}
+
+// Requirements :
+// Describes the tech tree dependencies of an element.
+// Each map associates the name of the building or the
+// technology required to the minimum level needed.
+type Requirements struct {
+	Buildings    map[string]int `yaml:"buildings" json:"buildings,omitempty"`
+	Technologies map[string]int `yaml:"technologies" json:"technologies,omitempty"`
+}
+
+// BuildingProductionRule :
+// Describes the production (or consumption) of some
+// resource by a building.
+type BuildingProductionRule struct {
+	Resource          string  `yaml:"resource" json:"resource"`
+	Base              int     `yaml:"base" json:"base"`
+	Progress          float32 `yaml:"progress" json:"progress"`
+	TemperatureCoeff  float32 `yaml:"temperature_coeff" json:"temperature_coeff"`
+	TemperatureOffset float32 `yaml:"temperature_offset" json:"temperature_offset"`
+}
+
+// BuildingStorageRule :
+// Describes the storage capacity brought by a building
+// for a resource.
+type BuildingStorageRule struct {
+	Resource   string  `yaml:"resource" json:"resource"`
+	Base       int     `yaml:"base" json:"base"`
+	Multiplier float32 `yaml:"multiplier" json:"multiplier"`
+	Progress   float32 `yaml:"progress" json:"progress"`
+}
+
+// BuildingFieldsRule :
+// Describes the additional fields brought by each level
+// of a building.
+type BuildingFieldsRule struct {
+	Multiplier float32 `yaml:"multiplier" json:"multiplier"`
+	Constant   int     `yaml:"constant" json:"constant"`
+}
+
+// BuildingRule :
+// Describes a building in a rule set.
+type BuildingRule struct {
+	Name              string                   `yaml:"name" json:"name"`
+	BuildableOnPlanet bool                     `yaml:"buildable_on_planet" json:"buildable_on_planet"`
+	BuildableOnMoon   bool                     `yaml:"buildable_on_moon" json:"buildable_on_moon"`
+	Costs             map[string]int           `yaml:"costs" json:"costs,omitempty"`
+	CostProgress      float32                  `yaml:"cost_progress" json:"cost_progress"`
+	Production        []BuildingProductionRule `yaml:"production" json:"production,omitempty"`
+	Storage           []BuildingStorageRule    `yaml:"storage" json:"storage,omitempty"`
+	Fields            *BuildingFieldsRule      `yaml:"fields" json:"fields,omitempty"`
+	Requirements      Requirements             `yaml:"requirements" json:"requirements"`
+}
+
+// TechnologyRule :
+// Describes a technology in a rule set.
+type TechnologyRule struct {
+	Name         string         `yaml:"name" json:"name"`
+	Costs        map[string]int `yaml:"costs" json:"costs,omitempty"`
+	CostProgress float32        `yaml:"cost_progress" json:"cost_progress"`
+	Requirements Requirements   `yaml:"requirements" json:"requirements"`
+}
+
+// PropulsionRule :
+// Describes one of the propulsion systems that can be
+// used by a ship.
+type PropulsionRule struct {
+	Technology string `yaml:"technology" json:"technology"`
+	Speed      int    `yaml:"speed" json:"speed"`
+	MinLevel   int    `yaml:"min_level" json:"min_level"`
+	Rank       int    `yaml:"rank" json:"rank"`
+}
+
+// RapidFireRule :
+// Describes the rapid fire of a ship against other ships
+// and defenses, referenced by their names.
+type RapidFireRule struct {
+	Ships    map[string]int `yaml:"ships" json:"ships,omitempty"`
+	Defenses map[string]int `yaml:"defenses" json:"defenses,omitempty"`
+}
+
+// ShipRule :
+// Describes a ship in a rule set.
+type ShipRule struct {
+	Name         string             `yaml:"name" json:"name"`
+	Cargo        int                `yaml:"cargo" json:"cargo"`
+	Shield       int                `yaml:"shield" json:"shield"`
+	Weapon       int                `yaml:"weapon" json:"weapon"`
+	Costs        map[string]int     `yaml:"costs" json:"costs,omitempty"`
+	Propulsion   []PropulsionRule   `yaml:"propulsion" json:"propulsion,omitempty"`
+	Consumption  map[string]int     `yaml:"consumption" json:"consumption,omitempty"`
+	Deployment   map[string]float32 `yaml:"deployment" json:"deployment,omitempty"`
+	RapidFire    RapidFireRule      `yaml:"rapid_fire" json:"rapid_fire"`
+	Requirements Requirements       `yaml:"requirements" json:"requirements"`
+}
+
+// DefenseRule :
+// Describes a defense in a rule set.
+type DefenseRule struct {
+	Name         string         `yaml:"name" json:"name"`
+	Shield       int            `yaml:"shield" json:"shield"`
+	Weapon       int            `yaml:"weapon" json:"weapon"`
+	Costs        map[string]int `yaml:"costs" json:"costs,omitempty"`
+	Requirements Requirements   `yaml:"requirements" json:"requirements"`
+}
+
+// ErrInvalidRuleSet :
+// Used to indicate that a rule set is not consistent.
+var ErrInvalidRuleSet = fmt.Errorf("invalid rule set")
+
+// invalid :
+// Convenience method to produce a detailed error when
+// the rule set is not valid.
+//
+// The `format` and `args` define the description of
+// the problem.
+//
+// Returns the produced error.
+func (rs RuleSet) invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%v \"%s\": %s", ErrInvalidRuleSet, rs.Name, fmt.Sprintf(format, args...))
+}
+
+// Validate :
+// Used to verify that the rule set is consistent: all
+// the names are unique, the costs reference existing
+// resources, the dependencies reference existing items
+// and do not form cycles and the rapid fire targets do
+// exist.
+//
+// Returns any error.
+func (rs RuleSet) Validate() error {
+	if rs.Name == "" {
+		return rs.invalid("no name")
+	}
+	if rs.Version <= 0 {
+		return rs.invalid("invalid version %d", rs.Version)
+	}
+
+	resources, err := rs.names("resource", len(rs.Resources), func(id int) string { return rs.Resources[id].Name })
+	if err != nil {
+		return err
+	}
+	buildings, err := rs.names("building", len(rs.Buildings), func(id int) string { return rs.Buildings[id].Name })
+	if err != nil {
+		return err
+	}
+	technologies, err := rs.names("technology", len(rs.Technologies), func(id int) string { return rs.Technologies[id].Name })
+	if err != nil {
+		return err
+	}
+	ships, err := rs.names("ship", len(rs.Ships), func(id int) string { return rs.Ships[id].Name })
+	if err != nil {
+		return err
+	}
+	defenses, err := rs.names("defense", len(rs.Defenses), func(id int) string { return rs.Defenses[id].Name })
+	if err != nil {
+		return err
+	}
+
+	// Verify each element.
+	for _, b := range rs.Buildings {
+		if err := rs.validateCosts(b.Name, b.Costs, b.CostProgress, resources); err != nil {
+			return err
+		}
+		if err := rs.validateRequirements(b.Name, b.Requirements, buildings, technologies); err != nil {
+			return err
+		}
+
+		for _, p := range b.Production {
+			if !resources[p.Resource] {
+				return rs.invalid("\"%s\" produces unknown resource \"%s\"", b.Name, p.Resource)
+			}
+		}
+		for _, s := range b.Storage {
+			if !resources[s.Resource] {
+				return rs.invalid("\"%s\" stores unknown resource \"%s\"", b.Name, s.Resource)
+			}
+		}
+	}
+
+	for _, t := range rs.Technologies {
+		if err := rs.validateCosts(t.Name, t.Costs, t.CostProgress, resources); err != nil {
+			return err
+		}
+		if err := rs.validateRequirements(t.Name, t.Requirements, buildings, technologies); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range rs.Ships {
+		// Ships have fixed costs.
+		if err := rs.validateCosts(s.Name, s.Costs, 1.0, resources); err != nil {
+			return err
+		}
+		if err := rs.validateRequirements(s.Name, s.Requirements, buildings, technologies); err != nil {
+			return err
+		}
+
+		if len(s.Propulsion) == 0 {
+			return rs.invalid("\"%s\" has no propulsion", s.Name)
+		}
+		for _, p := range s.Propulsion {
+			if !technologies[p.Technology] {
+				return rs.invalid("\"%s\" uses unknown propulsion \"%s\"", s.Name, p.Technology)
+			}
+			if p.Speed < 0 || p.MinLevel < 0 {
+				return rs.invalid("\"%s\" has invalid propulsion \"%s\"", s.Name, p.Technology)
+			}
+		}
+
+		for res := range s.Consumption {
+			if !resources[res] {
+				return rs.invalid("\"%s\" consumes unknown resource \"%s\"", s.Name, res)
+			}
+		}
+		for res := range s.Deployment {
+			if !resources[res] {
+				return rs.invalid("\"%s\" deploys with unknown resource \"%s\"", s.Name, res)
+			}
+		}
+
+		for target, rf := range s.RapidFire.Ships {
+			if !ships[target] || rf <= 1 {
+				return rs.invalid("\"%s\" has invalid rapid fire against \"%s\"", s.Name, target)
+			}
+		}
+		for target, rf := range s.RapidFire.Defenses {
+			if !defenses[target] || rf <= 1 {
+				return rs.invalid("\"%s\" has invalid rapid fire against \"%s\"", s.Name, target)
+			}
+		}
+	}
+
+	for _, d := range rs.Defenses {
+		if err := rs.validateCosts(d.Name, d.Costs, 1.0, resources); err != nil {
+			return err
+		}
+		if err := rs.validateRequirements(d.Name, d.Requirements, buildings, technologies); err != nil {
+			return err
+		}
+	}
+
+	for tech, increase := range rs.PropulsionIncrease {
+		if !technologies[tech] || increase <= 0 {
+			return rs.invalid("invalid propulsion increase for \"%s\"", tech)
+		}
+	}
+
+	return rs.validateTechTree()
+}
+
+// names :
+// Used to gather the names of a category of elements
+// and verify that they are unique.
+//
+// The `kind` defines the category of elements.
+//
+// The `count` defines the number of elements.
+//
+// The `name` allows to access the name of an element.
+//
+// Returns the set of names along with any error.
+func (rs RuleSet) names(kind string, count int, name func(id int) string) (map[string]bool, error) {
+	names := make(map[string]bool)
+
+	for id := 0; id < count; id++ {
+		n := name(id)
+
+		if n == "" {
+			return names, rs.invalid("%s with no name", kind)
+		}
+		if names[n] {
+			return names, rs.invalid("duplicated %s \"%s\"", kind, n)
+		}
+
+		names[n] = true
+	}
+
+	return names, nil
+}
+
+// validateCosts :
+// Used to verify that the costs of an element are all
+// referencing existing resources with a valid amount.
+//
+// The `elem` defines the name of the element.
+//
+// The `costs` defines the costs to verify.
+//
+// The `progress` defines the progression rule of the
+// costs.
+//
+// The `resources` defines the existing resources.
+//
+// Returns any error.
+func (rs RuleSet) validateCosts(elem string, costs map[string]int, progress float32, resources map[string]bool) error {
+	if len(costs) == 0 {
+		return rs.invalid("\"%s\" has no cost", elem)
+	}
+	if progress <= 0.0 {
+		return rs.invalid("\"%s\" has invalid cost progress %f", elem, progress)
+	}
+
+	for res, amount := range costs {
+		if !resources[res] {
+			return rs.invalid("\"%s\" costs unknown resource \"%s\"", elem, res)
+		}
+		if amount <= 0 {
+			return rs.invalid("\"%s\" has invalid cost %d for \"%s\"", elem, amount, res)
+		}
+	}
+
+	return nil
+}
+
+// validateRequirements :
+// Used to verify that the requirements of an element
+// are all referencing existing elements.
+//
+// The `elem` defines the name of the element.
+//
+// The `reqs` defines the requirements to verify.
+//
+// The `buildings` and `technologies` define the names
+// of the existing elements.
+//
+// Returns any error.
+func (rs RuleSet) validateRequirements(elem string, reqs Requirements, buildings map[string]bool, technologies map[string]bool) error {
+	for b, level := range reqs.Buildings {
+		if !buildings[b] || level <= 0 {
+			return rs.invalid("\"%s\" has invalid dependency on \"%s\"", elem, b)
+		}
+	}
+	for t, level := range reqs.Technologies {
+		if !technologies[t] || level <= 0 {
+			return rs.invalid("\"%s\" has invalid dependency on \"%s\"", elem, t)
+		}
+	}
+
+	return nil
+}
+
+// validateTechTree :
+// Used to verify that the dependencies between the
+// buildings and technologies do not form any cycle
+// which would make some elements unreachable.
+//
+// Returns any error.
+func (rs RuleSet) validateTechTree() error {
+	// Build the graph of dependencies: buildings and
+	// technologies are prefixed to avoid conflicts in
+	// the names.
+	graph := make(map[string][]string)
+
+	register := func(node string, reqs Requirements) {
+		for b := range reqs.Buildings {
+			graph[node] = append(graph[node], "building:"+b)
+		}
+		for t := range reqs.Technologies {
+			graph[node] = append(graph[node], "technology:"+t)
+		}
+	}
+
+	for _, b := range rs.Buildings {
+		register("building:"+b.Name, b.Requirements)
+	}
+	for _, t := range rs.Technologies {
+		register("technology:"+t.Name, t.Requirements)
+	}
+
+	// Traverse the graph in depth: a node that is met
+	// again while being visited indicates a cycle.
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+
+	var visit func(node string) error
+	visit = func(node string) error {
+		switch state[node] {
+		case visiting:
+			return rs.invalid("cyclic dependency on \"%s\"", node)
+		case visited:
+			return nil
+		}
+
+		state[node] = visiting
+		for _, dep := range graph[node] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[node] = visited
+
+		return nil
+	}
+
+	for node := range graph {
+		if err := visit(node); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
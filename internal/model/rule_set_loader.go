@@ -0,0 +1,262 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"oglike_server/pkg/db"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrUnknownRuleSet :
+// Used to indicate that a rule set could not be found.
+var ErrUnknownRuleSet = fmt.Errorf("unknown rule set")
+
+// LoadRuleSets :
+// Used to load all the rule sets defined in the input
+// directory. Files with a `.yml`, `.yaml` or `.json`
+// extension are considered. Rule sets extending some
+// other rule set are resolved and each of them is then
+// validated.
+//
+// The `dir` defines the directory to scan.
+//
+// Returns the rule sets indexed by their name along with
+// any error.
+func LoadRuleSets(dir string) (map[string]RuleSet, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]RuleSet)
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if ext != ".yml" && ext != ".yaml" && ext != ".json" {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var rs RuleSet
+		if ext == ".json" {
+			err = json.Unmarshal(content, &rs)
+		} else {
+			err = yaml.UnmarshalStrict(content, &rs)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse rule set \"%s\" (err: %v)", file.Name(), err)
+		}
+
+		if _, ok := raw[rs.Name]; ok {
+			return nil, rs.invalid("defined in several files")
+		}
+
+		raw[rs.Name] = rs
+	}
+
+	// Resolve inheritance and validate each rule set.
+	ruleSets := make(map[string]RuleSet)
+
+	for name := range raw {
+		rs, err := resolveRuleSet(name, raw, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := rs.Validate(); err != nil {
+			return nil, err
+		}
+
+		ruleSets[name] = rs
+	}
+
+	return ruleSets, nil
+}
+
+// resolveRuleSet :
+// Used to produce the complete description of a rule
+// set by merging it with the rule set it extends if
+// any.
+//
+// The `name` defines the name of the rule set.
+//
+// The `raw` defines all the rule sets as described in
+// the files.
+//
+// The `seen` defines the rule sets already traversed
+// and allows to detect cyclic inheritance.
+//
+// Returns the resolved rule set along with any error.
+func resolveRuleSet(name string, raw map[string]RuleSet, seen map[string]bool) (RuleSet, error) {
+	rs, ok := raw[name]
+	if !ok {
+		return rs, fmt.Errorf("%v \"%s\"", ErrUnknownRuleSet, name)
+	}
+
+	if seen[name] {
+		return rs, rs.invalid("cyclic inheritance")
+	}
+	seen[name] = true
+
+	if rs.Extends == "" {
+		return rs, nil
+	}
+
+	base, err := resolveRuleSet(rs.Extends, raw, seen)
+	if err != nil {
+		return rs, err
+	}
+
+	return base.merge(rs), nil
+}
+
+// merge :
+// Used to override the elements of this rule set with
+// the ones defined in the input rule set. Elements are
+// matched by name and the ones that do not exist yet in
+// this rule set are appended.
+//
+// The `o` defines the rule set to merge.
+//
+// Returns the merged rule set which has the name and
+// version of `o`.
+func (rs RuleSet) merge(o RuleSet) RuleSet {
+	out := RuleSet{
+		Name:               o.Name,
+		Version:            o.Version,
+		PropulsionIncrease: make(map[string]int),
+	}
+
+	out.Resources = mergeByName(rs.Resources, o.Resources).([]ResourceRule)
+	out.Buildings = mergeByName(rs.Buildings, o.Buildings).([]BuildingRule)
+	out.Technologies = mergeByName(rs.Technologies, o.Technologies).([]TechnologyRule)
+	out.Ships = mergeByName(rs.Ships, o.Ships).([]ShipRule)
+	out.Defenses = mergeByName(rs.Defenses, o.Defenses).([]DefenseRule)
+
+	for tech, increase := range rs.PropulsionIncrease {
+		out.PropulsionIncrease[tech] = increase
+	}
+	for tech, increase := range o.PropulsionIncrease {
+		out.PropulsionIncrease[tech] = increase
+	}
+
+	return out
+}
+
+// mergeByName :
+// Used to override the elements of the base slice with
+// the ones of the input slice. Elements are matched on
+// their `Name` field and the ones that do not exist in
+// the base slice are appended (duplicates are detected
+// when the rule set is validated). The base slice is
+// left untouched.
+//
+// The `base` defines the slice of elements to override.
+//
+// The `overrides` defines the slice of elements that
+// replace the base ones. It should have the same type
+// as `base`.
+//
+// Returns the merged slice, with the same type as the
+// input slices.
+func mergeByName(base interface{}, overrides interface{}) interface{} {
+	b := reflect.ValueOf(base)
+	o := reflect.ValueOf(overrides)
+
+	out := reflect.MakeSlice(b.Type(), 0, b.Len()+o.Len())
+	out = reflect.AppendSlice(out, b)
+
+	indices := make(map[string]int)
+	for id := 0; id < out.Len(); id++ {
+		indices[out.Index(id).FieldByName("Name").String()] = id
+	}
+
+	for id := 0; id < o.Len(); id++ {
+		elem := o.Index(id)
+		name := elem.FieldByName("Name").String()
+
+		if existing, ok := indices[name]; ok {
+			out.Index(existing).Set(elem)
+			continue
+		}
+
+		out = reflect.Append(out, elem)
+	}
+
+	return out.Interface()
+}
+
+// SyncRuleSet :
+// Used to synchronize the content of the DB with the
+// input rule set. In case the DB already contains the
+// same version of the rule set nothing happens so that
+// this method can safely be called at each start of
+// the server.
+//
+// The `rs` defines the rule set to synchronize.
+//
+// The `proxy` defines a way to access to the DB.
+//
+// Returns whether the DB was modified along with any
+// error.
+func SyncRuleSet(rs RuleSet, proxy db.Proxy) (bool, error) {
+	// Fetch the version of the rule set in the DB.
+	query := db.QueryDesc{
+		Props: []string{
+			"version",
+		},
+		Table: "rule_sets",
+		Filters: []db.Filter{
+			{
+				Key:    "name",
+				Values: []interface{}{rs.Name},
+			},
+		},
+	}
+
+	dbRes, err := proxy.FetchFromDB(query)
+	if err != nil {
+		return false, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return false, dbRes.Err
+	}
+
+	version := 0
+	for dbRes.Next() {
+		if err := dbRes.Scan(&version); err != nil {
+			return false, err
+		}
+	}
+
+	if version == rs.Version {
+		return false, nil
+	}
+
+	// Synchronize the data model.
+	sync := db.InsertReq{
+		Script: "sync_rule_set",
+		Args: []interface{}{
+			rs,
+		},
+	}
+
+	err = proxy.InsertToDB(sync)
+
+	return err == nil, err
+}
@@ -2,9 +2,9 @@ package routes
 
 import (
 	"encoding/json"
-	"net/http"
 	"oglike_server/internal/game"
 	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
 )
 
 // listAccounts :
@@ -12,7 +12,7 @@ import (
 // the requests on accounts.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listAccounts() http.HandlerFunc {
+func (s *Server) listAccounts() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("accounts")
 
@@ -25,12 +25,12 @@ func (s *Server) listAccounts() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("accounts").WithLocker(s.og)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.accounts.Accounts(filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.accounts.For(log).Accounts(filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // listAccountsPlayers :
@@ -38,7 +38,7 @@ func (s *Server) listAccounts() http.HandlerFunc {
 // the requests on players linked to an account.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listAccountsPlayers() http.HandlerFunc {
+func (s *Server) listAccountsPlayers() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("accounts")
 
@@ -49,12 +49,12 @@ func (s *Server) listAccountsPlayers() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithIDFilter("account").WithModule("accounts").WithLocker(s.og)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.players.Players(filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.players.For(log).Players(filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // createAccount :
@@ -62,14 +62,14 @@ func (s *Server) listAccountsPlayers() http.HandlerFunc {
 // the requests to create accounts.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) createAccount() http.HandlerFunc {
+func (s *Server) createAccount() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewCreateResourceEndpoint("accounts")
 
 	// Configure the endpoint.
 	ed.WithDataKey("account-data").WithModule("accounts").WithLocker(s.og)
 	ed.WithCreationFunc(
-		func(input RouteData) ([]string, error) {
+		func(input RouteData, log logger.Logger) ([]string, error) {
 			// We need to iterate over the data retrieved from the route and
 			// create accounts from it.
 			var acc game.Account
@@ -88,7 +88,7 @@ func (s *Server) createAccount() http.HandlerFunc {
 				}
 
 				// Create the account.
-				res, err := s.accounts.Create(acc)
+				res, err := s.accounts.For(log).Create(acc)
 				if err != nil {
 					return resources, err
 				}
@@ -102,7 +102,7 @@ func (s *Server) createAccount() http.HandlerFunc {
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // changeAccounts :
@@ -110,14 +110,14 @@ func (s *Server) createAccount() http.HandlerFunc {
 // the requests to change an account.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) changeAccounts() http.HandlerFunc {
+func (s *Server) changeAccounts() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewCreateResourceEndpoint("accounts")
 
 	// Configure the endpoint.
 	ed.WithDataKey("account-data").WithModule("accounts").WithLocker(s.og)
 	ed.WithCreationFunc(
-		func(input RouteData) ([]string, error) {
+		func(input RouteData, log logger.Logger) ([]string, error) {
 			// We need to iterate over the data retrieved from the route and
 			// create accounts from it.
 			var acc game.Account
@@ -146,7 +146,7 @@ func (s *Server) changeAccounts() http.HandlerFunc {
 				acc.ID = accID
 
 				// Update the account.
-				res, err := s.accounts.Update(acc)
+				res, err := s.accounts.For(log).Update(acc)
 				if err != nil {
 					return resources, err
 				}
@@ -160,5 +160,5 @@ func (s *Server) changeAccounts() http.HandlerFunc {
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
@@ -2,8 +2,8 @@ package routes
 
 import (
 	"encoding/json"
-	"net/http"
 	"oglike_server/internal/game"
+	"oglike_server/pkg/logger"
 )
 
 // registerFunc :
@@ -24,8 +24,16 @@ import (
 // these tokens to extract some info about the caller
 // for whicht he action should be registered (so for
 // a player in the case of technology, a planet in the
-// case of buildings, ships and defenses).
-type registerFunc func(input string, routeTokens []string) (string, error)
+// case of buildings, ships and defenses). The logger
+// attached to the request is provided as well.
+type registerFunc func(input string, routeTokens []string, log logger.Logger) (string, error)
+
+// previewActionFunc :
+// Similar to the `registerFunc` but used to validate the
+// action described by the input string without actually
+// registering it. The preview of the action is returned
+// in case the input data can be interpreted.
+type previewActionFunc func(input string, routeTokens []string, log logger.Logger) (game.ActionPreview, error)
 
 // registerUpgradeAction :
 // Used to perform the creation of a handler allowing to serve
@@ -36,15 +44,18 @@ type registerFunc func(input string, routeTokens []string) (string, error)
 // The `f` defines the registration function to apply when the
 // upgrade action should be created.
 //
+// The `pf` defines the function to apply when the upgrade action
+// should only be validated (in case of a dry run).
+//
 // Returns the handler to execute to perform said requests.
-func (s *Server) registerUpgradeAction(f registerFunc) http.HandlerFunc {
+func (s *Server) registerUpgradeAction(f registerFunc, pf previewActionFunc) endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewCreateResourceEndpoint("planets")
 
 	// Configure the endpoint.
 	ed.WithDataKey("action-data").WithModule("actions").WithLocker(s.og)
 	ed.WithCreationFunc(
-		func(input RouteData) ([]string, error) {
+		func(input RouteData, log logger.Logger) ([]string, error) {
 			// We need to iterate over the data retrieved from the route and
 			// create actions from it.
 			resources := make([]string, 0)
@@ -57,7 +68,7 @@ func (s *Server) registerUpgradeAction(f registerFunc) http.HandlerFunc {
 			for _, rawData := range input.Data {
 				// Unmarshal and perform the creation using the provided
 				// registration function.
-				res, err := f(rawData, input.ExtraElems)
+				res, err := f(rawData, input.ExtraElems, log)
 				if err != nil {
 					return resources, err
 				}
@@ -70,8 +81,29 @@ func (s *Server) registerUpgradeAction(f registerFunc) http.HandlerFunc {
 			return resources, nil
 		},
 	)
+	ed.WithPreviewFunc(
+		func(input RouteData, log logger.Logger) (interface{}, error) {
+			// Validate each action without registering them.
+			previews := make([]game.ActionPreview, 0)
+
+			if len(input.Data) == 0 {
+				return previews, ErrNoData
+			}
 
-	return ed.ServeRoute(s.log)
+			for _, rawData := range input.Data {
+				preview, err := pf(rawData, input.ExtraElems, log)
+				if err != nil {
+					return previews, err
+				}
+
+				previews = append(previews, preview)
+			}
+
+			return previews, nil
+		},
+	)
+
+	return ed
 }
 
 // registerBuildingAction :
@@ -79,30 +111,47 @@ func (s *Server) registerUpgradeAction(f registerFunc) http.HandlerFunc {
 // the requests to create building upgrade actions.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) registerBuildingAction() http.HandlerFunc {
-	return s.registerUpgradeAction(
-		func(input string, routeTokens []string) (string, error) {
-			// Unmarshal the input data into a building upgrade action
-			// and perform the registration through the dedicated func.
-			var action game.BuildingAction
+func (s *Server) registerBuildingAction() endpoint {
+	decode := func(input string, routeTokens []string) (game.BuildingAction, error) {
+		// Unmarshal the input data into a building upgrade action
+		// and perform the registration through the dedicated func.
+		var action game.BuildingAction
 
-			err := json.Unmarshal([]byte(input), &action)
-			if err != nil {
-				return "", ErrInvalidData
-			}
+		err := json.Unmarshal([]byte(input), &action)
+		if err != nil {
+			return action, ErrInvalidData
+		}
+
+		// The `routeTokens` should provide the planet's id
+		// so we can override any value provided by the act
+		// itself to maintain consistency.
+		if len(routeTokens) > 0 {
+			action.Planet = routeTokens[0]
+		}
+
+		return action, nil
+	}
 
-			// The `routeTokens` should provide the planet's id
-			// so we can override any value provided by the act
-			// itself to maintain consistency.
-			if len(routeTokens) > 0 {
-				action.Planet = routeTokens[0]
+	return s.registerUpgradeAction(
+		func(input string, routeTokens []string, log logger.Logger) (string, error) {
+			action, err := decode(input, routeTokens)
+			if err != nil {
+				return "", err
 			}
 
 			// Create the upgrade action.
-			_, err = s.actions.CreateBuildingAction(action)
+			_, err = s.actions.For(log).CreateBuildingAction(action)
 
 			return action.Planet, err
 		},
+		func(input string, routeTokens []string, log logger.Logger) (game.ActionPreview, error) {
+			action, err := decode(input, routeTokens)
+			if err != nil {
+				return game.ActionPreview{}, err
+			}
+
+			return s.actions.For(log).PreviewBuildingAction(action), nil
+		},
 	)
 }
 
@@ -111,30 +160,47 @@ func (s *Server) registerBuildingAction() http.HandlerFunc {
 // the requests to create technology upgrade actions.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) registerTechnologyAction() http.HandlerFunc {
-	return s.registerUpgradeAction(
-		func(input string, routeTokens []string) (string, error) {
-			// Unmarshal the input data into a technology upgrade action
-			// and perform the registration through the dedicated func.
-			var action game.TechnologyAction
+func (s *Server) registerTechnologyAction() endpoint {
+	decode := func(input string, routeTokens []string) (game.TechnologyAction, error) {
+		// Unmarshal the input data into a technology upgrade action
+		// and perform the registration through the dedicated func.
+		var action game.TechnologyAction
 
-			err := json.Unmarshal([]byte(input), &action)
-			if err != nil {
-				return "", ErrInvalidData
-			}
+		err := json.Unmarshal([]byte(input), &action)
+		if err != nil {
+			return action, ErrInvalidData
+		}
+
+		// The `routeTokens` should provide both the player and
+		// the planet's id so we can override any value provided
+		// in the upgrade action.
+		if len(routeTokens) > 0 {
+			action.Planet = routeTokens[0]
+		}
 
-			// The `routeTokens` should provide both the player and
-			// the planet's id so we can override any value provided
-			// in the upgrade action.
-			if len(routeTokens) > 0 {
-				action.Planet = routeTokens[0]
+		return action, nil
+	}
+
+	return s.registerUpgradeAction(
+		func(input string, routeTokens []string, log logger.Logger) (string, error) {
+			action, err := decode(input, routeTokens)
+			if err != nil {
+				return "", err
 			}
 
 			// Create the upgrade action.
-			_, err = s.actions.CreateTechnologyAction(action)
+			_, err = s.actions.For(log).CreateTechnologyAction(action)
 
 			return action.Planet, err
 		},
+		func(input string, routeTokens []string, log logger.Logger) (game.ActionPreview, error) {
+			action, err := decode(input, routeTokens)
+			if err != nil {
+				return game.ActionPreview{}, err
+			}
+
+			return s.actions.For(log).PreviewTechnologyAction(action), nil
+		},
 	)
 }
 
@@ -143,31 +209,48 @@ func (s *Server) registerTechnologyAction() http.HandlerFunc {
 // the requests to create ship upgrade actions.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) registerShipAction() http.HandlerFunc {
-	return s.registerUpgradeAction(
-		func(input string, routeTokens []string) (string, error) {
-			// Unmarshal the input data into a ship upgrade action
-			// and perform the registration through the dedicated
-			// function.
-			var action game.ShipAction
+func (s *Server) registerShipAction() endpoint {
+	decode := func(input string, routeTokens []string) (game.ShipAction, error) {
+		// Unmarshal the input data into a ship upgrade action
+		// and perform the registration through the dedicated
+		// function.
+		var action game.ShipAction
 
-			err := json.Unmarshal([]byte(input), &action)
-			if err != nil {
-				return "", ErrInvalidData
-			}
+		err := json.Unmarshal([]byte(input), &action)
+		if err != nil {
+			return action, ErrInvalidData
+		}
+
+		// The `routeTokens` should provide the planet's id
+		// so we can override any value provided by the act
+		// itself to maintain consistency.
+		if len(routeTokens) > 0 {
+			action.Planet = routeTokens[0]
+		}
+
+		return action, nil
+	}
 
-			// The `routeTokens` should provide the planet's id
-			// so we can override any value provided by the act
-			// itself to maintain consistency.
-			if len(routeTokens) > 0 {
-				action.Planet = routeTokens[0]
+	return s.registerUpgradeAction(
+		func(input string, routeTokens []string, log logger.Logger) (string, error) {
+			action, err := decode(input, routeTokens)
+			if err != nil {
+				return "", err
 			}
 
 			// Create the upgrade action.
-			_, err = s.actions.CreateShipAction(action)
+			_, err = s.actions.For(log).CreateShipAction(action)
 
 			return action.Planet, err
 		},
+		func(input string, routeTokens []string, log logger.Logger) (game.ActionPreview, error) {
+			action, err := decode(input, routeTokens)
+			if err != nil {
+				return game.ActionPreview{}, err
+			}
+
+			return s.actions.For(log).PreviewShipAction(action), nil
+		},
 	)
 }
 
@@ -176,30 +259,47 @@ func (s *Server) registerShipAction() http.HandlerFunc {
 // the requests to create defense upgrade actions.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) registerDefenseAction() http.HandlerFunc {
-	return s.registerUpgradeAction(
-		func(input string, routeTokens []string) (string, error) {
-			// Unmarshal the input data into a defense upgrade
-			// action and perform the registration through the
-			// dedicated function.
-			var action game.DefenseAction
+func (s *Server) registerDefenseAction() endpoint {
+	decode := func(input string, routeTokens []string) (game.DefenseAction, error) {
+		// Unmarshal the input data into a defense upgrade
+		// action and perform the registration through the
+		// dedicated function.
+		var action game.DefenseAction
 
-			err := json.Unmarshal([]byte(input), &action)
-			if err != nil {
-				return "", ErrInvalidData
-			}
+		err := json.Unmarshal([]byte(input), &action)
+		if err != nil {
+			return action, ErrInvalidData
+		}
+
+		// The `routeTokens` should provide the planet's id
+		// so we can override any value provided by the act
+		// itself to maintain consistency.
+		if len(routeTokens) > 0 {
+			action.Planet = routeTokens[0]
+		}
 
-			// The `routeTokens` should provide the planet's id
-			// so we can override any value provided by the act
-			// itself to maintain consistency.
-			if len(routeTokens) > 0 {
-				action.Planet = routeTokens[0]
+		return action, nil
+	}
+
+	return s.registerUpgradeAction(
+		func(input string, routeTokens []string, log logger.Logger) (string, error) {
+			action, err := decode(input, routeTokens)
+			if err != nil {
+				return "", err
 			}
 
 			// Create the upgrade action.
-			_, err = s.actions.CreateDefenseAction(action)
+			_, err = s.actions.For(log).CreateDefenseAction(action)
 
 			return action.Planet, err
 		},
+		func(input string, routeTokens []string, log logger.Logger) (game.ActionPreview, error) {
+			action, err := decode(input, routeTokens)
+			if err != nil {
+				return game.ActionPreview{}, err
+			}
+
+			return s.actions.For(log).PreviewDefenseAction(action), nil
+		},
 	)
 }
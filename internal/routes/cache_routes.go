@@ -0,0 +1,298 @@
+package routes
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// versionFunc :
+// Convenience define to refer to a function returning the
+// version of the data served by an endpoint. Any change
+// of the version invalidates the cached responses.
+type versionFunc func() uint32
+
+// tagFunc :
+// Convenience define to refer to a function computing a
+// weak validator for the data served by an endpoint. It
+// is used for resources which change too often to cache
+// their response but which can still be revalidated.
+type tagFunc func(data interface{}) string
+
+// modelVersion :
+// Used as the version of the responses computed from the
+// data model: they are discarded whenever the data model
+// is reloaded.
+//
+// Returns the version of the data model.
+func (s *Server) modelVersion() uint32 {
+	return s.og.Epoch()
+}
+
+// cachedResponse :
+// Holds the precomputed response to a request for some
+// static data.
+//
+// The `version` defines the version of the data at the
+// time the response was computed.
+//
+// The `tag` defines the hash of the plain response which
+// is used to build the entity tags.
+//
+// The `plain` defines the marshalled data.
+//
+// The `gzipped` defines the marshalled data compressed
+// with gzip.
+type cachedResponse struct {
+	version uint32
+	tag     string
+	plain   []byte
+	gzipped []byte
+}
+
+// responseCache :
+// Keeps the responses served by an endpoint returning
+// static data so that they don't need to be marshalled
+// again for each request. Responses are keyed by path
+// and query of the request and discarded as soon as the
+// version of the data changes.
+//
+// The `version` defines the function returning the
+// current version of the data.
+//
+// The `maxAge` defines how long the clients are allowed
+// to keep a response without revalidating it.
+//
+// The `entries` defines the cached responses.
+//
+// The `locker` protects the entries from concurrent
+// accesses.
+type responseCache struct {
+	version versionFunc
+	maxAge  time.Duration
+	entries map[string]cachedResponse
+	locker  sync.RWMutex
+}
+
+// maxCachedResponses :
+// Defines the maximum number of responses kept by a cache.
+// As the key includes the query of the request it is not
+// bounded by the data itself: the cache is reset whenever
+// this size is reached.
+const maxCachedResponses = 256
+
+// newResponseCache :
+// Creates a new empty cache using the input function to
+// determine the version of the data.
+//
+// The `version` defines the version of the data.
+//
+// The `maxAge` defines the duration for which clients can
+// keep the responses.
+//
+// Returns the created cache.
+func newResponseCache(version versionFunc, maxAge time.Duration) *responseCache {
+	return &responseCache{
+		version: version,
+		maxAge:  maxAge,
+		entries: make(map[string]cachedResponse),
+	}
+}
+
+// cacheKey :
+// Used to build the key identifying the response to the
+// input request. The query parameters are sorted so that
+// their order does not matter.
+//
+// The `r` defines the request.
+//
+// Returns the key of the request.
+func cacheKey(r *http.Request) string {
+	return r.URL.Path + "?" + r.URL.Query().Encode()
+}
+
+// get :
+// Used to retrieve the response to the request with the
+// input key if it is still valid.
+//
+// The `key` defines the key of the request.
+//
+// Returns the response and whether it was found.
+func (rc *responseCache) get(key string) (cachedResponse, bool) {
+	rc.locker.RLock()
+	defer rc.locker.RUnlock()
+
+	res, ok := rc.entries[key]
+	if !ok || res.version != rc.version() {
+		return cachedResponse{}, false
+	}
+
+	return res, true
+}
+
+// put :
+// Used to register the response to the request with the
+// input key. Responses computed for an outdated version
+// are removed in the process.
+//
+// The `key` defines the key of the request.
+//
+// The `res` defines the response.
+func (rc *responseCache) put(key string, res cachedResponse) {
+	rc.locker.Lock()
+	defer rc.locker.Unlock()
+
+	version := rc.version()
+	if res.version != version {
+		return
+	}
+
+	for k, e := range rc.entries {
+		if e.version != version {
+			delete(rc.entries, k)
+		}
+	}
+
+	if len(rc.entries) >= maxCachedResponses {
+		rc.entries = make(map[string]cachedResponse)
+	}
+
+	rc.entries[key] = res
+}
+
+// precompute :
+// Used to marshal the input data and compress it so that
+// it can be kept in a cache.
+//
+// The `data` defines the data to marshal.
+//
+// The `version` defines the version of the data.
+//
+// Returns the response along with any error.
+func precompute(data interface{}, version uint32) (cachedResponse, error) {
+	out, err := json.Marshal(data)
+	if err != nil {
+		return cachedResponse{}, ErrMarshallingError
+	}
+
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+
+	_, err = gz.Write(out)
+	gz.Close()
+
+	if err != nil {
+		return cachedResponse{}, ErrGzipCompressionError
+	}
+
+	hash := sha256.Sum256(out)
+
+	res := cachedResponse{
+		version: version,
+		tag:     hex.EncodeToString(hash[:16]),
+		plain:   out,
+		gzipped: b.Bytes(),
+	}
+
+	return res, nil
+}
+
+// send :
+// Used to send the cached response to the client. The
+// response carries a strong entity tag which differs
+// for the plain and the gzipped representations and a
+// `304` is returned if the client already holds it.
+//
+// The `res` defines the response to send.
+//
+// The `w` defines the response writer.
+//
+// The `r` defines the request.
+//
+// Returns any error.
+func (rc *responseCache) send(res cachedResponse, w http.ResponseWriter, r *http.Request) error {
+	out := res.plain
+	etag := fmt.Sprintf("\"%s\"", res.tag)
+
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		out = res.gzipped
+		etag = fmt.Sprintf("\"%s-gzip\"", res.tag)
+
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(rc.maxAge.Seconds())))
+	w.Header().Set("Vary", "Accept-Encoding")
+
+	if matchesETag(r, etag) {
+		w.Header().Del("Content-Encoding")
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(out)))
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(out)
+	if err != nil {
+		return ErrWriteError
+	}
+
+	return nil
+}
+
+// matchesETag :
+// Used to determine whether the input entity tag is one
+// of the tags listed in the `If-None-Match` header of the
+// request. As mandated for this header the comparison is
+// weak: the `W/` prefix is ignored.
+//
+// The `r` defines the request.
+//
+// The `etag` defines the tag of the current response.
+//
+// Returns `true` if the client already holds the response.
+func matchesETag(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// weakETag :
+// Used to build a weak entity tag from the input value.
+// The value is hashed so that no internal information is
+// leaked to the client.
+//
+// The `value` defines the value identifying the version
+// of the resource.
+//
+// Returns the entity tag.
+func weakETag(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("W/\"%s\"", hex.EncodeToString(hash[:16]))
+}
@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"oglike_server/internal/game"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+	"testing"
+	"time"
+)
+
+// fetchETag :
+// Performs a request on the input handler and returns the
+// entity tag of the response.
+func fetchETag(t *testing.T, handler http.HandlerFunc) string {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/buildings", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+
+	return w.Header().Get("ETag")
+}
+
+func TestCacheIsDiscardedOnReload(t *testing.T) {
+	s := &Server{
+		og:  game.NewInstance(db.Proxy{}, discardLogger{}),
+		log: discardLogger{},
+		config: configuration{
+			CacheMaxAge: time.Hour,
+		},
+	}
+
+	name := "metal mine"
+
+	ed := NewGetResourceEndpoint("buildings")
+	ed.WithCache(s.modelVersion, s.config.CacheMaxAge)
+	ed.WithDataFunc(
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return []string{name}, nil
+		},
+	)
+
+	handler := ed.ServeRoute(s.log)
+
+	first := fetchETag(t, handler)
+	if first == "" {
+		t.Fatalf("no entity tag in response")
+	}
+
+	// The data changes but the model is not reloaded: the
+	// cached response is still served.
+	name = "crystal mine"
+
+	if etag := fetchETag(t, handler); etag != first {
+		t.Errorf("cached response was discarded without reload (before: %s, after: %s)", first, etag)
+	}
+
+	s.og.Swap(game.Model{})
+
+	if etag := fetchETag(t, handler); etag == first {
+		t.Errorf("entity tag did not change after reload (etag: %s)", etag)
+	}
+}
@@ -1,8 +1,8 @@
 package routes
 
 import (
-	"net/http"
 	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
 )
 
 // listDefenses :
@@ -10,7 +10,7 @@ import (
 // the requests on defenses.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listDefenses() http.HandlerFunc {
+func (s *Server) listDefenses() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("defenses")
 
@@ -21,11 +21,12 @@ func (s *Server) listDefenses() http.HandlerFunc {
 
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("defenses")
+	ed.WithCache(s.modelVersion, s.config.CacheMaxAge)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.og.Defenses.Defenses(s.proxy, filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.og.Current().Defenses.Defenses(s.proxy, filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
@@ -0,0 +1,133 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"oglike_server/internal/game"
+	"oglike_server/internal/locker"
+	"oglike_server/pkg/logger"
+	"strings"
+)
+
+// locksDump :
+// Describes the state of the locks of the server at a
+// given moment.
+//
+// The `Model` defines the state of the lock protecting
+// the data model.
+//
+// The `Proxies` defines the resources locked by each of
+// the proxies of the server keyed by their name.
+type locksDump struct {
+	Model   game.LockState             `json:"model"`
+	Proxies map[string][]locker.Holder `json:"proxies"`
+}
+
+// adminOnly :
+// Used to wrap the input handler so that it can only be
+// accessed by clients providing the admin token as a
+// bearer in the `Authorization` header. In case no token
+// is configured the handler is not reachable at all.
+//
+// The `next` defines the handler to wrap.
+//
+// Returns the wrapped handler.
+func (s *Server) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.AdminToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !s.isAdmin(bearerToken(r)) {
+			logger.FromContext(r.Context(), s.log).Trace(logger.Warning, "server", fmt.Sprintf("Rejected unauthorized access to \"%v\"", r.URL))
+
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// bearerToken :
+// Used to retrieve the token provided as a bearer in the
+// `Authorization` header of the input request.
+//
+// The `r` defines the request to analyze.
+//
+// Returns the token or an empty string if none is set.
+func bearerToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// isAdmin :
+// Used to determine whether the input token matches the
+// admin token of the server. An empty token is never an
+// admin token.
+//
+// The `token` defines the token to check.
+//
+// Returns `true` if the token is the admin token.
+func (s *Server) isAdmin(token string) bool {
+	if s.config.AdminToken == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) == 1
+}
+
+// profiles :
+// Used to register the routes serving the runtime
+// profiling data of the server. The routes are only
+// accessible to admins.
+func (s *Server) profiles() {
+	s.diagnosticRoute("GET", "/debug/pprof", s.adminOnly(pprof.Index)).
+		WithDescription("Index of the runtime profiles of the server, reserved to admins.").
+		WithMediaType("text/html")
+	s.diagnosticRoute("GET", "/debug/pprof/cmdline", s.adminOnly(pprof.Cmdline)).
+		WithDescription("Command line of the server, reserved to admins.").
+		WithMediaType("text/plain")
+	s.diagnosticRoute("GET", "/debug/pprof/profile", s.adminOnly(pprof.Profile)).
+		WithDescription("CPU profile of the server, reserved to admins.").
+		WithMediaType("application/octet-stream")
+	s.diagnosticRoute("GET", "/debug/pprof/symbol", s.adminOnly(pprof.Symbol)).
+		WithDescription("Symbols of the program counters, reserved to admins.").
+		WithMediaType("text/plain")
+	s.diagnosticRoute("GET", "/debug/pprof/trace", s.adminOnly(pprof.Trace)).
+		WithDescription("Execution trace of the server, reserved to admins.").
+		WithMediaType("application/octet-stream")
+}
+
+// dumpLocks :
+// Used to create a handler returning the state of the
+// locks of the server. This includes the lock on the
+// data model and the resources locked by each proxy.
+// It does not acquire any of the locks.
+//
+// Returns the handler to serve said requests.
+func (s *Server) dumpLocks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dump := locksDump{
+			Model: s.og.LockState(),
+			Proxies: map[string][]locker.Holder{
+				"universes": s.universes.LockHolders(),
+				"accounts":  s.accounts.LockHolders(),
+				"players":   s.players.LockHolders(),
+				"planets":   s.planets.LockHolders(),
+				"fleets":    s.fleets.LockHolders(),
+				"actions":   s.actions.LockHolders(),
+				"logistics": s.logistics.LockHolders(),
+				"market":    s.market.LockHolders(),
+			},
+		}
+
+		err := marshalAndSend(dump, w, r)
+		if err != nil {
+			logger.FromContext(r.Context(), s.log).Trace(logger.Error, "server", fmt.Sprintf("Error while sending locks to client (err: %v)", err))
+		}
+	}
+}
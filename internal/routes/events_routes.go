@@ -0,0 +1,392 @@
+package routes
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"oglike_server/internal/game"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxEventsPerBatch :
+// Defines the maximum number of events fetched from the
+// DB at once when streaming events to a client.
+var maxEventsPerBatch = 100
+
+// streamPlayerEvents :
+// Used to perform the creation of a handler allowing to
+// stream the events of a player using the Server-Sent
+// Events protocol. The client can resume the stream by
+// providing the identifier of the last event received
+// either through the `Last-Event-ID` header or through
+// the `last_event_id` query parameter.
+// The stream is only served to clients providing either
+// the admin token or the token of the player, as a bearer
+// or through the `access_token` query parameter (as the
+// browsers can't set headers on an event source). In case
+// no admin token is configured the stream is disabled.
+//
+// Returns the handler that can be executed to serve said
+// requests.
+func (s *Server) streamPlayerEvents() endpoint {
+	return handlerEndpoint(func(w http.ResponseWriter, r *http.Request) {
+		// Attach the identifier of the request to the logs.
+		log := logger.FromContext(r.Context(), s.log)
+
+		vars, err := extractRouteVars("/players", r)
+		if err != nil || len(vars.ExtraElems) == 0 {
+			http.Error(w, fmt.Sprintf("%v", ErrInvalidRequest), http.StatusBadRequest)
+			return
+		}
+
+		player := vars.ExtraElems[0]
+
+		if s.config.AdminToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !s.canStreamEvents(player, r) {
+			log.Trace(logger.Warning, "events", fmt.Sprintf("Rejected unauthorized access to events of \"%s\"", player))
+
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			log.Trace(logger.Error, "events", "Streaming is not supported by the response writer")
+			http.Error(w, InternalServerErrorString, http.StatusInternalServerError)
+			return
+		}
+
+		// Determine the event from which the stream should
+		// start.
+		last, err := lastEventID(r, vars.Params)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%v", err), http.StatusBadRequest)
+			return
+		}
+
+		// Subscribe to the notifications for this player
+		// before reading anything so that no event can be
+		// missed.
+		notifications, unsubscribe := s.og.Events.Subscribe(player)
+		defer unsubscribe()
+
+		// Make sure that the player exists and fetch the
+		// last event if the client did not provide one.
+		// The events are only read from the DB: there is
+		// no need to hold the lock on the data model.
+		err = func() error {
+			players, err := s.players.For(log).Players(
+				[]db.Filter{
+					{
+						Key:    "id",
+						Values: []interface{}{player},
+					},
+				},
+			)
+			if err != nil {
+				return err
+			}
+			if len(players) != 1 {
+				return game.ErrElementNotFound
+			}
+
+			if last < 0 {
+				last, err = s.players.For(log).LastEventID(player)
+			}
+
+			return err
+		}()
+
+		if err == game.ErrElementNotFound {
+			http.Error(w, fmt.Sprintf("%v", err), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Trace(logger.Error, "events", fmt.Sprintf("Could not start events stream for \"%s\" (err: %v)", player, err))
+			http.Error(w, InternalServerErrorString, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "retry: %d\n\n", s.config.EventsPoll.Milliseconds())
+		flusher.Flush()
+
+		log.Trace(logger.Verbose, "events", fmt.Sprintf("Started events stream for \"%s\" from %d", player, last))
+
+		ticker := time.NewTicker(s.config.EventsPoll)
+		defer ticker.Stop()
+
+		// The events are only read when the broker notifies
+		// that some are available: the actions producing
+		// them are processed by the scheduler and by the
+		// requests. The first read sends the events missed
+		// by the client since its last connection.
+		pending := true
+
+		for {
+			if pending {
+				events, err := s.players.For(log).Events(player, last, maxEventsPerBatch)
+				if err != nil {
+					log.Trace(logger.Error, "events", fmt.Sprintf("Interrupting events stream for \"%s\" (err: %v)", player, err))
+					return
+				}
+
+				for _, e := range events {
+					out, err := json.Marshal(e)
+					if err != nil {
+						log.Trace(logger.Error, "events", fmt.Sprintf("Could not marshal event %d (err: %v)", e.ID, err))
+						continue
+					}
+
+					fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Kind, out)
+					last = e.ID
+				}
+
+				// A full batch might not include all the events
+				// available: read again right away.
+				pending = len(events) == maxEventsPerBatch
+			} else {
+				// Keep the connection alive.
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+
+			flusher.Flush()
+
+			if pending {
+				continue
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			case <-notifications:
+				pending = true
+			}
+		}
+	})
+}
+
+// tokenSignature :
+// Used to compute the signature of the token of the input
+// player. It is derived from the admin token so that the
+// tokens can be verified without being stored. Changing
+// the admin token revokes all the tokens of the players.
+//
+// The `account` defines the identifier of the account of
+// the player.
+//
+// The `player` defines the identifier of the player.
+//
+// Returns the signature of the token.
+func (s *Server) tokenSignature(account string, player string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.AdminToken))
+	mac.Write([]byte(account + "." + player))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// playerToken :
+// Used to compute the token authenticating the input
+// player. It embeds the identifiers of the account and
+// of the player along with their signature so that a
+// trusted front-end can hand it to the player without
+// disclosing the admin token.
+//
+// The `account` defines the identifier of the account of
+// the player.
+//
+// The `player` defines the identifier of the player.
+//
+// Returns the token of the player.
+func (s *Server) playerToken(account string, player string) string {
+	return account + "." + player + "." + s.tokenSignature(account, player)
+}
+
+// playerFromToken :
+// Used to verify the input token of a player and to
+// retrieve the account and the player it was issued
+// for. No token is valid when no admin token is set.
+//
+// The `token` defines the token to verify.
+//
+// Returns the identifiers of the account and of the
+// player along with `false` if the token is invalid.
+func (s *Server) playerFromToken(token string) (string, string, bool) {
+	if s.config.AdminToken == "" {
+		return "", "", false
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", "", false
+	}
+
+	signature := s.tokenSignature(parts[0], parts[1])
+	if subtle.ConstantTimeCompare([]byte(parts[2]), []byte(signature)) != 1 {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// accessToken :
+// Used to retrieve the token provided by the input request
+// either as a bearer or through the `access_token` query
+// parameter (as the browsers can't set headers on an event
+// source).
+//
+// The `r` defines the request to analyze.
+//
+// Returns the token or an empty string if none is found.
+func accessToken(r *http.Request) string {
+	token := bearerToken(r)
+	if token == "" {
+		token = r.URL.Query().Get("access_token")
+	}
+
+	return token
+}
+
+// canStreamEvents :
+// Used to determine whether the input request provides a
+// token allowing to stream the events of the player. The
+// token is either the admin token or the player's token.
+//
+// The `player` defines the identifier of the player.
+//
+// The `r` defines the request to analyze.
+//
+// Returns `true` if the events can be streamed.
+func (s *Server) canStreamEvents(player string, r *http.Request) bool {
+	token := accessToken(r)
+	if token == "" {
+		return false
+	}
+
+	if s.isAdmin(token) {
+		return true
+	}
+
+	_, owner, ok := s.playerFromToken(token)
+
+	return ok && owner == player
+}
+
+// playerAccess :
+// Describes the token authenticating a player.
+//
+// The `Account` defines the identifier of the account of
+// the player.
+//
+// The `Player` defines the identifier of the player.
+//
+// The `Token` defines the token to provide to access the
+// events of the player. It also identifies the account
+// of the player for the rate limits.
+type playerAccess struct {
+	Account string `json:"account"`
+	Player  string `json:"player"`
+	Token   string `json:"token"`
+}
+
+// issuePlayerToken :
+// Used to create a handler returning the token allowing
+// to stream the events of a player. It is meant to be
+// called by a trusted front-end once the player is logged
+// in and should thus be reserved to admins.
+//
+// Returns the handler to execute to serve said requests.
+func (s *Server) issuePlayerToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars, err := extractRouteVars("/debug/tokens", r)
+		if err != nil || len(vars.ExtraElems) == 0 {
+			http.Error(w, fmt.Sprintf("%v", ErrInvalidRequest), http.StatusBadRequest)
+			return
+		}
+
+		// Attach the identifier of the request to the logs.
+		log := logger.FromContext(r.Context(), s.log)
+
+		// Fetch the account of the player: it is embedded
+		// in the token.
+		players, err := s.players.For(log).Players(
+			[]db.Filter{
+				{
+					Key:    "id",
+					Values: []interface{}{vars.ExtraElems[0]},
+				},
+			},
+		)
+
+		if err != nil {
+			log.Trace(logger.Error, "events", fmt.Sprintf("Could not fetch player \"%s\" (err: %v)", vars.ExtraElems[0], err))
+			http.Error(w, InternalServerErrorString, http.StatusInternalServerError)
+			return
+		}
+		if len(players) != 1 {
+			http.NotFound(w, r)
+			return
+		}
+
+		access := playerAccess{
+			Account: players[0].Account,
+			Player:  players[0].ID,
+			Token:   s.playerToken(players[0].Account, players[0].ID),
+		}
+
+		err = marshalAndSend(access, w, r)
+		if err != nil {
+			log.Trace(logger.Error, "events", fmt.Sprintf("Error while sending token to client (err: %v)", err))
+		}
+	}
+}
+
+// lastEventID :
+// Used to retrieve the identifier of the last event
+// received by the client from the input request. It
+// is either defined through the `Last-Event-ID` header
+// or the `last_event_id` query parameter.
+//
+// The `r` defines the request to analyze.
+//
+// The `params` defines the query parameters of the req.
+//
+// Returns the identifier of the last event or `-1` if
+// none is provided along with any error.
+func lastEventID(r *http.Request, params map[string]Values) (int64, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = stringParam(params, "last_event_id")
+	}
+
+	if raw == "" {
+		return -1, nil
+	}
+
+	ID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || ID < 0 {
+		return -1, ErrInvalidQueryParameter
+	}
+
+	return ID, nil
+}
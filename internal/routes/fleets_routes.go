@@ -2,11 +2,26 @@ package routes
 
 import (
 	"encoding/json"
-	"net/http"
 	"oglike_server/internal/game"
 	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
 )
 
+// holdingOrderFunc :
+// Convenience define allowing to refer to an order given
+// by a defended player to a fleet holding at one of its
+// planets.
+//
+// The `fleet` defines the identifier of the fleet.
+//
+// The `order` defines the order's data fetched from the
+// route.
+//
+// The `log` defines the logger attached to the request.
+//
+// Returns any error.
+type holdingOrderFunc func(fleet string, order game.HoldingOrder, log logger.Logger) error
+
 // fleetCreationFunc :
 // Convenience define allowing to refer to the creation
 // process of a fleet. Depending on whether the fleet to
@@ -19,17 +34,32 @@ import (
 // The `fleet` defines the fleet's data fetched from
 // the route. This represent the resource to create.
 //
+// The `log` defines the logger attached to the request.
+//
 // The return value includes both any error and the ID
 // of the fleet that was created from the `fleet` input
 // data.
-type fleetCreationFunc func(fleet game.Fleet) (string, error)
+type fleetCreationFunc func(fleet game.Fleet, log logger.Logger) (string, error)
+
+// fleetPreviewFunc :
+// Similar to the `fleetCreationFunc` but used to only
+// validate the fleet without creating it. It is used
+// to serve the dry run requests.
+//
+// The `fleet` defines the fleet's data fetched from
+// the route.
+//
+// The `log` defines the logger attached to the request.
+//
+// Returns the preview of the fleet.
+type fleetPreviewFunc func(fleet game.Fleet, log logger.Logger) game.FleetPreview
 
 // listFleets :
 // Used to perform the creation of a handler allowing to serve
 // the requests on fleets.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listFleets() http.HandlerFunc {
+func (s *Server) listFleets() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("fleets")
 
@@ -48,12 +78,12 @@ func (s *Server) listFleets() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("fleets").WithLocker(s.og)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.fleets.Fleets(filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.fleets.For(log).Fleets(filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // listACSFleets :
@@ -61,7 +91,7 @@ func (s *Server) listFleets() http.HandlerFunc {
 // the requests for ACS fleets.
 //
 // Returns the handler than can be executed to serve said reqs.
-func (s *Server) listACSFleets() http.HandlerFunc {
+func (s *Server) listACSFleets() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("fleets/acs")
 
@@ -76,12 +106,41 @@ func (s *Server) listACSFleets() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("fleets").WithLocker(s.og)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.fleets.ACSFleets(filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.fleets.For(log).ACSFleets(filters)
+		},
+	)
+
+	return ed
+}
+
+// listScheduledFleets :
+// Used to perform the creation of a handler allowing to serve
+// the requests on fleets waiting for their departure.
+//
+// Returns the handler that can be executed to serve said reqs.
+func (s *Server) listScheduledFleets() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewGetResourceEndpoint("fleets/scheduled")
+
+	allowed := map[string]string{
+		"id":        "id",
+		"universe":  "universe",
+		"player":    "player",
+		"objective": "objective",
+		"source":    "source",
+		"acs":       "acs",
+	}
+
+	// Configure the endpoint.
+	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("fleets").WithLocker(s.og)
+	ed.WithDataFunc(
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.fleets.For(log).ScheduledFleets(filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // listFleetObjectives :
@@ -89,7 +148,7 @@ func (s *Server) listACSFleets() http.HandlerFunc {
 // the requests on fleet objectives.
 //
 // Returns the created handler.
-func (s *Server) listFleetObjectives() http.HandlerFunc {
+func (s *Server) listFleetObjectives() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("fleets/objectives")
 
@@ -100,13 +159,14 @@ func (s *Server) listFleetObjectives() http.HandlerFunc {
 
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("fleets")
+	ed.WithCache(s.modelVersion, s.config.CacheMaxAge)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.og.Objectives.Objectives(s.proxy, filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.og.Current().Objectives.Objectives(s.proxy, filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // createGenericFleet :
@@ -120,8 +180,11 @@ func (s *Server) listFleetObjectives() http.HandlerFunc {
 // The `creator` defines the creation function to call once
 // the fleet has been unmarshalled from input data.
 //
+// The `preview` defines the function to call instead of the
+// `creator` in case the client only requests a dry run.
+//
 // Returns the created handler.
-func (s *Server) createGenericFleet(route string, create fleetCreationFunc) http.HandlerFunc {
+func (s *Server) createGenericFleet(route string, create fleetCreationFunc, preview fleetPreviewFunc) endpoint {
 	// Create the endpoint from the route.
 	ed := NewCreateResourceEndpoint(route)
 
@@ -131,7 +194,7 @@ func (s *Server) createGenericFleet(route string, create fleetCreationFunc) http
 	// The unmarshalling process is always the same, only the
 	// last creation step is actually specific.
 	ed.WithCreationFunc(
-		func(input RouteData) ([]string, error) {
+		func(input RouteData, log logger.Logger) ([]string, error) {
 			// We need to iterate over the data retrieved from the route and
 			// create fleets from it.
 			var fleet game.Fleet
@@ -150,7 +213,7 @@ func (s *Server) createGenericFleet(route string, create fleetCreationFunc) http
 				}
 
 				// Create the fleet component.
-				res, err := create(fleet)
+				res, err := create(fleet, log)
 				if err != nil {
 					return resources, err
 				}
@@ -163,8 +226,31 @@ func (s *Server) createGenericFleet(route string, create fleetCreationFunc) http
 			return resources, nil
 		},
 	)
+	ed.WithPreviewFunc(
+		func(input RouteData, log logger.Logger) (interface{}, error) {
+			// Validate each fleet without creating them.
+			previews := make([]game.FleetPreview, 0)
+
+			if len(input.Data) == 0 {
+				return previews, ErrNoData
+			}
+
+			for _, rawData := range input.Data {
+				var fleet game.Fleet
+
+				err := json.Unmarshal([]byte(rawData), &fleet)
+				if err != nil {
+					return previews, ErrInvalidData
+				}
+
+				previews = append(previews, preview(fleet, log))
+			}
+
+			return previews, nil
+		},
+	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // createFleet :
@@ -172,11 +258,14 @@ func (s *Server) createGenericFleet(route string, create fleetCreationFunc) http
 // the requests to create a fleet.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) createFleet() http.HandlerFunc {
+func (s *Server) createFleet() endpoint {
 	return s.createGenericFleet(
 		"fleets",
-		func(fleet game.Fleet) (string, error) {
-			return s.fleets.CreateFleet(fleet)
+		func(fleet game.Fleet, log logger.Logger) (string, error) {
+			return s.fleets.For(log).CreateFleet(fleet)
+		},
+		func(fleet game.Fleet, log logger.Logger) game.FleetPreview {
+			return s.fleets.For(log).PreviewFleet(fleet)
 		},
 	)
 }
@@ -186,11 +275,115 @@ func (s *Server) createFleet() http.HandlerFunc {
 // the requets to create an ACS fleet.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) createACSFleet() http.HandlerFunc {
+func (s *Server) createACSFleet() endpoint {
 	return s.createGenericFleet(
 		"fleets/acs",
-		func(fleet game.Fleet) (string, error) {
-			return s.fleets.CreateACSFleet(fleet)
+		func(fleet game.Fleet, log logger.Logger) (string, error) {
+			return s.fleets.For(log).CreateACSFleet(fleet)
+		},
+		func(fleet game.Fleet, log logger.Logger) game.FleetPreview {
+			return s.fleets.For(log).PreviewACSFleet(fleet)
+		},
+	)
+}
+
+// cancelScheduledFleet :
+// Used to perform the creation of a handler allowing to serve
+// the requests to cancel a fleet waiting for its departure.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) cancelScheduledFleet() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewDeleteResourceEndpoint("fleets/scheduled")
+
+	// Configure the endpoint.
+	ed.WithModule("fleets").WithLocker(s.og)
+	ed.WithDeleterFunc(
+		func(resource string, log logger.Logger) error {
+			return s.fleets.For(log).CancelScheduledFleet(resource)
+		},
+	)
+
+	return ed
+}
+
+// createHoldingOrder :
+// Used to mutualize the common code used to serve the orders
+// given by a defended player to the fleets holding at one of
+// its planets.
+//
+// The `process` defines the function to call once the order
+// has been unmarshalled from input data.
+//
+// Returns the created handler.
+func (s *Server) createHoldingOrder(process holdingOrderFunc) endpoint {
+	// Create the endpoint from the route.
+	ed := NewCreateResourceEndpoint("fleets")
+
+	// Configure the endpoint.
+	ed.WithDataKey("order-data").WithModule("fleets").WithLocker(s.og)
+	ed.WithCreationFunc(
+		func(input RouteData, log logger.Logger) ([]string, error) {
+			resources := make([]string, 0)
+
+			// Prevent request with no data.
+			if len(input.Data) == 0 {
+				return resources, ErrNoData
+			}
+
+			// The `ExtraElems` should provide the fleet's id.
+			if len(input.ExtraElems) == 0 {
+				return resources, ErrInvalidData
+			}
+
+			fleet := input.ExtraElems[0]
+
+			for _, rawData := range input.Data {
+				var order game.HoldingOrder
+
+				err := json.Unmarshal([]byte(rawData), &order)
+				if err != nil {
+					return resources, ErrInvalidData
+				}
+
+				err = process(fleet, order, log)
+				if err != nil {
+					return resources, err
+				}
+
+				resources = append(resources, fleet)
+			}
+
+			return resources, nil
+		},
+	)
+
+	return ed
+}
+
+// supplyHoldingFleet :
+// Used to perform the creation of a handler allowing to serve
+// the requests to supply a fleet holding at a planet with the
+// fuel of the alliance depot.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) supplyHoldingFleet() endpoint {
+	return s.createHoldingOrder(
+		func(fleet string, order game.HoldingOrder, log logger.Logger) error {
+			return s.fleets.For(log).SupplyHoldingFleet(fleet, order)
+		},
+	)
+}
+
+// releaseHoldingFleet :
+// Used to perform the creation of a handler allowing to serve
+// the requests to send a fleet holding at a planet back home.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) releaseHoldingFleet() endpoint {
+	return s.createHoldingOrder(
+		func(fleet string, order game.HoldingOrder, log logger.Logger) error {
+			return s.fleets.For(log).ReleaseHoldingFleet(fleet, order)
 		},
 	)
 }
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"oglike_server/internal/game"
 	"oglike_server/pkg/logger"
+	"strconv"
 )
 
 // creationFunc :
@@ -23,11 +24,31 @@ import (
 // like `universe-data` that the resource creator can
 // look upon in the input request.
 //
+// The `log` defines the logger attached to the request.
+// It should be used by the proxies to log with the id
+// of the request.
+//
 // The return value includes both any error and the list
 // of resources that could be created from the input data
 // so that it can be returned to the client as requested
 // by the REST architecture.
-type creationFunc func(data RouteData) ([]string, error)
+type creationFunc func(data RouteData, log logger.Logger) ([]string, error)
+
+// previewFunc :
+// Convenience define which allows to refer to the process
+// of validating the data provided in a creation request
+// without actually creating anything in the DB. It is
+// used to serve the `dry_run` requests.
+//
+// The `data` defines the data extracted from the route in
+// the same way as for the `creationFunc`.
+//
+// The `log` defines the logger attached to the request.
+//
+// The return value includes both any error and the preview
+// of the resources that would be created from the input
+// data. It will be marshalled and sent back to the client.
+type previewFunc func(data RouteData, log logger.Logger) (interface{}, error)
 
 // CreateResourceEndpoint :
 // Defines the information to describe a endpoint which can be
@@ -66,10 +87,16 @@ type creationFunc func(data RouteData) ([]string, error)
 // be automatically acquired before passing on the request
 // to the `creator` function and release afterwards. If it
 // is set to `nil` (default behavior) no lock is acquired.
+//
+// The `previewer` defines the function to use to validate
+// the data of the request when the `dry_run` query param
+// is set. If it is `nil` (default behavior) this param is
+// ignored and the resources are created.
 type CreateResourceEndpoint struct {
 	route     string
 	key       string
 	creator   creationFunc
+	previewer previewFunc
 	module    string
 	prefixRes bool
 	lock      *game.Instance
@@ -85,6 +112,16 @@ var ErrNoData = fmt.Errorf("no data provided to perform resource creation")
 // of a creation request could not be read.
 var ErrInvalidData = fmt.Errorf("unable to parse input data")
 
+// ErrInvalidDryRun :
+// Used to indicate that the value provided for the dry run
+// query parameter could not be interpreted.
+var ErrInvalidDryRun = fmt.Errorf("invalid value provided for dry run")
+
+// dryRunKey :
+// Defines the name of the query parameter allowing to request
+// the validation of the resources without creating them.
+var dryRunKey = "dry_run"
+
 // NewCreateResourceEndpoint :
 // Creates a new empty endpoint description with the provided
 // route. The fetcher func is defined as an empty element to
@@ -127,6 +164,19 @@ func (cre *CreateResourceEndpoint) WithCreationFunc(f creationFunc) *CreateResou
 	return cre
 }
 
+// WithPreviewFunc :
+// Assigns the input function as the way to validate the
+// data of a request without creating it in the DB. This
+// allows the endpoint to serve the `dry_run` requests.
+//
+// The `f` represents the preview function to use.
+//
+// Returns this endpoint to allow chain calling.
+func (cre *CreateResourceEndpoint) WithPreviewFunc(f previewFunc) *CreateResourceEndpoint {
+	cre.previewer = f
+	return cre
+}
+
 // WithModule :
 // Assigns a new string as the module name for this object.
 //
@@ -162,6 +212,18 @@ func (cre *CreateResourceEndpoint) WithoutPrefix() *CreateResourceEndpoint {
 	return cre
 }
 
+// describe :
+// Implementation of the `endpoint` interface which sets
+// the key of the data expected by the route and whether
+// dry runs are supported.
+//
+// The `doc` defines the documentation to complete.
+func (cre *CreateResourceEndpoint) describe(doc *routeDoc) {
+	doc.key = cre.key
+	doc.creation = true
+	doc.dryRun = cre.previewer != nil
+}
+
 // ServeRoute :
 // Returns a handler using this endpoint description to be
 // able to serve requests given the data present in this
@@ -177,26 +239,47 @@ func (cre *CreateResourceEndpoint) WithoutPrefix() *CreateResourceEndpoint {
 // requests defined by the data of this endpoint.
 func (cre *CreateResourceEndpoint) ServeRoute(log logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Attach the identifier of the request to the logs.
+		log := logger.FromContext(r.Context(), log)
+
 		route := fmt.Sprintf("/%s", cre.route)
 
 		// Extract data from the input request to perform the
 		// creation of data in the DB.
 		data, err := extractRouteData(route, cre.key, r)
+		if err == ErrRequestTooLarge {
+			log.Trace(logger.Warning, cre.module, fmt.Sprintf("Rejected too large request for route \"%s\"", cre.route))
+
+			http.Error(w, fmt.Sprintf("%v", err), http.StatusRequestEntityTooLarge)
+			return
+		}
 		if err != nil {
 			log.Trace(logger.Error, cre.module, fmt.Sprintf("Could not fetch data from request for route \"%s\" (err: %v)", cre.route, err))
 
 			panic(err)
 		}
 
+		// Serve the request as a preview if needed.
+		dryRun, err := isDryRun(data)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%v", err), http.StatusBadRequest)
+			return
+		}
+
+		if dryRun && cre.previewer != nil {
+			cre.servePreview(data, w, r, log)
+			return
+		}
+
 		var resNames []string
 
 		func() {
 			if cre.lock != nil {
-				cre.lock.Lock()
+				cre.lock.LockFor(r.Context())
 				defer cre.lock.Unlock()
 			}
 
-			resNames, err = cre.creator(data)
+			resNames, err = cre.creator(data, log)
 		}()
 
 		if err != nil {
@@ -235,6 +318,68 @@ func (cre *CreateResourceEndpoint) ServeRoute(log logger.Logger) http.HandlerFun
 	}
 }
 
+// servePreview :
+// Used to serve a request for which the client only wants
+// to validate the data without creating any resource. The
+// preview is marshalled and returned to the client.
+//
+// The `data` defines the data extracted from the request.
+//
+// The `w` defines the response writer to use to notify
+// the client.
+//
+// The `r` defines the request to serve.
+//
+// The `log` allows to notify errors if needed.
+func (cre *CreateResourceEndpoint) servePreview(data RouteData, w http.ResponseWriter, r *http.Request, log logger.Logger) {
+	var preview interface{}
+	var err error
+
+	func() {
+		if cre.lock != nil {
+			cre.lock.LockFor(r.Context())
+			defer cre.lock.Unlock()
+		}
+
+		preview, err = cre.previewer(data, log)
+	}()
+
+	if err != nil {
+		log.Trace(logger.Error, cre.module, fmt.Sprintf("Could not preview resource from route \"%s\" (err: %v)", cre.route, err))
+
+		http.Error(w, fmt.Sprintf("%v", err), http.StatusBadRequest)
+		return
+	}
+
+	err = marshalAndSend(preview, w, r)
+	if err != nil {
+		log.Trace(logger.Error, cre.module, fmt.Sprintf("Error while sending preview to client (err: %v)", err))
+	}
+}
+
+// isDryRun :
+// Used to determine whether the input data request a dry
+// run of the creation process. This is indicated by the
+// `dry_run` query parameter.
+//
+// The `data` defines the data extracted from the request.
+//
+// Returns whether a dry run is requested along with any
+// error in case the value of the parameter is invalid.
+func isDryRun(data RouteData) (bool, error) {
+	values, ok := data.Params[dryRunKey]
+	if !ok || len(values) == 0 {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false, ErrInvalidDryRun
+	}
+
+	return dryRun, nil
+}
+
 // notifyCreation :
 // Used to setup the input response writer to indicate that the
 // resource defined by the input string has successfully been
@@ -12,8 +12,9 @@ import (
 // to delete a resource from the DB. The resources passed
 // as input argument will be removed from the DB if it can
 // be found. If no such resource exist an error should be
-// returned.
-type deleteFunc func(resource string) error
+// returned. The logger attached to the request is also
+// provided.
+type deleteFunc func(resource string, log logger.Logger) error
 
 // DeleteResourceEndpoint :
 // Common information to perform the deletion of an abstract
@@ -103,6 +104,13 @@ func (dre *DeleteResourceEndpoint) WithLocker(i game.Instance) *DeleteResourceEn
 	return dre
 }
 
+// describe :
+// Implementation of the `endpoint` interface. Deleting
+// a resource does not require any data.
+//
+// The `doc` defines the documentation to complete.
+func (dre *DeleteResourceEndpoint) describe(doc *routeDoc) {}
+
 // ServeRoute :
 // Returns a handler using this endpoint description to
 // be able to serve requests given the configuration as
@@ -117,6 +125,9 @@ func (dre *DeleteResourceEndpoint) WithLocker(i game.Instance) *DeleteResourceEn
 // requests defined by the data of this endpoint.
 func (dre *DeleteResourceEndpoint) ServeRoute(log logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Attach the identifier of the request to the logs.
+		log := logger.FromContext(r.Context(), log)
+
 		route := fmt.Sprintf("/%s", dre.route)
 
 		// First extract the route variables: we will mostly
@@ -150,11 +161,11 @@ func (dre *DeleteResourceEndpoint) ServeRoute(log logger.Logger) http.HandlerFun
 
 		func() {
 			if dre.lock != nil {
-				dre.lock.Lock()
+				dre.lock.LockFor(r.Context())
 				defer dre.lock.Unlock()
 			}
 
-			err = dre.deleter(resource)
+			err = dre.deleter(resource, log)
 		}()
 
 		if err != nil {
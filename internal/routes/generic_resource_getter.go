@@ -10,6 +10,7 @@ import (
 	"oglike_server/pkg/db"
 	"oglike_server/pkg/logger"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -24,7 +25,16 @@ import (
 // This wrapper is used in an `EndpointDesc` to mutualize
 // even more the basic functionalities to fetch data of
 // different kind from the main DB.
-type dataFunc func(filters []db.Filter) (interface{}, error)
+// The logger attached to the request is also provided so
+// that the proxies can log with the request's identifier.
+type dataFunc func(filters []db.Filter, log logger.Logger) (interface{}, error)
+
+// paramsDataFunc :
+// Similar to the `dataFunc` but also receives the query
+// parameters of the request. It is useful for endpoints
+// which accept options that can't be expressed as plain
+// filters on the DB (such as a pagination).
+type paramsDataFunc func(filters []db.Filter, params map[string]Values, log logger.Logger) (interface{}, error)
 
 // GetResourceEndpoint :
 // Defines the information to describe a endpoint. This allows to
@@ -82,14 +92,29 @@ type dataFunc func(filters []db.Filter) (interface{}, error)
 // be automatically acquired before passing on the request
 // to the `fetcher` function and release afterwards. If it
 // is set to `nil` (default behavior) no lock is acquired.
+//
+// The `paramsFetcher` is similar to the `fetcher` but it
+// also receives the query parameters of the request. It
+// is used instead of the `fetcher` if it is defined.
+//
+// The `cache` allows to keep the responses of endpoints
+// serving static data. If it is `nil` (default behavior)
+// the data is fetched and marshalled for each request.
+//
+// The `tagger` allows to compute a weak entity tag from
+// the fetched data so that clients can revalidate their
+// copy. It is ignored if the `cache` is defined.
 type GetResourceEndpoint struct {
-	route     string
-	fetcher   dataFunc
-	filters   map[string]string
-	idFilter  string
-	resFilter string
-	module    string
-	lock      *game.Instance
+	route         string
+	fetcher       dataFunc
+	paramsFetcher paramsDataFunc
+	filters       map[string]string
+	idFilter      string
+	resFilter     string
+	module        string
+	lock          *game.Instance
+	cache         *responseCache
+	tagger        tagFunc
 }
 
 // ErrMarshallingError :
@@ -104,6 +129,11 @@ var ErrGzipCompressionError = fmt.Errorf("unable to gzip content to send back")
 // Used to indicate an error when sending the data back.
 var ErrWriteError = fmt.Errorf("unable to send data back to the client")
 
+// ErrInvalidQueryParameter :
+// Used to indicate that a query parameter has an invalid
+// value.
+var ErrInvalidQueryParameter = fmt.Errorf("invalid value provided for query parameter")
+
 // NewGetResourceEndpoint :
 // Creates a new empty endpoint description with the provided
 // route. The fetcher func is defined as an empty element to
@@ -183,6 +213,20 @@ func (gre *GetResourceEndpoint) WithDataFunc(f dataFunc) *GetResourceEndpoint {
 	return gre
 }
 
+// WithParamsDataFunc :
+// Assigns the input data function as the main way to query
+// data for this endpoint. Unlike the `WithDataFunc` the
+// function also receives the query parameters.
+//
+// The `f` represents the data function that should be used
+// by this endpoint to fetch data.
+//
+// Returns this endpoint to allow chain calling.
+func (gre *GetResourceEndpoint) WithParamsDataFunc(f paramsDataFunc) *GetResourceEndpoint {
+	gre.paramsFetcher = f
+	return gre
+}
+
 // WithModule :
 // Assigns a new string as the module name for this object.
 //
@@ -207,6 +251,55 @@ func (gre *GetResourceEndpoint) WithLocker(i game.Instance) *GetResourceEndpoint
 	return gre
 }
 
+// WithCache :
+// Used to keep the responses of this endpoint rather than
+// fetching and marshalling the data for each request. It
+// should only be used for data that rarely changes: the
+// responses are discarded when the version changes.
+//
+// The `version` defines the function returning the version
+// of the data served by this endpoint.
+//
+// The `maxAge` defines the duration for which clients can
+// keep the responses without revalidating them.
+//
+// Returns this endpoint to allow chain calling.
+func (gre *GetResourceEndpoint) WithCache(version versionFunc, maxAge time.Duration) *GetResourceEndpoint {
+	gre.cache = newResponseCache(version, maxAge)
+	return gre
+}
+
+// WithWeakETag :
+// Used to attach a weak entity tag computed from the data
+// to the responses of this endpoint. Clients providing it
+// in the `If-None-Match` header receive a `304` when the
+// data did not change.
+//
+// The `f` defines the function computing the tag.
+//
+// Returns this endpoint to allow chain calling.
+func (gre *GetResourceEndpoint) WithWeakETag(f tagFunc) *GetResourceEndpoint {
+	gre.tagger = f
+	return gre
+}
+
+// describe :
+// Implementation of the `endpoint` interface which adds
+// the filters of this endpoint to the query parameters
+// of the route and indicates whether its responses can
+// be revalidated.
+//
+// The `doc` defines the documentation to complete.
+func (gre *GetResourceEndpoint) describe(doc *routeDoc) {
+	for filter := range gre.filters {
+		doc.query = append(doc.query, filter)
+	}
+
+	doc.resource = gre.resFilter != ""
+	doc.listing = true
+	doc.conditional = gre.cache != nil || gre.tagger != nil
+}
+
 // ServeRoute :
 // Returns a handler using this endpoint description to be
 // able to serve requests given the data present in this
@@ -222,6 +315,9 @@ func (gre *GetResourceEndpoint) WithLocker(i game.Instance) *GetResourceEndpoint
 // requests defined by the data of this endpoint.
 func (gre *GetResourceEndpoint) ServeRoute(log logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Attach the identifier of the request to the logs.
+		log := logger.FromContext(r.Context(), log)
+
 		route := fmt.Sprintf("/%s", gre.route)
 
 		// First extract the route variables: this include both the path
@@ -236,28 +332,56 @@ func (gre *GetResourceEndpoint) ServeRoute(log logger.Logger) http.HandlerFunc {
 		filters := gre.extractFilters(vars)
 
 		// Retrieve the data using the provided filters.
-		if gre.fetcher == nil {
+		if gre.fetcher == nil && gre.paramsFetcher == nil {
 			// The fetcher is not assigned, terminate the request here.
 			return
 		}
 
+		// Serve the response from the cache if possible. The
+		// version is retrieved before fetching the data so
+		// that a concurrent reload is detected.
+		var key string
+		var version uint32
+
+		if gre.cache != nil {
+			key = cacheKey(r)
+
+			if res, ok := gre.cache.get(key); ok {
+				err = gre.cache.send(res, w, r)
+				if err != nil {
+					log.Trace(logger.Error, gre.module, fmt.Sprintf("Error while serving route \"%s\" (err: %v)", gre.route, err))
+				}
+
+				return
+			}
+
+			version = gre.cache.version()
+		}
+
 		var data interface{}
 
 		func() {
 			if gre.lock != nil {
-				gre.lock.Lock()
+				gre.lock.LockFor(r.Context())
 				defer gre.lock.Unlock()
 			}
 
-			data, err = gre.fetcher(filters)
+			if gre.paramsFetcher != nil {
+				data, err = gre.paramsFetcher(filters, vars.Params, log)
+			} else {
+				data, err = gre.fetcher(filters, log)
+			}
 		}()
 
 		if err != nil {
 			log.Trace(logger.Error, gre.module, fmt.Sprintf("Unexpected error while fetching data for route \"%s\" (err: %v)", gre.route, err))
 
-			// Detect special cases of a not found element.
+			// Detect special cases of a not found element
+			// or of invalid query parameters.
 			if err == game.ErrElementNotFound {
 				http.Error(w, fmt.Sprintf("%v", err), http.StatusNotFound)
+			} else if err == ErrInvalidQueryParameter {
+				http.Error(w, fmt.Sprintf("%v", err), http.StatusBadRequest)
 			} else {
 				http.Error(w, InternalServerErrorString, http.StatusInternalServerError)
 			}
@@ -265,6 +389,40 @@ func (gre *GetResourceEndpoint) ServeRoute(log logger.Logger) http.HandlerFunc {
 			return
 		}
 
+		if gre.cache != nil {
+			res, err := precompute(data, version)
+			if err != nil {
+				log.Trace(logger.Error, gre.module, fmt.Sprintf("Error while serving route \"%s\" (err: %v)", gre.route, err))
+				http.Error(w, InternalServerErrorString, http.StatusInternalServerError)
+
+				return
+			}
+
+			gre.cache.put(key, res)
+
+			err = gre.cache.send(res, w, r)
+			if err != nil {
+				log.Trace(logger.Error, gre.module, fmt.Sprintf("Error while serving route \"%s\" (err: %v)", gre.route, err))
+			}
+
+			return
+		}
+
+		// Answer with a `304` if the client already has the
+		// current version of the data.
+		if gre.tagger != nil {
+			etag := weakETag(gre.tagger(data))
+
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Vary", "Accept-Encoding")
+
+			if matchesETag(r, etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
 		// Marshal the content of the data.
 		err = marshalAndSend(data, w, r)
 		if err != nil {
@@ -0,0 +1,372 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"oglike_server/internal/model"
+	"oglike_server/pkg/background"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+	"oglike_server/pkg/migrate"
+	"sync"
+)
+
+// serverHealth :
+// Keeps track of the initialization of the data model
+// of the server. The rule set is synchronized with the
+// DB and the modules are loaded from it: as this might
+// fail in case the DB is not reachable the operation
+// can be attempted again until it succeeds.
+//
+// The `schema` defines the version of the schema of the
+// DB expected by the server.
+//
+// The `schemaErr` is set when the schema of the DB is not
+// at the expected version. Nothing else is initialized
+// until this is fixed.
+//
+// The `ruleSetName` defines the name of the rule set used
+// by the server.
+//
+// The `ruleSetErr` is set when some universes of the DB
+// use another rule set than the one of the server. As
+// the data model is shared by all the universes, nothing
+// else is initialized until this is fixed.
+//
+// The `ruleSet` defines the rule set to synchronize with
+// the DB. It is `nil` in case the data already in the DB
+// should be used.
+//
+// The `synced` defines whether the rule set has already
+// been synchronized with the DB.
+//
+// The `modules` defines the modules to initialize.
+//
+// The `ready` defines whether all the modules have been
+// initialized successfully.
+//
+// The `locker` protects the status from concurrent use.
+//
+// The `reloading` serializes the reloads of the data
+// model once it is initialized.
+type serverHealth struct {
+	schema      uint
+	schemaErr   error
+	ruleSetName string
+	ruleSetErr  error
+	ruleSet     *model.RuleSet
+	synced      bool
+	modules     []model.DBModule
+	ready       bool
+	locker      sync.Mutex
+	reloading   sync.Mutex
+}
+
+// readiness :
+// Describes the response to a readiness probe. Each of
+// the checks is either "ok" or the reason of a failure.
+//
+// The `Status` is either "ready" or "not ready".
+//
+// The `Checks` defines the result of each check keyed
+// by its name.
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// ErrNotInitialized :
+// Used to indicate that the data model of the server is
+// not initialized yet.
+var ErrNotInitialized = fmt.Errorf("server is not ready")
+
+// ErrActionsBacklog :
+// Used to indicate that too many actions are waiting to
+// be executed.
+var ErrActionsBacklog = fmt.Errorf("too many actions waiting to be executed")
+
+// ErrProcessNotRunning :
+// Used to indicate that a background process is not
+// running.
+var ErrProcessNotRunning = fmt.Errorf("background process is not running")
+
+// ErrSchemaMismatch :
+// Used to indicate that the schema of the DB is not at
+// the version expected by the server.
+var ErrSchemaMismatch = fmt.Errorf("unexpected version of the schema of the DB")
+
+// ErrRuleSetMismatch :
+// Used to indicate that some universes of the DB use a
+// rule set which is not the one used by the server.
+var ErrRuleSetMismatch = fmt.Errorf("universes use another rule set than the server")
+
+// newServerHealth :
+// Creates a new health status where nothing has been
+// initialized yet.
+//
+// The `schema` defines the expected version of the schema
+// of the DB.
+//
+// The `ruleSetName` defines the name of the rule set
+// used by the server.
+//
+// The `ruleSet` defines the rule set to synchronize.
+//
+// The `modules` defines the modules to initialize.
+//
+// Returns the created status.
+func newServerHealth(schema uint, ruleSetName string, ruleSet *model.RuleSet, modules []model.DBModule) *serverHealth {
+	return &serverHealth{
+		schema:      schema,
+		ruleSetName: ruleSetName,
+		ruleSet:     ruleSet,
+		modules:     modules,
+	}
+}
+
+// initialize :
+// Used to verify the version of the schema of the DB and
+// that the universes use the rule set of the server, to
+// synchronize the rule set with the DB and to initialize
+// the modules of the data model. Modules that are
+// already initialized are not loaded again so that this
+// method can be called until it succeeds.
+//
+// The `proxy` defines the DB to use.
+//
+// The `log` allows to notify information.
+//
+// Returns any error.
+func (sh *serverHealth) initialize(proxy db.Proxy, log logger.Logger) error {
+	sh.locker.Lock()
+	defer sh.locker.Unlock()
+
+	if sh.ready {
+		return nil
+	}
+
+	version, dirty, err := migrate.Version(proxy)
+	if err != nil {
+		return err
+	}
+
+	sh.schemaErr = nil
+	if dirty {
+		sh.schemaErr = fmt.Errorf("%v (version: %d)", migrate.ErrDirtySchema, version)
+	} else if version != sh.schema {
+		sh.schemaErr = fmt.Errorf("%v (version: %d, expected: %d)", ErrSchemaMismatch, version, sh.schema)
+	}
+
+	if sh.schemaErr != nil {
+		return sh.schemaErr
+	}
+
+	others, err := otherRuleSets(proxy, sh.ruleSetName)
+	if err != nil {
+		return err
+	}
+
+	sh.ruleSetErr = nil
+	if len(others) > 0 {
+		sh.ruleSetErr = fmt.Errorf("%v (rule set: \"%s\", others: %v)", ErrRuleSetMismatch, sh.ruleSetName, others)
+		return sh.ruleSetErr
+	}
+
+	if sh.ruleSet != nil && !sh.synced {
+		synced, err := model.SyncRuleSet(*sh.ruleSet, proxy)
+		if err != nil {
+			return err
+		}
+
+		if synced {
+			log.Trace(logger.Notice, "server", fmt.Sprintf("Synchronized rule set \"%s\" (version: %d)", sh.ruleSet.Name, sh.ruleSet.Version))
+		}
+
+		sh.synced = true
+	}
+
+	for _, m := range sh.modules {
+		err := m.Init(proxy, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	sh.ready = true
+
+	return nil
+}
+
+// otherRuleSets :
+// Used to fetch the rule sets used by the universes of
+// the DB which are not the input one. Synchronizing the
+// input rule set would change the data model of these
+// universes.
+//
+// The `proxy` defines the DB to use.
+//
+// The `name` defines the name of the expected rule set.
+//
+// Returns the other rule sets along with any error.
+func otherRuleSets(proxy db.Proxy, name string) ([]string, error) {
+	others := make([]string, 0)
+
+	query := db.QueryDesc{
+		Props: []string{
+			"distinct rule_set",
+		},
+		Table: "universes",
+	}
+
+	dbRes, err := proxy.FetchFromDB(query)
+	if err != nil {
+		return others, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		return others, dbRes.Err
+	}
+
+	var ruleSet string
+	for dbRes.Next() {
+		err = dbRes.Scan(&ruleSet)
+		if err != nil {
+			return others, err
+		}
+
+		if ruleSet != name {
+			others = append(others, ruleSet)
+		}
+	}
+
+	return others, nil
+}
+
+// initialized :
+// Returns whether the data model is initialized.
+func (sh *serverHealth) initialized() bool {
+	sh.locker.Lock()
+	defer sh.locker.Unlock()
+
+	return sh.ready
+}
+
+// whenInitialized :
+// Used to wrap the operation of a background process so
+// that it is only executed once the data model is ready.
+// Until then the operation is skipped.
+//
+// The `op` defines the operation to wrap.
+//
+// Returns the wrapped operation.
+func (sh *serverHealth) whenInitialized(op background.OperationFunc) background.OperationFunc {
+	return func() (bool, error) {
+		if !sh.initialized() {
+			return true, nil
+		}
+
+		return op()
+	}
+}
+
+// requireInitialized :
+// Used to wrap the input handler so that requests are
+// rejected until the data model is initialized.
+//
+// The `next` defines the handler to wrap.
+//
+// Returns the wrapped handler.
+func (s *Server) requireInitialized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.health.initialized() {
+			http.Error(w, fmt.Sprintf("%v", ErrNotInitialized), http.StatusServiceUnavailable)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// healthz :
+// Used to create a handler answering the liveness probe.
+// It succeeds as long as the process is able to serve
+// requests.
+//
+// Returns the handler to serve said requests.
+func (s *Server) healthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	}
+}
+
+// readyz :
+// Used to create a handler answering the readiness probe.
+// The server is ready when the DB is reachable, the data
+// model is initialized, all the background processes are
+// running and the backlog of actions waiting to be run is
+// below the configured threshold.
+//
+// Returns the handler to serve said requests.
+func (s *Server) readyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := readiness{
+			Status: "ready",
+			Checks: make(map[string]string),
+		}
+
+		check := func(name string, err error) {
+			res.Checks[name] = "ok"
+
+			if err != nil {
+				res.Status = "not ready"
+				res.Checks[name] = err.Error()
+			}
+		}
+
+		check("db", s.proxy.Healthcheck())
+
+		var err error
+		if !s.health.initialized() {
+			err = ErrNotInitialized
+		}
+		check("modules", err)
+
+		err = nil
+		for _, p := range s.processes {
+			if !p.Running() {
+				err = fmt.Errorf("%v (module: \"%s\")", ErrProcessNotRunning, p.Module())
+				break
+			}
+		}
+		check("processes", err)
+
+		backlog, err := s.og.ActionsBacklog()
+		if err == nil && backlog > s.config.MaxActionsBacklog {
+			err = fmt.Errorf("%v (count: %d)", ErrActionsBacklog, backlog)
+		}
+		check("actions", err)
+
+		code := http.StatusOK
+		if res.Status != "ready" {
+			code = http.StatusServiceUnavailable
+		}
+
+		out, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, InternalServerErrorString, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+
+		_, err = w.Write(out)
+		if err != nil {
+			logger.FromContext(r.Context(), s.log).Trace(logger.Error, "server", fmt.Sprintf("Error while sending readiness to client (err: %v)", err))
+		}
+	}
+}
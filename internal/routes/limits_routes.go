@@ -0,0 +1,372 @@
+package routes
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"oglike_server/pkg/logger"
+	"oglike_server/pkg/metrics"
+	"oglike_server/pkg/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// limitClass :
+// Defines the budget used to rate limit a route. Reading
+// routes are cheaper than the ones modifying data while
+// some routes are expensive enough to deserve a budget
+// of their own.
+type limitClass string
+
+// Define the possible classes of routes.
+const (
+	readClass      limitClass = "read"
+	writeClass     limitClass = "write"
+	expensiveClass limitClass = "expensive"
+)
+
+// budget :
+// Defines the number of requests allowed for a class of
+// routes.
+//
+// The `Rate` defines the number of requests per second
+// allowed in the long run.
+//
+// The `Burst` defines the number of requests that can be
+// performed at once.
+type budget struct {
+	Rate  float64
+	Burst int
+}
+
+// limitsConfiguration :
+// Defines the limits applied to the requests made by the
+// clients of the server. They are read from the `Limits`
+// section of the configuration file.
+//
+// The `Enabled` defines whether the requests are rate
+// limited. The default value is `true`.
+//
+// The `Budgets` defines the budget allowed to each client
+// for each class of routes. The default values are `20`
+// requests per second with a burst of `40` for reading
+// routes, `5` requests per second with a burst of `10`
+// for writing routes and `1` request per second with a
+// burst of `5` for expensive routes.
+//
+// The `MaxBodySize` defines the maximum size in bytes of
+// the body of a request. The default value is `1048576`.
+//
+// The `MaxQuerySize` defines the maximum size in bytes of
+// the query string of a request. The default value is
+// `4096`.
+//
+// The `TrustForwarded` defines whether the address of the
+// client can be read from the `X-Forwarded-For` header.
+// This should only be activated when the server is run
+// behind a trusted reverse proxy: the rightmost address
+// of the header (i.e. the one appended by the proxy) is
+// used. The default value is `false`.
+type limitsConfiguration struct {
+	Enabled        bool
+	Budgets        map[limitClass]budget
+	MaxBodySize    int64
+	MaxQuerySize   int
+	TrustForwarded bool
+}
+
+// requestLimits :
+// Holds the limiters used to restrict the number of
+// requests performed by each client.
+//
+// The `config` defines the limits to apply.
+//
+// The `limiters` defines the limiter for each class of
+// routes.
+type requestLimits struct {
+	config   limitsConfiguration
+	limiters map[limitClass]*ratelimit.Limiter
+}
+
+// ErrRateLimited :
+// Used to indicate that a client performed too many
+// requests.
+var ErrRateLimited = fmt.Errorf("too many requests")
+
+// ErrRequestTooLarge :
+// Used to indicate that the body of a request exceeds
+// the maximum allowed size.
+var ErrRequestTooLarge = fmt.Errorf("request body too large")
+
+// ErrQueryTooLarge :
+// Used to indicate that the query of a request exceeds
+// the maximum allowed size.
+var ErrQueryTooLarge = fmt.Errorf("request query too large")
+
+// rejectedRequests :
+// Counts the requests rejected because of the limits
+// for each route and reason.
+var rejectedRequests = metrics.NewCounter(
+	"oglike_http_requests_rejected_total",
+	"Number of HTTP requests rejected because of the limits.",
+	"route",
+	"reason",
+)
+
+// parseLimitsConfiguration :
+// Used to parse the limits from the configuration file
+// and environment variables provided when executing the
+// server. Any non-set property keeps its default value.
+//
+// Returns the parsed configuration.
+func parseLimitsConfiguration() limitsConfiguration {
+	config := limitsConfiguration{
+		Enabled: true,
+		Budgets: map[limitClass]budget{
+			readClass:      {Rate: 20.0, Burst: 40},
+			writeClass:     {Rate: 5.0, Burst: 10},
+			expensiveClass: {Rate: 1.0, Burst: 5},
+		},
+		MaxBodySize:    1024 * 1024,
+		MaxQuerySize:   4096,
+		TrustForwarded: false,
+	}
+
+	if viper.IsSet("Limits.Enabled") {
+		config.Enabled = viper.GetBool("Limits.Enabled")
+	}
+
+	sections := map[limitClass]string{
+		readClass:      "Limits.Read",
+		writeClass:     "Limits.Write",
+		expensiveClass: "Limits.Expensive",
+	}
+
+	for class, key := range sections {
+		b := config.Budgets[class]
+
+		if viper.IsSet(key + ".Rate") {
+			b.Rate = viper.GetFloat64(key + ".Rate")
+		}
+		if viper.IsSet(key + ".Burst") {
+			b.Burst = viper.GetInt(key + ".Burst")
+		}
+
+		config.Budgets[class] = b
+	}
+
+	if viper.IsSet("Limits.MaxBodySize") {
+		config.MaxBodySize = viper.GetInt64("Limits.MaxBodySize")
+	}
+	if viper.IsSet("Limits.MaxQuerySize") {
+		config.MaxQuerySize = viper.GetInt("Limits.MaxQuerySize")
+	}
+	if viper.IsSet("Limits.TrustForwarded") {
+		config.TrustForwarded = viper.GetBool("Limits.TrustForwarded")
+	}
+
+	return config
+}
+
+// newRequestLimits :
+// Creates the limiters for each class of routes from
+// the input configuration.
+//
+// The `config` defines the limits to apply.
+//
+// Returns the created limits.
+func newRequestLimits(config limitsConfiguration) *requestLimits {
+	rl := requestLimits{
+		config:   config,
+		limiters: make(map[limitClass]*ratelimit.Limiter),
+	}
+
+	for class, b := range config.Budgets {
+		rl.limiters[class] = ratelimit.NewLimiter(b.Rate, b.Burst)
+	}
+
+	return &rl
+}
+
+// classify :
+// Used to determine the class of the route defined by
+// the input method and name. Fleets, actions and the
+// rankings are considered expensive as they involve a
+// lot of computations or large amounts of data.
+//
+// The `method` defines the method of the route.
+//
+// The `name` defines the name of the route.
+//
+// Returns the class of the route.
+func classify(method string, name string) limitClass {
+	if strings.Contains(name, "/rankings") {
+		return expensiveClass
+	}
+
+	if method == "GET" {
+		return readClass
+	}
+
+	if strings.HasPrefix(name, "/fleets") || strings.Contains(name, "/actions/") {
+		return expensiveClass
+	}
+
+	return writeClass
+}
+
+// clientIdentity :
+// Used to determine the identity of the client that
+// performed the input request from the token it holds.
+// Requests providing the admin token share a budget of
+// their own while the ones providing the token of a
+// player are identified by the account of the player.
+//
+// The `r` defines the request.
+//
+// Returns the identity of the client or an empty string
+// if the request is anonymous.
+func (s *Server) clientIdentity(r *http.Request) string {
+	token := accessToken(r)
+	if token == "" {
+		return ""
+	}
+
+	if s.isAdmin(token) {
+		return "admin"
+	}
+
+	if account, _, ok := s.playerFromToken(token); ok {
+		return "account:" + account
+	}
+
+	return ""
+}
+
+// clientKey :
+// Used to determine the key identifying the client that
+// performed the input request. Authenticated requests
+// are identified by their identity while the anonymous
+// ones are identified by the address of the client.
+//
+// The `r` defines the request.
+//
+// The `identity` defines the identity of the client as
+// returned by `clientIdentity`.
+//
+// Returns the key of the client.
+func (rl *requestLimits) clientKey(r *http.Request, identity string) string {
+	if identity != "" {
+		return identity
+	}
+
+	if rl.config.TrustForwarded {
+		// The leftmost entries are provided by the client
+		// and can't be trusted: only the address appended
+		// by the reverse proxy is used.
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if address := strings.TrimSpace(entries[len(entries)-1]); address != "" {
+				return "ip:" + address
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// limit :
+// Used to wrap the input handler so that the requests
+// exceeding the budget of the client for the class of
+// the route are rejected with a `429` status. The size
+// of the query and of the body of requests are checked
+// as well before the handler has a chance to parse it.
+//
+// The `method` defines the method of the route.
+//
+// The `name` defines the name of the route.
+//
+// The `next` defines the handler to wrap.
+//
+// Returns the wrapped handler.
+func (s *Server) limit(method string, name string, next http.HandlerFunc) http.HandlerFunc {
+	class := classify(method, name)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		config := s.limits.config
+
+		if config.MaxQuerySize > 0 && len(r.URL.RawQuery) > config.MaxQuerySize {
+			rejectedRequests.Inc(name, "query_size")
+			http.Error(w, fmt.Sprintf("%v", ErrQueryTooLarge), http.StatusRequestURITooLong)
+			return
+		}
+
+		if config.Enabled {
+			key := s.limits.clientKey(r, s.clientIdentity(r))
+
+			ok, wait := s.limits.limiters[class].Allow(key)
+			if !ok {
+				logger.FromContext(r.Context(), s.log).Trace(logger.Warning, "server", fmt.Sprintf("Rate limited client \"%s\" on \"%s\" (class: %s)", key, name, class))
+				rejectedRequests.Inc(name, "rate")
+
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter(wait)))
+				http.Error(w, fmt.Sprintf("%v", ErrRateLimited), http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		if r.Body != nil && config.MaxBodySize > 0 {
+			if r.ContentLength > config.MaxBodySize {
+				rejectedRequests.Inc(name, "body_size")
+				http.Error(w, fmt.Sprintf("%v", ErrRequestTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, config.MaxBodySize)
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// retryAfter :
+// Used to convert the input duration into a number of
+// seconds suitable for the `Retry-After` header. The
+// value is rounded up and at least one second.
+//
+// The `wait` defines the duration to convert.
+//
+// Returns the corresponding number of seconds.
+func retryAfter(wait time.Duration) int {
+	sec := math.Ceil(wait.Seconds())
+	if sec < 1.0 {
+		return 1
+	}
+	if sec > math.MaxInt32 {
+		return math.MaxInt32
+	}
+
+	return int(sec)
+}
+
+// isBodyTooLarge :
+// Used to determine whether the input error was caused
+// by a body exceeding the size allowed by the limits of
+// the server.
+//
+// The `err` defines the error to check.
+//
+// Returns `true` if the body was too large.
+func isBodyTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "request body too large")
+}
@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"encoding/json"
+	"oglike_server/internal/game"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+)
+
+// listTransportRoutes :
+// Used to perform the creation of a handler allowing to serve
+// the requests on transport routes.
+//
+// Returns the handler that can be executed to serve said reqs.
+func (s *Server) listTransportRoutes() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewGetResourceEndpoint("logistics")
+
+	allowed := map[string]string{
+		"id":       "id",
+		"universe": "universe",
+		"player":   "player",
+		"source":   "source",
+		"target":   "target",
+	}
+
+	// Configure the endpoint.
+	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("logistics").WithLocker(s.og)
+	ed.WithDataFunc(
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.logistics.For(log).Routes(filters)
+		},
+	)
+
+	return ed
+}
+
+// createTransportRoute :
+// Used to perform the creation of a handler allowing to serve
+// the requests to create transport routes.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) createTransportRoute() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewCreateResourceEndpoint("logistics")
+
+	// Configure the endpoint.
+	ed.WithDataKey("route-data").WithModule("logistics").WithLocker(s.og)
+	ed.WithCreationFunc(
+		func(input RouteData, log logger.Logger) ([]string, error) {
+			// We need to iterate over the data retrieved from the route and
+			// create transport routes from it.
+			resources := make([]string, 0)
+
+			// Prevent request with no data.
+			if len(input.Data) == 0 {
+				return resources, ErrNoData
+			}
+
+			for _, rawData := range input.Data {
+				// Try to unmarshal the data into a valid `TransportRoute` struct.
+				var route game.TransportRoute
+
+				err := json.Unmarshal([]byte(rawData), &route)
+				if err != nil {
+					return resources, ErrInvalidData
+				}
+
+				// Create the route.
+				res, err := s.logistics.For(log).Create(route)
+				if err != nil {
+					return resources, err
+				}
+
+				// Successfully created a route.
+				resources = append(resources, res)
+			}
+
+			// Return the path to the resources created during the process.
+			return resources, nil
+		},
+	)
+
+	return ed
+}
+
+// deleteTransportRoute :
+// Used to perform the creation of a handler allowing to serve
+// the requests to delete a transport route.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) deleteTransportRoute() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewDeleteResourceEndpoint("logistics")
+
+	// Configure the endpoint.
+	ed.WithModule("logistics").WithLocker(s.og)
+	ed.WithDeleterFunc(
+		func(resource string, log logger.Logger) error {
+			return s.logistics.For(log).Delete(resource)
+		},
+	)
+
+	return ed
+}
@@ -0,0 +1,156 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"oglike_server/internal/game"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+)
+
+// listOffers :
+// Used to perform the creation of a handler allowing to serve
+// the requests on the offers of the market.
+//
+// Returns the handler that can be executed to serve said reqs.
+func (s *Server) listOffers() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewGetResourceEndpoint("market")
+
+	allowed := map[string]string{
+		"id":       "id",
+		"universe": "universe",
+		"player":   "player",
+		"planet":   "planet",
+	}
+
+	// Configure the endpoint.
+	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("market").WithLocker(s.og)
+	ed.WithDataFunc(
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.market.For(log).Offers(filters)
+		},
+	)
+
+	return ed
+}
+
+// createOffer :
+// Used to perform the creation of a handler allowing to serve
+// the requests to post offers on the market.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) createOffer() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewCreateResourceEndpoint("market")
+
+	// Configure the endpoint.
+	ed.WithDataKey("offer-data").WithModule("market").WithLocker(s.og)
+	ed.WithCreationFunc(
+		func(input RouteData, log logger.Logger) ([]string, error) {
+			resources := make([]string, 0)
+
+			// Prevent request with no data.
+			if len(input.Data) == 0 {
+				return resources, ErrNoData
+			}
+
+			for _, rawData := range input.Data {
+				// Try to unmarshal the data into a valid `MarketOffer` struct.
+				var offer game.MarketOffer
+
+				err := json.Unmarshal([]byte(rawData), &offer)
+				if err != nil {
+					return resources, ErrInvalidData
+				}
+
+				res, err := s.market.For(log).Create(offer)
+				if err != nil {
+					return resources, err
+				}
+
+				resources = append(resources, res)
+			}
+
+			return resources, nil
+		},
+	)
+
+	return ed
+}
+
+// acceptOffer :
+// Used to perform the creation of a handler allowing to serve
+// the requests to accept an offer of the market. The paths of
+// the fleets delivering the goods and the price are returned.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) acceptOffer() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewCreateResourceEndpoint("market")
+
+	// Configure the endpoint.
+	ed.WithDataKey("purchase-data").WithModule("market").WithLocker(s.og).WithoutPrefix()
+	ed.WithCreationFunc(
+		func(input RouteData, log logger.Logger) ([]string, error) {
+			resources := make([]string, 0)
+
+			// Prevent request with no data.
+			if len(input.Data) == 0 {
+				return resources, ErrNoData
+			}
+
+			// The `ExtraElems` should provide the offer's id.
+			if len(input.ExtraElems) == 0 {
+				return resources, ErrInvalidData
+			}
+
+			offer := input.ExtraElems[0]
+
+			for _, rawData := range input.Data {
+				// Try to unmarshal the data into a valid `MarketPurchase` struct.
+				var purchase game.MarketPurchase
+
+				err := json.Unmarshal([]byte(rawData), &purchase)
+				if err != nil {
+					return resources, ErrInvalidData
+				}
+
+				purchase.Offer = offer
+
+				fleets, err := s.market.For(log).Accept(purchase)
+				if err != nil {
+					return resources, err
+				}
+
+				for _, fleet := range fleets {
+					resources = append(resources, fmt.Sprintf("fleets/%s", fleet))
+				}
+			}
+
+			return resources, nil
+		},
+	)
+
+	return ed
+}
+
+// cancelOffer :
+// Used to perform the creation of a handler allowing to serve
+// the requests to withdraw an offer from the market.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) cancelOffer() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewDeleteResourceEndpoint("market")
+
+	// Configure the endpoint.
+	ed.WithModule("market").WithLocker(s.og)
+	ed.WithDeleterFunc(
+		func(resource string, log logger.Logger) error {
+			return s.market.For(log).Cancel(resource)
+		},
+	)
+
+	return ed
+}
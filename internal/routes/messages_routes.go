@@ -1,8 +1,8 @@
 package routes
 
 import (
-	"net/http"
 	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
 )
 
 // listMessages :
@@ -10,7 +10,7 @@ import (
 // the requests on messages.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listMessages() http.HandlerFunc {
+func (s *Server) listMessages() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("messages")
 
@@ -23,10 +23,10 @@ func (s *Server) listMessages() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("messages")
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.og.Messages.Messages(s.proxy, filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.og.Current().Messages.Messages(s.proxy, filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
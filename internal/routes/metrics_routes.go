@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"net/http"
+	"oglike_server/pkg/metrics"
+	"strconv"
+	"time"
+)
+
+// requestsCount :
+// Counts the requests served by the server for each
+// route, method and status code.
+var requestsCount = metrics.NewCounter(
+	"oglike_http_requests_total",
+	"Number of HTTP requests served.",
+	"method",
+	"route",
+	"code",
+)
+
+// requestsDuration :
+// Measures the time needed to serve requests for each
+// route and method.
+var requestsDuration = metrics.NewHistogram(
+	"oglike_http_request_duration_seconds",
+	"Time needed to serve HTTP requests.",
+	metrics.DefaultDurationBuckets,
+	"method",
+	"route",
+)
+
+// statusRecorder :
+// Wraps a response writer to keep track of the status
+// code sent to the client.
+//
+// The `status` defines the status code sent so far. It
+// is set to `200` if nothing is written explicitly.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader :
+// Overrides the method of the wrapped writer to record
+// the status code.
+//
+// The `code` defines the status code to send.
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Flush :
+// Forwards the flush request to the wrapped writer if
+// it supports it. This is needed to stream events.
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// instrument :
+// Used to wrap the input handler so that the number of
+// requests and the time needed to serve them are kept
+// in the metrics of the server.
+//
+// The `method` defines the method of the route.
+//
+// The `route` defines the name of the route. It is used
+// rather than the path of the request to keep a bounded
+// number of series.
+//
+// The `next` defines the handler to wrap.
+//
+// Returns the wrapped handler.
+func instrument(method string, route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sr := &statusRecorder{w, http.StatusOK}
+
+		next.ServeHTTP(sr, r)
+
+		requestsCount.Inc(method, route, strconv.Itoa(sr.status))
+		requestsDuration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
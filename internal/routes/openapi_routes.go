@@ -0,0 +1,476 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"oglike_server/pkg/logger"
+	"oglike_server/pkg/openapi"
+	"sort"
+	"strings"
+)
+
+// endpoint :
+// Common interface of the elements that can be bound to
+// a route of the server. In addition to the handler to
+// serve the requests it allows the endpoint to describe
+// what it knows of the route (filters, data key, etc.)
+// to generate the specification of the API.
+//
+// The `ServeRoute` returns the handler to serve requests.
+//
+// The `describe` completes the documentation of the route
+// with the information available in the endpoint.
+type endpoint interface {
+	ServeRoute(log logger.Logger) http.HandlerFunc
+	describe(doc *routeDoc)
+}
+
+// handlerEndpoint :
+// Allows to use a plain handler as an endpoint when it
+// does not rely on one of the generic endpoints. All the
+// documentation should be provided with the route.
+type handlerEndpoint http.HandlerFunc
+
+// routeDoc :
+// Describes a route of the server for its documentation.
+// Part of it is filled by the endpoint bound to the route
+// while the rest is provided when the route is declared.
+//
+// The `method` defines the method of the route.
+//
+// The `name` defines the pattern of the route as it is
+// registered in the router.
+//
+// The `description` briefly describes the route. It is
+// mandatory.
+//
+// The `params` defines the names of the parameters that
+// are part of the path, in order.
+//
+// The `query` defines the names of the query parameters
+// accepted by the route.
+//
+// The `resource` defines whether the identifier of a
+// single resource can be appended to the route.
+//
+// The `key` defines the name of the form value holding
+// the data of the request. It is empty if the route does
+// not expect data.
+//
+// The `body` defines a value whose type is the one of
+// the data of the request.
+//
+// The `response` defines a value whose type is the one
+// of the response.
+//
+// The `preview` defines a value whose type is the one of
+// the response to a dry run.
+//
+// The `dryRun` defines whether the route supports dry
+// runs, in which case a `preview` should be provided.
+//
+// The `mediaType` defines the media type of the response.
+//
+// The `creation` defines whether the route creates some
+// resources, in which case their paths are returned and
+// a `body` should be provided.
+//
+// The `listing` defines whether the route returns some
+// resources, in which case a `response` should be given.
+//
+// The `limited` defines whether the route is subject to
+// the limits of the server.
+//
+// The `conditional` defines whether the responses of the
+// route carry an entity tag, in which case the clients can
+// revalidate them with the `If-None-Match` header.
+type routeDoc struct {
+	method      string
+	name        string
+	description string
+	params      []string
+	query       []string
+	resource    bool
+	key         string
+	body        interface{}
+	response    interface{}
+	preview     interface{}
+	dryRun      bool
+	mediaType   string
+	creation    bool
+	listing     bool
+	limited     bool
+	conditional bool
+}
+
+// ErrUndocumentedRoute :
+// Used to indicate that a route does not define all the
+// information needed to document it.
+var ErrUndocumentedRoute = fmt.Errorf("route is not documented")
+
+// routeParamPattern :
+// Defines the pattern used in the routes to match the
+// identifier of a resource.
+var routeParamPattern = "[a-zA-Z0-9-]+"
+
+// ServeRoute :
+// Implementation of the `endpoint` interface which
+// returns the handler itself.
+//
+// The `log` is not used.
+//
+// Returns the wrapped handler.
+func (he handlerEndpoint) ServeRoute(log logger.Logger) http.HandlerFunc {
+	return http.HandlerFunc(he)
+}
+
+// describe :
+// Implementation of the `endpoint` interface. Nothing
+// is known about the handler.
+//
+// The `doc` defines the documentation to complete.
+func (he handlerEndpoint) describe(doc *routeDoc) {}
+
+// newRouteDoc :
+// Creates a new documentation for the route with the
+// specified method and pattern. The response is assumed
+// to be some JSON data.
+//
+// The `method` defines the method of the route.
+//
+// The `name` defines the pattern of the route.
+//
+// Returns the created documentation.
+func newRouteDoc(method string, name string) *routeDoc {
+	return &routeDoc{
+		method:    method,
+		name:      name,
+		mediaType: "application/json",
+	}
+}
+
+// WithDescription :
+// Defines the description of the route.
+//
+// The `desc` defines the description.
+//
+// Returns this documentation to allow chain calling.
+func (rd *routeDoc) WithDescription(desc string) *routeDoc {
+	rd.description = desc
+	return rd
+}
+
+// WithParams :
+// Defines the names of the parameters of the path of
+// the route. There should be one name for each part of
+// the path matching an identifier.
+//
+// The `params` defines the names of the parameters.
+//
+// Returns this documentation to allow chain calling.
+func (rd *routeDoc) WithParams(params ...string) *routeDoc {
+	rd.params = append(rd.params, params...)
+	return rd
+}
+
+// WithQuery :
+// Defines some query parameters accepted by the route
+// in addition to the filters of the endpoint.
+//
+// The `query` defines the names of the parameters.
+//
+// Returns this documentation to allow chain calling.
+func (rd *routeDoc) WithQuery(query ...string) *routeDoc {
+	rd.query = append(rd.query, query...)
+	return rd
+}
+
+// WithBody :
+// Defines the type of the data expected by the route.
+//
+// The `body` defines a value of the expected type.
+//
+// Returns this documentation to allow chain calling.
+func (rd *routeDoc) WithBody(body interface{}) *routeDoc {
+	rd.body = body
+	return rd
+}
+
+// WithResponse :
+// Defines the type of the response of the route.
+//
+// The `response` defines a value of the returned type.
+//
+// Returns this documentation to allow chain calling.
+func (rd *routeDoc) WithResponse(response interface{}) *routeDoc {
+	rd.response = response
+	return rd
+}
+
+// WithPreview :
+// Defines the type of the response of the route when a
+// dry run is requested.
+//
+// The `preview` defines a value of the returned type.
+//
+// Returns this documentation to allow chain calling.
+func (rd *routeDoc) WithPreview(preview interface{}) *routeDoc {
+	rd.preview = preview
+	return rd
+}
+
+// WithMediaType :
+// Defines the media type of the response of the route
+// when it is not JSON.
+//
+// The `mediaType` defines the media type.
+//
+// Returns this documentation to allow chain calling.
+func (rd *routeDoc) WithMediaType(mediaType string) *routeDoc {
+	rd.mediaType = mediaType
+	return rd
+}
+
+// validate :
+// Used to make sure that the route is documented. It
+// must have a description, name all the parameters of
+// its path and describe the data it handles.
+//
+// Returns any error.
+func (rd *routeDoc) validate() error {
+	missing := ""
+
+	switch {
+	case rd.description == "":
+		missing = "description"
+	case rd.creation && rd.body == nil:
+		missing = "body"
+	case rd.dryRun && rd.preview == nil:
+		missing = "preview"
+	case rd.listing && rd.response == nil:
+		missing = "response"
+	}
+
+	if missing != "" {
+		return fmt.Errorf("%v (route: %s \"%s\", err: missing %s)", ErrUndocumentedRoute, rd.method, rd.name, missing)
+	}
+
+	count := strings.Count(rd.name, routeParamPattern)
+	if count != len(rd.params) {
+		return fmt.Errorf("%v (route: %s \"%s\", err: %d parameter(s) named out of %d)", ErrUndocumentedRoute, rd.method, rd.name, len(rd.params), count)
+	}
+
+	return nil
+}
+
+// path :
+// Used to convert the pattern of the route into a path
+// following the OpenAPI syntax where the identifiers
+// are replaced by the names of the parameters.
+//
+// Returns the path of the route.
+func (rd *routeDoc) path() string {
+	out := rd.name
+
+	for _, param := range rd.params {
+		out = strings.Replace(out, routeParamPattern, fmt.Sprintf("{%s}", param), 1)
+	}
+
+	return out
+}
+
+// operation :
+// Used to build the OpenAPI operation describing the
+// route. Schemas are registered in the input document.
+//
+// The `doc` defines the document to which the schemas
+// should be added.
+//
+// Returns the operation.
+func (rd *routeDoc) operation(doc *openapi.Document) *openapi.Operation {
+	op := &openapi.Operation{
+		Summary:   rd.description,
+		Tags:      []string{strings.Split(strings.TrimPrefix(rd.name, "/"), "/")[0]},
+		Responses: make(map[string]openapi.Response),
+	}
+
+	for _, param := range rd.params {
+		op.Parameters = append(op.Parameters, pathParameter(param))
+	}
+
+	query := append([]string{}, rd.query...)
+	sort.Strings(query)
+
+	explode := true
+	for _, name := range query {
+		op.Parameters = append(
+			op.Parameters,
+			openapi.Parameter{
+				Name:    name,
+				In:      "query",
+				Explode: &explode,
+				Schema:  &openapi.Schema{Type: "string"},
+			},
+		)
+	}
+
+	if rd.dryRun {
+		op.Parameters = append(
+			op.Parameters,
+			openapi.Parameter{
+				Name:        dryRunKey,
+				In:          "query",
+				Description: "validates the data without creating anything",
+				Schema:      &openapi.Schema{Type: "boolean"},
+			},
+		)
+	}
+
+	if rd.key != "" {
+		data := doc.SchemaOf(rd.body)
+		data.Description = "JSON data, can be repeated"
+
+		schema := &openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				rd.key: data,
+			},
+		}
+
+		op.RequestBody = &openapi.RequestBody{
+			Required: true,
+			Content: map[string]openapi.MediaType{
+				"multipart/form-data": {
+					Schema: schema,
+					Encoding: map[string]openapi.Encoding{
+						rd.key: {ContentType: "application/json"},
+					},
+				},
+				"application/x-www-form-urlencoded": {
+					Schema: schema,
+				},
+			},
+		}
+	}
+
+	switch {
+	case rd.creation:
+		op.Responses["201"] = openapi.Response{
+			Description: "paths of the created resources",
+			Content: map[string]openapi.MediaType{
+				"application/json": {Schema: doc.SchemaOf([]string{})},
+			},
+		}
+
+		if rd.dryRun {
+			op.Responses["200"] = openapi.Response{
+				Description: "preview of the resources in case of a dry run",
+				Content: map[string]openapi.MediaType{
+					"application/json": {Schema: doc.SchemaOf(rd.preview)},
+				},
+			}
+		}
+
+		op.Responses["400"] = openapi.Response{Description: "invalid data"}
+		op.Responses["413"] = openapi.Response{Description: "request body too large"}
+	case rd.response != nil:
+		op.Responses["200"] = openapi.Response{
+			Description: "success",
+			Content: map[string]openapi.MediaType{
+				rd.mediaType: {Schema: doc.SchemaOf(rd.response)},
+			},
+		}
+	default:
+		op.Responses["200"] = openapi.Response{Description: "success"}
+	}
+
+	if rd.conditional {
+		op.Parameters = append(
+			op.Parameters,
+			openapi.Parameter{
+				Name:        "If-None-Match",
+				In:          "header",
+				Description: "entity tags of the responses already held by the client",
+				Schema:      &openapi.Schema{Type: "string"},
+			},
+		)
+
+		op.Responses["304"] = openapi.Response{Description: "not modified, the client already holds the response"}
+	}
+
+	if rd.limited {
+		op.Responses["429"] = openapi.Response{Description: "too many requests, see the `Retry-After` header"}
+		op.Responses["503"] = openapi.Response{Description: "server is not ready"}
+	}
+
+	return op
+}
+
+// pathParameter :
+// Used to describe the parameter of a path with the
+// input name.
+//
+// The `name` defines the name of the parameter.
+//
+// Returns the parameter.
+func pathParameter(name string) openapi.Parameter {
+	return openapi.Parameter{
+		Name:     name,
+		In:       "path",
+		Required: true,
+		Schema:   &openapi.Schema{Type: "string"},
+	}
+}
+
+// specification :
+// Used to generate the OpenAPI document describing all
+// the routes registered in the server. Routes accepting
+// the identifier of a resource appended to their path
+// are described a second time with this identifier.
+//
+// Returns the marshalled document along with any error
+// in case a route is not properly documented.
+func (s *Server) specification() ([]byte, error) {
+	doc := openapi.NewDocument(
+		"oglike server",
+		"REST API of the oglike server.",
+		"1.0.0",
+	)
+
+	for _, rd := range s.docs {
+		err := rd.validate()
+		if err != nil {
+			return nil, err
+		}
+
+		doc.AddOperation(rd.path(), rd.method, rd.operation(doc))
+
+		if rd.resource {
+			op := rd.operation(doc)
+			op.Parameters = append([]openapi.Parameter{pathParameter("id")}, op.Parameters...)
+
+			doc.AddOperation(rd.path()+"/{id}", rd.method, op)
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+// serveSpecification :
+// Used to create a handler returning the OpenAPI document
+// describing the routes of the server. The document is
+// generated when the server starts.
+//
+// Returns the handler to serve said requests.
+func (s *Server) serveSpecification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		_, err := w.Write(s.spec)
+		if err != nil {
+			logger.FromContext(r.Context(), s.log).Trace(logger.Error, "server", fmt.Sprintf("Error while sending specification to client (err: %v)", err))
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"oglike_server/pkg/dispatcher"
+	"oglike_server/pkg/logger"
+	"testing"
+)
+
+// discardLogger :
+// Logger ignoring all the messages, used to build a server
+// without any output.
+type discardLogger struct{}
+
+func (discardLogger) Trace(level logger.Severity, module string, message string) {}
+
+// newDocumentedServer :
+// Builds a server with all its routes registered so that
+// their documentation can be checked. No DB is needed as
+// the handlers are not invoked.
+func newDocumentedServer() *Server {
+	s := &Server{
+		router: dispatcher.NewRouter(discardLogger{}),
+		log:    discardLogger{},
+	}
+
+	s.routes()
+
+	return s
+}
+
+func TestRoutesAreDocumented(t *testing.T) {
+	s := newDocumentedServer()
+
+	if len(s.docs) == 0 {
+		t.Fatalf("no routes registered")
+	}
+
+	for _, rd := range s.docs {
+		if err := rd.validate(); err != nil {
+			t.Errorf("route %s \"%s\" is not documented (err: %v)", rd.method, rd.name, err)
+		}
+	}
+
+	if _, err := s.specification(); err != nil {
+		t.Errorf("could not generate specification (err: %v)", err)
+	}
+}
+
+func TestUndocumentedRouteIsRejected(t *testing.T) {
+	cases := []struct {
+		name string
+		doc  *routeDoc
+	}{
+		{
+			name: "missing description",
+			doc:  newRouteDoc("GET", "/resources"),
+		},
+		{
+			name: "unnamed parameter",
+			doc:  newRouteDoc("GET", "/players/"+routeParamPattern+"/events").WithDescription("Events of a player."),
+		},
+		{
+			name: "too many parameters",
+			doc:  newRouteDoc("GET", "/resources").WithDescription("Resources.").WithParams("resource"),
+		},
+	}
+
+	for _, c := range cases {
+		if err := c.doc.validate(); err == nil {
+			t.Errorf("%s: route was accepted", c.name)
+		}
+	}
+
+	// The specification can't be generated as soon as one
+	// of the routes is not documented.
+	s := newDocumentedServer()
+	s.docs = append(s.docs, newRouteDoc("GET", "/undocumented"))
+
+	if _, err := s.specification(); err == nil {
+		t.Errorf("specification was generated with an undocumented route")
+	}
+}
@@ -2,9 +2,11 @@ package routes
 
 import (
 	"encoding/json"
-	"net/http"
+	"fmt"
 	"oglike_server/internal/game"
 	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+	"strings"
 )
 
 // listPlanets :
@@ -12,7 +14,7 @@ import (
 // the requests on planets.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listPlanets() http.HandlerFunc {
+func (s *Server) listPlanets() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("planets")
 
@@ -29,12 +31,76 @@ func (s *Server) listPlanets() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("p.id").WithModule("planets").WithLocker(s.og)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.planets.Planets(filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.planets.For(log).Planets(filters)
 		},
 	)
+	ed.WithWeakETag(
+		func(data interface{}) string {
+			// The planets are versioned by the last date at
+			// which an event occurred on each of them.
+			planets, _ := data.([]game.Planet)
+
+			var tag strings.Builder
+			for _, p := range planets {
+				tag.WriteString(fmt.Sprintf("%s:%d;", p.ID, p.LastActivity.UnixNano()))
+			}
 
-	return ed.ServeRoute(s.log)
+			return tag.String()
+		},
+	)
+
+	return ed
+}
+
+// listFields :
+// Used to perform the creation of a handler allowing to serve
+// the requests on the fields of planets or moons.
+//
+// The `route` defines the route of the endpoint: either the
+// planets or the moons.
+//
+// The `moon` defines whether the endpoint serves moons.
+//
+// Returns the handler that can be executed to serve said reqs.
+func (s *Server) listFields(route string, moon bool) endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewGetResourceEndpoint(route)
+
+	// Configure the endpoint.
+	ed.WithIDFilter("id").WithModule(route).WithLocker(s.og)
+	ed.WithDataFunc(
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			// The identifier of the route is mandatory.
+			if len(filters) == 0 || len(filters[0].Values) == 0 {
+				return nil, game.ErrElementNotFound
+			}
+
+			ID := fmt.Sprintf("%v", filters[0].Values[0])
+
+			return s.planets.For(log).Fields(ID, moon)
+		},
+	)
+
+	return ed
+}
+
+// listPlanetFields :
+// Used to perform the creation of a handler allowing to serve
+// the requests on the fields of a planet.
+//
+// Returns the handler that can be executed to serve said reqs.
+func (s *Server) listPlanetFields() endpoint {
+	return s.listFields("planets", false)
+}
+
+// listMoonFields :
+// Used to perform the creation of a handler allowing to serve
+// the requests on the fields of a moon.
+//
+// Returns the handler that can be executed to serve said reqs.
+func (s *Server) listMoonFields() endpoint {
+	return s.listFields("moons", true)
 }
 
 // listMoons :
@@ -42,7 +108,7 @@ func (s *Server) listPlanets() http.HandlerFunc {
 // the requests on moons.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listMoons() http.HandlerFunc {
+func (s *Server) listMoons() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("moons")
 
@@ -60,12 +126,12 @@ func (s *Server) listMoons() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("m.id").WithModule("moons").WithLocker(s.og)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.planets.Moons(filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.planets.For(log).Moons(filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // listDebris :
@@ -73,7 +139,7 @@ func (s *Server) listMoons() http.HandlerFunc {
 // the requests on debris.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listDebris() http.HandlerFunc {
+func (s *Server) listDebris() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("debris")
 
@@ -88,12 +154,12 @@ func (s *Server) listDebris() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("d.id").WithModule("debris").WithLocker(s.og)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.planets.Debris(filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.planets.For(log).Debris(filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // changePlanets :
@@ -101,14 +167,14 @@ func (s *Server) listDebris() http.HandlerFunc {
 // the requests to change a planet.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) changePlanets() http.HandlerFunc {
+func (s *Server) changePlanets() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewCreateResourceEndpoint("planets")
 
 	// Configure the endpoint.
 	ed.WithDataKey("planet-data").WithModule("planets").WithLocker(s.og)
 	ed.WithCreationFunc(
-		func(input RouteData) ([]string, error) {
+		func(input RouteData, log logger.Logger) ([]string, error) {
 			// We need to iterate over the data retrieved from the route and
 			// create planets from it.
 			var planet game.Planet
@@ -137,7 +203,7 @@ func (s *Server) changePlanets() http.HandlerFunc {
 				planet.ID = planetID
 
 				// Update the planet.
-				res, err := s.planets.Update(planet)
+				res, err := s.planets.For(log).Update(planet)
 				if err != nil {
 					return resources, err
 				}
@@ -151,7 +217,7 @@ func (s *Server) changePlanets() http.HandlerFunc {
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // changeProduction :
@@ -160,14 +226,14 @@ func (s *Server) changePlanets() http.HandlerFunc {
 // of a planet.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) changeProduction() http.HandlerFunc {
+func (s *Server) changeProduction() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewCreateResourceEndpoint("planets")
 
 	// Configure the endpoint.
 	ed.WithDataKey("planet-data").WithModule("planets").WithLocker(s.og)
 	ed.WithCreationFunc(
-		func(input RouteData) ([]string, error) {
+		func(input RouteData, log logger.Logger) ([]string, error) {
 			// We need to iterate over the data retrieved from the route and
 			// create planets from it.
 			var production []game.BuildingInfo
@@ -193,7 +259,7 @@ func (s *Server) changeProduction() http.HandlerFunc {
 				}
 
 				// Update the planet.
-				res, err := s.planets.UpdateProduction(planetID, production)
+				res, err := s.planets.For(log).UpdateProduction(planetID, production)
 				if err != nil {
 					return resources, err
 				}
@@ -207,7 +273,7 @@ func (s *Server) changeProduction() http.HandlerFunc {
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // changeMoons :
@@ -215,14 +281,14 @@ func (s *Server) changeProduction() http.HandlerFunc {
 // the requests to change a moon.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) changeMoons() http.HandlerFunc {
+func (s *Server) changeMoons() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewCreateResourceEndpoint("moons")
 
 	// Configure the endpoint.
 	ed.WithDataKey("moon-data").WithModule("moons").WithLocker(s.og)
 	ed.WithCreationFunc(
-		func(input RouteData) ([]string, error) {
+		func(input RouteData, log logger.Logger) ([]string, error) {
 			// We need to iterate over the data retrieved from the route and
 			// create moons from it.
 			var moon game.Planet
@@ -253,7 +319,7 @@ func (s *Server) changeMoons() http.HandlerFunc {
 				moon.Moon = true
 
 				// Update the planet.
-				res, err := s.planets.Update(moon)
+				res, err := s.planets.For(log).Update(moon)
 				if err != nil {
 					return resources, err
 				}
@@ -267,7 +333,7 @@ func (s *Server) changeMoons() http.HandlerFunc {
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // deletePlanet :
@@ -275,17 +341,170 @@ func (s *Server) changeMoons() http.HandlerFunc {
 // the requests to delete a planet.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) deletePlanet() http.HandlerFunc {
+func (s *Server) deletePlanet() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewDeleteResourceEndpoint("planets")
 
 	// Configure the endpoint.
 	ed.WithModule("planets").WithLocker(s.og)
 	ed.WithDeleterFunc(
-		func(resource string) error {
-			return s.planets.Delete(resource)
+		func(resource string, log logger.Logger) error {
+			return s.planets.For(log).Delete(resource)
+		},
+	)
+
+	return ed
+}
+
+// listRelocations :
+// Used to perform the creation of a handler allowing to serve
+// the requests on the relocations of planets.
+//
+// Returns the handler that can be executed to serve said reqs.
+func (s *Server) listRelocations() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewGetResourceEndpoint("planets/relocations")
+
+	allowed := map[string]string{
+		"id":       "id",
+		"universe": "universe",
+		"player":   "player",
+		"planet":   "planet",
+	}
+
+	// Configure the endpoint.
+	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("planets").WithLocker(s.og)
+	ed.WithDataFunc(
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.planets.For(log).Relocations(filters)
+		},
+	)
+
+	return ed
+}
+
+// relocatePlanet :
+// Used to perform the creation of a handler allowing to serve
+// the requests to relocate a planet to new coordinates.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) relocatePlanet() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewCreateResourceEndpoint("planets")
+
+	// Configure the endpoint.
+	ed.WithDataKey("relocation-data").WithModule("planets").WithLocker(s.og)
+	ed.WithCreationFunc(
+		func(input RouteData, log logger.Logger) ([]string, error) {
+			resources := make([]string, 0)
+
+			// Prevent request with no data.
+			if len(input.Data) == 0 {
+				return resources, ErrNoData
+			}
+
+			// The `ExtraElems` should provide the planet's id.
+			if len(input.ExtraElems) == 0 {
+				return resources, ErrInvalidData
+			}
+
+			planet := input.ExtraElems[0]
+
+			for _, rawData := range input.Data {
+				// Try to unmarshal the data into a valid `Relocation` struct.
+				var relocation game.Relocation
+
+				err := json.Unmarshal([]byte(rawData), &relocation)
+				if err != nil {
+					return resources, ErrInvalidData
+				}
+
+				relocation.Planet = planet
+
+				res, err := s.planets.For(log).Relocate(relocation)
+				if err != nil {
+					return resources, err
+				}
+
+				resources = append(resources, res)
+			}
+
+			return resources, nil
+		},
+	)
+
+	return ed
+}
+
+// cancelRelocation :
+// Used to perform the creation of a handler allowing to serve
+// the requests to cancel the relocation of a planet.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) cancelRelocation() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewDeleteResourceEndpoint("planets/relocations")
+
+	// Configure the endpoint.
+	ed.WithModule("planets").WithLocker(s.og)
+	ed.WithDeleterFunc(
+		func(resource string, log logger.Logger) error {
+			return s.planets.For(log).CancelRelocation(resource)
+		},
+	)
+
+	return ed
+}
+
+// tradeResources :
+// Used to perform the creation of a handler allowing to serve
+// the requests to trade resources of a planet with the merchant.
+//
+// Returns the handler to execute to perform said requests.
+func (s *Server) tradeResources() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewCreateResourceEndpoint("planets")
+
+	// Configure the endpoint.
+	ed.WithDataKey("trade-data").WithModule("planets").WithLocker(s.og)
+	ed.WithCreationFunc(
+		func(input RouteData, log logger.Logger) ([]string, error) {
+			resources := make([]string, 0)
+
+			// Prevent request with no data.
+			if len(input.Data) == 0 {
+				return resources, ErrNoData
+			}
+
+			// The `ExtraElems` should provide the planet's id.
+			if len(input.ExtraElems) == 0 {
+				return resources, ErrInvalidData
+			}
+
+			planet := input.ExtraElems[0]
+
+			for _, rawData := range input.Data {
+				// Try to unmarshal the data into a valid `Trade` struct.
+				var trade game.Trade
+
+				err := json.Unmarshal([]byte(rawData), &trade)
+				if err != nil {
+					return resources, ErrInvalidData
+				}
+
+				trade.Planet = planet
+
+				res, err := s.planets.For(log).Trade(trade)
+				if err != nil {
+					return resources, err
+				}
+
+				resources = append(resources, res)
+			}
+
+			return resources, nil
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
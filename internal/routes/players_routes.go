@@ -2,9 +2,10 @@ package routes
 
 import (
 	"encoding/json"
-	"net/http"
+	"fmt"
 	"oglike_server/internal/game"
 	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
 )
 
 // listPlayers :
@@ -12,7 +13,7 @@ import (
 // the requests on players.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listPlayers() http.HandlerFunc {
+func (s *Server) listPlayers() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("players")
 
@@ -26,12 +27,12 @@ func (s *Server) listPlayers() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("players").WithLocker(s.og)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.players.Players(filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.players.For(log).Players(filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // listPlayersMessages :
@@ -39,7 +40,7 @@ func (s *Server) listPlayers() http.HandlerFunc {
 // the requests to list messages.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) listPlayersMessages() http.HandlerFunc {
+func (s *Server) listPlayersMessages() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("players")
 
@@ -50,12 +51,12 @@ func (s *Server) listPlayersMessages() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithIDFilter("mp.player").WithResourceFilter("mp.id").WithModule("players").WithLocker(s.og)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.players.Messages(filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.players.For(log).Messages(filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // createPlayer :
@@ -63,14 +64,14 @@ func (s *Server) listPlayersMessages() http.HandlerFunc {
 // the requests to create players.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) createPlayer() http.HandlerFunc {
+func (s *Server) createPlayer() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewCreateResourceEndpoint("players")
 
 	// Configure the endpoint.
 	ed.WithDataKey("player-data").WithModule("players").WithLocker(s.og)
 	ed.WithCreationFunc(
-		func(input RouteData) ([]string, error) {
+		func(input RouteData, log logger.Logger) ([]string, error) {
 			// We need to iterate over the data retrieved from the route and
 			// create players from it.
 			var player game.Player
@@ -89,7 +90,7 @@ func (s *Server) createPlayer() http.HandlerFunc {
 				}
 
 				// Create the player.
-				res, err := s.players.Create(player)
+				res, err := s.players.For(log).Create(player)
 				if err != nil {
 					return resources, err
 				}
@@ -98,7 +99,7 @@ func (s *Server) createPlayer() http.HandlerFunc {
 				player.ID = res
 
 				// Choose a homeworld for this account and create it.
-				_, err = s.planets.CreateFor(player)
+				_, err = s.planets.For(log).CreateFor(player)
 
 				if err != nil {
 					// Indicate that we could not create the planet for the player. It
@@ -121,7 +122,98 @@ func (s *Server) createPlayer() http.HandlerFunc {
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
+}
+
+// listPlayerRankingsHistory :
+// Used to perform the creation of a handler allowing to serve
+// the requests on the history of the rankings of a player. It
+// can be refined with the `category`, `from` and `to` query
+// parameters.
+//
+// Returns the handler that can be executed to serve said reqs.
+func (s *Server) listPlayerRankingsHistory() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewGetResourceEndpoint("players")
+
+	// Configure the endpoint.
+	ed.WithIDFilter("rsp.player").WithModule("players").WithLocker(s.og)
+	ed.WithParamsDataFunc(
+		func(filters []db.Filter, params map[string]Values, log logger.Logger) (interface{}, error) {
+			// The identifier of the route is mandatory.
+			if len(filters) == 0 {
+				return nil, game.ErrElementNotFound
+			}
+
+			category := stringParam(params, "category")
+			if category != "" && !game.ValidRankingCategory(category) {
+				return nil, ErrInvalidQueryParameter
+			}
+
+			from, err := timeParam(params, "from")
+			if err != nil {
+				return nil, err
+			}
+			to, err := timeParam(params, "to")
+			if err != nil {
+				return nil, err
+			}
+
+			if !from.IsZero() {
+				filters = append(
+					filters,
+					db.Filter{
+						Key:      "rs.created_at",
+						Values:   []interface{}{from},
+						Operator: db.GreaterThan,
+					},
+				)
+			}
+			if !to.IsZero() {
+				filters = append(
+					filters,
+					db.Filter{
+						Key:      "rs.created_at",
+						Values:   []interface{}{to},
+						Operator: db.LessThan,
+					},
+				)
+			}
+
+			return s.players.For(log).RankingsHistory(filters, category)
+		},
+	)
+
+	return ed
+}
+
+// listPlayerFleetsMovements :
+// Used to perform the creation of a handler allowing to serve
+// the requests on the movements of fleets related to a player.
+// This includes the fleets of the player and the ones heading
+// towards its planets or moons.
+//
+// Returns the handler that can be executed to serve said reqs.
+func (s *Server) listPlayerFleetsMovements() endpoint {
+	// Create the endpoint with the suited route.
+	ed := NewGetResourceEndpoint("players")
+
+	// Configure the endpoint.
+	ed.WithIDFilter("id").WithModule("players").WithLocker(s.og)
+	ed.WithDataFunc(
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			// The identifier of the route is mandatory.
+			if len(filters) == 0 || len(filters[0].Values) == 0 {
+				return nil, game.ErrElementNotFound
+			}
+
+			player := fmt.Sprintf("%v", filters[0].Values[0])
+
+			return s.players.For(log).FleetsMovements(player)
+		},
+	)
+
+	return ed
 }
 
 // changePlayers :
@@ -129,14 +221,14 @@ func (s *Server) createPlayer() http.HandlerFunc {
 // the requests to change a player.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) changePlayers() http.HandlerFunc {
+func (s *Server) changePlayers() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewCreateResourceEndpoint("players")
 
 	// Configure the endpoint.
 	ed.WithDataKey("player-data").WithModule("players").WithLocker(s.og)
 	ed.WithCreationFunc(
-		func(input RouteData) ([]string, error) {
+		func(input RouteData, log logger.Logger) ([]string, error) {
 			// We need to iterate over the data retrieved from the route and
 			// create players from it.
 			var player game.Player
@@ -154,7 +246,26 @@ func (s *Server) changePlayers() http.HandlerFunc {
 				return resources, ErrNoData
 			}
 
+			// Fetch the current state of the player so that any
+			// property not specified in the input data is kept.
+			players, err := s.players.For(log).Players(
+				[]db.Filter{
+					{
+						Key:    "id",
+						Values: []interface{}{playerID},
+					},
+				},
+			)
+			if err != nil {
+				return resources, err
+			}
+			if len(players) != 1 {
+				return resources, game.ErrElementNotFound
+			}
+
 			for _, rawData := range input.Data {
+				player = players[0]
+
 				// Try to unmarshal the data into a valid `Player` struct.
 				err := json.Unmarshal([]byte(rawData), &player)
 				if err != nil {
@@ -165,7 +276,7 @@ func (s *Server) changePlayers() http.HandlerFunc {
 				player.ID = playerID
 
 				// Update the player.
-				res, err := s.players.Update(player)
+				res, err := s.players.For(log).Update(player)
 				if err != nil {
 					return resources, err
 				}
@@ -179,7 +290,7 @@ func (s *Server) changePlayers() http.HandlerFunc {
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // deletePlayer :
@@ -187,17 +298,17 @@ func (s *Server) changePlayers() http.HandlerFunc {
 // the requests to delete a player.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) deletePlayer() http.HandlerFunc {
+func (s *Server) deletePlayer() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewDeleteResourceEndpoint("players")
 
 	// Configure the endpoint.
 	ed.WithModule("players").WithLocker(s.og)
 	ed.WithDeleterFunc(
-		func(resource string) error {
-			return s.players.Delete(resource)
+		func(resource string, log logger.Logger) error {
+			return s.players.For(log).Delete(resource)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
@@ -0,0 +1,160 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"oglike_server/internal/game"
+	"oglike_server/pkg/logger"
+	"oglike_server/pkg/metrics"
+	"os"
+	"time"
+)
+
+// reloadResult :
+// Describes the outcome of a reload of the data model.
+//
+// The `Status` is either "reloaded" or "failed".
+//
+// The `Duration` defines the time it took to load and
+// validate the data model in seconds.
+//
+// The `Error` describes the reason of the failure if
+// any. The previous data model is kept in this case.
+type reloadResult struct {
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// ErrReloadFailed :
+// Used to indicate that the data model could not be
+// reloaded from the DB.
+var ErrReloadFailed = fmt.Errorf("unable to reload data model")
+
+// modelReloads :
+// Counts the reloads of the data model for each status.
+var modelReloads = metrics.NewCounter(
+	"oglike_model_reloads_total",
+	"Number of reloads of the data model.",
+	"status",
+)
+
+// reloadModel :
+// Used to load a new data model from the DB and to
+// install it in place of the current one. All the
+// modules are loaded into fresh instances and checked
+// for consistency before being installed: the current
+// model is kept if anything goes wrong. Only a single
+// reload can be performed at a time.
+//
+// Returns any error.
+func (s *Server) reloadModel() error {
+	if !s.health.initialized() {
+		return ErrNotInitialized
+	}
+
+	s.health.reloading.Lock()
+	defer s.health.reloading.Unlock()
+
+	m := game.NewModel(s.log)
+
+	for _, module := range m.Modules() {
+		err := module.Init(s.proxy, true)
+		if err != nil {
+			modelReloads.Inc("failure")
+			return fmt.Errorf("%v (err: %v)", ErrReloadFailed, err)
+		}
+	}
+
+	err := m.Validate()
+	if err != nil {
+		modelReloads.Inc("failure")
+		return fmt.Errorf("%v (err: %v)", ErrReloadFailed, err)
+	}
+
+	s.og.Swap(m)
+	modelReloads.Inc("success")
+
+	return nil
+}
+
+// reloadOnSignal :
+// Used to reload the data model each time a signal is
+// received on the input channel until the server stops.
+//
+// The `signals` defines the channel notified when the
+// data model should be reloaded.
+func (s *Server) reloadOnSignal(signals chan os.Signal) {
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-signals:
+		}
+
+		s.log.Trace(logger.Notice, "server", "Received signal, reloading data model")
+
+		start := time.Now()
+
+		err := s.reloadModel()
+		if err != nil {
+			s.log.Trace(logger.Error, "server", fmt.Sprintf("Could not reload data model, keeping the current one (err: %v)", err))
+			continue
+		}
+
+		s.log.Trace(logger.Notice, "server", fmt.Sprintf("Reloaded data model in %v", time.Since(start)))
+	}
+}
+
+// reload :
+// Used to create a handler reloading the data model of
+// the server. The response describes the outcome of the
+// operation: a `503` is returned if the data model was
+// not initialized yet and a `500` if it could not be
+// reloaded, in which case the current model is kept.
+//
+// Returns the handler to serve said requests.
+func (s *Server) reload() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context(), s.log)
+
+		start := time.Now()
+
+		err := s.reloadModel()
+
+		res := reloadResult{
+			Status:   "reloaded",
+			Duration: time.Since(start).Seconds(),
+		}
+		code := http.StatusOK
+
+		if err != nil {
+			log.Trace(logger.Error, "server", fmt.Sprintf("Could not reload data model, keeping the current one (err: %v)", err))
+
+			res.Status = "failed"
+			res.Error = err.Error()
+			code = http.StatusInternalServerError
+
+			if err == ErrNotInitialized {
+				code = http.StatusServiceUnavailable
+			}
+		} else {
+			log.Trace(logger.Notice, "server", fmt.Sprintf("Reloaded data model in %v", time.Since(start)))
+		}
+
+		out, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, InternalServerErrorString, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+
+		_, err = w.Write(out)
+		if err != nil {
+			log.Trace(logger.Error, "server", fmt.Sprintf("Error while sending reload result to client (err: %v)", err))
+		}
+	}
+}
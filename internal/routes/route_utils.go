@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Values :
@@ -71,10 +73,15 @@ type RouteVars struct {
 // It is represented as an array of raw strings which are usually
 // unmarshalled into meaningful structures by the data creation
 // process.
+//
+// The `Params` define the query parameters associated to the
+// input request. Note that in some case no parameters are
+// provided.
 type RouteData struct {
 	RouteElems []string
 	ExtraElems []string
 	Data       Values
+	Params     map[string]Values
 }
 
 // ErrInvalidRequest :
@@ -332,6 +339,7 @@ func extractRouteData(route string, dataKey string, r *http.Request) (RouteData,
 		make([]string, 0),
 		make([]string, 0),
 		make([]string, 0),
+		make(map[string]Values),
 	}
 
 	// Extract the route from the input request.
@@ -352,9 +360,21 @@ func extractRouteData(route string, dataKey string, r *http.Request) (RouteData,
 		return elems, err
 	}
 
-	// Fetch the extra elements of the route, but we don't care about the
-	// query parameters for now.
-	elems.ExtraElems, _ = tokenizeRoute(extra)
+	// Fetch the extra elements of the route along with the query params
+	// which might refine the behavior of the creation.
+	var queryStr string
+	elems.ExtraElems, queryStr = tokenizeRoute(extra)
+
+	if len(queryStr) > 0 {
+		params, err := url.ParseQuery(queryStr)
+		if err != nil {
+			return elems, ErrInvalidRequest
+		}
+
+		for key, values := range params {
+			elems.Params[key] = values
+		}
+	}
 
 	// Fetch the data from the input request: as we want to allow
 	// for multiple instances of the same key we need to call the
@@ -366,7 +386,12 @@ func extractRouteData(route string, dataKey string, r *http.Request) (RouteData,
 	// value: this value represents the maximum size loaded in
 	// memory before things begin being dumped to disk. We will
 	// use a large default values.
+	// The body of the request might have been limited: in
+	// this case we want to notify it to the client.
 	err = r.ParseMultipartForm(maxRoutePropsSizeInMemory)
+	if isBodyTooLarge(err) {
+		return elems, ErrRequestTooLarge
+	}
 	if err != nil {
 		return elems, ErrInvalidRequest
 	}
@@ -379,3 +404,71 @@ func extractRouteData(route string, dataKey string, r *http.Request) (RouteData,
 
 	return elems, nil
 }
+
+// stringParam :
+// Used to retrieve the first value of the query parameter
+// with the specified name.
+//
+// The `params` defines the query parameters of a request.
+//
+// The `key` defines the name of the parameter to fetch.
+//
+// Returns the value of the parameter or an empty string
+// if it is not defined.
+func stringParam(params map[string]Values, key string) string {
+	values, ok := params[key]
+	if !ok || len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// intParam :
+// Used to interpret the query parameter with the name
+// specified in input as an integer.
+//
+// The `params` defines the query parameters of a request.
+//
+// The `key` defines the name of the parameter to fetch.
+//
+// Returns the value of the parameter or `0` if it is not
+// defined along with any error.
+func intParam(params map[string]Values, key string) (int, error) {
+	value := stringParam(params, key)
+	if value == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, ErrInvalidQueryParameter
+	}
+
+	return i, nil
+}
+
+// timeParam :
+// Used to interpret the query parameter with the name
+// specified in input as a time using the `RFC3339`
+// syntax.
+//
+// The `params` defines the query parameters of a request.
+//
+// The `key` defines the name of the parameter to fetch.
+//
+// Returns the value of the parameter or a zero time if it
+// is not defined along with any error.
+func timeParam(params map[string]Values, key string) (time.Time, error) {
+	value := stringParam(params, key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidQueryParameter
+	}
+
+	return t, nil
+}
@@ -2,7 +2,10 @@ package routes
 
 import (
 	"net/http"
+	"oglike_server/internal/game"
+	"oglike_server/internal/model"
 	"oglike_server/pkg/dispatcher"
+	"oglike_server/pkg/metrics"
 )
 
 // routes :
@@ -10,50 +13,264 @@ import (
 // All the routes are set up with the adequate handler but no
 // actual binding is done.
 func (s *Server) routes() {
+	// Handle diagnostics routes: they are available even
+	// if the data model is not initialized.
+	s.diagnosticRoute("GET", "/healthz", s.healthz()).
+		WithDescription("Liveness probe, succeeds as long as the server can serve requests.").
+		WithMediaType("text/plain")
+	s.diagnosticRoute("GET", "/readyz", s.readyz()).
+		WithDescription("Readiness probe, answers 503 with the failed checks when the server is not ready.").
+		WithResponse(readiness{})
+	s.diagnosticRoute("GET", "/metrics", metrics.Handler()).
+		WithDescription("Metrics of the server in the Prometheus text format.").
+		WithMediaType("text/plain")
+	s.diagnosticRoute("GET", "/openapi.json", s.serveSpecification()).
+		WithDescription("OpenAPI specification of the server.")
+	s.diagnosticRoute("GET", "/debug/locks", s.adminOnly(s.dumpLocks())).
+		WithDescription("State of the locks of the server, reserved to admins.").
+		WithResponse(locksDump{})
+	s.diagnosticRoute("POST", "/debug/reload", s.adminOnly(s.reload())).
+		WithDescription("Reloads the data model from the DB, reserved to admins.").
+		WithResponse(reloadResult{})
+	s.diagnosticRoute("GET", "/debug/tokens/[a-zA-Z0-9-]+", s.adminOnly(s.issuePlayerToken())).
+		WithDescription("Issues the token allowing to stream the events of a player, reserved to admins.").
+		WithParams("player").
+		WithResponse(playerAccess{})
+	s.profiles()
+
 	// Handle known routes.
-	s.route("GET", "/resources", s.listResources())
-	s.route("GET", "/universes", s.listUniverses())
-	s.route("GET", "/universes/[a-zA-Z0-9-]+/rankings", s.listUniverseRankings())
-	s.route("GET", "/accounts", s.listAccounts())
-	s.route("GET", "/accounts/[a-zA-Z0-9-]+/players", s.listAccountsPlayers())
-	s.route("GET", "/buildings", s.listBuildings())
-	s.route("GET", "/technologies", s.listTechnologies())
-	s.route("GET", "/ships", s.listShips())
-	s.route("GET", "/defenses", s.listDefenses())
-	s.route("GET", "/messages", s.listMessages())
-	s.route("GET", "/players", s.listPlayers())
-	s.route("GET", "/players/[a-zA-Z0-9-]+/messages", s.listPlayersMessages())
-	s.route("GET", "/planets", s.listPlanets())
-	s.route("GET", "/moons", s.listMoons())
-	s.route("GET", "/debris", s.listDebris())
-	s.route("GET", "/fleets", s.listFleets())
-	s.route("GET", "/fleets/acs", s.listACSFleets())
-	s.route("GET", "/fleets/objectives", s.listFleetObjectives())
+	s.route("GET", "/resources", s.listResources()).
+		WithDescription("Lists the resources of the game.").
+		WithResponse([]model.ResourceDesc{})
+	s.route("GET", "/universes", s.listUniverses()).
+		WithDescription("Lists the universes.").
+		WithResponse([]game.Universe{})
+	s.route("GET", "/universes/[a-zA-Z0-9-]+/rankings", s.listUniverseRankings()).
+		WithDescription("Returns a page of the rankings of a universe.").
+		WithParams("universe").
+		WithQuery("category", "at", "page", "count").
+		WithResponse(game.RankingPage{})
+	s.route("GET", "/accounts", s.listAccounts()).
+		WithDescription("Lists the accounts.").
+		WithResponse([]game.Account{})
+	s.route("GET", "/accounts/[a-zA-Z0-9-]+/players", s.listAccountsPlayers()).
+		WithDescription("Lists the players of an account.").
+		WithParams("account").
+		WithResponse([]game.Player{})
+	s.route("GET", "/buildings", s.listBuildings()).
+		WithDescription("Lists the buildings of the game.").
+		WithResponse([]model.BuildingDesc{})
+	s.route("GET", "/technologies", s.listTechnologies()).
+		WithDescription("Lists the technologies of the game.").
+		WithResponse([]model.TechnologyDesc{})
+	s.route("GET", "/ships", s.listShips()).
+		WithDescription("Lists the ships of the game.").
+		WithResponse([]model.ShipDesc{})
+	s.route("GET", "/defenses", s.listDefenses()).
+		WithDescription("Lists the defenses of the game.").
+		WithResponse([]model.DefenseDesc{})
+	s.route("GET", "/messages", s.listMessages()).
+		WithDescription("Lists the kinds of messages of the game.").
+		WithResponse([]model.Message{})
+	s.route("GET", "/players", s.listPlayers()).
+		WithDescription("Lists the players.").
+		WithResponse([]game.Player{})
+	s.route("GET", "/players/[a-zA-Z0-9-]+/messages", s.listPlayersMessages()).
+		WithDescription("Lists the messages of a player.").
+		WithParams("player").
+		WithResponse([]game.Message{})
+	s.route("GET", "/players/[a-zA-Z0-9-]+/rankings/history", s.listPlayerRankingsHistory()).
+		WithDescription("Returns the history of the rankings of a player.").
+		WithParams("player").
+		WithQuery("category", "from", "to").
+		WithResponse([]game.RankingHistoryEntry{})
+	s.route("GET", "/players/[a-zA-Z0-9-]+/events", s.streamPlayerEvents()).
+		WithDescription("Streams the events of a player with the Server-Sent Events protocol.").
+		WithParams("player").
+		WithQuery("last_event_id", "access_token").
+		WithResponse(game.Event{}).
+		WithMediaType("text/event-stream")
+	s.route("GET", "/players/[a-zA-Z0-9-]+/fleets/movements", s.listPlayerFleetsMovements()).
+		WithDescription("Lists the fleets moving from or to the planets of a player.").
+		WithParams("player").
+		WithResponse([]game.FleetMovement{})
+	s.route("GET", "/planets", s.listPlanets()).
+		WithDescription("Lists the planets.").
+		WithResponse([]game.Planet{})
+	s.route("GET", "/planets/[a-zA-Z0-9-]+/fields", s.listPlanetFields()).
+		WithDescription("Describes the fields used on a planet.").
+		WithParams("planet").
+		WithResponse(game.FieldsReport{})
+	s.route("GET", "/planets/relocations", s.listRelocations()).
+		WithDescription("Lists the relocations of planets.").
+		WithResponse([]game.Relocation{})
+	s.route("GET", "/moons", s.listMoons()).
+		WithDescription("Lists the moons.").
+		WithResponse([]game.Planet{})
+	s.route("GET", "/moons/[a-zA-Z0-9-]+/fields", s.listMoonFields()).
+		WithDescription("Describes the fields used on a moon.").
+		WithParams("moon").
+		WithResponse(game.FieldsReport{})
+	s.route("GET", "/debris", s.listDebris()).
+		WithDescription("Lists the debris fields.").
+		WithResponse([]game.DebrisField{})
+	s.route("GET", "/fleets", s.listFleets()).
+		WithDescription("Lists the fleets.").
+		WithResponse([]game.Fleet{})
+	s.route("GET", "/fleets/acs", s.listACSFleets()).
+		WithDescription("Lists the ACS fleets.").
+		WithResponse([]game.ACSFleet{})
+	s.route("GET", "/fleets/scheduled", s.listScheduledFleets()).
+		WithDescription("Lists the fleets waiting for their departure.").
+		WithResponse([]game.Fleet{})
+	s.route("GET", "/fleets/objectives", s.listFleetObjectives()).
+		WithDescription("Lists the objectives of fleets.").
+		WithResponse([]model.Objective{})
+	s.route("GET", "/logistics", s.listTransportRoutes()).
+		WithDescription("Lists the transport routes.").
+		WithResponse([]game.TransportRoute{})
+	s.route("GET", "/market", s.listOffers()).
+		WithDescription("Lists the offers of the market.").
+		WithResponse([]game.MarketOffer{})
 
-	s.route("POST", "/universes", s.createUniverse())
-	s.route("POST", "/accounts", s.createAccount())
-	s.route("POST", "/players", s.createPlayer())
-	s.route("POST", "/planets/[a-zA-Z0-9-]+/actions/technologies", s.registerTechnologyAction())
-	s.route("POST", "/planets/[a-zA-Z0-9-]+/actions/buildings", s.registerBuildingAction())
-	s.route("POST", "/planets/[a-zA-Z0-9-]+/actions/ships", s.registerShipAction())
-	s.route("POST", "/planets/[a-zA-Z0-9-]+/actions/defenses", s.registerDefenseAction())
-	s.route("POST", "/fleets", s.createFleet())
-	s.route("POST", "/fleets/acs", s.createACSFleet())
+	s.route("POST", "/universes", s.createUniverse()).
+		WithDescription("Creates universes.").
+		WithBody(game.Universe{})
+	s.route("POST", "/accounts", s.createAccount()).
+		WithDescription("Creates accounts.").
+		WithBody(game.Account{})
+	s.route("POST", "/players", s.createPlayer()).
+		WithDescription("Creates players.").
+		WithBody(game.Player{})
+	s.route("POST", "/planets/[a-zA-Z0-9-]+/actions/technologies", s.registerTechnologyAction()).
+		WithDescription("Registers the research of a technology from a planet.").
+		WithParams("planet").
+		WithBody(game.TechnologyAction{}).
+		WithPreview(game.ActionPreview{})
+	s.route("POST", "/planets/[a-zA-Z0-9-]+/actions/buildings", s.registerBuildingAction()).
+		WithDescription("Registers the upgrade of a building on a planet.").
+		WithParams("planet").
+		WithBody(game.BuildingAction{}).
+		WithPreview(game.ActionPreview{})
+	s.route("POST", "/planets/[a-zA-Z0-9-]+/actions/ships", s.registerShipAction()).
+		WithDescription("Registers the construction of ships on a planet.").
+		WithParams("planet").
+		WithBody(game.ShipAction{}).
+		WithPreview(game.ActionPreview{})
+	s.route("POST", "/planets/[a-zA-Z0-9-]+/actions/defenses", s.registerDefenseAction()).
+		WithDescription("Registers the construction of defenses on a planet.").
+		WithParams("planet").
+		WithBody(game.DefenseAction{}).
+		WithPreview(game.ActionPreview{})
+	s.route("POST", "/planets/[a-zA-Z0-9-]+/relocation", s.relocatePlanet()).
+		WithDescription("Requests the relocation of a planet.").
+		WithParams("planet").
+		WithBody(game.Relocation{})
+	s.route("POST", "/planets/[a-zA-Z0-9-]+/trade", s.tradeResources()).
+		WithDescription("Trades resources of a planet with the merchant.").
+		WithParams("planet").
+		WithBody(game.Trade{})
+	s.route("POST", "/fleets", s.createFleet()).
+		WithDescription("Creates fleets.").
+		WithBody(game.Fleet{}).
+		WithPreview([]game.FleetPreview{})
+	s.route("POST", "/fleets/acs", s.createACSFleet()).
+		WithDescription("Creates fleets joining an ACS operation.").
+		WithBody(game.Fleet{}).
+		WithPreview([]game.FleetPreview{})
+	s.route("POST", "/fleets/[a-zA-Z0-9-]+/supply", s.supplyHoldingFleet()).
+		WithDescription("Supplies a fleet holding at a planet of the player.").
+		WithParams("fleet").
+		WithBody(game.HoldingOrder{})
+	s.route("POST", "/fleets/[a-zA-Z0-9-]+/release", s.releaseHoldingFleet()).
+		WithDescription("Releases a fleet holding at a planet of the player.").
+		WithParams("fleet").
+		WithBody(game.HoldingOrder{})
+	s.route("POST", "/logistics", s.createTransportRoute()).
+		WithDescription("Creates transport routes.").
+		WithBody(game.TransportRoute{})
+	s.route("POST", "/market", s.createOffer()).
+		WithDescription("Creates offers on the market.").
+		WithBody(game.MarketOffer{})
+	s.route("POST", "/market/[a-zA-Z0-9-]+/accept", s.acceptOffer()).
+		WithDescription("Accepts an offer of the market.").
+		WithParams("offer").
+		WithBody(game.MarketPurchase{})
 
-	s.route("PATCH", "/accounts/[a-zA-Z0-9-]+", s.changeAccounts())
-	s.route("PATCH", "/players/[a-zA-Z0-9-]+", s.changePlayers())
-	s.route("PATCH", "/planets/[a-zA-Z0-9-]+", s.changePlanets())
-	s.route("PATCH", "/planets/[a-zA-Z0-9-]+/production", s.changeProduction())
-	s.route("PATCH", "/moons/[a-zA-Z0-9-]+", s.changeMoons())
+	s.route("PATCH", "/accounts/[a-zA-Z0-9-]+", s.changeAccounts()).
+		WithDescription("Updates an account.").
+		WithParams("account").
+		WithBody(game.Account{})
+	s.route("PATCH", "/players/[a-zA-Z0-9-]+", s.changePlayers()).
+		WithDescription("Updates a player.").
+		WithParams("player").
+		WithBody(game.Player{})
+	s.route("PATCH", "/planets/[a-zA-Z0-9-]+", s.changePlanets()).
+		WithDescription("Updates a planet.").
+		WithParams("planet").
+		WithBody(game.Planet{})
+	s.route("PATCH", "/planets/[a-zA-Z0-9-]+/production", s.changeProduction()).
+		WithDescription("Updates the production factors of the buildings of a planet.").
+		WithParams("planet").
+		WithBody([]game.BuildingInfo{})
+	s.route("PATCH", "/moons/[a-zA-Z0-9-]+", s.changeMoons()).
+		WithDescription("Updates a moon.").
+		WithParams("moon").
+		WithBody(game.Planet{})
 
-	s.route("DELETE", "/planets/[a-zA-Z0-9-]+", s.deletePlanet())
-	s.route("DELETE", "/players/[a-zA-Z0-9-]+", s.deletePlayer())
+	s.route("DELETE", "/planets/[a-zA-Z0-9-]+", s.deletePlanet()).
+		WithDescription("Deletes a planet.").
+		WithParams("planet")
+	s.route("DELETE", "/planets/relocations/[a-zA-Z0-9-]+", s.cancelRelocation()).
+		WithDescription("Cancels the relocation of a planet.").
+		WithParams("relocation")
+	s.route("DELETE", "/players/[a-zA-Z0-9-]+", s.deletePlayer()).
+		WithDescription("Deletes a player.").
+		WithParams("player")
+	s.route("DELETE", "/fleets/scheduled/[a-zA-Z0-9-]+", s.cancelScheduledFleet()).
+		WithDescription("Cancels a fleet waiting for its departure.").
+		WithParams("fleet")
+	s.route("DELETE", "/logistics/[a-zA-Z0-9-]+", s.deleteTransportRoute()).
+		WithDescription("Deletes a transport route.").
+		WithParams("route")
+	s.route("DELETE", "/market/[a-zA-Z0-9-]+", s.cancelOffer()).
+		WithDescription("Cancels an offer of the market.").
+		WithParams("offer")
 }
 
 // route :
 // Used to perform the necessary wrapping around the specified
 // handler provided that it should be binded to the input route
-// and only respond to said method.
+// and only respond to said method. Requests are rejected until
+// the data model of the server is initialized and are subject
+// to the limits configured for the server.
+//
+// The `method` indicates the method for which the handler is
+// sensible.
+//
+// The `name` of the route define the binding that should be
+// performed for the input handler.
+//
+// The `e` defines the endpoint that will serve input req and
+// which should be wrapped to provide more security. It also
+// describes the route as much as possible.
+//
+// Returns the documentation of the route so that it can be
+// completed.
+func (s *Server) route(method string, name string, e endpoint) *routeDoc {
+	doc := s.diagnosticRoute(method, name, s.limit(method, name, s.requireInitialized(e.ServeRoute(s.log))))
+
+	doc.limited = true
+	e.describe(doc)
+
+	return doc
+}
+
+// diagnosticRoute :
+// Similar to the `route` method but the handler is available
+// even if the data model of the server is not initialized.
+// It is also instrumented to keep track of the requests that
+// are served.
 //
 // The `method` indicates the method for which the handler is
 // sensible.
@@ -63,12 +280,24 @@ func (s *Server) routes() {
 //
 // The `handler` defines the element that will serve input req
 // and which should be wrapped to provide more security.
-func (s *Server) route(method string, name string, handler http.HandlerFunc) {
+//
+// Returns the documentation of the route so that it can be
+// completed.
+func (s *Server) diagnosticRoute(method string, name string, handler http.HandlerFunc) *routeDoc {
 	s.router.HandleFunc(
 		name,
-		dispatcher.WithSafetyNet(
-			s.log,
-			handler,
+		instrument(
+			method,
+			name,
+			dispatcher.WithSafetyNet(
+				s.log,
+				handler,
+			),
 		),
 	).Methods(method)
+
+	doc := newRouteDoc(method, name)
+	s.docs = append(s.docs, doc)
+
+	return doc
 }
@@ -7,14 +7,17 @@ import (
 	"oglike_server/internal/data"
 	"oglike_server/internal/game"
 	"oglike_server/internal/model"
+	ogdb "oglike_server/og_db"
 	"oglike_server/pkg/background"
 	"oglike_server/pkg/db"
 	"oglike_server/pkg/dispatcher"
 	"oglike_server/pkg/logger"
+	"oglike_server/pkg/migrate"
 	"os"
 	"os/signal"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/handlers"
@@ -86,9 +89,30 @@ import (
 // generally any useful information that could be monitored
 // by the execution system of the server.
 //
-// The `process` defines the background process that is used
-// to make sure that certain operations are executed in a way
-// that guarantee consistency of the data in the server's DB.
+// The `processes` defines the background processes that are
+// used to make sure that certain operations are executed in a
+// way that guarantee consistency of the data in the server's
+// DB.
+//
+// The `config` defines the configuration of the server as
+// parsed from the configuration file.
+//
+// The `stop` is closed when the server is shutting down so
+// that long-lived requests can be interrupted.
+//
+// The `health` keeps track of the initialization status of
+// the data model. Its modules are initialized from the DB
+// when the server is created or later on by a background
+// process in case the DB can not be reached at first.
+//
+// The `limits` defines the limits applied to the requests
+// of each client of the server.
+//
+// The `docs` defines the documentation of the routes of the
+// server in the order they were registered.
+//
+// The `spec` defines the OpenAPI document generated from the
+// documentation of the routes when the server starts.
 type Server struct {
 	port      int
 	router    *dispatcher.Router
@@ -98,12 +122,23 @@ type Server struct {
 	planets   data.PlanetProxy
 	fleets    data.FleetProxy
 	actions   data.ActionProxy
+	logistics data.TransportRouteProxy
+	market    data.MarketProxy
 
 	og    game.Instance
 	proxy db.Proxy
 	log   logger.Logger
 
-	process *background.Process
+	processes []*background.Process
+
+	config configuration
+	stop   chan struct{}
+
+	health *serverHealth
+	limits *requestLimits
+
+	docs []*routeDoc
+	spec []byte
 }
 
 // ErrUnexpectedServeError : Indicates that an error occurred
@@ -126,8 +161,89 @@ var ErrServerShutdownError = fmt.Errorf("unexpected error occurred while shuttin
 // the duration become too long.
 // The duration is expressed in minutes and the default value
 // is set to `60`.
+//
+// The `ActivityUpdate` defines the time interval between two
+// consecutive updates of the activity status of players. The
+// duration is expressed in minutes and the default value is
+// set to `60`.
+//
+// The `RankingsUpdate` defines the time interval between two
+// consecutive snapshots of the rankings of the universes. The
+// duration is expressed in minutes and the default value is
+// set to `60`.
+//
+// The `RankingsRetention` defines the duration during which
+// the snapshots of the rankings are kept. Older snapshots are
+// removed by the process registering new ones, except for the
+// most recent snapshot of each universe. The duration is in
+// days and the default value is set to `30`. A value of `0`
+// keeps the snapshots forever.
+//
+// The `TransportRoutesUpdate` defines the time interval
+// between two consecutive checks of the transport routes
+// that should send a fleet. The duration is expressed in
+// minutes and the default value is set to `5`.
+//
+// The `MarketUpdate` defines the time interval between two
+// consecutive checks of the offers of the market that have
+// expired. The duration is expressed in minutes and the
+// default value is set to `5`.
+//
+// The `EventsPoll` defines the interval at which the events
+// streams send a keep-alive comment when no new events are
+// notified. It is also the delay before a client retries to
+// connect. The duration is expressed in seconds and the
+// default value is set to `5`.
+//
+// The `RulesDir` defines the directory where the files
+// describing the rule sets can be found. The default
+// value is `configs/rules`.
+//
+// The `RuleSet` defines the name of the rule set used to
+// describe the data model of the game. It is synchronized
+// with the DB when the server starts. The default value
+// is `classic`.
+//
+// The `RelocationCost` defines the resources needed to
+// relocate a planet, keyed by the name of the resource.
+// The default value is `100000` metal, `100000` crystal
+// and `50000` deuterium.
+//
+// The `InitRetry` defines the interval between attempts
+// to initialize the data model when the DB can not be
+// reached. The duration is expressed in seconds and the
+// default value is set to `5`.
+//
+// The `MaxActionsBacklog` defines the number of actions
+// that completed but were not executed yet above which
+// the server is not considered ready. The default value
+// is `1000`.
+//
+// The `AdminToken` defines the token that should be sent
+// as a bearer in the `Authorization` header to access the
+// admin endpoints. The admin endpoints are disabled when
+// it is empty, which is the default.
+//
+// The `CacheMaxAge` defines the duration for which the
+// clients can keep the static data of the model (such as
+// the buildings or the ships) without revalidating it.
+// The duration is expressed in seconds and the default
+// value is set to `3600`.
 type configuration struct {
-	BackgroundUpdate time.Duration
+	BackgroundUpdate      time.Duration
+	ActivityUpdate        time.Duration
+	RankingsUpdate        time.Duration
+	RankingsRetention     time.Duration
+	TransportRoutesUpdate time.Duration
+	MarketUpdate          time.Duration
+	EventsPoll            time.Duration
+	RulesDir              string
+	RuleSet               string
+	RelocationCost        map[string]int
+	InitRetry             time.Duration
+	MaxActionsBacklog     int
+	AdminToken            string
+	CacheMaxAge           time.Duration
 }
 
 // parseConfiguration :
@@ -142,7 +258,24 @@ type configuration struct {
 func parseConfiguration() configuration {
 	// Create the default configuration.
 	config := configuration{
-		BackgroundUpdate: 60 * time.Minute,
+		BackgroundUpdate:      60 * time.Minute,
+		ActivityUpdate:        60 * time.Minute,
+		RankingsUpdate:        60 * time.Minute,
+		RankingsRetention:     30 * 24 * time.Hour,
+		TransportRoutesUpdate: 5 * time.Minute,
+		MarketUpdate:          5 * time.Minute,
+		EventsPoll:            5 * time.Second,
+		RulesDir:              "configs/rules",
+		RuleSet:               "classic",
+		RelocationCost: map[string]int{
+			"metal":     100000,
+			"crystal":   100000,
+			"deuterium": 50000,
+		},
+		InitRetry:         5 * time.Second,
+		MaxActionsBacklog: 1000,
+		AdminToken:        "",
+		CacheMaxAge:       time.Hour,
 	}
 
 	// Parse custom properties.
@@ -150,6 +283,57 @@ func parseConfiguration() configuration {
 		min := viper.GetInt("Server.BackgroundUpdate")
 		config.BackgroundUpdate = time.Duration(min) * time.Minute
 	}
+	if viper.IsSet("Server.ActivityUpdate") {
+		min := viper.GetInt("Server.ActivityUpdate")
+		config.ActivityUpdate = time.Duration(min) * time.Minute
+	}
+	if viper.IsSet("Server.RankingsUpdate") {
+		min := viper.GetInt("Server.RankingsUpdate")
+		config.RankingsUpdate = time.Duration(min) * time.Minute
+	}
+	if viper.IsSet("Server.RankingsRetention") {
+		days := viper.GetInt("Server.RankingsRetention")
+		config.RankingsRetention = time.Duration(days) * 24 * time.Hour
+	}
+	if viper.IsSet("Server.TransportRoutesUpdate") {
+		min := viper.GetInt("Server.TransportRoutesUpdate")
+		config.TransportRoutesUpdate = time.Duration(min) * time.Minute
+	}
+	if viper.IsSet("Server.MarketUpdate") {
+		min := viper.GetInt("Server.MarketUpdate")
+		config.MarketUpdate = time.Duration(min) * time.Minute
+	}
+	if viper.IsSet("Server.EventsPoll") {
+		sec := viper.GetInt("Server.EventsPoll")
+		config.EventsPoll = time.Duration(sec) * time.Second
+	}
+	if viper.IsSet("Server.RulesDir") {
+		config.RulesDir = viper.GetString("Server.RulesDir")
+	}
+	if viper.IsSet("Server.RuleSet") {
+		config.RuleSet = viper.GetString("Server.RuleSet")
+	}
+	if viper.IsSet("Server.RelocationCost") {
+		config.RelocationCost = make(map[string]int)
+
+		for res := range viper.GetStringMap("Server.RelocationCost") {
+			config.RelocationCost[res] = viper.GetInt(fmt.Sprintf("Server.RelocationCost.%s", res))
+		}
+	}
+	if viper.IsSet("Server.InitRetry") {
+		sec := viper.GetInt("Server.InitRetry")
+		config.InitRetry = time.Duration(sec) * time.Second
+	}
+	if viper.IsSet("Server.MaxActionsBacklog") {
+		config.MaxActionsBacklog = viper.GetInt("Server.MaxActionsBacklog")
+	}
+	if viper.IsSet("Server.AdminToken") {
+		config.AdminToken = viper.GetString("Server.AdminToken")
+	}
+	if viper.IsSet("Server.CacheMaxAge") {
+		sec := viper.GetInt("Server.CacheMaxAge")
+		config.CacheMaxAge = time.Duration(sec) * time.Second
+	}
 
 	return config
 }
@@ -157,8 +341,12 @@ func parseConfiguration() configuration {
 // NewServer :
 // Create a new server with the input elements to use internally to
 // access data and perform logging.
-// In case any of the arguments are not valid a panic is issued to
-// indicate the failure.
+// In case the configuration is not valid, the schema of the DB
+// is not the one expected by the server or the DB contains some
+// universes using another rule set an error is returned. In case
+// the data model can not be loaded from the DB the server is
+// created but is not ready: attempts to initialize it are then
+// performed regularly once it is started.
 //
 // The `port` defines the port to listen to by the server.
 //
@@ -167,69 +355,69 @@ func parseConfiguration() configuration {
 //
 // The `log` is used to notify from various processes in the server
 // and keep track of the activity.
-func NewServer(port int, proxy db.Proxy, log logger.Logger) Server {
-	// Create modules to handle data model and initialize each one
-	// of them.
-	cm := model.NewCountriesModule(log)
-	bm := model.NewBuildingsModule(log)
-	tm := model.NewTechnologiesModule(log)
-	sm := model.NewShipsModule(log)
-	dm := model.NewDefensesModule(log)
-	rm := model.NewResourcesModule(log)
-	om := model.NewFleetObjectivesModule(log)
-	mm := model.NewMessagesModule(log)
-
-	err := cm.Init(proxy, false)
-	if err != nil {
-		panic(fmt.Errorf("cannot create server (err: %v)", err))
-	}
+//
+// Returns the created server along with any error.
+func NewServer(port int, proxy db.Proxy, log logger.Logger) (Server, error) {
+	config := parseConfiguration()
 
-	err = bm.Init(proxy, false)
-	if err != nil {
-		panic(fmt.Errorf("cannot create server (err: %v)", err))
-	}
+	// Load the rule set used by the server. In case no
+	// rule sets can be found we rely on the data that
+	// is already in the DB.
+	var ruleSet *model.RuleSet
 
-	err = tm.Init(proxy, false)
-	if err != nil {
-		panic(fmt.Errorf("cannot create server (err: %v)", err))
+	ruleSets, err := model.LoadRuleSets(config.RulesDir)
+	if err != nil && !os.IsNotExist(err) {
+		return Server{}, fmt.Errorf("cannot create server (err: %v)", err)
 	}
 
-	err = sm.Init(proxy, false)
 	if err != nil {
-		panic(fmt.Errorf("cannot create server (err: %v)", err))
-	}
+		log.Trace(logger.Warning, "server", fmt.Sprintf("No rule sets found in \"%s\", using data model from DB", config.RulesDir))
+	} else {
+		rs, ok := ruleSets[config.RuleSet]
+		if !ok {
+			return Server{}, fmt.Errorf("cannot create server (err: %v \"%s\")", model.ErrUnknownRuleSet, config.RuleSet)
+		}
 
-	err = dm.Init(proxy, false)
-	if err != nil {
-		panic(fmt.Errorf("cannot create server (err: %v)", err))
+		ruleSet = &rs
 	}
 
-	err = rm.Init(proxy, false)
+	// The expected version of the schema of the DB is the
+	// one reached by applying all the embedded migrations.
+	migrations, err := migrate.Load(ogdb.Migrations, ogdb.MigrationsDir)
 	if err != nil {
-		panic(fmt.Errorf("cannot create server (err: %v)", err))
+		return Server{}, fmt.Errorf("cannot create server (err: %v)", err)
 	}
 
-	err = om.Init(proxy, false)
-	if err != nil {
-		panic(fmt.Errorf("cannot create server (err: %v)", err))
-	}
+	// Create modules to handle data model. They will be
+	// initialized along with the synchronization of the
+	// rule set.
+	models := game.NewModel(log)
+	health := newServerHealth(migrate.Latest(migrations), config.RuleSet, ruleSet, models.Modules())
 
-	err = mm.Init(proxy, false)
+	err = health.initialize(proxy, log)
+	if health.schemaErr != nil {
+		return Server{}, fmt.Errorf("cannot create server, apply the migrations with \"-migrate\" (err: %v)", health.schemaErr)
+	}
+	if health.ruleSetErr != nil {
+		return Server{}, fmt.Errorf("cannot create server, only one rule set is supported (err: %v)", health.ruleSetErr)
+	}
 	if err != nil {
-		panic(fmt.Errorf("cannot create server (err: %v)", err))
+		log.Trace(logger.Error, "server", fmt.Sprintf("Could not initialize data model, retrying every %v (err: %v)", config.InitRetry, err))
 	}
 
 	// Create the data model from it.
-	ogDataModel := game.NewInstance(proxy, log)
-
-	ogDataModel.Countries = cm
-	ogDataModel.Buildings = bm
-	ogDataModel.Technologies = tm
-	ogDataModel.Ships = sm
-	ogDataModel.Defenses = dm
-	ogDataModel.Resources = rm
-	ogDataModel.Objectives = om
-	ogDataModel.Messages = mm
+	// The data model uses a logger which attaches the
+	// identifier of the request holding the lock on it
+	// to its messages. The proxies use the base logger
+	// as they are shared by all the requests: the routes
+	// bind them to the logger of the request they serve
+	// through `For`.
+	scope := logger.NewScopedLogger(log)
+	ogDataModel := game.NewInstance(proxy, scope)
+
+	ogDataModel.Model = models
+	ogDataModel.RuleSet = config.RuleSet
+	ogDataModel.RelocationCost = config.RelocationCost
 
 	// Create proxies on composite types.
 	up := data.NewUniverseProxy(ogDataModel, log)
@@ -238,20 +426,78 @@ func NewServer(port int, proxy db.Proxy, log logger.Logger) Server {
 	ppp := data.NewPlanetProxy(ogDataModel, log)
 	fp := data.NewFleetProxy(ogDataModel, log)
 	aap := data.NewActionProxy(ogDataModel, log)
+	trp := data.NewTransportRouteProxy(ogDataModel, log)
+	mp := data.NewMarketProxy(ogDataModel, log)
 
 	// Create the background process to ensure
 	// data consistency in the game's DB.
-	config := parseConfiguration()
-
 	p := background.NewProcess(config.BackgroundUpdate, log)
 
 	p.WithModule("cron").WithRetry().WithOperation(
-		func() (bool, error) {
+		health.whenInitialized(func() (bool, error) {
 			defer ogDataModel.Unlock()
 			ogDataModel.Lock()
 
 			return true, nil
-		},
+		}),
+	)
+
+	// Create the process to keep track of the players
+	// that are inactive. It also removes the outdated
+	// events of players.
+	ip := background.NewProcess(config.ActivityUpdate, log)
+
+	ip.WithModule("activity").WithRetry().WithOperation(
+		health.whenInitialized(func() (bool, error) {
+			err := pp.UpdateActivity()
+			if err == nil {
+				err = pp.PurgeEvents()
+			}
+			return err == nil, err
+		}),
+	)
+
+	// Create the process to regularly register snapshots
+	// of the rankings and remove the ones that are past
+	// the retention.
+	rp := background.NewProcess(config.RankingsUpdate, log)
+
+	rp.WithModule("rankings").WithRetry().WithOperation(
+		health.whenInitialized(func() (bool, error) {
+			err := up.CreateRankingSnapshots()
+			if err == nil {
+				err = up.PurgeRankingSnapshots(config.RankingsRetention)
+			}
+			return err == nil, err
+		}),
+	)
+
+	// Create the process sending the fleets of the
+	// transport routes that are due.
+	tp := background.NewProcess(config.TransportRoutesUpdate, log)
+
+	tp.WithModule("logistics").WithRetry().WithOperation(
+		health.whenInitialized(func() (bool, error) {
+			defer ogDataModel.Unlock()
+			ogDataModel.Lock()
+
+			err := trp.Process()
+			return err == nil, err
+		}),
+	)
+
+	// Create the process giving back the goods of the
+	// offers of the market that expired.
+	mkp := background.NewProcess(config.MarketUpdate, log)
+
+	mkp.WithModule("market").WithRetry().WithOperation(
+		health.whenInitialized(func() (bool, error) {
+			defer ogDataModel.Unlock()
+			ogDataModel.Lock()
+
+			err := mp.Process()
+			return err == nil, err
+		}),
 	)
 
 	return Server{
@@ -264,13 +510,21 @@ func NewServer(port int, proxy db.Proxy, log logger.Logger) Server {
 		players:   pp,
 		fleets:    fp,
 		actions:   aap,
+		logistics: trp,
+		market:    mp,
 
 		og:    ogDataModel,
 		proxy: proxy,
 		log:   log,
 
-		process: p,
-	}
+		processes: []*background.Process{p, ip, rp, tp, mkp},
+
+		config: config,
+		stop:   make(chan struct{}),
+
+		health: health,
+		limits: newRequestLimits(parseLimitsConfiguration()),
+	}, nil
 }
 
 // Serve :
@@ -288,14 +542,22 @@ func (s *Server) Serve() error {
 
 	s.router = dispatcher.NewRouter(s.log)
 
-	// Setup routes.
+	// Setup routes and generate their documentation: all
+	// the routes should be documented.
 	s.routes()
 
+	spec, err := s.specification()
+	if err != nil {
+		return err
+	}
+	s.spec = spec
+
 	// Wrap the router in a server allowing all origins.
 	aMethods := handlers.AllowedMethods([]string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"})
 	aOrigins := handlers.AllowedOrigins([]string{"*"})
-	aHeaders := handlers.AllowedHeaders([]string{"Origin", "X-Requested-With", "Content-Type", "Accept", "Authorization"})
-	corsRouter := handlers.CORS(aHeaders, aOrigins, aMethods)(s.router)
+	aHeaders := handlers.AllowedHeaders([]string{"Origin", "X-Requested-With", "Content-Type", "Accept", "Authorization", dispatcher.RequestIDHeader})
+	eHeaders := handlers.ExposedHeaders([]string{dispatcher.RequestIDHeader})
+	corsRouter := handlers.CORS(aHeaders, eHeaders, aOrigins, aMethods)(s.router)
 
 	// Create the server which will serve requests. The
 	// idiom used to serve requests is inspired from the
@@ -311,7 +573,15 @@ func (s *Server) Serve() error {
 	// Start the routine which will handle the automatic
 	// update of some processes if it is not done often
 	// enough.
-	s.process.Start()
+	for _, p := range s.processes {
+		p.Start()
+	}
+
+	// Keep trying to initialize the data model if it
+	// failed when creating the server.
+	if !s.health.initialized() {
+		go s.retryInitialization()
+	}
 
 	// Serve the root path.
 	var serveErr error
@@ -341,6 +611,13 @@ func (s *Server) Serve() error {
 		}
 	}()
 
+	// Reload the data model on SIGHUP.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	go s.reloadOnSignal(hup)
+
 	// Setting up signal capturing.
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt)
@@ -365,10 +642,40 @@ func (s *Server) Serve() error {
 	return serveErr
 }
 
+// retryInitialization :
+// Used to regularly attempt to initialize the data
+// model until it succeeds or the server is stopped.
+func (s *Server) retryInitialization() {
+	ticker := time.NewTicker(s.config.InitRetry)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		err := s.health.initialize(s.proxy, s.log)
+		if err != nil {
+			s.log.Trace(logger.Warning, "server", fmt.Sprintf("Could not initialize data model (err: %v)", err))
+			continue
+		}
+
+		s.log.Trace(logger.Notice, "server", "Initialized data model, server is ready")
+
+		return
+	}
+}
+
 // shutdown :
 // Requests the server to gracefully shutdown and
 // terminate all the processes that are pending
 // before doing so.
 func (s *Server) shutdown() {
-	s.process.Stop()
+	close(s.stop)
+
+	for _, p := range s.processes {
+		p.Stop()
+	}
 }
@@ -1,8 +1,8 @@
 package routes
 
 import (
-	"net/http"
 	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
 )
 
 // listShips :
@@ -10,7 +10,7 @@ import (
 // the requests on ships.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listShips() http.HandlerFunc {
+func (s *Server) listShips() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("ships")
 
@@ -21,11 +21,12 @@ func (s *Server) listShips() http.HandlerFunc {
 
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("ships")
+	ed.WithCache(s.modelVersion, s.config.CacheMaxAge)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.og.Ships.Ships(s.proxy, filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.og.Current().Ships.Ships(s.proxy, filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
@@ -1,8 +1,8 @@
 package routes
 
 import (
-	"net/http"
 	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
 )
 
 // listTechnologies :
@@ -10,7 +10,7 @@ import (
 // the requests on technologies.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listTechnologies() http.HandlerFunc {
+func (s *Server) listTechnologies() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("technologies")
 
@@ -21,11 +21,12 @@ func (s *Server) listTechnologies() http.HandlerFunc {
 
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("technologies")
+	ed.WithCache(s.modelVersion, s.config.CacheMaxAge)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.og.Technologies.Technologies(s.proxy, filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.og.Current().Technologies.Technologies(s.proxy, filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
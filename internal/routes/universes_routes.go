@@ -2,9 +2,9 @@ package routes
 
 import (
 	"encoding/json"
-	"net/http"
 	"oglike_server/internal/game"
 	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
 )
 
 // listUniverses :
@@ -12,7 +12,7 @@ import (
 // the requests on universes.
 //
 // Returns the handler that can be executed to serve said reqs.
-func (s *Server) listUniverses() http.HandlerFunc {
+func (s *Server) listUniverses() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("universes")
 
@@ -24,36 +24,65 @@ func (s *Server) listUniverses() http.HandlerFunc {
 	// Configure the endpoint.
 	ed.WithFilters(allowed).WithResourceFilter("id").WithModule("universes").WithLocker(s.og)
 	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.universes.Universes(filters)
+		func(filters []db.Filter, log logger.Logger) (interface{}, error) {
+			return s.universes.For(log).Universes(filters)
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // listUniverseRankings :
 // Used to perform the creation of a handler allowing to server
-// the requests related to rankings in universes.
+// the requests related to rankings in universes. The rankings
+// are served from the snapshots regularly registered and can
+// be refined with the `category`, `at`, `page` and `count`
+// query parameters.
 //
 // Returns the handler that can be executed to server said reqs.
-func (s *Server) listUniverseRankings() http.HandlerFunc {
+func (s *Server) listUniverseRankings() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewGetResourceEndpoint("universes")
 
-	allowed := map[string]string{
-		"id": "p.universe",
-	}
-
 	// Configure the endpoint.
-	ed.WithFilters(allowed).WithIDFilter("p.universe").WithResourceFilter("p.id").WithModule("universes").WithLocker(s.og)
-	ed.WithDataFunc(
-		func(filters []db.Filter) (interface{}, error) {
-			return s.universes.Rankings(filters)
+	ed.WithIDFilter("universe").WithModule("universes").WithLocker(s.og)
+	ed.WithParamsDataFunc(
+		func(filters []db.Filter, params map[string]Values, log logger.Logger) (interface{}, error) {
+			// The identifier of the route is mandatory.
+			if len(filters) == 0 {
+				return nil, game.ErrElementNotFound
+			}
+
+			var err error
+
+			opts := game.RankingOptions{
+				Category: stringParam(params, "category"),
+			}
+
+			if opts.Category != "" && !game.ValidRankingCategory(opts.Category) {
+				return nil, ErrInvalidQueryParameter
+			}
+
+			if opts.At, err = timeParam(params, "at"); err != nil {
+				return nil, err
+			}
+			if opts.Page, err = intParam(params, "page"); err != nil {
+				return nil, err
+			}
+			if opts.Count, err = intParam(params, "count"); err != nil {
+				return nil, err
+			}
+
+			rp, err := s.universes.For(log).Rankings(filters, opts)
+			if err == game.ErrInvalidRankingPage {
+				return nil, ErrInvalidQueryParameter
+			}
+
+			return rp, err
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
 
 // createUniverse :
@@ -61,14 +90,14 @@ func (s *Server) listUniverseRankings() http.HandlerFunc {
 // the requests to create universes.
 //
 // Returns the handler to execute to perform said requests.
-func (s *Server) createUniverse() http.HandlerFunc {
+func (s *Server) createUniverse() endpoint {
 	// Create the endpoint with the suited route.
 	ed := NewCreateResourceEndpoint("universes")
 
 	// Configure the endpoint.
 	ed.WithDataKey("universe-data").WithModule("universes").WithLocker(s.og)
 	ed.WithCreationFunc(
-		func(input RouteData) ([]string, error) {
+		func(input RouteData, log logger.Logger) ([]string, error) {
 			// We need to iterate over the data retrieved from the route and
 			// create universes from it.
 			var uni game.Universe
@@ -87,7 +116,7 @@ func (s *Server) createUniverse() http.HandlerFunc {
 				}
 
 				// Create the universe.
-				res, err := s.universes.Create(uni)
+				res, err := s.universes.For(log).Create(uni)
 				if err != nil {
 					return resources, err
 				}
@@ -101,5 +130,5 @@ func (s *Server) createUniverse() http.HandlerFunc {
 		},
 	)
 
-	return ed.ServeRoute(s.log)
+	return ed
 }
@@ -0,0 +1,260 @@
+package snapshot
+
+import (
+	"fmt"
+	"time"
+)
+
+// Version :
+// Defines the version of the format of the archives. It
+// should be increased whenever the layout of the archive
+// changes in a way that older archives can't be imported
+// anymore.
+const Version = 1
+
+// Row :
+// Describes a row of a table of the DB, keyed by the name
+// of each column.
+type Row map[string]interface{}
+
+// Archive :
+// Describes the snapshot of a universe. It contains the
+// rows of all the tables describing the universe along
+// with the names of the elements of the data model they
+// reference: the identifiers of these elements are not
+// the same from one DB to another.
+type Archive struct {
+	// The `Version` defines the version of the format of
+	// the archive.
+	Version int `json:"version"`
+
+	// The `Schema` defines the version of the schema of
+	// the DB from which the archive was produced.
+	Schema uint `json:"schema"`
+
+	// The `CreatedAt` defines the time at which the archive
+	// was produced.
+	CreatedAt time.Time `json:"created_at"`
+
+	// The `Universe` defines the identifier of the universe
+	// saved in the archive.
+	Universe string `json:"universe"`
+
+	// The `References` defines for each table of the data
+	// model the name of each element keyed by identifier.
+	References map[string]map[string]string `json:"references"`
+
+	// The `Tables` defines the rows of each table of the
+	// universe.
+	Tables map[string][]Row `json:"tables"`
+}
+
+// ErrUnsupportedVersion :
+// Used to indicate that the format of the archive is not
+// supported by this version of the server.
+var ErrUnsupportedVersion = fmt.Errorf("unsupported archive version")
+
+// ErrSchemaMismatch :
+// Used to indicate that the archive was produced from a
+// DB with a different schema.
+var ErrSchemaMismatch = fmt.Errorf("archive was produced with another schema")
+
+// ErrUnknownTable :
+// Used to indicate that the archive contains a table that
+// is not handled.
+var ErrUnknownTable = fmt.Errorf("unknown table in archive")
+
+// ErrUnknownReference :
+// Used to indicate that an element of the data model used
+// by the archive does not exist in the DB.
+var ErrUnknownReference = fmt.Errorf("unknown element of the data model")
+
+// ErrUniverseExists :
+// Used to indicate that the universe to restore already
+// exists in the DB.
+var ErrUniverseExists = fmt.Errorf("universe already exists")
+
+// ErrUniverseNotFound :
+// Used to indicate that the universe to export does not
+// exist in the DB.
+var ErrUniverseNotFound = fmt.Errorf("universe does not exist")
+
+// reference :
+// Describes a table of the data model referenced by the
+// data of the universes.
+//
+// The `name` defines the name of the table.
+//
+// The `key` defines the column uniquely identifying each
+// element of the table by name.
+type reference struct {
+	name string
+	key  string
+}
+
+// references :
+// Defines the tables of the data model which can be used
+// by the data of the universes.
+var references = []reference{
+	{"countries", "name"},
+	{"resources", "name"},
+	{"buildings", "name"},
+	{"technologies", "name"},
+	{"ships", "name"},
+	{"defenses", "name"},
+	{"fleets_objectives", "name"},
+	{"messages_types", "type"},
+	{"messages_ids", "name"},
+}
+
+// table :
+// Describes a table holding data of a universe.
+//
+// The `name` defines the name of the table.
+//
+// The `filter` defines the condition selecting the rows
+// of the table that belong to the universe. The table is
+// aliased as `t` and the identifier of the universe is
+// provided as `$1`.
+//
+// The `triggered` defines whether the creation time of
+// the rows is overridden by a trigger upon insertion.
+type table struct {
+	name      string
+	filter    string
+	triggered bool
+}
+
+// Subqueries selecting the identifiers of the elements
+// of a universe.
+const (
+	playersOf = "select id from players where universe = $1"
+	planetsOf = "select p.id from planets p inner join players pl on p.player = pl.id where pl.universe = $1"
+	moonsOf   = "select m.id from moons m inner join planets p on m.planet = p.id inner join players pl on p.player = pl.id where pl.universe = $1"
+)
+
+// accountsTable :
+// Defines the name of the table holding the accounts. Only
+// the identifier and the name of the accounts of players
+// are saved in the archive.
+const accountsTable = "accounts"
+
+// tables :
+// Defines the tables holding the data of a universe. The
+// tables are ordered so that rows are inserted after the
+// ones they reference.
+// Note that the events of the players are not saved as
+// they are only kept for a short time anyway.
+var tables = []table{
+	{"universes", "t.id = $1", true},
+	{accountsTable, "t.id in (select account from players where universe = $1)", false},
+	{"players", "t.universe = $1", true},
+	{"players_technologies", "t.player in (" + playersOf + ")", false},
+	{"players_points", "t.player in (" + playersOf + ")", false},
+	{"planets", "t.player in (" + playersOf + ")", true},
+	{"planets_resources", "t.planet in (" + planetsOf + ")", false},
+	{"planets_buildings", "t.planet in (" + planetsOf + ")", false},
+	{"planets_buildings_production_factor", "t.planet in (" + planetsOf + ")", false},
+	{"planets_buildings_production_resources", "t.planet in (" + planetsOf + ")", false},
+	{"planets_ships", "t.planet in (" + planetsOf + ")", false},
+	{"planets_defenses", "t.planet in (" + planetsOf + ")", false},
+	{"moons", "t.planet in (" + planetsOf + ")", true},
+	{"moons_resources", "t.moon in (" + moonsOf + ")", false},
+	{"moons_buildings", "t.moon in (" + moonsOf + ")", false},
+	{"moons_ships", "t.moon in (" + moonsOf + ")", false},
+	{"moons_defenses", "t.moon in (" + moonsOf + ")", false},
+	{"debris_fields", "t.universe = $1", true},
+	{"debris_fields_resources", "t.field in (select id from debris_fields where universe = $1)", false},
+	{"planets_attacks", "t.player in (" + playersOf + ")", false},
+	{"planets_relocations", "t.universe = $1", false},
+	{"construction_actions_buildings", "t.planet in (" + planetsOf + ")", false},
+	{"construction_actions_buildings_production_effects", "t.action in (select id from construction_actions_buildings where planet in (" + planetsOf + "))", false},
+	{"construction_actions_buildings_storage_effects", "t.action in (select id from construction_actions_buildings where planet in (" + planetsOf + "))", false},
+	{"construction_actions_buildings_fields_effects", "t.action in (select id from construction_actions_buildings where planet in (" + planetsOf + "))", false},
+	{"construction_actions_technologies", "t.player in (" + playersOf + ")", false},
+	{"construction_actions_ships", "t.planet in (" + planetsOf + ")", false},
+	{"construction_actions_defenses", "t.planet in (" + planetsOf + ")", false},
+	{"construction_actions_buildings_moon", "t.moon in (" + moonsOf + ")", false},
+	{"construction_actions_buildings_fields_effects_moon", "t.action in (select id from construction_actions_buildings_moon where moon in (" + moonsOf + "))", false},
+	{"construction_actions_ships_moon", "t.moon in (" + moonsOf + ")", false},
+	{"construction_actions_defenses_moon", "t.moon in (" + moonsOf + ")", false},
+	{"fleets_acs", "t.universe = $1", false},
+	{"fleets", "t.universe = $1", true},
+	{"fleets_ships", "t.fleet in (select id from fleets where universe = $1)", false},
+	{"fleets_resources", "t.fleet in (select id from fleets where universe = $1)", false},
+	{"fleets_acs_components", "t.acs in (select id from fleets_acs where universe = $1)", false},
+	{"fleets_scheduled", "t.universe = $1", false},
+	{"transport_routes", "t.universe = $1", false},
+	{"transport_routes_ships", "t.route in (select id from transport_routes where universe = $1)", false},
+	{"transport_routes_resources", "t.route in (select id from transport_routes where universe = $1)", false},
+	{"market_offers", "t.universe = $1", false},
+	{"messages_players", "t.player in (" + playersOf + ")", false},
+	{"messages_arguments", "t.message in (select id from messages_players where player in (" + playersOf + "))", false},
+	{"rankings_snapshots", "t.universe = $1", false},
+	{"rankings_snapshots_players", "t.snapshot in (select id from rankings_snapshots where universe = $1)", false},
+	{"actions_queue", "t.action in (" + queuedOf + ")", false},
+}
+
+// queuedOf :
+// Selects the identifiers of the actions of a universe
+// which can be registered in the actions queue.
+const queuedOf = "select id from construction_actions_buildings where planet in (" + planetsOf + ")" +
+	" union all select id from construction_actions_technologies where player in (" + playersOf + ")" +
+	" union all select id from construction_actions_ships where planet in (" + planetsOf + ")" +
+	" union all select id from construction_actions_defenses where planet in (" + planetsOf + ")" +
+	" union all select id from construction_actions_buildings_moon where moon in (" + moonsOf + ")" +
+	" union all select id from construction_actions_ships_moon where moon in (" + moonsOf + ")" +
+	" union all select id from construction_actions_defenses_moon where moon in (" + moonsOf + ")" +
+	" union all select id from fleets where universe = $1" +
+	" union all select id from fleets_acs where universe = $1" +
+	" union all select id from fleets_scheduled where universe = $1" +
+	" union all select id from planets_relocations where universe = $1"
+
+// Rows :
+// Used to count the rows saved in the archive for each
+// table.
+//
+// Returns the number of rows keyed by table.
+func (a Archive) Rows() map[string]int {
+	counts := make(map[string]int)
+
+	for name, rows := range a.Tables {
+		counts[name] = len(rows)
+	}
+
+	return counts
+}
+
+// remap :
+// Used to replace all the identifiers of the input value
+// which are registered in the mapping. Nested values (as
+// found in the `json` columns) are traversed as well.
+//
+// The `v` defines the value to update.
+//
+// The `mapping` defines the new value of identifiers.
+//
+// Returns the updated value.
+func remap(v interface{}, mapping map[string]string) interface{} {
+	switch value := v.(type) {
+	case string:
+		if id, ok := mapping[value]; ok {
+			return id
+		}
+	case []interface{}:
+		for i, elem := range value {
+			value[i] = remap(elem, mapping)
+		}
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for key, elem := range value {
+			out[remap(key, mapping).(string)] = remap(elem, mapping)
+		}
+
+		return out
+	case Row:
+		return Row(remap(map[string]interface{}(value), mapping).(map[string]interface{}))
+	}
+
+	return v
+}
@@ -0,0 +1,167 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/migrate"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+// Export :
+// Used to produce an archive of the input universe. All
+// the data is read in a single transaction so that the
+// archive is consistent even if the universe is still
+// being played.
+//
+// The `dbase` defines the DB holding the universe.
+//
+// The `universe` defines the identifier of the universe
+// to export.
+//
+// Returns the archive along with any error.
+func Export(dbase *db.DB, universe string) (Archive, error) {
+	archive := Archive{
+		Version:    Version,
+		CreatedAt:  time.Now(),
+		Universe:   universe,
+		References: make(map[string]map[string]string),
+		Tables:     make(map[string][]Row),
+	}
+
+	schema, dirty, err := migrate.Version(db.NewProxy(dbase))
+	if err != nil {
+		return archive, err
+	}
+	if dirty {
+		return archive, fmt.Errorf("%v (version: %d)", migrate.ErrDirtySchema, schema)
+	}
+
+	archive.Schema = schema
+
+	conn, release, err := dbase.DBConnection()
+	defer release()
+
+	if err != nil {
+		return archive, err
+	}
+
+	tx, err := conn.BeginEx(
+		context.Background(),
+		&pgx.TxOptions{
+			IsoLevel:   pgx.RepeatableRead,
+			AccessMode: pgx.ReadOnly,
+		},
+	)
+	if err != nil {
+		return archive, err
+	}
+
+	// The transaction is only used for reading.
+	defer tx.Rollback()
+
+	for _, ref := range references {
+		elems, err := fetchReference(tx, ref)
+		if err != nil {
+			return archive, fmt.Errorf("could not export \"%s\" (err: %v)", ref.name, err)
+		}
+
+		archive.References[ref.name] = elems
+	}
+
+	for _, t := range tables {
+		rows, err := fetchRows(tx, t, universe)
+		if err != nil {
+			return archive, fmt.Errorf("could not export \"%s\" (err: %v)", t.name, err)
+		}
+
+		// Only keep the information needed to restore the
+		// players: the credentials should not leave the DB.
+		if t.name == accountsTable {
+			for _, row := range rows {
+				for key := range row {
+					if key != "id" && key != "name" {
+						delete(row, key)
+					}
+				}
+			}
+		}
+
+		archive.Tables[t.name] = rows
+	}
+
+	if len(archive.Tables["universes"]) == 0 {
+		return archive, fmt.Errorf("%v (universe: \"%s\")", ErrUniverseNotFound, universe)
+	}
+
+	return archive, nil
+}
+
+// fetchReference :
+// Used to retrieve the names of the elements defined by
+// the input table of the data model.
+//
+// The `tx` defines the transaction to use.
+//
+// The `ref` defines the table to fetch.
+//
+// Returns the names of the elements keyed by identifier
+// along with any error.
+func fetchReference(tx *pgx.Tx, ref reference) (map[string]string, error) {
+	elems := make(map[string]string)
+
+	query := fmt.Sprintf(
+		"select coalesce(json_object_agg(id, %s), '{}')::text from %s",
+		pgx.Identifier{ref.key}.Sanitize(),
+		pgx.Identifier{ref.name}.Sanitize(),
+	)
+
+	var raw string
+	err := tx.QueryRow(query).Scan(&raw)
+	if err != nil {
+		return elems, err
+	}
+
+	err = json.Unmarshal([]byte(raw), &elems)
+
+	return elems, err
+}
+
+// fetchRows :
+// Used to retrieve the rows of the input table belonging
+// to a universe.
+//
+// The `tx` defines the transaction to use.
+//
+// The `t` defines the table to fetch.
+//
+// The `universe` defines the identifier of the universe.
+//
+// Returns the rows along with any error.
+func fetchRows(tx *pgx.Tx, t table, universe string) ([]Row, error) {
+	rows := make([]Row, 0)
+
+	query := fmt.Sprintf(
+		"select coalesce(json_agg(t), '[]')::text from %s t where %s",
+		pgx.Identifier{t.name}.Sanitize(),
+		t.filter,
+	)
+
+	var raw string
+	err := tx.QueryRow(query, universe).Scan(&raw)
+	if err != nil {
+		return rows, err
+	}
+
+	// Keep numbers as they are to not lose precision.
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+
+	err = dec.Decode(&rows)
+
+	return rows, err
+}
@@ -0,0 +1,294 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/migrate"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx"
+)
+
+// Options :
+// Defines how an archive should be imported.
+type Options struct {
+	// The `Remap` defines whether new identifiers should be
+	// generated for all the elements of the universe. This
+	// allows to import the archive as a new universe in a
+	// DB which already contains the original one.
+	Remap bool
+
+	// The `Name` defines the name of the imported universe.
+	// If empty the name saved in the archive is used.
+	Name string
+
+	// The `DryRun` defines whether the import should only
+	// be checked: the changes are rolled back once all the
+	// data has been inserted.
+	DryRun bool
+}
+
+// Import :
+// Used to restore the universe described by the input
+// archive. The elements of the data model referenced by
+// the archive are matched by name with the ones of the
+// DB, and the accounts of the players are created if
+// needed (without any credentials).
+// The import is performed in a single transaction: in
+// case of failure the DB is not modified.
+//
+// The `dbase` defines the DB into which the universe is
+// imported.
+//
+// The `archive` defines the data to import. It will be
+// modified by the process.
+//
+// The `opts` defines how the archive is imported.
+//
+// Returns the identifier of the imported universe along
+// with any error.
+func Import(dbase *db.DB, archive Archive, opts Options) (string, error) {
+	if archive.Version != Version {
+		return "", fmt.Errorf("%v (version: %d, supported: %d)", ErrUnsupportedVersion, archive.Version, Version)
+	}
+
+	schema, dirty, err := migrate.Version(db.NewProxy(dbase))
+	if err != nil {
+		return "", err
+	}
+	if dirty {
+		return "", fmt.Errorf("%v (version: %d)", migrate.ErrDirtySchema, schema)
+	}
+	if schema != archive.Schema {
+		return "", fmt.Errorf("%v (schema: %d, archive: %d)", ErrSchemaMismatch, schema, archive.Schema)
+	}
+
+	known := make(map[string]bool)
+	for _, t := range tables {
+		known[t.name] = true
+	}
+	for name := range archive.Tables {
+		if !known[name] {
+			return "", fmt.Errorf("%v (table: \"%s\")", ErrUnknownTable, name)
+		}
+	}
+
+	conn, release, err := dbase.DBConnection()
+	defer release()
+
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return "", err
+	}
+
+	// Rolling back a committed transaction does nothing.
+	defer tx.Rollback()
+
+	mapping, err := mapReferences(tx, archive.References)
+	if err != nil {
+		return "", err
+	}
+
+	universe := archive.Universe
+
+	if opts.Remap {
+		universe = mapIdentifiers(archive, mapping)
+	}
+
+	var count int
+	err = tx.QueryRow("select count(*) from universes where id = $1", universe).Scan(&count)
+	if err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return "", fmt.Errorf("%v (universe: \"%s\")", ErrUniverseExists, universe)
+	}
+
+	for name, rows := range archive.Tables {
+		for i := range rows {
+			rows[i] = remap(rows[i], mapping).(Row)
+		}
+
+		archive.Tables[name] = rows
+	}
+
+	if opts.Name != "" {
+		for _, row := range archive.Tables["universes"] {
+			row["name"] = opts.Name
+		}
+	}
+
+	for _, t := range tables {
+		err = insertRows(tx, t, archive.Tables[t.name])
+		if err != nil {
+			return "", fmt.Errorf("could not import \"%s\" (err: %v)", t.name, err)
+		}
+	}
+
+	// Inserting messages generates events for the players:
+	// they are not relevant for restored messages.
+	_, err = tx.Exec("delete from players_events where player in ("+playersOf+")", universe)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.DryRun {
+		return universe, nil
+	}
+
+	return universe, tx.Commit()
+}
+
+// mapReferences :
+// Used to match the elements of the data model used by an
+// archive with the ones defined in the DB.
+//
+// The `tx` defines the transaction to use.
+//
+// The `refs` defines the elements used by the archive for
+// each table of the data model.
+//
+// Returns the identifier in the DB of each element of the
+// archive along with any error.
+func mapReferences(tx *pgx.Tx, refs map[string]map[string]string) (map[string]string, error) {
+	mapping := make(map[string]string)
+
+	for _, ref := range references {
+		elems, err := fetchReference(tx, ref)
+		if err != nil {
+			return mapping, err
+		}
+
+		IDs := make(map[string]string)
+		for id, name := range elems {
+			IDs[name] = id
+		}
+
+		for id, name := range refs[ref.name] {
+			local, ok := IDs[name]
+			if !ok {
+				return mapping, fmt.Errorf("%v (table: \"%s\", name: \"%s\")", ErrUnknownReference, ref.name, name)
+			}
+
+			if local != id {
+				mapping[id] = local
+			}
+		}
+	}
+
+	return mapping, nil
+}
+
+// mapIdentifiers :
+// Used to generate a new identifier for the universe and
+// for each of its elements. Accounts are shared between
+// universes and thus keep their identifier.
+//
+// The `archive` defines the data of the universe.
+//
+// The `mapping` defines the mapping to complete.
+//
+// Returns the new identifier of the universe.
+func mapIdentifiers(archive Archive, mapping map[string]string) string {
+	universe := uuid.New().String()
+	mapping[archive.Universe] = universe
+
+	for name, rows := range archive.Tables {
+		if name == accountsTable {
+			continue
+		}
+
+		for _, row := range rows {
+			id, ok := row["id"].(string)
+			if _, mapped := mapping[id]; !ok || mapped {
+				continue
+			}
+
+			if _, err := uuid.Parse(id); err == nil {
+				mapping[id] = uuid.New().String()
+			}
+		}
+	}
+
+	return universe
+}
+
+// insertRows :
+// Used to insert the input rows in the specified table.
+// Accounts which already exist are kept as is.
+//
+// The `tx` defines the transaction to use.
+//
+// The `t` defines the table to update.
+//
+// The `rows` defines the rows to insert.
+//
+// Returns any error.
+func insertRows(tx *pgx.Tx, t table, rows []Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	raw, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+
+	name := pgx.Identifier{t.name}.Sanitize()
+
+	if t.name == accountsTable {
+		// Accounts are created without credentials: they
+		// can't be used to log in.
+		query := "insert into accounts (id, name, mail, password)" +
+			" select a.id, case when exists (select 1 from accounts where name = a.name) then a.name || '-' || left(a.id::text, 8) else a.name end, a.id::text || '@snapshot.invalid', ''" +
+			" from json_populate_recordset(null::accounts, $1::json) a" +
+			" where not exists (select 1 from accounts where id = a.id)"
+
+		_, err = tx.Exec(query, string(raw))
+		return err
+	}
+
+	columns := make([]string, 0, len(rows[0]))
+	for column := range rows[0] {
+		columns = append(columns, pgx.Identifier{column}.Sanitize())
+	}
+	sort.Strings(columns)
+
+	list := strings.Join(columns, ", ")
+
+	query := fmt.Sprintf(
+		"insert into %s (%s) select %s from json_populate_recordset(null::%s, $1::json)",
+		name,
+		list,
+		list,
+		name,
+	)
+
+	_, err = tx.Exec(query, string(raw))
+	if err != nil {
+		return err
+	}
+
+	if !t.triggered {
+		return nil
+	}
+
+	// The creation time is overridden upon insertion so it
+	// is restored afterwards.
+	query = fmt.Sprintf(
+		"update %s t set created_at = s.created_at from json_populate_recordset(null::%s, $1::json) s where t.id = s.id",
+		name,
+		name,
+	)
+
+	_, err = tx.Exec(query, string(raw))
+
+	return err
+}
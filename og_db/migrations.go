@@ -0,0 +1,16 @@
+package ogdb
+
+import "embed"
+
+// Migrations :
+// Holds the migrations describing the schema of the DB
+// so that they are available to the server without the
+// need to distribute them along with it.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// MigrationsDir :
+// Defines the directory holding the migrations in the
+// embedded file system.
+const MigrationsDir = "migrations"
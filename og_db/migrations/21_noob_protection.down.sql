@@ -0,0 +1,10 @@
+-- Drop the attack registration script.
+DROP FUNCTION register_planet_attack(player_id uuid, target_id uuid, target_kind text, moment TIMESTAMP WITH TIME ZONE);
+
+-- Drop the table registering attacks.
+DROP TABLE planets_attacks;
+
+-- Remove the noob protection properties from universes.
+ALTER TABLE universes DROP COLUMN bashing_limit;
+ALTER TABLE universes DROP COLUMN noob_protection_ratio;
+ALTER TABLE universes DROP COLUMN noob_protection_points;
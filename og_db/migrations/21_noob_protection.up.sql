@@ -0,0 +1,32 @@
+-- Add the noob protection properties to the universes.
+ALTER TABLE universes ADD COLUMN noob_protection_points integer NOT NULL DEFAULT 0;
+ALTER TABLE universes ADD COLUMN noob_protection_ratio numeric(5, 2) NOT NULL DEFAULT 0;
+ALTER TABLE universes ADD COLUMN bashing_limit integer NOT NULL DEFAULT 0;
+
+-- Create the table registering the successful attacks
+-- performed by players on planets and moons.
+CREATE TABLE planets_attacks (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  player uuid NOT NULL,
+  target uuid NOT NULL,
+  target_type text NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (id)
+);
+
+-- Register a successful attack of a player on a planet.
+CREATE OR REPLACE FUNCTION register_planet_attack(player_id uuid, target_id uuid, target_kind text, moment TIMESTAMP WITH TIME ZONE) RETURNS VOID AS $$
+BEGIN
+  -- Make sure that the target type is valid.
+  IF target_kind != 'planet' AND target_kind != 'moon' THEN
+    RAISE EXCEPTION 'Invalid kind % specified for attack', target_kind;
+  END IF;
+
+  INSERT INTO planets_attacks("player", "target", "target_type", "created_at")
+    VALUES(player_id, target_id, target_kind, moment);
+
+  -- Attacks older than a day are not relevant anymore
+  -- to enforce the bashing rule so we can remove them.
+  DELETE FROM planets_attacks WHERE created_at < moment - interval '1 day';
+END
+$$ LANGUAGE plpgsql;
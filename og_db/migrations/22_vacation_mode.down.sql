@@ -0,0 +1,28 @@
+-- Drop the players' activity update script.
+DROP FUNCTION update_players_activity();
+
+-- Restore the player's update script.
+CREATE OR REPLACE FUNCTION update_player(player_id uuid, inputs json) RETURNS VOID AS $$
+DECLARE
+  p_name text;
+BEGIN
+  -- Fetch the data from the `inputs` and update only
+  -- values that are filled. For now there's only the
+  -- name of the player but we could add more later.
+  SELECT t.name INTO p_name FROM json_to_record(inputs) AS t(name text);
+
+  -- Update each prop if it is defined.
+  IF p_name != '' THEN
+    UPDATE players SET name = p_name WHERE id = player_id;
+  END IF;
+END
+$$ LANGUAGE plpgsql;
+
+-- Restore the original resources update script.
+DROP FUNCTION update_resources_for_planet_to_time(planet_id uuid, moment TIMESTAMP WITH TIME ZONE);
+ALTER FUNCTION update_resources_for_active_planet_to_time(planet_id uuid, moment TIMESTAMP WITH TIME ZONE) RENAME TO update_resources_for_planet_to_time;
+
+-- Remove the vacation mode and inactivity flags from players.
+ALTER TABLE players DROP COLUMN long_inactive;
+ALTER TABLE players DROP COLUMN inactive;
+ALTER TABLE players DROP COLUMN vacation_mode;
@@ -0,0 +1,82 @@
+-- Add the vacation mode and the inactivity flags to the players.
+ALTER TABLE players ADD COLUMN vacation_mode boolean NOT NULL DEFAULT false;
+ALTER TABLE players ADD COLUMN inactive boolean NOT NULL DEFAULT false;
+ALTER TABLE players ADD COLUMN long_inactive boolean NOT NULL DEFAULT false;
+
+-- The update of resources should not produce anything
+-- for players in vacation mode: we keep the original
+-- script under a new name and wrap it.
+ALTER FUNCTION update_resources_for_planet_to_time(planet_id uuid, moment TIMESTAMP WITH TIME ZONE) RENAME TO update_resources_for_active_planet_to_time;
+
+-- Update the resources available on a planet until the
+-- provided `moment` in time taking into account the
+-- vacation mode of its owner.
+CREATE OR REPLACE FUNCTION update_resources_for_planet_to_time(planet_id uuid, moment TIMESTAMP WITH TIME ZONE) RETURNS VOID AS $$
+BEGIN
+  -- In case the player owning the planet is in vacation
+  -- mode we only need to update the last update time of
+  -- the resources so that nothing is produced.
+  IF EXISTS (SELECT p.id FROM planets p INNER JOIN players pl ON p.player = pl.id WHERE p.id = planet_id AND pl.vacation_mode = 'true') THEN
+    UPDATE planets_resources SET updated_at = moment WHERE planet = planet_id;
+  ELSE
+    PERFORM update_resources_for_active_planet_to_time(planet_id, moment);
+  END IF;
+END
+$$ LANGUAGE plpgsql;
+
+-- Update data for an existing player.
+CREATE OR REPLACE FUNCTION update_player(player_id uuid, inputs json) RETURNS VOID AS $$
+DECLARE
+  p_name text;
+  p_vacation boolean;
+  temprow record;
+BEGIN
+  -- Fetch the data from the `inputs` and update only
+  -- values that are filled.
+  SELECT t.name, t.vacation_mode INTO p_name, p_vacation FROM json_to_record(inputs) AS t(name text, vacation_mode boolean);
+
+  -- Update each prop if it is defined.
+  IF p_name != '' THEN
+    UPDATE players SET name = p_name WHERE id = player_id;
+  END IF;
+
+  -- In case the vacation mode changes we need to update
+  -- the resources of the planets of the player: this
+  -- will account for the production until now (or the
+  -- absence of production) before changing the status.
+  IF p_vacation IS NOT NULL AND p_vacation != (SELECT vacation_mode FROM players WHERE id = player_id) THEN
+    FOR temprow IN
+      SELECT id FROM planets WHERE player = player_id
+    LOOP
+      PERFORM update_resources_for_planet(temprow.id);
+    END LOOP;
+
+    UPDATE players SET vacation_mode = p_vacation WHERE id = player_id;
+  END IF;
+END
+$$ LANGUAGE plpgsql;
+
+-- Update the inactivity flags of players based on the
+-- most recent activity registered on their planets.
+CREATE OR REPLACE FUNCTION update_players_activity() RETURNS VOID AS $$
+BEGIN
+  WITH activity AS (
+    SELECT
+      pl.id AS player,
+      MAX(p.last_activity) AS last_activity
+    FROM
+      players pl
+      LEFT JOIN planets p ON p.player = pl.id
+    GROUP BY
+      pl.id
+    )
+  UPDATE players AS pl
+    SET
+      inactive = COALESCE(a.last_activity < NOW() - interval '7 days', true),
+      long_inactive = COALESCE(a.last_activity < NOW() - interval '28 days', true)
+  FROM
+    activity AS a
+  WHERE
+    pl.id = a.player;
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,7 @@
+-- Drop the snapshots creation scripts.
+DROP FUNCTION create_rankings_snapshots(moment TIMESTAMP WITH TIME ZONE);
+DROP FUNCTION create_rankings_snapshot(universe_id uuid, moment TIMESTAMP WITH TIME ZONE);
+
+-- Drop the tables holding the snapshots.
+DROP TABLE rankings_snapshots_players;
+DROP TABLE rankings_snapshots;
@@ -0,0 +1,86 @@
+-- Create the table defining the snapshots of the rankings
+-- taken regularly for each universe.
+CREATE TABLE rankings_snapshots (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  universe uuid NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (id),
+  FOREIGN KEY (universe) REFERENCES universes(id)
+);
+
+-- Create the table defining the points and ranks of each
+-- player in a snapshot. Note that we don't reference the
+-- players table so that the history is kept even when a
+-- player is deleted.
+CREATE TABLE rankings_snapshots_players (
+  snapshot uuid NOT NULL,
+  player uuid NOT NULL,
+  economy_points numeric(15, 5) NOT NULL,
+  research_points numeric(15, 5) NOT NULL,
+  military_points numeric(15, 5) NOT NULL,
+  military_points_built numeric(15, 5) NOT NULL,
+  military_points_lost numeric(15, 5) NOT NULL,
+  military_points_destroyed numeric(15, 5) NOT NULL,
+  points numeric(15, 5) NOT NULL,
+  economy_rank integer NOT NULL,
+  research_rank integer NOT NULL,
+  military_rank integer NOT NULL,
+  military_built_rank integer NOT NULL,
+  military_lost_rank integer NOT NULL,
+  military_destroyed_rank integer NOT NULL,
+  points_rank integer NOT NULL,
+  FOREIGN KEY (snapshot) REFERENCES rankings_snapshots(id),
+  UNIQUE (snapshot, player)
+);
+
+-- Create a snapshot of the rankings of the players of
+-- the input universe at the specified moment.
+CREATE OR REPLACE FUNCTION create_rankings_snapshot(universe_id uuid, moment TIMESTAMP WITH TIME ZONE) RETURNS VOID AS $$
+DECLARE
+  snapshot_id uuid;
+BEGIN
+  INSERT INTO rankings_snapshots("universe", "created_at")
+    VALUES(universe_id, moment)
+    RETURNING id INTO snapshot_id;
+
+  -- Compute the rank of each player in each category.
+  -- Players with the same amount of points share the
+  -- same rank.
+  INSERT INTO rankings_snapshots_players
+    SELECT
+      snapshot_id,
+      pp.player,
+      pp.economy_points,
+      pp.research_points,
+      pp.military_points,
+      pp.military_points_built,
+      pp.military_points_lost,
+      pp.military_points_destroyed,
+      pp.economy_points + pp.research_points + pp.military_points,
+      rank() OVER (ORDER BY pp.economy_points DESC),
+      rank() OVER (ORDER BY pp.research_points DESC),
+      rank() OVER (ORDER BY pp.military_points DESC),
+      rank() OVER (ORDER BY pp.military_points_built DESC),
+      rank() OVER (ORDER BY pp.military_points_lost DESC),
+      rank() OVER (ORDER BY pp.military_points_destroyed DESC),
+      rank() OVER (ORDER BY pp.economy_points + pp.research_points + pp.military_points DESC)
+    FROM
+      players_points pp
+      INNER JOIN players p ON pp.player = p.id
+    WHERE
+      p.universe = universe_id;
+END
+$$ LANGUAGE plpgsql;
+
+-- Create a snapshot of the rankings for all universes.
+CREATE OR REPLACE FUNCTION create_rankings_snapshots(moment TIMESTAMP WITH TIME ZONE) RETURNS VOID AS $$
+DECLARE
+  temprow record;
+BEGIN
+  FOR temprow IN
+    SELECT id FROM universes
+  LOOP
+    PERFORM create_rankings_snapshot(temprow.id, moment);
+  END LOOP;
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,8 @@
+-- Drop the synchronization script.
+DROP FUNCTION sync_rule_set(inputs json);
+
+-- Drop the rule set used by universes.
+ALTER TABLE universes DROP COLUMN rule_set;
+
+-- Drop the rule sets table.
+DROP TABLE rule_sets;
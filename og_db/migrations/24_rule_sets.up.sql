@@ -0,0 +1,259 @@
+-- Create the table keeping track of the rule sets that
+-- were synchronized in the DB.
+CREATE TABLE rule_sets (
+  name text NOT NULL,
+  version integer NOT NULL,
+  synced_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (name)
+);
+
+-- Add the rule set used by each universe.
+ALTER TABLE universes ADD COLUMN rule_set text NOT NULL DEFAULT 'classic';
+
+-- Synchronize the data model described by the input rule
+-- set with the content of the DB. Elements are matched by
+-- name: existing ones are updated while the others are
+-- created. Note that elements are never removed as they
+-- might be referenced by some planets or fleets.
+CREATE OR REPLACE FUNCTION sync_rule_set(inputs json) RETURNS VOID AS $$
+DECLARE
+  elem json;
+  elem_id uuid;
+BEGIN
+  -- Resources.
+  FOR elem IN SELECT * FROM json_array_elements(inputs->'resources')
+  LOOP
+    UPDATE resources
+      SET
+        base_production = (elem->>'base_production')::integer,
+        base_storage = (elem->>'base_storage')::integer,
+        base_amount = (elem->>'base_amount')::integer,
+        movable = (elem->>'movable')::boolean,
+        storable = (elem->>'storable')::boolean,
+        dispersable = (elem->>'dispersable')::boolean,
+        economy_scalable = (elem->>'economy_scalable')::boolean
+      WHERE
+        name = elem->>'name';
+
+    IF NOT FOUND THEN
+      INSERT INTO resources("name", "base_production", "base_storage", "base_amount", "movable", "storable", "dispersable", "economy_scalable")
+        VALUES(
+          elem->>'name',
+          (elem->>'base_production')::integer,
+          (elem->>'base_storage')::integer,
+          (elem->>'base_amount')::integer,
+          (elem->>'movable')::boolean,
+          (elem->>'storable')::boolean,
+          (elem->>'dispersable')::boolean,
+          (elem->>'economy_scalable')::boolean
+        );
+    END IF;
+  END LOOP;
+
+  -- Create or update the base description of all the
+  -- elements first so that they can reference each
+  -- other in a second pass.
+  FOR elem IN SELECT * FROM json_array_elements(inputs->'buildings')
+  LOOP
+    UPDATE buildings
+      SET
+        buildable_on_planet = (elem->>'buildable_on_planet')::boolean,
+        buildable_on_moon = (elem->>'buildable_on_moon')::boolean
+      WHERE
+        name = elem->>'name';
+
+    IF NOT FOUND THEN
+      INSERT INTO buildings("name", "buildable_on_planet", "buildable_on_moon")
+        VALUES(elem->>'name', (elem->>'buildable_on_planet')::boolean, (elem->>'buildable_on_moon')::boolean);
+    END IF;
+  END LOOP;
+
+  FOR elem IN SELECT * FROM json_array_elements(inputs->'technologies')
+  LOOP
+    IF NOT EXISTS (SELECT id FROM technologies WHERE name = elem->>'name') THEN
+      INSERT INTO technologies("name") VALUES(elem->>'name');
+    END IF;
+  END LOOP;
+
+  FOR elem IN SELECT * FROM json_array_elements(inputs->'ships')
+  LOOP
+    UPDATE ships
+      SET
+        cargo = (elem->>'cargo')::integer,
+        shield = (elem->>'shield')::integer,
+        weapon = (elem->>'weapon')::integer
+      WHERE
+        name = elem->>'name';
+
+    IF NOT FOUND THEN
+      INSERT INTO ships("name", "cargo", "shield", "weapon")
+        VALUES(elem->>'name', (elem->>'cargo')::integer, (elem->>'shield')::integer, (elem->>'weapon')::integer);
+    END IF;
+  END LOOP;
+
+  FOR elem IN SELECT * FROM json_array_elements(inputs->'defenses')
+  LOOP
+    UPDATE defenses
+      SET
+        shield = (elem->>'shield')::integer,
+        weapon = (elem->>'weapon')::integer
+      WHERE
+        name = elem->>'name';
+
+    IF NOT FOUND THEN
+      INSERT INTO defenses("name", "shield", "weapon")
+        VALUES(elem->>'name', (elem->>'shield')::integer, (elem->>'weapon')::integer);
+    END IF;
+  END LOOP;
+
+  -- Buildings' properties.
+  FOR elem IN SELECT * FROM json_array_elements(inputs->'buildings')
+  LOOP
+    SELECT id INTO elem_id FROM buildings WHERE name = elem->>'name';
+
+    DELETE FROM buildings_costs WHERE element = elem_id;
+    INSERT INTO buildings_costs("element", "res", "cost")
+      SELECT elem_id, r.id, c.value::integer
+      FROM json_each_text(elem->'costs') AS c INNER JOIN resources r ON r.name = c.key;
+
+    DELETE FROM buildings_costs_progress WHERE element = elem_id;
+    INSERT INTO buildings_costs_progress("element", "progress")
+      VALUES(elem_id, (elem->>'cost_progress')::numeric);
+
+    DELETE FROM buildings_gains_progress WHERE element = elem_id;
+    INSERT INTO buildings_gains_progress("element", "res", "base", "progress", "temperature_coeff", "temperature_offset")
+      SELECT elem_id, r.id, t.base, t.progress, t.temperature_coeff, t.temperature_offset
+      FROM
+        json_to_recordset(elem->'production') AS t(resource text, base integer, progress numeric(15, 5), temperature_coeff numeric(15, 5), temperature_offset numeric(15, 5))
+        INNER JOIN resources r ON r.name = t.resource;
+
+    DELETE FROM buildings_storage_progress WHERE element = elem_id;
+    INSERT INTO buildings_storage_progress("element", "res", "base", "multiplier", "progress")
+      SELECT elem_id, r.id, t.base, t.multiplier, t.progress
+      FROM
+        json_to_recordset(elem->'storage') AS t(resource text, base integer, multiplier numeric(15, 5), progress numeric(15, 5))
+        INNER JOIN resources r ON r.name = t.resource;
+
+    DELETE FROM buildings_fields_progress WHERE element = elem_id;
+    IF json_typeof(elem->'fields') = 'object' THEN
+      INSERT INTO buildings_fields_progress("element", "multiplier", "constant")
+        VALUES(elem_id, (elem->'fields'->>'multiplier')::numeric, (elem->'fields'->>'constant')::integer);
+    END IF;
+
+    DELETE FROM tech_tree_buildings_vs_buildings WHERE element = elem_id;
+    INSERT INTO tech_tree_buildings_vs_buildings("element", "requirement", "level")
+      SELECT elem_id, b.id, d.value::integer
+      FROM json_each_text(elem->'requirements'->'buildings') AS d INNER JOIN buildings b ON b.name = d.key;
+
+    DELETE FROM tech_tree_buildings_vs_technologies WHERE element = elem_id;
+    INSERT INTO tech_tree_buildings_vs_technologies("element", "requirement", "level")
+      SELECT elem_id, t.id, d.value::integer
+      FROM json_each_text(elem->'requirements'->'technologies') AS d INNER JOIN technologies t ON t.name = d.key;
+  END LOOP;
+
+  -- Technologies' properties.
+  FOR elem IN SELECT * FROM json_array_elements(inputs->'technologies')
+  LOOP
+    SELECT id INTO elem_id FROM technologies WHERE name = elem->>'name';
+
+    DELETE FROM technologies_costs WHERE element = elem_id;
+    INSERT INTO technologies_costs("element", "res", "cost")
+      SELECT elem_id, r.id, c.value::integer
+      FROM json_each_text(elem->'costs') AS c INNER JOIN resources r ON r.name = c.key;
+
+    DELETE FROM technologies_costs_progress WHERE element = elem_id;
+    INSERT INTO technologies_costs_progress("element", "progress")
+      VALUES(elem_id, (elem->>'cost_progress')::numeric);
+
+    DELETE FROM tech_tree_technologies_vs_buildings WHERE element = elem_id;
+    INSERT INTO tech_tree_technologies_vs_buildings("element", "requirement", "level")
+      SELECT elem_id, b.id, d.value::integer
+      FROM json_each_text(elem->'requirements'->'buildings') AS d INNER JOIN buildings b ON b.name = d.key;
+
+    DELETE FROM tech_tree_technologies_vs_technologies WHERE element = elem_id;
+    INSERT INTO tech_tree_technologies_vs_technologies("element", "requirement", "level")
+      SELECT elem_id, t.id, d.value::integer
+      FROM json_each_text(elem->'requirements'->'technologies') AS d INNER JOIN technologies t ON t.name = d.key;
+  END LOOP;
+
+  -- Ships' properties.
+  FOR elem IN SELECT * FROM json_array_elements(inputs->'ships')
+  LOOP
+    SELECT id INTO elem_id FROM ships WHERE name = elem->>'name';
+
+    DELETE FROM ships_costs WHERE element = elem_id;
+    INSERT INTO ships_costs("element", "res", "cost")
+      SELECT elem_id, r.id, c.value::integer
+      FROM json_each_text(elem->'costs') AS c INNER JOIN resources r ON r.name = c.key;
+
+    DELETE FROM ships_propulsion WHERE ship = elem_id;
+    INSERT INTO ships_propulsion("ship", "propulsion", "speed", "min_level", "rank")
+      SELECT elem_id, te.id, t.speed, t.min_level, t.rank
+      FROM
+        json_to_recordset(elem->'propulsion') AS t(technology text, speed integer, min_level integer, rank integer)
+        INNER JOIN technologies te ON te.name = t.technology;
+
+    DELETE FROM ships_propulsion_cost WHERE ship = elem_id;
+    INSERT INTO ships_propulsion_cost("ship", "res", "amount")
+      SELECT elem_id, r.id, c.value::integer
+      FROM json_each_text(elem->'consumption') AS c INNER JOIN resources r ON r.name = c.key;
+
+    DELETE FROM ships_deployment_cost WHERE ship = elem_id;
+    INSERT INTO ships_deployment_cost("ship", "res", "cost")
+      SELECT elem_id, r.id, c.value::numeric
+      FROM json_each_text(elem->'deployment') AS c INNER JOIN resources r ON r.name = c.key;
+
+    DELETE FROM ships_rapid_fire WHERE ship = elem_id;
+    INSERT INTO ships_rapid_fire("ship", "target", "rapid_fire")
+      SELECT elem_id, s.id, rf.value::integer
+      FROM json_each_text(elem->'rapid_fire'->'ships') AS rf INNER JOIN ships s ON s.name = rf.key;
+
+    DELETE FROM ships_rapid_fire_defenses WHERE ship = elem_id;
+    INSERT INTO ships_rapid_fire_defenses("ship", "target", "rapid_fire")
+      SELECT elem_id, d.id, rf.value::integer
+      FROM json_each_text(elem->'rapid_fire'->'defenses') AS rf INNER JOIN defenses d ON d.name = rf.key;
+
+    DELETE FROM tech_tree_ships_vs_buildings WHERE element = elem_id;
+    INSERT INTO tech_tree_ships_vs_buildings("element", "requirement", "level")
+      SELECT elem_id, b.id, d.value::integer
+      FROM json_each_text(elem->'requirements'->'buildings') AS d INNER JOIN buildings b ON b.name = d.key;
+
+    DELETE FROM tech_tree_ships_vs_technologies WHERE element = elem_id;
+    INSERT INTO tech_tree_ships_vs_technologies("element", "requirement", "level")
+      SELECT elem_id, t.id, d.value::integer
+      FROM json_each_text(elem->'requirements'->'technologies') AS d INNER JOIN technologies t ON t.name = d.key;
+  END LOOP;
+
+  -- Defenses' properties.
+  FOR elem IN SELECT * FROM json_array_elements(inputs->'defenses')
+  LOOP
+    SELECT id INTO elem_id FROM defenses WHERE name = elem->>'name';
+
+    DELETE FROM defenses_costs WHERE element = elem_id;
+    INSERT INTO defenses_costs("element", "res", "cost")
+      SELECT elem_id, r.id, c.value::integer
+      FROM json_each_text(elem->'costs') AS c INNER JOIN resources r ON r.name = c.key;
+
+    DELETE FROM tech_tree_defenses_vs_buildings WHERE element = elem_id;
+    INSERT INTO tech_tree_defenses_vs_buildings("element", "requirement", "level")
+      SELECT elem_id, b.id, d.value::integer
+      FROM json_each_text(elem->'requirements'->'buildings') AS d INNER JOIN buildings b ON b.name = d.key;
+
+    DELETE FROM tech_tree_defenses_vs_technologies WHERE element = elem_id;
+    INSERT INTO tech_tree_defenses_vs_technologies("element", "requirement", "level")
+      SELECT elem_id, t.id, d.value::integer
+      FROM json_each_text(elem->'requirements'->'technologies') AS d INNER JOIN technologies t ON t.name = d.key;
+  END LOOP;
+
+  -- Propulsion increase.
+  DELETE FROM ships_propulsion_increase;
+  INSERT INTO ships_propulsion_increase("propulsion", "increase")
+    SELECT t.id, p.value::integer
+    FROM json_each_text(inputs->'propulsion_increase') AS p INNER JOIN technologies t ON t.name = p.key;
+
+  -- Register the version of the rule set.
+  INSERT INTO rule_sets("name", "version", "synced_at")
+    VALUES(inputs->>'name', (inputs->>'version')::integer, NOW())
+    ON CONFLICT (name) DO UPDATE SET version = excluded.version, synced_at = excluded.synced_at;
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,10 @@
+-- Drop the trigger on messages.
+DROP TRIGGER create_players_messages_event ON messages_players;
+DROP FUNCTION create_message_event();
+
+-- Drop the events' functions.
+DROP FUNCTION delete_players_events(moment timestamp with time zone);
+DROP FUNCTION create_player_event(inputs json);
+
+-- Drop the events table.
+DROP TABLE players_events;
@@ -0,0 +1,49 @@
+-- Create the table registering the events produced for
+-- each player. The identifier is monotonic so that it
+-- can be used to resume a stream of events.
+CREATE TABLE players_events (
+  id bigserial NOT NULL,
+  player uuid NOT NULL,
+  kind text NOT NULL,
+  data json NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  FOREIGN KEY (player) REFERENCES players(id) ON DELETE CASCADE
+);
+
+CREATE INDEX players_events_player_index ON players_events (player, id);
+
+-- Register a new event for a player.
+CREATE OR REPLACE FUNCTION create_player_event(inputs json) RETURNS VOID AS $$
+BEGIN
+  INSERT INTO players_events("player", "kind", "data")
+    VALUES((inputs->>'player')::uuid, inputs->>'kind', inputs->'data');
+END
+$$ LANGUAGE plpgsql;
+
+-- Remove the events older than the input date.
+CREATE OR REPLACE FUNCTION delete_players_events(moment timestamp with time zone) RETURNS VOID AS $$
+BEGIN
+  DELETE FROM players_events WHERE created_at < moment;
+END
+$$ LANGUAGE plpgsql;
+
+-- Register an event whenever a message is created for a
+-- player so that it can be notified.
+CREATE OR REPLACE FUNCTION create_message_event() RETURNS TRIGGER AS $$
+BEGIN
+  INSERT INTO players_events("player", "kind", "data")
+    SELECT
+      NEW.player,
+      'message',
+      json_build_object('message', NEW.id, 'type', mi.name)
+    FROM
+      messages_ids AS mi
+    WHERE
+      mi.id = NEW.message;
+
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER create_players_messages_event AFTER INSERT ON messages_players FOR EACH ROW EXECUTE PROCEDURE create_message_event();
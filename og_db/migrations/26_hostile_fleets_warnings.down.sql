@@ -0,0 +1,10 @@
+-- Drop the function creating the hostile fleet warnings.
+DROP FUNCTION create_hostile_fleet_message(fleet_id uuid);
+
+-- Remove the messages already generated and the seeded
+-- message identifier.
+DELETE FROM messages_arguments WHERE message IN (
+  SELECT mp.id FROM messages_players AS mp INNER JOIN messages_ids AS mi ON mp.message = mi.id WHERE mi.name = 'hostile_fleet_incoming'
+);
+DELETE FROM messages_players WHERE message IN (SELECT id FROM messages_ids WHERE name = 'hostile_fleet_incoming');
+DELETE FROM messages_ids WHERE name = 'hostile_fleet_incoming';
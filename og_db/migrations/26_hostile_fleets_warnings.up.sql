@@ -0,0 +1,122 @@
+-- Seed the message warning a player of an incoming hostile fleet.
+INSERT INTO public.messages_ids ("type", "name", "content")
+  VALUES(
+    (SELECT id FROM messages_types WHERE type='fleets'),
+    'hostile_fleet_incoming',
+    'a hostile fleet from $PLANET_NAME $COORD ($PLAYER_NAME) is heading towards your planet $PLANET_NAME $COORD with the objective $OBJECTIVE. It will arrive at $TIME'
+  );
+
+-- Create a function allowing to warn the owner of the
+-- target of a fleet that it is heading towards one of
+-- its planets or moons.
+CREATE OR REPLACE FUNCTION create_hostile_fleet_message(fleet_id uuid) RETURNS VOID AS $$
+DECLARE
+  source_kind text;
+  source_name text;
+  source_coords text;
+  source_player_name text;
+
+  target_kind text;
+  target_name text;
+  target_coords text;
+  target_player_id uuid;
+
+  objective_name text;
+  arrival_date timestamp with time zone;
+BEGIN
+  -- Fetch information for this fleet.
+  SELECT
+    f.source_type,
+    f.target_type,
+    fo.name,
+    f.arrival_time,
+    pl.name
+  INTO
+    source_kind,
+    target_kind,
+    objective_name,
+    arrival_date,
+    source_player_name
+  FROM
+    fleets AS f
+    INNER JOIN fleets_objectives AS fo ON fo.id = f.objective
+    INNER JOIN players AS pl ON pl.id = f.player
+  WHERE
+    f.id = fleet_id;
+
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid data for fleet % in hostile warning operation', fleet_id;
+  END IF;
+
+  -- Retrieve the source of the fleet.
+  IF source_kind = 'planet' THEN
+    SELECT
+      p.name,
+      concat_ws(':', p.galaxy, p.solar_system, p.position)
+    INTO
+      source_name,
+      source_coords
+    FROM
+      fleets AS f
+      INNER JOIN planets AS p ON p.id = f.source
+    WHERE
+      f.id = fleet_id;
+  END IF;
+
+  IF source_kind = 'moon' THEN
+    SELECT
+      m.name,
+      concat_ws(':', p.galaxy, p.solar_system, p.position)
+    INTO
+      source_name,
+      source_coords
+    FROM
+      fleets AS f
+      INNER JOIN moons AS m ON m.id = f.source
+      INNER JOIN planets AS p ON m.planet = p.id
+    WHERE
+      f.id = fleet_id;
+  END IF;
+
+  -- Retrieve the target of the fleet: only planets and
+  -- moons have an owner that can be warned.
+  IF target_kind = 'planet' THEN
+    SELECT
+      p.name,
+      concat_ws(':', p.galaxy, p.solar_system, p.position),
+      p.player
+    INTO
+      target_name,
+      target_coords,
+      target_player_id
+    FROM
+      fleets AS f
+      INNER JOIN planets AS p ON p.id = f.target
+    WHERE
+      f.id = fleet_id;
+  END IF;
+
+  IF target_kind = 'moon' THEN
+    SELECT
+      m.name,
+      concat_ws(':', p.galaxy, p.solar_system, p.position),
+      p.player
+    INTO
+      target_name,
+      target_coords,
+      target_player_id
+    FROM
+      fleets AS f
+      INNER JOIN moons AS m ON m.id = f.target
+      INNER JOIN planets AS p ON m.planet = p.id
+    WHERE
+      f.id = fleet_id;
+  END IF;
+
+  IF target_player_id IS NULL THEN
+    RETURN;
+  END IF;
+
+  PERFORM create_message_for(target_player_id, 'hostile_fleet_incoming', NOW(), source_name, source_coords, source_player_name, target_name, target_coords, objective_name, to_char(arrival_date, 'YYYY-MM-DD HH24:MI:SS'));
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,20 @@
+-- Drop the trigger on scheduled fleets.
+DROP TRIGGER delete_fleets_scheduled_action ON fleets_scheduled;
+DROP FUNCTION delete_scheduled_fleet_action();
+
+-- Drop the scheduled fleets' functions.
+DROP FUNCTION launch_scheduled_fleet(fleet_id uuid, fleet json, consumption json, moment timestamp with time zone);
+DROP FUNCTION cancel_scheduled_fleet(fleet_id uuid, notify boolean);
+DROP FUNCTION create_scheduled_fleet(fleet json, ships json, resources json, consumption json);
+DROP FUNCTION update_fleet_source(source_id uuid, source_kind text, ships json, resources json, consumption json, factor integer);
+
+-- Remove the messages related to scheduled fleets.
+DELETE FROM messages_arguments WHERE message IN (
+  SELECT mp.id FROM messages_players AS mp INNER JOIN messages_ids AS mi ON mp.message = mi.id WHERE mi.name = 'scheduled_fleet_cancelled'
+);
+DELETE FROM messages_players WHERE message IN (SELECT id FROM messages_ids WHERE name = 'scheduled_fleet_cancelled');
+DELETE FROM messages_ids WHERE name = 'scheduled_fleet_cancelled';
+
+-- Drop the scheduled fleets table.
+DELETE FROM actions_queue WHERE type = 'scheduled_fleet';
+DROP TABLE fleets_scheduled;
@@ -0,0 +1,248 @@
+-- Create the table referencing the fleets waiting for
+-- their departure. The description of the fleet is kept
+-- as provided by the player so that it can be launched
+-- later on: the ships, cargo and fuel are reserved on
+-- the source in the meantime.
+CREATE TABLE fleets_scheduled (
+  id uuid NOT NULL,
+  universe uuid NOT NULL,
+  player uuid NOT NULL,
+  objective uuid NOT NULL,
+  source uuid NOT NULL,
+  source_type text NOT NULL,
+  acs uuid,
+  departure_time TIMESTAMP WITH TIME ZONE NOT NULL,
+  fleet json NOT NULL,
+  consumption json NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  FOREIGN KEY (universe) REFERENCES universes(id),
+  FOREIGN KEY (player) REFERENCES players(id) ON DELETE CASCADE,
+  FOREIGN KEY (objective) REFERENCES fleets_objectives(id)
+);
+
+-- Seed the message indicating that a scheduled fleet
+-- could not be launched.
+INSERT INTO public.messages_ids ("type", "name", "content")
+  VALUES(
+    (SELECT id FROM messages_types WHERE type='fleets'),
+    'scheduled_fleet_cancelled',
+    'your fleet scheduled to leave $PLANET_NAME $COORD at $TIME could not be launched. The ships and resources have been restored'
+  );
+
+-- Update the ships and resources of the source of a
+-- fleet. The `factor` allows to either reserve the
+-- elements (with a value of `-1`) or to restore them
+-- (with a value of `1`).
+CREATE OR REPLACE FUNCTION update_fleet_source(source_id uuid, source_kind text, ships json, resources json, consumption json, factor integer) RETURNS VOID AS $$
+BEGIN
+  IF source_kind = 'planet' THEN
+    WITH cr AS (
+      SELECT
+        t.resource,
+        sum(t.amount) AS quantity
+      FROM
+        (
+          SELECT * FROM json_to_recordset(consumption) AS c(resource uuid, amount numeric(15, 5))
+          UNION ALL
+          SELECT * FROM json_to_recordset(resources) AS r(resource uuid, amount numeric(15, 5))
+        ) AS t
+      GROUP BY
+        t.resource
+      )
+    UPDATE planets_resources
+      SET amount = amount + factor * cr.quantity
+    FROM
+      cr
+    WHERE
+      planet = source_id
+      AND res = cr.resource;
+
+    WITH cs AS (
+      SELECT
+        t.ship AS vessel,
+        t.count AS quantity
+      FROM
+        json_to_recordset(ships) AS t(ship uuid, count integer)
+      )
+    UPDATE planets_ships
+      SET count = count + factor * cs.quantity
+    FROM
+      cs
+    WHERE
+      planet = source_id
+      AND ship = cs.vessel;
+  END IF;
+
+  IF source_kind = 'moon' THEN
+    WITH cr AS (
+      SELECT
+        t.resource,
+        sum(t.amount) AS quantity
+      FROM
+        (
+          SELECT * FROM json_to_recordset(consumption) AS c(resource uuid, amount numeric(15, 5))
+          UNION ALL
+          SELECT * FROM json_to_recordset(resources) AS r(resource uuid, amount numeric(15, 5))
+        ) AS t
+      GROUP BY
+        t.resource
+      )
+    UPDATE moons_resources
+      SET amount = amount + factor * cr.quantity
+    FROM
+      cr
+    WHERE
+      moon = source_id
+      AND res = cr.resource;
+
+    WITH cs AS (
+      SELECT
+        t.ship AS vessel,
+        t.count AS quantity
+      FROM
+        json_to_recordset(ships) AS t(ship uuid, count integer)
+      )
+    UPDATE moons_ships
+      SET count = count + factor * cs.quantity
+    FROM
+      cs
+    WHERE
+      moon = source_id
+      AND ship = cs.vessel;
+  END IF;
+END
+$$ LANGUAGE plpgsql;
+
+-- Register a fleet to be launched at a later time and
+-- reserve the ships, cargo and fuel on its source.
+CREATE OR REPLACE FUNCTION create_scheduled_fleet(fleet json, ships json, resources json, consumption json) RETURNS VOID AS $$
+BEGIN
+  IF fleet->>'source_type' != 'planet' AND fleet->>'source_type' != 'moon' THEN
+    RAISE EXCEPTION 'Invalid kind % specified for source of scheduled fleet', fleet->>'source_type';
+  END IF;
+
+  INSERT INTO fleets_scheduled("id", "universe", "player", "objective", "source", "source_type", "acs", "departure_time", "fleet", "consumption")
+    VALUES(
+      (fleet->>'id')::uuid,
+      (fleet->>'universe')::uuid,
+      (fleet->>'player')::uuid,
+      (fleet->>'objective')::uuid,
+      (fleet->>'source')::uuid,
+      fleet->>'source_type',
+      NULLIF(fleet->>'acs', '')::uuid,
+      (fleet->>'departure_time')::timestamp with time zone,
+      fleet,
+      consumption
+    );
+
+  PERFORM update_fleet_source((fleet->>'source')::uuid, fleet->>'source_type', ships, resources, consumption, -1);
+
+  -- Register the departure of the fleet in the actions
+  -- system.
+  INSERT INTO actions_queue("action", "completion_time", "type")
+    VALUES(
+      (fleet->>'id')::uuid,
+      (fleet->>'departure_time')::timestamp with time zone,
+      'scheduled_fleet'
+    );
+END
+$$ LANGUAGE plpgsql;
+
+-- Cancel a scheduled fleet and restore the ships and
+-- resources reserved on its source. The owner of the
+-- fleet can be notified if needed.
+CREATE OR REPLACE FUNCTION cancel_scheduled_fleet(fleet_id uuid, notify boolean) RETURNS VOID AS $$
+DECLARE
+  scheduled record;
+  source_name text;
+  source_coords text;
+BEGIN
+  SELECT * INTO scheduled FROM fleets_scheduled WHERE id = fleet_id;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid scheduled fleet % in cancellation operation', fleet_id;
+  END IF;
+
+  PERFORM update_fleet_source(scheduled.source, scheduled.source_type, scheduled.fleet->'ships', COALESCE(scheduled.fleet->'cargo', '[]'::json), scheduled.consumption, 1);
+
+  DELETE FROM fleets_scheduled WHERE id = fleet_id;
+
+  IF NOT notify THEN
+    RETURN;
+  END IF;
+
+  IF scheduled.source_type = 'planet' THEN
+    SELECT
+      p.name,
+      concat_ws(':', p.galaxy, p.solar_system, p.position)
+    INTO
+      source_name,
+      source_coords
+    FROM
+      planets AS p
+    WHERE
+      p.id = scheduled.source;
+  END IF;
+
+  IF scheduled.source_type = 'moon' THEN
+    SELECT
+      m.name,
+      concat_ws(':', p.galaxy, p.solar_system, p.position)
+    INTO
+      source_name,
+      source_coords
+    FROM
+      moons AS m
+      INNER JOIN planets AS p ON m.planet = p.id
+    WHERE
+      m.id = scheduled.source;
+  END IF;
+
+  PERFORM create_message_for(scheduled.player, 'scheduled_fleet_cancelled', NOW(), source_name, source_coords, to_char(scheduled.departure_time, 'YYYY-MM-DD HH24:MI:SS'));
+END
+$$ LANGUAGE plpgsql;
+
+-- Launch a scheduled fleet: the reserved elements are
+-- given back to the source and the fleet is created as
+-- a regular fleet (or ACS component) with the updated
+-- flight data.
+CREATE OR REPLACE FUNCTION launch_scheduled_fleet(fleet_id uuid, fleet json, consumption json, moment timestamp with time zone) RETURNS VOID AS $$
+DECLARE
+  scheduled record;
+  ships json;
+  resources json;
+BEGIN
+  SELECT * INTO scheduled FROM fleets_scheduled WHERE id = fleet_id;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid scheduled fleet % in launch operation', fleet_id;
+  END IF;
+
+  ships := scheduled.fleet->'ships';
+  resources := COALESCE(scheduled.fleet->'cargo', '[]'::json);
+
+  PERFORM update_fleet_source(scheduled.source, scheduled.source_type, ships, resources, scheduled.consumption, 1);
+
+  DELETE FROM fleets_scheduled WHERE id = fleet_id;
+
+  IF scheduled.acs IS NULL THEN
+    PERFORM create_fleet(fleet, ships, resources, consumption);
+  ELSE
+    PERFORM create_acs_fleet(scheduled.acs, fleet, ships, resources, consumption);
+  END IF;
+
+  -- The fleet left at the requested time and not when
+  -- it was processed.
+  UPDATE fleets SET created_at = moment WHERE id = fleet_id;
+END
+$$ LANGUAGE plpgsql;
+
+-- Remove the departure of a scheduled fleet from the
+-- actions queue whenever it is deleted.
+CREATE OR REPLACE FUNCTION delete_scheduled_fleet_action() RETURNS TRIGGER AS $$
+BEGIN
+  DELETE FROM actions_queue WHERE action = OLD.id AND type = 'scheduled_fleet';
+  RETURN OLD;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER delete_fleets_scheduled_action AFTER DELETE ON fleets_scheduled FOR EACH ROW EXECUTE PROCEDURE delete_scheduled_fleet_action();
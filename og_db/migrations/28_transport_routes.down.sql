@@ -0,0 +1,17 @@
+-- Drop the transport routes' functions.
+DROP FUNCTION create_transport_route_message(route_id uuid, reason text);
+DROP FUNCTION delete_transport_route(route_id uuid);
+DROP FUNCTION update_transport_route(route_id uuid, moment timestamp with time zone);
+DROP FUNCTION create_transport_route(route json, ships json, resources json);
+
+-- Remove the messages related to transport routes.
+DELETE FROM messages_arguments WHERE message IN (
+  SELECT mp.id FROM messages_players AS mp INNER JOIN messages_ids AS mi ON mp.message = mi.id WHERE mi.name = 'transport_route_skipped'
+);
+DELETE FROM messages_players WHERE message IN (SELECT id FROM messages_ids WHERE name = 'transport_route_skipped');
+DELETE FROM messages_ids WHERE name = 'transport_route_skipped';
+
+-- Drop the transport routes tables.
+DROP TABLE transport_routes_resources;
+DROP TABLE transport_routes_ships;
+DROP TABLE transport_routes;
@@ -0,0 +1,148 @@
+-- Create the table referencing the transport routes
+-- defined by players to regularly move resources from
+-- one of their planets to another.
+CREATE TABLE transport_routes (
+  id uuid NOT NULL DEFAULT uuid_generate_v4(),
+  universe uuid NOT NULL,
+  player uuid NOT NULL,
+  source uuid NOT NULL,
+  source_type text NOT NULL,
+  target uuid NOT NULL,
+  target_type text NOT NULL,
+  speed numeric(3, 2) NOT NULL,
+  period integer NOT NULL,
+  rule text NOT NULL,
+  threshold numeric(3, 2) NOT NULL DEFAULT 0,
+  next_run TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  FOREIGN KEY (universe) REFERENCES universes(id),
+  FOREIGN KEY (player) REFERENCES players(id) ON DELETE CASCADE
+);
+
+CREATE INDEX transport_routes_next_run_index ON transport_routes (next_run);
+
+-- Create the table referencing the ships used by each
+-- transport route.
+CREATE TABLE transport_routes_ships (
+  route uuid NOT NULL,
+  ship uuid NOT NULL,
+  count integer NOT NULL,
+  FOREIGN KEY (route) REFERENCES transport_routes(id) ON DELETE CASCADE,
+  FOREIGN KEY (ship) REFERENCES ships(id),
+  UNIQUE (route, ship)
+);
+
+-- Create the table referencing the fixed amounts of
+-- resources moved by a transport route.
+CREATE TABLE transport_routes_resources (
+  route uuid NOT NULL,
+  resource uuid NOT NULL,
+  amount numeric(15, 5) NOT NULL,
+  FOREIGN KEY (route) REFERENCES transport_routes(id) ON DELETE CASCADE,
+  FOREIGN KEY (resource) REFERENCES resources(id),
+  UNIQUE (route, resource)
+);
+
+-- Seed the message indicating that a transport route
+-- could not send a fleet.
+INSERT INTO public.messages_ids ("type", "name", "content")
+  VALUES(
+    (SELECT id FROM messages_types WHERE type='fleets'),
+    'transport_route_skipped',
+    'the transport route from $PLANET_NAME $COORD to $PLANET_NAME $COORD could not send a fleet: $REASON'
+  );
+
+-- Import a transport route into the corresponding tables.
+CREATE OR REPLACE FUNCTION create_transport_route(route json, ships json, resources json) RETURNS VOID AS $$
+BEGIN
+  IF route->>'source_type' != 'planet' AND route->>'source_type' != 'moon' THEN
+    RAISE EXCEPTION 'Invalid kind % specified for source of transport route', route->>'source_type';
+  END IF;
+
+  IF route->>'target_type' != 'planet' AND route->>'target_type' != 'moon' THEN
+    RAISE EXCEPTION 'Invalid kind % specified for target of transport route', route->>'target_type';
+  END IF;
+
+  INSERT INTO transport_routes("id", "universe", "player", "source", "source_type", "target", "target_type", "speed", "period", "rule", "threshold", "next_run")
+    VALUES(
+      (route->>'id')::uuid,
+      (route->>'universe')::uuid,
+      (route->>'player')::uuid,
+      (route->>'source')::uuid,
+      route->>'source_type',
+      (route->>'target')::uuid,
+      route->>'target_type',
+      (route->>'speed')::numeric,
+      (route->>'period')::integer,
+      route->>'rule',
+      (route->>'threshold')::numeric,
+      (route->>'next_run')::timestamp with time zone
+    );
+
+  INSERT INTO transport_routes_ships
+    SELECT
+      (route->>'id')::uuid AS route,
+      t.ship AS ship,
+      t.count AS count
+    FROM
+      json_to_recordset(ships) AS t(ship uuid, count integer);
+
+  INSERT INTO transport_routes_resources
+    SELECT
+      (route->>'id')::uuid AS route,
+      t.resource AS resource,
+      t.amount AS amount
+    FROM
+      json_to_recordset(resources) AS t(resource uuid, amount numeric(15, 5));
+END
+$$ LANGUAGE plpgsql;
+
+-- Update the next time a transport route should run.
+CREATE OR REPLACE FUNCTION update_transport_route(route_id uuid, moment timestamp with time zone) RETURNS VOID AS $$
+BEGIN
+  UPDATE transport_routes SET next_run = moment WHERE id = route_id;
+END
+$$ LANGUAGE plpgsql;
+
+-- Delete a transport route.
+CREATE OR REPLACE FUNCTION delete_transport_route(route_id uuid) RETURNS VOID AS $$
+BEGIN
+  DELETE FROM transport_routes WHERE id = route_id;
+END
+$$ LANGUAGE plpgsql;
+
+-- Notify the owner of a transport route that it could
+-- not send a fleet for the specified reason.
+CREATE OR REPLACE FUNCTION create_transport_route_message(route_id uuid, reason text) RETURNS VOID AS $$
+DECLARE
+  route record;
+  source_name text;
+  source_coords text;
+  target_name text;
+  target_coords text;
+BEGIN
+  SELECT * INTO route FROM transport_routes WHERE id = route_id;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid transport route % in message operation', route_id;
+  END IF;
+
+  IF route.source_type = 'planet' THEN
+    SELECT p.name, concat_ws(':', p.galaxy, p.solar_system, p.position) INTO source_name, source_coords
+    FROM planets AS p WHERE p.id = route.source;
+  ELSE
+    SELECT m.name, concat_ws(':', p.galaxy, p.solar_system, p.position) INTO source_name, source_coords
+    FROM moons AS m INNER JOIN planets AS p ON m.planet = p.id WHERE m.id = route.source;
+  END IF;
+
+  IF route.target_type = 'planet' THEN
+    SELECT p.name, concat_ws(':', p.galaxy, p.solar_system, p.position) INTO target_name, target_coords
+    FROM planets AS p WHERE p.id = route.target;
+  ELSE
+    SELECT m.name, concat_ws(':', p.galaxy, p.solar_system, p.position) INTO target_name, target_coords
+    FROM moons AS m INNER JOIN planets AS p ON m.planet = p.id WHERE m.id = route.target;
+  END IF;
+
+  PERFORM create_message_for(route.player, 'transport_route_skipped', NOW(), source_name, source_coords, target_name, target_coords, reason);
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,13 @@
+-- Drop the functions related to holding fleets.
+DROP FUNCTION fleet_acs_defend_release(fleet_id uuid, moment timestamp with time zone);
+DROP FUNCTION fleet_acs_defend_supply(fleet_id uuid, duration integer, resources json);
+
+-- Remove the messages related to holding fleets.
+DELETE FROM messages_arguments WHERE message IN (
+  SELECT mp.id FROM messages_players AS mp INNER JOIN messages_ids AS mi ON mp.message = mi.id WHERE mi.name = 'acs_defend_supplied_owner'
+);
+DELETE FROM messages_players WHERE message IN (SELECT id FROM messages_ids WHERE name = 'acs_defend_supplied_owner');
+DELETE FROM messages_ids WHERE name = 'acs_defend_supplied_owner';
+
+-- Remove the hold times from the universes.
+ALTER TABLE universes DROP COLUMN acs_defend_hold_times;
@@ -0,0 +1,150 @@
+-- Add the hold times allowed for ACS defend operations
+-- to the universes. They are expressed in hours.
+ALTER TABLE universes ADD COLUMN acs_defend_hold_times integer[] NOT NULL DEFAULT '{0, 1, 2, 4, 8, 16, 32}';
+
+-- Seed the message indicating that the fuel of a fleet
+-- holding at a planet was supplied by the defender.
+INSERT INTO public.messages_ids ("type", "name", "content")
+  VALUES(
+    (SELECT id FROM messages_types WHERE type='fleets'),
+    'acs_defend_supplied_owner',
+    'your fleet holding at $PLANET_NAME $COORD ($PLAYER_NAME) received $RESOURCES from the alliance depot and stays $DURATION more hour(s)'
+  );
+
+-- Supply a fleet holding at the target with the fuel
+-- provided by the alliance depot of the target: this
+-- extends the duration of the deployment.
+CREATE OR REPLACE FUNCTION fleet_acs_defend_supply(fleet_id uuid, duration integer, resources json) RETURNS VOID AS $$
+DECLARE
+  fleet record;
+
+  target_planet_name text;
+  target_coords text;
+  target_player_name text;
+
+  resources_txt text;
+BEGIN
+  SELECT * INTO fleet FROM fleets WHERE id = fleet_id;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid fleet % in acs defend supply operation', fleet_id;
+  END IF;
+
+  IF fleet.is_deployed = 'false' OR fleet.is_returning = 'true' THEN
+    RAISE EXCEPTION 'Fleet % is not holding in acs defend supply operation', fleet_id;
+  END IF;
+
+  -- Remove the fuel from the target.
+  IF fleet.target_type = 'planet' THEN
+    UPDATE planets_resources AS pr
+      SET amount = pr.amount - t.amount
+    FROM
+      json_to_recordset(resources) AS t(resource uuid, amount numeric(15, 5))
+    WHERE
+      pr.planet = fleet.target
+      AND pr.res = t.resource;
+
+    SELECT
+      p.name,
+      concat_ws(':', p.galaxy, p.solar_system, p.position),
+      pl.name
+    INTO
+      target_planet_name,
+      target_coords,
+      target_player_name
+    FROM
+      planets AS p
+      INNER JOIN players AS pl ON pl.id = p.player
+    WHERE
+      p.id = fleet.target;
+  END IF;
+
+  IF fleet.target_type = 'moon' THEN
+    UPDATE moons_resources AS mr
+      SET amount = mr.amount - t.amount
+    FROM
+      json_to_recordset(resources) AS t(resource uuid, amount numeric(15, 5))
+    WHERE
+      mr.moon = fleet.target
+      AND mr.res = t.resource;
+
+    SELECT
+      m.name,
+      concat_ws(':', p.galaxy, p.solar_system, p.position),
+      pl.name
+    INTO
+      target_planet_name,
+      target_coords,
+      target_player_name
+    FROM
+      moons AS m
+      INNER JOIN planets AS p ON p.id = m.planet
+      INNER JOIN players AS pl ON pl.id = p.player
+    WHERE
+      m.id = fleet.target;
+  END IF;
+
+  -- Extend the deployment of the fleet.
+  UPDATE fleets
+    SET
+      deployment_time = deployment_time + duration,
+      return_time = return_time + make_interval(secs := CAST(duration AS DOUBLE PRECISION))
+  WHERE
+    id = fleet_id;
+
+  UPDATE actions_queue
+    SET completion_time = completion_time + make_interval(secs := CAST(duration AS DOUBLE PRECISION))
+  WHERE
+    action = fleet_id;
+
+  -- Notify the owner of the fleet.
+  SELECT
+    string_agg(concat_ws(' unit(s) of ', t.amount::integer, r.name), ', ')
+  INTO
+    resources_txt
+  FROM
+    json_to_recordset(resources) AS t(resource uuid, amount numeric(15, 5))
+    INNER JOIN resources AS r ON r.id = t.resource;
+
+  PERFORM create_message_for(fleet.player, 'acs_defend_supplied_owner', NOW(), target_planet_name, target_coords, target_player_name, resources_txt, (duration / 3600)::text);
+END
+$$ LANGUAGE plpgsql;
+
+-- Send a fleet holding at the target back to its
+-- source before the end of its deployment. The end
+-- of the deployment is set to the input moment and
+-- the rest of the process is handled as usual when
+-- the fleet is processed.
+CREATE OR REPLACE FUNCTION fleet_acs_defend_release(fleet_id uuid, moment timestamp with time zone) RETURNS VOID AS $$
+DECLARE
+  fleet record;
+  remaining integer;
+BEGIN
+  SELECT * INTO fleet FROM fleets WHERE id = fleet_id;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid fleet % in acs defend release operation', fleet_id;
+  END IF;
+
+  IF fleet.is_deployed = 'false' OR fleet.is_returning = 'true' THEN
+    RAISE EXCEPTION 'Fleet % is not holding in acs defend release operation', fleet_id;
+  END IF;
+
+  -- Keep at least one second of deployment so that the
+  -- fleet is still considered as deployed.
+  remaining := fleet.deployment_time - GREATEST(1, CAST(EXTRACT(EPOCH FROM moment - fleet.arrival_time) AS integer));
+  IF remaining <= 0 THEN
+    RETURN;
+  END IF;
+
+  UPDATE fleets
+    SET
+      deployment_time = deployment_time - remaining,
+      return_time = return_time - make_interval(secs := CAST(remaining AS DOUBLE PRECISION))
+  WHERE
+    id = fleet_id;
+
+  UPDATE actions_queue
+    SET completion_time = completion_time - make_interval(secs := CAST(remaining AS DOUBLE PRECISION))
+  WHERE
+    action = fleet_id;
+END
+$$ LANGUAGE plpgsql;
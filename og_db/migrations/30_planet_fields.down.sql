@@ -0,0 +1,335 @@
+-- Restore the previous version of the building actions
+-- functions.
+-- Import building upgrade action in the dedicated table.
+CREATE OR REPLACE FUNCTION create_building_upgrade_action(upgrade json, costs json, production_effects json, storage_effects json, fields_effects json, kind text) RETURNS VOID AS $$
+DECLARE
+  processing_time TIMESTAMP WITH TIME ZONE := NOW();
+BEGIN
+  -- The `kind` can reference either a planet or a moon.
+  -- We have to make sure that it's a valid value before
+  -- attempting to use it.
+  IF kind != 'planet' AND kind != 'moon' THEN
+    RAISE EXCEPTION 'Invalid kind % specified for building action', kind;
+  END IF;
+
+  IF kind = 'planet' THEN
+    -- Create the building upgrade action itself.
+    INSERT INTO construction_actions_buildings
+      SELECT *
+      FROM json_populate_record(null::construction_actions_buildings, upgrade);
+
+    -- Update the construction action effects (both in terms of
+    -- storage and production).
+    INSERT INTO construction_actions_buildings_production_effects
+      SELECT
+        (upgrade->>'id')::uuid,
+        resource,
+        production_change
+      FROM
+        json_populate_recordset(null::construction_actions_buildings_production_effects, production_effects);
+
+    INSERT INTO construction_actions_buildings_storage_effects
+      SELECT
+        (upgrade->>'id')::uuid,
+        resource,
+        storage_capacity_change
+      FROM
+        json_populate_recordset(null::construction_actions_buildings_storage_effects, storage_effects);
+
+    INSERT INTO construction_actions_buildings_fields_effects
+      SELECT
+        (upgrade->>'id')::uuid,
+        additional_fields
+      FROM
+        json_populate_record(null::construction_actions_buildings_fields_effects, fields_effects)
+      WHERE
+        additional_fields > 0;
+
+    -- Decrease the amount of resources existing on the planet
+    -- after the construction of this building. Note that we
+    -- do not update the resources to the current time which
+    -- might lead to negative values if it hasn't been done
+    -- in a long time. We assume the update will be enforced
+    -- by other processes.
+    WITH rc AS (
+      SELECT
+        t.resource,
+        t.cost
+      FROM
+        json_to_recordset(costs) AS t(resource uuid, cost numeric(15, 5))
+      )
+    UPDATE planets_resources AS pr
+      SET amount = pr.amount - rc.cost
+    FROM
+      rc
+      INNER JOIN resources AS r ON rc.resource = r.id
+    WHERE
+      pr.planet = (upgrade->>'planet')::uuid
+      AND pr.res = rc.resource
+      AND r.storable = 'true';
+
+    -- Update the last activity time for this planet.
+    UPDATE planets SET last_activity = processing_time WHERE id = (upgrade->>'planet')::uuid;
+
+    -- Register this action in the actions system.
+    INSERT INTO actions_queue
+      SELECT
+        cab.id AS action,
+        cab.completion_time AS completion_time,
+        'building_upgrade' AS type
+      FROM
+        construction_actions_buildings cab
+      WHERE
+        cab.id = (upgrade->>'id')::uuid;
+  END IF;
+
+  IF kind = 'moon' THEN
+    -- Create the building upgrade action itself.
+    INSERT INTO construction_actions_buildings_moon
+      SELECT *
+      FROM json_populate_record(null::construction_actions_buildings_moon, upgrade);
+
+    INSERT INTO construction_actions_buildings_fields_effects_moon
+      SELECT
+        (upgrade->>'id')::uuid,
+        additional_fields
+      FROM
+        json_populate_record(null::construction_actions_buildings_fields_effects_moon, fields_effects)
+      WHERE
+        additional_fields > 0;
+
+    -- No production or storage effects available
+    -- on moons as most of the buildings are not
+    -- allowed to be built. We still need to take
+    -- out the resources needed by the action.
+    WITH rc AS (
+      SELECT
+        t.resource,
+        t.cost
+      FROM
+        json_to_recordset(costs) AS t(resource uuid, cost numeric(15, 5))
+      )
+    UPDATE moons_resources AS mr
+      SET amount = mr.amount - rc.cost
+    FROM
+      rc
+      INNER JOIN resources AS r ON rc.resource = r.id
+    WHERE
+      mr.moon = (upgrade->>'planet')::uuid
+      AND mr.res = rc.resource
+      AND r.storable = 'true';
+
+    -- Update the last activity time for this moon.
+    UPDATE moons SET last_activity = processing_time WHERE id = (upgrade->>'planet')::uuid;
+
+    -- Register this action in the actions system.
+    INSERT INTO actions_queue
+      SELECT
+        cabm.id AS action,
+        cabm.completion_time AS completion_time,
+        'building_upgrade_moon' AS type
+      FROM
+        construction_actions_buildings_moon cabm
+      WHERE
+        cabm.id = (upgrade->>'id')::uuid;
+  END IF;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_building_upgrade_action(action_id uuid, kind text) RETURNS VOID AS $$
+DECLARE
+  moment timestamp with time zone;
+  planet_id uuid;
+BEGIN
+  -- We can have building upgrades both for planets and moons.
+  -- These actions are stored in different tables and we don't
+  -- have a way to determine which beforehand. The `kind` helps
+  -- define this: we just need to make sure it is correct.
+  IF kind != 'planet' AND kind != 'moon' THEN
+    RAISE EXCEPTION 'Invalid kind % specified for %', kind, action_id;
+  END IF;
+
+  IF kind = 'planet' THEN
+    -- 1. Update the level of the building described by the
+    -- action on the corresponding planet.
+    UPDATE planets_buildings AS pb
+      SET level = cab.desired_level
+    FROM
+      construction_actions_buildings AS cab
+    WHERE
+      cab.id = action_id
+      AND pb.planet = cab.planet
+      AND pb.building = cab.element
+      AND pb.level = cab.current_level;
+
+    -- 2. Update the resources on this planet based on the
+    -- type of building that has been completed. Before it
+    -- can happen we need to bring the production of the
+    -- planet to its value at the time of the update of
+    -- the building.
+    -- 2.a) Update resources to reach the current time.
+    SELECT completion_time INTO moment FROM construction_actions_buildings WHERE id = action_id;
+    IF NOT FOUND THEN
+      RAISE EXCEPTION 'Unable to fetch completion time for action %', action_id;
+    END IF;
+
+    SELECT planet INTO planet_id FROM construction_actions_buildings WHERE id = action_id;
+    IF NOT FOUND THEN
+      RAISE EXCEPTION 'Unable to fetch planet id for action %', action_id;
+    END IF;
+
+    PERFORM update_resources_for_planet_to_time(planet_id, moment);
+
+    -- 2.b) Proceed to update the mines with their new prod
+    -- values.
+    WITH prod_effects AS (
+      SELECT
+        cab.element AS building,
+        cab.planet AS planet,
+        cabpe.production_change AS change,
+        cabpe.resource AS resource
+      FROM
+        construction_actions_buildings AS cab
+        INNER JOIN construction_actions_buildings_production_effects AS cabpe ON cab.id = cabpe.action
+      )
+    UPDATE planets_buildings_production_resources AS pbpr
+      SET production = production + GREATEST(0, pe.change),
+          consumption = consumption + LEAST(0, pe.change)
+    FROM
+      prod_effects AS pe
+    WHERE
+      pe.planet = pbpr.planet
+      AND pe.building = pbpr.building
+      AND pe.resource = pbpr.res;
+
+    -- 2.c) Update the storage facilities with their new
+    -- values.
+    UPDATE planets_resources AS pr
+      SET storage_capacity = storage_capacity + cabse.storage_capacity_change
+    FROM
+      construction_actions_buildings_storage_effects AS cabse
+      INNER JOIN construction_actions_buildings AS cab ON cabse.action = cab.id
+    WHERE
+      cabse.action = action_id
+      AND pr.planet = cab.planet
+      AND pr.res = cabse.resource;
+
+    -- 2.d) Update the fields available on the planet based
+    -- on the additional fields produced by the building.
+    UPDATE planets AS p
+      SET fields = fields + cabfe.additional_fields
+    FROM
+      construction_actions_buildings_fields_effects AS cabfe
+      INNER JOIN construction_actions_buildings AS cab ON cabfe.action = cab.id
+    WHERE
+      cabfe.action = action_id
+      AND p.id = cab.planet;
+
+    -- 2.e) Update the last activity time for this planet.
+    UPDATE planets SET last_activity = moment WHERE id = planet_id;
+
+    -- 2.f) Add the cost of the action to the points of the
+    -- player in the economy section. The cost is directly
+    -- registered in the action.
+    UPDATE players_points
+      SET economy_points = economy_points + points
+    FROM
+      construction_actions_buildings AS cab
+      INNER JOIN planets AS p ON cab.planet = p.id
+    WHERE
+      cab.id = action_id
+      AND p.player = players_points.player;
+
+      -- 2.g) Add the cost of this building to the total
+      -- cost for this building on this planet.
+    UPDATE planets_buildings
+      SET points = planets_buildings.points + cab.points
+    FROM
+      construction_actions_buildings AS cab
+    WHERE
+      cab.id = action_id
+      AND planets_buildings.planet = cab.planet
+      AND planets_buildings.building = cab.element;
+
+    -- 3. Destroy the processed action effects.
+    DELETE FROM construction_actions_buildings_production_effects WHERE action = action_id;
+
+    DELETE FROM construction_actions_buildings_storage_effects WHERE action = action_id;
+
+    DELETE FROM construction_actions_buildings_fields_effects WHERE action = action_id;
+
+    -- 4. Remove the processed action from the events queue.
+    DELETE FROM actions_queue WHERE action = action_id;
+
+    -- 5. And finally delete the processed action.
+    DELETE FROM construction_actions_buildings WHERE id = action_id;
+  END IF;
+
+  IF kind = 'moon' THEN
+    -- Fetch the identifier of the moon.
+    SELECT moon INTO planet_id FROM construction_actions_buildings_moon WHERE id = action_id;
+    IF NOT FOUND THEN
+      RAISE EXCEPTION 'Unable to fetch moon id for action %', action_id;
+    END IF;
+
+    -- 1. See comment in above section.
+    UPDATE moons_buildings AS mb
+      SET level = cabm.desired_level
+    FROM
+      construction_actions_buildings_moon AS cabm
+    WHERE
+      cabm.id = action_id
+      AND mb.moon = cabm.moon
+      AND mb.building = cabm.element
+      AND mb.level = cabm.current_level;
+
+    -- 2. No need to update the resources, there's no prod
+    -- that can happen on a moon (at least for now). There
+    -- is a need to update the fields for moons though.
+    UPDATE moons AS m
+      SET fields = fields + cabfem.additional_fields
+    FROM
+      construction_actions_buildings_fields_effects_moon AS cabfem
+      INNER JOIN construction_actions_buildings_moon AS cabm ON cabfe.action = cab.id
+    WHERE
+      cabfem.action = action_id
+      AND m.id = cabm.moon;
+
+    -- 2.e) Update the last activity time for this moon.
+    UPDATE moons SET last_activity = moment WHERE id = planet_id;
+
+    -- 2.f) Add the cost of the action to the points of the
+    -- player in the economy section.
+    UPDATE players_points
+      SET economy_points = economy_points + points
+    FROM
+      construction_actions_buildings_moon AS cabm
+      INNER JOIN moons AS m ON cabm.moon = m.id
+      INNER JOIN planets AS p ON m.planet = p.id
+    WHERE
+      cabm.id = action_id
+      AND p.player = players_points.player;
+
+      -- 2.g) Add the cost of this building to the total
+      -- cost for this building on this moon.
+    UPDATE moons_buildings
+      SET points = moons_buildings.points + cabm.points
+    FROM
+      construction_actions_buildings_moon AS cabm
+    WHERE
+      cabm.id = action_id
+      AND moons_buildings.moon = cabm.moon
+      AND moons_buildings.building = cabm.element;
+
+    -- 3. Only fields effects can be applied in the case of
+    -- moon buildings.
+    DELETE FROM construction_actions_buildings_fields_effects_moon WHERE action = action_id;
+
+    -- 4. See comment in above section.
+    DELETE FROM actions_queue WHERE action = action_id;
+
+    -- 5. See comment in above section.
+    DELETE FROM construction_actions_buildings_moon WHERE id = action_id;
+  END IF;
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,19 @@
+-- Drop the trigger on relocations.
+DROP TRIGGER delete_planets_relocations_action ON planets_relocations;
+DROP FUNCTION delete_planet_relocation_action();
+
+-- Drop the relocations' functions.
+DROP FUNCTION perform_planet_relocation(relocation_id uuid);
+DROP FUNCTION cancel_planet_relocation(relocation_id uuid, reason text);
+DROP FUNCTION create_planet_relocation(relocation json, cost json);
+
+-- Remove the messages related to relocations.
+DELETE FROM messages_arguments WHERE message IN (
+  SELECT mp.id FROM messages_players AS mp INNER JOIN messages_ids AS mi ON mp.message = mi.id WHERE mi.name IN ('planet_relocation_completed', 'planet_relocation_cancelled')
+);
+DELETE FROM messages_players WHERE message IN (SELECT id FROM messages_ids WHERE name IN ('planet_relocation_completed', 'planet_relocation_cancelled'));
+DELETE FROM messages_ids WHERE name IN ('planet_relocation_completed', 'planet_relocation_cancelled');
+
+-- Drop the relocations table.
+DELETE FROM actions_queue WHERE type = 'planet_relocation';
+DROP TABLE planets_relocations;
@@ -0,0 +1,221 @@
+-- Create the table referencing the planets waiting to
+-- be moved to new coordinates. The target slot stays
+-- reserved until the relocation is performed or it is
+-- cancelled.
+CREATE TABLE planets_relocations (
+  id uuid NOT NULL,
+  universe uuid NOT NULL,
+  player uuid NOT NULL,
+  planet uuid NOT NULL,
+  galaxy integer NOT NULL,
+  solar_system integer NOT NULL,
+  position integer NOT NULL,
+  min_temperature integer NOT NULL,
+  max_temperature integer NOT NULL,
+  cost json NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  completion_time TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (id),
+  FOREIGN KEY (universe) REFERENCES universes(id),
+  FOREIGN KEY (player) REFERENCES players(id) ON DELETE CASCADE,
+  FOREIGN KEY (planet) REFERENCES planets(id) ON DELETE CASCADE,
+  UNIQUE (planet),
+  UNIQUE (universe, galaxy, solar_system, position)
+);
+
+-- Seed the messages indicating the outcome of the
+-- relocation of a planet.
+INSERT INTO public.messages_ids ("type", "name", "content")
+  VALUES(
+    (SELECT id FROM messages_types WHERE type='universe'),
+    'planet_relocation_completed',
+    'your planet $PLANET_NAME has been relocated from $COORD to $COORD'
+  );
+
+INSERT INTO public.messages_ids ("type", "name", "content")
+  VALUES(
+    (SELECT id FROM messages_types WHERE type='universe'),
+    'planet_relocation_cancelled',
+    'the relocation of your planet $PLANET_NAME $COORD has been cancelled: $REASON. The resources have been restored'
+  );
+
+-- Reserve the target slot of a relocation, take its
+-- cost from the planet and register the countdown in
+-- the actions system.
+CREATE OR REPLACE FUNCTION create_planet_relocation(relocation json, cost json) RETURNS VOID AS $$
+BEGIN
+  INSERT INTO planets_relocations("id", "universe", "player", "planet", "galaxy", "solar_system", "position", "min_temperature", "max_temperature", "cost", "completion_time")
+    VALUES(
+      (relocation->>'id')::uuid,
+      (relocation->>'universe')::uuid,
+      (relocation->>'player')::uuid,
+      (relocation->>'planet')::uuid,
+      (relocation->'target'->>'galaxy')::integer,
+      (relocation->'target'->>'system')::integer,
+      (relocation->'target'->>'position')::integer,
+      (relocation->>'min_temperature')::integer,
+      (relocation->>'max_temperature')::integer,
+      cost,
+      (relocation->>'completion_time')::timestamp with time zone
+    );
+
+  WITH rc AS (
+    SELECT
+      t.resource,
+      t.amount
+    FROM
+      json_to_recordset(cost) AS t(resource uuid, amount numeric(15, 5))
+    )
+  UPDATE planets_resources
+    SET amount = amount - rc.amount
+  FROM
+    rc
+  WHERE
+    planet = (relocation->>'planet')::uuid
+    AND res = rc.resource;
+
+  INSERT INTO actions_queue("action", "completion_time", "type")
+    VALUES(
+      (relocation->>'id')::uuid,
+      (relocation->>'completion_time')::timestamp with time zone,
+      'planet_relocation'
+    );
+END
+$$ LANGUAGE plpgsql;
+
+-- Cancel a relocation and give its cost back to the
+-- planet. The owner is notified in case a reason is
+-- provided.
+CREATE OR REPLACE FUNCTION cancel_planet_relocation(relocation_id uuid, reason text) RETURNS VOID AS $$
+DECLARE
+  relocation record;
+  planet_name text;
+  planet_coords text;
+BEGIN
+  SELECT * INTO relocation FROM planets_relocations WHERE id = relocation_id;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid relocation % in cancellation operation', relocation_id;
+  END IF;
+
+  WITH rc AS (
+    SELECT
+      t.resource,
+      t.amount
+    FROM
+      json_to_recordset(relocation.cost) AS t(resource uuid, amount numeric(15, 5))
+    )
+  UPDATE planets_resources
+    SET amount = amount + rc.amount
+  FROM
+    rc
+  WHERE
+    planet = relocation.planet
+    AND res = rc.resource;
+
+  DELETE FROM planets_relocations WHERE id = relocation_id;
+
+  IF reason = '' THEN
+    RETURN;
+  END IF;
+
+  SELECT
+    p.name,
+    concat_ws(':', p.galaxy, p.solar_system, p.position)
+  INTO
+    planet_name,
+    planet_coords
+  FROM
+    planets AS p
+  WHERE
+    p.id = relocation.planet;
+
+  PERFORM create_message_for(relocation.player, 'planet_relocation_cancelled', NOW(), planet_name, planet_coords, reason);
+END
+$$ LANGUAGE plpgsql;
+
+-- Move a planet to the target of its relocation. The
+-- moon follows the planet as it is attached to it. The
+-- debris field is moved as well unless a fleet targets
+-- it or another field already exists at the target.
+CREATE OR REPLACE FUNCTION perform_planet_relocation(relocation_id uuid) RETURNS VOID AS $$
+DECLARE
+  relocation record;
+  planet_data record;
+  field_id uuid;
+BEGIN
+  SELECT * INTO relocation FROM planets_relocations WHERE id = relocation_id;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid relocation % in perform operation', relocation_id;
+  END IF;
+
+  SELECT * INTO planet_data FROM planets WHERE id = relocation.planet;
+
+  SELECT
+    df.id
+  INTO
+    field_id
+  FROM
+    debris_fields AS df
+  WHERE
+    df.universe = relocation.universe
+    AND df.galaxy = planet_data.galaxy
+    AND df.solar_system = planet_data.solar_system
+    AND df.position = planet_data.position;
+
+  IF FOUND
+    AND NOT EXISTS (SELECT 1 FROM fleets WHERE target = field_id)
+    AND NOT EXISTS (
+      SELECT
+        1
+      FROM
+        debris_fields
+      WHERE
+        universe = relocation.universe
+        AND galaxy = relocation.galaxy
+        AND solar_system = relocation.solar_system
+        AND position = relocation.position
+    )
+  THEN
+    UPDATE debris_fields
+      SET
+        galaxy = relocation.galaxy,
+        solar_system = relocation.solar_system,
+        position = relocation.position
+    WHERE
+      id = field_id;
+  END IF;
+
+  UPDATE planets
+    SET
+      galaxy = relocation.galaxy,
+      solar_system = relocation.solar_system,
+      position = relocation.position,
+      min_temperature = relocation.min_temperature,
+      max_temperature = relocation.max_temperature,
+      last_activity = relocation.completion_time
+  WHERE
+    id = relocation.planet;
+
+  DELETE FROM planets_relocations WHERE id = relocation_id;
+
+  PERFORM create_message_for(
+    relocation.player,
+    'planet_relocation_completed',
+    relocation.completion_time,
+    planet_data.name,
+    concat_ws(':', planet_data.galaxy, planet_data.solar_system, planet_data.position),
+    concat_ws(':', relocation.galaxy, relocation.solar_system, relocation.position)
+  );
+END
+$$ LANGUAGE plpgsql;
+
+-- Remove the countdown of a relocation from the actions
+-- queue whenever it is deleted.
+CREATE OR REPLACE FUNCTION delete_planet_relocation_action() RETURNS TRIGGER AS $$
+BEGIN
+  DELETE FROM actions_queue WHERE action = OLD.id AND type = 'planet_relocation';
+  RETURN OLD;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER delete_planets_relocations_action AFTER DELETE ON planets_relocations FOR EACH ROW EXECUTE PROCEDURE delete_planet_relocation_action();
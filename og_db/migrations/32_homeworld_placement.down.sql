@@ -0,0 +1,4 @@
+-- Remove the homeworld placement properties from universes.
+ALTER TABLE universes DROP COLUMN homeworld_buffer;
+ALTER TABLE universes DROP COLUMN homeworld_density;
+ALTER TABLE universes DROP COLUMN homeworld_placement;
@@ -0,0 +1,4 @@
+-- Add the homeworld placement properties to the universes.
+ALTER TABLE universes ADD COLUMN homeworld_placement text NOT NULL DEFAULT 'random';
+ALTER TABLE universes ADD COLUMN homeworld_density integer NOT NULL DEFAULT 4;
+ALTER TABLE universes ADD COLUMN homeworld_buffer integer NOT NULL DEFAULT 0;
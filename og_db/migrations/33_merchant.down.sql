@@ -0,0 +1,13 @@
+-- Drop the merchant's function.
+DROP FUNCTION perform_merchant_trade(planet_id uuid, trade json);
+
+-- Remove the messages related to the merchant.
+DELETE FROM messages_arguments WHERE message IN (
+  SELECT mp.id FROM messages_players AS mp INNER JOIN messages_ids AS mi ON mp.message = mi.id WHERE mi.name = 'merchant_trade'
+);
+DELETE FROM messages_players WHERE message IN (SELECT id FROM messages_ids WHERE name = 'merchant_trade');
+DELETE FROM messages_ids WHERE name = 'merchant_trade';
+
+-- Remove the merchant properties from universes.
+ALTER TABLE universes DROP COLUMN merchant_variance;
+ALTER TABLE universes DROP COLUMN merchant_rates;
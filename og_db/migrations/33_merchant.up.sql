@@ -0,0 +1,73 @@
+-- Add the merchant properties to the universes.
+ALTER TABLE universes ADD COLUMN merchant_rates json NOT NULL DEFAULT '{"metal": 3, "crystal": 2, "deuterium": 1}';
+ALTER TABLE universes ADD COLUMN merchant_variance numeric(5, 2) NOT NULL DEFAULT 0;
+
+-- Seed the message indicating that a trade with the
+-- merchant was performed.
+INSERT INTO public.messages_ids ("type", "name", "content")
+  VALUES(
+    (SELECT id FROM messages_types WHERE type='economy'),
+    'merchant_trade',
+    'the merchant on $PLANET_NAME $COORD took $RESOURCES and gave $RESOURCES in exchange'
+  );
+
+-- Perform a trade with the merchant on a planet: the
+-- sold resource is taken from the planet and the one
+-- bought is added to it. The trade is refused if the
+-- planet does not have enough of the sold resource or
+-- not enough storage for the bought one.
+CREATE OR REPLACE FUNCTION perform_merchant_trade(planet_id uuid, trade json) RETURNS VOID AS $$
+DECLARE
+  planet_data record;
+  sold_name text;
+  bought_name text;
+BEGIN
+  UPDATE planets_resources
+    SET amount = amount - (trade->>'amount')::numeric(15, 5)
+  WHERE
+    planet = planet_id
+    AND res = (trade->>'sold')::uuid
+    AND amount >= (trade->>'amount')::numeric(15, 5);
+
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Not enough resources to trade on planet %', planet_id;
+  END IF;
+
+  UPDATE planets_resources
+    SET amount = amount + (trade->>'received')::numeric(15, 5)
+  WHERE
+    planet = planet_id
+    AND res = (trade->>'bought')::uuid
+    AND amount + (trade->>'received')::numeric(15, 5) <= storage_capacity;
+
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Not enough storage to trade on planet %', planet_id;
+  END IF;
+
+  UPDATE planets SET last_activity = NOW() WHERE id = planet_id;
+
+  SELECT name INTO sold_name FROM resources WHERE id = (trade->>'sold')::uuid;
+  SELECT name INTO bought_name FROM resources WHERE id = (trade->>'bought')::uuid;
+
+  SELECT
+    p.player,
+    p.name,
+    concat_ws(':', p.galaxy, p.solar_system, p.position) AS coordinates
+  INTO
+    planet_data
+  FROM
+    planets AS p
+  WHERE
+    p.id = planet_id;
+
+  PERFORM create_message_for(
+    planet_data.player,
+    'merchant_trade',
+    NOW(),
+    planet_data.name,
+    planet_data.coordinates,
+    concat_ws(' ', floor((trade->>'amount')::numeric), sold_name),
+    concat_ws(' ', floor((trade->>'received')::numeric), bought_name)
+  );
+END
+$$ LANGUAGE plpgsql;
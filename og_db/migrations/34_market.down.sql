@@ -0,0 +1,15 @@
+-- Drop the marketplace's functions.
+DROP FUNCTION accept_market_offer(offer_id uuid, buyer uuid, fleets json);
+DROP FUNCTION release_market_offer(offer_id uuid, reason text);
+DROP FUNCTION create_market_offer(offer json);
+DROP FUNCTION market_offer_escrow(offer json, factor integer);
+
+-- Remove the messages related to the marketplace.
+DELETE FROM messages_arguments WHERE message IN (
+  SELECT mp.id FROM messages_players AS mp INNER JOIN messages_ids AS mi ON mp.message = mi.id WHERE mi.name IN ('market_offer_accepted', 'market_offer_returned')
+);
+DELETE FROM messages_players WHERE message IN (SELECT id FROM messages_ids WHERE name IN ('market_offer_accepted', 'market_offer_returned'));
+DELETE FROM messages_ids WHERE name IN ('market_offer_accepted', 'market_offer_returned');
+
+-- Drop the offers table.
+DROP TABLE market_offers;
@@ -0,0 +1,207 @@
+-- Create the table referencing the offers posted on
+-- the marketplace of a universe. The resources, the
+-- ships sold and the carriers delivering them are
+-- taken from the planet of the seller while the offer
+-- is pending.
+CREATE TABLE market_offers (
+  id uuid NOT NULL,
+  universe uuid NOT NULL,
+  player uuid NOT NULL,
+  planet uuid NOT NULL,
+  resources json NOT NULL,
+  ships json NOT NULL,
+  carriers json NOT NULL,
+  price json NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expiration_time TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (id),
+  FOREIGN KEY (universe) REFERENCES universes(id),
+  FOREIGN KEY (player) REFERENCES players(id) ON DELETE CASCADE,
+  FOREIGN KEY (planet) REFERENCES planets(id) ON DELETE CASCADE
+);
+
+-- Seed the messages indicating the outcome of the
+-- offers of the marketplace.
+INSERT INTO public.messages_ids ("type", "name", "content")
+  VALUES(
+    (SELECT id FROM messages_types WHERE type='economy'),
+    'market_offer_accepted',
+    'your offer from $PLANET_NAME $COORD has been accepted by $PLAYER_NAME. The goods are sent to $COORD and the payment of $RESOURCES is on its way'
+  );
+
+INSERT INTO public.messages_ids ("type", "name", "content")
+  VALUES(
+    (SELECT id FROM messages_types WHERE type='economy'),
+    'market_offer_returned',
+    'your offer from $PLANET_NAME $COORD has been withdrawn: $REASON. The goods have been restored'
+  );
+
+-- Move the goods of an offer from or to its planet.
+-- The `factor` should be `-1` to escrow the goods and
+-- `1` to give them back.
+CREATE OR REPLACE FUNCTION market_offer_escrow(offer json, factor integer) RETURNS VOID AS $$
+BEGIN
+  WITH rc AS (
+    SELECT
+      t.resource,
+      t.amount
+    FROM
+      json_to_recordset(offer->'resources') AS t(resource uuid, amount numeric(15, 5))
+    )
+  UPDATE planets_resources
+    SET amount = amount + factor * rc.amount
+  FROM
+    rc
+  WHERE
+    planet = (offer->>'planet')::uuid
+    AND res = rc.resource;
+
+  WITH sc AS (
+    SELECT
+      t.ship,
+      sum(t.count) AS count
+    FROM
+      (
+        SELECT * FROM json_to_recordset(offer->'ships') AS s(ship uuid, count integer)
+        UNION ALL
+        SELECT * FROM json_to_recordset(offer->'carriers') AS c(ship uuid, count integer)
+      ) AS t
+    GROUP BY
+      t.ship
+    )
+  UPDATE planets_ships
+    SET count = count + factor * sc.count
+  FROM
+    sc
+  WHERE
+    planet = (offer->>'planet')::uuid
+    AND ship = sc.ship;
+END
+$$ LANGUAGE plpgsql;
+
+-- Register a new offer and escrow its goods from the
+-- planet of the seller.
+CREATE OR REPLACE FUNCTION create_market_offer(offer json) RETURNS VOID AS $$
+BEGIN
+  INSERT INTO market_offers("id", "universe", "player", "planet", "resources", "ships", "carriers", "price", "expiration_time")
+    VALUES(
+      (offer->>'id')::uuid,
+      (offer->>'universe')::uuid,
+      (offer->>'player')::uuid,
+      (offer->>'planet')::uuid,
+      offer->'resources',
+      offer->'ships',
+      offer->'carriers',
+      offer->'price',
+      (offer->>'expiration_time')::timestamp with time zone
+    );
+
+  PERFORM market_offer_escrow(offer, -1);
+END
+$$ LANGUAGE plpgsql;
+
+-- Remove an offer and give its goods back to the
+-- planet of the seller. The seller is notified in
+-- case a reason is provided.
+CREATE OR REPLACE FUNCTION release_market_offer(offer_id uuid, reason text) RETURNS VOID AS $$
+DECLARE
+  offer record;
+  planet_name text;
+  planet_coords text;
+BEGIN
+  SELECT * INTO offer FROM market_offers WHERE id = offer_id;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid offer % in release operation', offer_id;
+  END IF;
+
+  PERFORM market_offer_escrow(row_to_json(offer), 1);
+
+  DELETE FROM market_offers WHERE id = offer_id;
+
+  IF reason = '' THEN
+    RETURN;
+  END IF;
+
+  SELECT
+    p.name,
+    concat_ws(':', p.galaxy, p.solar_system, p.position)
+  INTO
+    planet_name,
+    planet_coords
+  FROM
+    planets AS p
+  WHERE
+    p.id = offer.planet;
+
+  PERFORM create_message_for(offer.player, 'market_offer_returned', NOW(), planet_name, planet_coords, reason);
+END
+$$ LANGUAGE plpgsql;
+
+-- Accept an offer: the goods are given back to the
+-- planet of the seller so that the fleets delivering
+-- them can be created like any other fleet. The input
+-- `fleets` is an array where each element defines the
+-- arguments of the `create_fleet` script.
+CREATE OR REPLACE FUNCTION accept_market_offer(offer_id uuid, buyer uuid, fleets json) RETURNS VOID AS $$
+DECLARE
+  offer record;
+  fleet json;
+  planet_data record;
+  buyer_data record;
+  price text;
+BEGIN
+  SELECT * INTO offer FROM market_offers WHERE id = offer_id;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid offer % in accept operation', offer_id;
+  END IF;
+
+  PERFORM market_offer_escrow(row_to_json(offer), 1);
+
+  DELETE FROM market_offers WHERE id = offer_id;
+
+  FOR fleet IN SELECT * FROM json_array_elements(fleets)
+  LOOP
+    PERFORM create_fleet(fleet->'fleet', fleet->'ships', fleet->'resources', fleet->'consumption');
+  END LOOP;
+
+  SELECT
+    p.name,
+    concat_ws(':', p.galaxy, p.solar_system, p.position) AS coordinates
+  INTO
+    planet_data
+  FROM
+    planets AS p
+  WHERE
+    p.id = offer.planet;
+
+  SELECT
+    pl.name,
+    concat_ws(':', p.galaxy, p.solar_system, p.position) AS coordinates
+  INTO
+    buyer_data
+  FROM
+    planets AS p
+    INNER JOIN players AS pl ON p.player = pl.id
+  WHERE
+    p.id = buyer;
+
+  SELECT
+    string_agg(concat_ws(' ', floor(t.amount), r.name), ', ')
+  INTO
+    price
+  FROM
+    json_to_recordset(offer.price) AS t(resource uuid, amount numeric(15, 5))
+    INNER JOIN resources AS r ON r.id = t.resource;
+
+  PERFORM create_message_for(
+    offer.player,
+    'market_offer_accepted',
+    NOW(),
+    planet_data.name,
+    planet_data.coordinates,
+    buyer_data.name,
+    buyer_data.coordinates,
+    price
+  );
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,9 @@
+-- Drop the administration scripts.
+DROP FUNCTION purge_action(action_id uuid);
+DROP FUNCTION grant_ships(planet_id uuid, kind text, ships json);
+DROP FUNCTION grant_resources(planet_id uuid, kind text, resources json);
+DROP FUNCTION update_universe(universe_id uuid, inputs json);
+DROP FUNCTION ban_player(player_id uuid, ban boolean);
+
+-- Remove the ban flag from players.
+ALTER TABLE players DROP COLUMN banned;
@@ -0,0 +1,152 @@
+-- Allow administrators to ban players from a universe.
+ALTER TABLE players ADD COLUMN banned boolean NOT NULL DEFAULT false;
+
+-- Ban a player or lift the ban of a player.
+CREATE OR REPLACE FUNCTION ban_player(player_id uuid, ban boolean) RETURNS VOID AS $$
+BEGIN
+  UPDATE players SET banned = ban WHERE id = player_id;
+
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Unable to find player %', player_id;
+  END IF;
+END
+$$ LANGUAGE plpgsql;
+
+-- Update the configuration of an existing universe. The
+-- dimensions of the universe and its country can't be
+-- changed as planets might already be using them: the
+-- corresponding keys of the `inputs` are ignored.
+CREATE OR REPLACE FUNCTION update_universe(universe_id uuid, inputs json) RETURNS VOID AS $$
+BEGIN
+  UPDATE universes AS u
+    SET
+      name = t.name,
+      economic_speed = t.economic_speed,
+      fleet_speed = t.fleet_speed,
+      research_speed = t.research_speed,
+      fleets_to_ruins_ratio = t.fleets_to_ruins_ratio,
+      defenses_to_ruins_ratio = t.defenses_to_ruins_ratio,
+      fleets_consumption_ratio = t.fleets_consumption_ratio,
+      noob_protection_points = t.noob_protection_points,
+      noob_protection_ratio = t.noob_protection_ratio,
+      bashing_limit = t.bashing_limit,
+      acs_defend_hold_times = t.acs_defend_hold_times,
+      homeworld_placement = t.homeworld_placement,
+      homeworld_density = t.homeworld_density,
+      homeworld_buffer = t.homeworld_buffer,
+      merchant_rates = t.merchant_rates,
+      merchant_variance = t.merchant_variance
+  FROM
+    json_populate_record(null::universes, (inputs::jsonb - 'id' - 'country')::json) AS t
+  WHERE
+    u.id = universe_id;
+
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Unable to find universe %', universe_id;
+  END IF;
+END
+$$ LANGUAGE plpgsql;
+
+-- Grant resources to a planet or a moon. The resources
+-- are described by an array of objects with a `resource`
+-- and an `amount` key. Negative amounts remove resources
+-- without going below zero. The production of the planet
+-- is accounted for before the resources are granted.
+CREATE OR REPLACE FUNCTION grant_resources(planet_id uuid, kind text, resources json) RETURNS VOID AS $$
+BEGIN
+  IF kind != 'planet' AND kind != 'moon' THEN
+    RAISE EXCEPTION 'Invalid kind % specified for %', kind, planet_id;
+  END IF;
+
+  IF kind = 'planet' THEN
+    PERFORM update_resources_for_planet(planet_id);
+
+    UPDATE planets_resources AS pr
+      SET amount = GREATEST(0, pr.amount + r.amount)
+    FROM
+      json_to_recordset(resources) AS r(resource uuid, amount numeric(15, 5))
+    WHERE
+      pr.planet = planet_id
+      AND pr.res = r.resource;
+  END IF;
+
+  IF kind = 'moon' THEN
+    UPDATE moons_resources AS mr
+      SET amount = GREATEST(0, mr.amount + r.amount)
+    FROM
+      json_to_recordset(resources) AS r(resource uuid, amount numeric(15, 5))
+    WHERE
+      mr.moon = planet_id
+      AND mr.res = r.resource;
+  END IF;
+END
+$$ LANGUAGE plpgsql;
+
+-- Grant ships to a planet or a moon. The ships are
+-- described by an array of objects with a `ship` and
+-- an `amount` key. Negative amounts remove ships without
+-- going below zero. Note that the points of the player
+-- are not updated.
+CREATE OR REPLACE FUNCTION grant_ships(planet_id uuid, kind text, ships json) RETURNS VOID AS $$
+BEGIN
+  IF kind != 'planet' AND kind != 'moon' THEN
+    RAISE EXCEPTION 'Invalid kind % specified for %', kind, planet_id;
+  END IF;
+
+  IF kind = 'planet' THEN
+    UPDATE planets_ships AS ps
+      SET count = GREATEST(0, ps.count + s.amount)
+    FROM
+      json_to_recordset(ships) AS s(ship uuid, amount integer)
+    WHERE
+      ps.planet = planet_id
+      AND ps.ship = s.ship;
+
+    INSERT INTO planets_ships(planet, ship, count)
+      SELECT planet_id, s.ship, GREATEST(0, s.amount)
+      FROM json_to_recordset(ships) AS s(ship uuid, amount integer)
+      WHERE NOT EXISTS (SELECT ps.ship FROM planets_ships AS ps WHERE ps.planet = planet_id AND ps.ship = s.ship);
+  END IF;
+
+  IF kind = 'moon' THEN
+    UPDATE moons_ships AS ms
+      SET count = GREATEST(0, ms.count + s.amount)
+    FROM
+      json_to_recordset(ships) AS s(ship uuid, amount integer)
+    WHERE
+      ms.moon = planet_id
+      AND ms.ship = s.ship;
+
+    INSERT INTO moons_ships(moon, ship, count)
+      SELECT planet_id, s.ship, GREATEST(0, s.amount)
+      FROM json_to_recordset(ships) AS s(ship uuid, amount integer)
+      WHERE NOT EXISTS (SELECT ms.ship FROM moons_ships AS ms WHERE ms.moon = planet_id AND ms.ship = s.ship);
+  END IF;
+END
+$$ LANGUAGE plpgsql;
+
+-- Remove an action from the actions queue. Construction
+-- actions are removed along with their effects so that
+-- the planet is able to register new actions. Other
+-- kinds of actions are only removed from the queue.
+CREATE OR REPLACE FUNCTION purge_action(action_id uuid) RETURNS VOID AS $$
+BEGIN
+  DELETE FROM actions_queue WHERE action = action_id;
+
+  DELETE FROM construction_actions_buildings_production_effects WHERE action = action_id;
+  DELETE FROM construction_actions_buildings_storage_effects WHERE action = action_id;
+  DELETE FROM construction_actions_buildings_fields_effects WHERE action = action_id;
+  DELETE FROM construction_actions_buildings WHERE id = action_id;
+
+  DELETE FROM construction_actions_buildings_fields_effects_moon WHERE action = action_id;
+  DELETE FROM construction_actions_buildings_moon WHERE id = action_id;
+
+  DELETE FROM construction_actions_technologies WHERE id = action_id;
+
+  DELETE FROM construction_actions_ships WHERE id = action_id;
+  DELETE FROM construction_actions_ships_moon WHERE id = action_id;
+
+  DELETE FROM construction_actions_defenses WHERE id = action_id;
+  DELETE FROM construction_actions_defenses_moon WHERE id = action_id;
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,35 @@
+-- Launch a scheduled fleet using the fuel reserved on
+-- its source when it was scheduled: the reserved items
+-- are given back to the source and then taken again by
+-- the fleet, so the source can't go negative.
+DROP FUNCTION launch_scheduled_fleet(fleet_id uuid, fleet json, consumption json, moment timestamp with time zone);
+
+CREATE OR REPLACE FUNCTION launch_scheduled_fleet(fleet_id uuid, fleet json, moment timestamp with time zone) RETURNS VOID AS $$
+DECLARE
+  scheduled record;
+  ships json;
+  resources json;
+BEGIN
+  SELECT * INTO scheduled FROM fleets_scheduled WHERE id = fleet_id;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'Invalid scheduled fleet % in launch operation', fleet_id;
+  END IF;
+
+  ships := scheduled.fleet->'ships';
+  resources := COALESCE(scheduled.fleet->'cargo', '[]'::json);
+
+  PERFORM update_fleet_source(scheduled.source, scheduled.source_type, ships, resources, scheduled.consumption, 1);
+
+  DELETE FROM fleets_scheduled WHERE id = fleet_id;
+
+  IF scheduled.acs IS NULL THEN
+    PERFORM create_fleet(fleet, ships, resources, scheduled.consumption);
+  ELSE
+    PERFORM create_acs_fleet(scheduled.acs, fleet, ships, resources, scheduled.consumption);
+  END IF;
+
+  -- The fleet left at the requested time and not when
+  -- it was processed.
+  UPDATE fleets SET created_at = moment WHERE id = fleet_id;
+END
+$$ LANGUAGE plpgsql;
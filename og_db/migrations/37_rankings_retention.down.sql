@@ -0,0 +1,2 @@
+-- Drop the function pruning the snapshots.
+DROP FUNCTION delete_rankings_snapshots(moment timestamp with time zone);
@@ -0,0 +1,22 @@
+-- Remove the snapshots of the rankings older than the
+-- input date. The most recent snapshot of each universe
+-- is always kept so that the rankings can be served.
+CREATE OR REPLACE FUNCTION delete_rankings_snapshots(moment timestamp with time zone) RETURNS VOID AS $$
+BEGIN
+  DELETE FROM rankings_snapshots_players
+    WHERE snapshot IN (
+      SELECT
+        rs.id
+      FROM
+        rankings_snapshots AS rs
+      WHERE
+        rs.created_at < moment
+        AND rs.created_at < (SELECT max(created_at) FROM rankings_snapshots WHERE universe = rs.universe)
+    );
+
+  DELETE FROM rankings_snapshots AS rs
+    WHERE
+      rs.created_at < moment
+      AND rs.created_at < (SELECT max(created_at) FROM rankings_snapshots WHERE universe = rs.universe);
+END
+$$ LANGUAGE plpgsql;
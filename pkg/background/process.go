@@ -3,7 +3,9 @@ package background
 import (
 	"fmt"
 	"oglike_server/pkg/logger"
+	"oglike_server/pkg/metrics"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -44,6 +46,9 @@ import (
 //
 // The `waiter` allows to wait for this process to
 // complete before returning from the `Stop` func.
+//
+// The `active` mirrors the `running` flag but can be
+// read without acquiring the `lock`.
 type Process struct {
 	interval      time.Duration
 	retryInterval time.Duration
@@ -56,6 +61,7 @@ type Process struct {
 	running     bool
 	termination chan bool
 	waiter      sync.WaitGroup
+	active      int32
 }
 
 // OperationFunc :
@@ -73,6 +79,17 @@ var ErrAlreadyRunning = fmt.Errorf("unable to start already running process")
 // associated to this process is not valid.
 var ErrInvalidOperation = fmt.Errorf("invalid operation to start process")
 
+// runsDuration :
+// Measures the time needed to execute the operation of
+// processes for each module and status.
+var runsDuration = metrics.NewHistogram(
+	"oglike_background_process_duration_seconds",
+	"Time needed to execute the operation of background processes.",
+	metrics.DefaultDurationBuckets,
+	"module",
+	"status",
+)
+
 // NewProcess :
 // Defines a new process object with the specified
 // interval and logger.
@@ -226,6 +243,7 @@ func (p *Process) Start() error {
 	}
 
 	p.running = true
+	atomic.StoreInt32(&p.active, 1)
 	p.waiter.Add(1)
 
 	go p.activeLoop()
@@ -256,6 +274,7 @@ func (p *Process) activeLoop() {
 		// The process is not running anymore.
 		p.lock.Lock()
 		p.running = false
+		atomic.StoreInt32(&p.active, 0)
 		p.lock.Unlock()
 
 		// Release the wait group.
@@ -300,6 +319,25 @@ func (p *Process) activeLoop() {
 	p.log.Trace(logger.Info, p.module, "Stopping background process")
 }
 
+// Running :
+// Used to determine whether the main processing loop
+// of this process is running.
+//
+// Returns `true` if the process is running.
+func (p *Process) Running() bool {
+	// The `lock` is held while the operation executes so
+	// we rely on a dedicated flag to answer right away.
+	return atomic.LoadInt32(&p.active) == 1
+}
+
+// Module :
+// Returns the module associated to this process. The
+// module is expected to be set before the process is
+// started.
+func (p *Process) Module() string {
+	return p.module
+}
+
 // execute :
 // Wrapper function allowing to execute the main
 // operation binded to this process. The process
@@ -318,8 +356,16 @@ func (p *Process) execute() error {
 			defer p.lock.Unlock()
 
 			// Perform the operation.
+			start := time.Now()
 			success, err = p.operation()
 
+			status := "success"
+			if err != nil || !success {
+				status = "failure"
+			}
+
+			runsDuration.Observe(time.Since(start).Seconds(), p.module, status)
+
 			if err != nil {
 				p.log.Trace(logger.Error, p.module, fmt.Sprintf("Caught error while executing process (err: %v)", err))
 			}
@@ -197,6 +197,7 @@ func NewPool(logger logger.Logger) *DB {
 
 	// Try to connect to the DB.
 	dbase.createPoolAttempt()
+	dbase.registerMetrics()
 
 	// Create a ticker to maintain the connection with the
 	// DB healthy in case of a disconnection later on.
@@ -374,3 +375,36 @@ func (dbase *DB) DBQuery(query string, args ...interface{}) (*pgx.Rows, error) {
 
 	return r, err
 }
+
+// DBConnection :
+// Attempts to acquire a connection from the pool for the
+// exclusive use of the caller. This is needed when some
+// operations rely on the state of the session such as
+// advisory locks or explicit transactions.
+// Note that if the connection has not yet been established
+// with the DB an error is returned.
+//
+// Returns the connection along with a function that must
+// be called to give it back to the pool and any errors.
+func (dbase *DB) DBConnection() (*pgx.Conn, func(), error) {
+	dbase.lock.Lock()
+	pool := dbase.pool
+	dbase.lock.Unlock()
+
+	if pool == nil {
+		dbase.trace(logger.Error, fmt.Sprintf("Cannot acquire connection on DB \"%s\" (err: connection is invalid)", dbase.config.name))
+
+		return nil, func() {}, ErrInvalidDB
+	}
+
+	conn, err := pool.Acquire()
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	release := func() {
+		pool.Release(conn)
+	}
+
+	return conn, release, nil
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"oglike_server/pkg/metrics"
+
+	"github.com/jackc/pgx"
+)
+
+// stat :
+// Used to retrieve the statistics of the pool of
+// connections to the DB. In case the connection is
+// not yet established, empty statistics are used.
+//
+// Returns the statistics of the pool.
+func (dbase *DB) stat() pgx.ConnPoolStat {
+	dbase.lock.Lock()
+	defer dbase.lock.Unlock()
+
+	if dbase.pool == nil {
+		return pgx.ConnPoolStat{}
+	}
+
+	return dbase.pool.Stat()
+}
+
+// registerMetrics :
+// Used to expose the statistics of the pool of
+// connections of this DB as metrics. Registering
+// the metrics for another DB replaces them.
+func (dbase *DB) registerMetrics() {
+	metrics.NewGaugeFunc(
+		"oglike_db_pool_max_connections",
+		"Maximum number of connections in the pool.",
+		func() float64 {
+			return float64(dbase.stat().MaxConnections)
+		},
+	)
+
+	metrics.NewGaugeFunc(
+		"oglike_db_pool_current_connections",
+		"Number of connections currently opened in the pool.",
+		func() float64 {
+			return float64(dbase.stat().CurrentConnections)
+		},
+	)
+
+	metrics.NewGaugeFunc(
+		"oglike_db_pool_available_connections",
+		"Number of connections available for use in the pool.",
+		func() float64 {
+			return float64(dbase.stat().AvailableConnections)
+		},
+	)
+}
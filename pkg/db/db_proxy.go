@@ -350,3 +350,73 @@ func (p Proxy) InsertToDB(req InsertReq) error {
 
 	return formatDBError(err)
 }
+
+// Healthcheck :
+// Used to verify that the DB can be reached. In case the
+// connection is lost an attempt to establish it again is
+// performed before running a trivial query.
+//
+// Returns any error.
+func (p Proxy) Healthcheck() error {
+	// Check for invalid DB.
+	if p.dbase == nil {
+		return ErrInvalidDB
+	}
+
+	p.dbase.Healthcheck()
+
+	rows, err := p.dbase.DBQuery("select 1")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+
+	return rows.Err()
+}
+
+// AdvisoryLock :
+// Used to acquire the advisory lock defined by the input
+// key. The lock is held by a connection dedicated to it
+// until it is released: other processes using the same
+// DB (possibly other programs) will wait for it. This
+// method blocks until the lock is acquired.
+//
+// The `key` defines the key of the lock.
+//
+// Returns a function releasing the lock along with any
+// error. The function is always valid.
+func (p Proxy) AdvisoryLock(key int64) (func(), error) {
+	// Check for invalid DB.
+	if p.dbase == nil {
+		return func() {}, ErrInvalidDB
+	}
+
+	conn, release, err := p.dbase.DBConnection()
+	if err != nil {
+		release()
+		return func() {}, err
+	}
+
+	_, err = conn.Exec("select pg_advisory_lock($1)", key)
+	if err != nil {
+		release()
+		return func() {}, err
+	}
+
+	unlock := func() {
+		_, err := conn.Exec("select pg_advisory_unlock($1)", key)
+		if err != nil {
+			// Closing the connection releases the lock and
+			// prevents the pool from reusing it.
+			p.dbase.trace(logger.Error, fmt.Sprintf("Failed to release advisory lock %d (err: %v)", key, err))
+			conn.Close()
+		}
+
+		release()
+	}
+
+	return unlock, nil
+}
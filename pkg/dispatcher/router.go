@@ -4,8 +4,23 @@ import (
 	"fmt"
 	"net/http"
 	"oglike_server/pkg/logger"
+
+	"github.com/google/uuid"
 )
 
+// RequestIDHeader :
+// Defines the header used to transmit the identifier of
+// a request. Clients can provide it to correlate their
+// own logs with the server's, otherwise it is generated
+// and always returned in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength :
+// Defines the maximum length of a request identifier set
+// by a client. Longer identifiers are replaced by one
+// generated by the router.
+const maxRequestIDLength = 64
+
 // Router :
 // Defines a generic router that can be used to simplify the
 // handling of multiple routes for a server. It helps with
@@ -123,6 +138,16 @@ func (r *Router) HandleFunc(path string, f func(http.ResponseWriter, *http.Reque
 // The `req` defines the input request which should be
 // routed through the internal handlers.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Assign an identifier to the request so that all the
+	// logs produced while serving it can be correlated.
+	id := req.Header.Get(RequestIDHeader)
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		id = uuid.New().String()
+	}
+
+	w.Header().Set(RequestIDHeader, id)
+	req = req.WithContext(logger.WithRequestID(req.Context(), id))
+
 	// Try to match the input request against the internal
 	// registered routes.
 	var match routeMatch
@@ -36,6 +36,13 @@ func NewDuration(t time.Duration) Duration {
 	}
 }
 
+// JSONModel :
+// Returns a value having the same JSON representation
+// as the duration, which is marshalled as a string.
+func (d Duration) JSONModel() interface{} {
+	return ""
+}
+
 // MarshalJSON :
 // Imlepementation of the marshaller interface to be
 // able to use this object out-of-the-box with the
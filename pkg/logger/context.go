@@ -0,0 +1,72 @@
+package logger
+
+import "context"
+
+// contextKey :
+// Defines the type of the keys used to store values in
+// a context. Using a dedicated type prevents collisions
+// with keys defined by other packages.
+type contextKey string
+
+// requestIDKey :
+// Defines the key used to store the request identifier
+// in a context.
+const requestIDKey contextKey = "request-id"
+
+// RequestIDField :
+// Defines the name of the field used to attach the request
+// identifier to log messages.
+const RequestIDField = "request_id"
+
+// WithRequestID :
+// Used to create a new context from the input one which
+// holds the input request identifier.
+//
+// The `ctx` defines the parent context.
+//
+// The `id` defines the identifier of the request.
+//
+// Returns the created context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID :
+// Used to retrieve the request identifier stored in the
+// input context. In case none is defined the empty string
+// is returned.
+//
+// The `ctx` defines the context to inspect.
+//
+// Returns the request identifier.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	id, ok := ctx.Value(requestIDKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return id
+}
+
+// FromContext :
+// Used to create a logger attaching the request identifier
+// found in the input context to all the messages. In case
+// no identifier is defined the input logger is returned.
+//
+// The `ctx` defines the context of the request.
+//
+// The `log` defines the logger to wrap.
+//
+// Returns the logger to use for the request.
+func FromContext(ctx context.Context, log Logger) Logger {
+	id := RequestID(ctx)
+	if id == "" {
+		return log
+	}
+
+	return WithFields(log, Fields{RequestIDField: id})
+}
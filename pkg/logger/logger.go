@@ -1,5 +1,12 @@
 package logger
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
 // Logger :
 // Describes a common interface used for logging purposes.
 // A single method is needed to allow the logging of some
@@ -10,3 +17,258 @@ package logger
 type Logger interface {
 	Trace(level Severity, module string, message string)
 }
+
+// Fields :
+// Describes a set of key/value pairs that can be attached
+// to a log message. It allows to correlate messages that
+// are produced by different modules, typically for the
+// same request.
+type Fields map[string]interface{}
+
+// FieldsLogger :
+// Extension of the `Logger` interface which is able to
+// attach some fields to the messages it logs. Loggers
+// that do not implement it will still receive the fields
+// but as part of the message itself.
+//
+// The `TraceWithFields` allows to log a message with the
+// specified level and some additional fields.
+type FieldsLogger interface {
+	Logger
+	TraceWithFields(level Severity, module string, message string, fields Fields)
+}
+
+// merge :
+// Used to create a new set of fields containing both the
+// fields of this element and the input ones. In case a key
+// is defined in both, the input value is kept.
+//
+// The `other` defines the fields to merge with this one.
+//
+// Returns the merged fields.
+func (f Fields) merge(other Fields) Fields {
+	out := make(Fields, len(f)+len(other))
+
+	for k, v := range f {
+		out[k] = v
+	}
+	for k, v := range other {
+		out[k] = v
+	}
+
+	return out
+}
+
+// String :
+// Implementation of the `Stringer` interface to display
+// the fields as a sorted list of `key=value` pairs.
+//
+// Returns the string representing the fields.
+func (f Fields) String() string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for id, k := range keys {
+		pairs[id] = fmt.Sprintf("%s=%v", k, f[k])
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// traceWithFields :
+// Used to forward the input message to the logger with
+// the fields if it supports it. Otherwise the fields are
+// appended to the message.
+//
+// The `log` defines the logger to use.
+//
+// The `level` defines the severity of the message.
+//
+// The `module` defines the module producing the message.
+//
+// The `message` defines the content of the message.
+//
+// The `fields` defines the fields to attach.
+func traceWithFields(log Logger, level Severity, module string, message string, fields Fields) {
+	if fl, ok := log.(FieldsLogger); ok {
+		fl.TraceWithFields(level, module, message, fields)
+		return
+	}
+
+	if len(fields) > 0 {
+		message = fmt.Sprintf("%s (%s)", message, fields)
+	}
+
+	log.Trace(level, module, message)
+}
+
+// boundLogger :
+// Defines a logger which attaches a constant set of fields
+// to all the messages it forwards to an underlying logger.
+//
+// The `log` defines the logger receiving the messages.
+//
+// The `fields` defines the fields attached to messages.
+type boundLogger struct {
+	log    Logger
+	fields Fields
+}
+
+// WithFields :
+// Used to create a logger attaching the input fields to
+// all the messages logged through it. The messages are
+// forwarded to the input logger.
+//
+// The `log` defines the logger to wrap.
+//
+// The `fields` defines the fields to attach.
+//
+// Returns the created logger.
+func WithFields(log Logger, fields Fields) Logger {
+	// Avoid nesting bound loggers.
+	if bl, ok := log.(*boundLogger); ok {
+		return &boundLogger{
+			log:    bl.log,
+			fields: bl.fields.merge(fields),
+		}
+	}
+
+	return &boundLogger{
+		log:    log,
+		fields: Fields{}.merge(fields),
+	}
+}
+
+// Trace :
+// Implementation of the `Logger` interface which adds
+// the fields bound to this logger.
+//
+// The `level` defines the severity of the message.
+//
+// The `module` defines the module producing the message.
+//
+// The `message` defines the content of the message.
+func (bl *boundLogger) Trace(level Severity, module string, message string) {
+	traceWithFields(bl.log, level, module, message, bl.fields)
+}
+
+// TraceWithFields :
+// Implementation of the `FieldsLogger` interface which
+// merges the input fields with the bound ones.
+//
+// The `level` defines the severity of the message.
+//
+// The `module` defines the module producing the message.
+//
+// The `message` defines the content of the message.
+//
+// The `fields` defines the fields to attach.
+func (bl *boundLogger) TraceWithFields(level Severity, module string, message string, fields Fields) {
+	traceWithFields(bl.log, level, module, message, bl.fields.merge(fields))
+}
+
+// ScopedLogger :
+// Defines a logger which attaches some fields that can
+// be changed at runtime to all the messages it forwards.
+// It is useful for elements that are shared by several
+// processes but only used by one of them at a time: the
+// fields can describe the current user of the element.
+//
+// The `log` defines the logger receiving the messages.
+//
+// The `fields` defines the fields currently attached to
+// the messages.
+//
+// The `locker` protects the fields from concurrent use.
+type ScopedLogger struct {
+	log    Logger
+	fields Fields
+	locker sync.Mutex
+}
+
+// NewScopedLogger :
+// Creates a new scoped logger forwarding messages to the
+// input logger. No fields are attached initially.
+//
+// The `log` defines the logger to wrap.
+//
+// Returns the created logger.
+func NewScopedLogger(log Logger) *ScopedLogger {
+	return &ScopedLogger{
+		log:    log,
+		fields: Fields{},
+	}
+}
+
+// Enter :
+// Used to define the fields to attach to the messages
+// logged from now on. Any previous fields are replaced.
+//
+// The `fields` defines the fields to attach.
+func (sl *ScopedLogger) Enter(fields Fields) {
+	sl.locker.Lock()
+	defer sl.locker.Unlock()
+
+	sl.fields = Fields{}.merge(fields)
+}
+
+// Leave :
+// Used to remove all the fields attached to messages.
+func (sl *ScopedLogger) Leave() {
+	sl.locker.Lock()
+	defer sl.locker.Unlock()
+
+	sl.fields = Fields{}
+}
+
+// Unscoped :
+// Used to retrieve the logger wrapped by this scope. It
+// allows to log messages without the fields of the scope
+// for callers which are not part of it.
+//
+// Returns the wrapped logger.
+func (sl *ScopedLogger) Unscoped() Logger {
+	return sl.log
+}
+
+// current :
+// Returns the fields currently attached to messages.
+func (sl *ScopedLogger) current() Fields {
+	sl.locker.Lock()
+	defer sl.locker.Unlock()
+
+	return sl.fields
+}
+
+// Trace :
+// Implementation of the `Logger` interface which adds
+// the fields of the current scope.
+//
+// The `level` defines the severity of the message.
+//
+// The `module` defines the module producing the message.
+//
+// The `message` defines the content of the message.
+func (sl *ScopedLogger) Trace(level Severity, module string, message string) {
+	traceWithFields(sl.log, level, module, message, sl.current())
+}
+
+// TraceWithFields :
+// Implementation of the `FieldsLogger` interface which
+// merges the input fields with the ones of the scope.
+//
+// The `level` defines the severity of the message.
+//
+// The `module` defines the module producing the message.
+//
+// The `message` defines the content of the message.
+//
+// The `fields` defines the fields to attach.
+func (sl *ScopedLogger) TraceWithFields(level Severity, module string, message string, fields Fields) {
+	traceWithFields(sl.log, level, module, message, sl.current().merge(fields))
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+)
+
+// rotatingFile :
+// Describes a file which is rotated when its size reaches
+// a certain threshold. The current file is renamed with a
+// numerical suffix and a new one is created. Only a given
+// number of old files is kept.
+//
+// The `path` defines the path to the current log file.
+//
+// The `maxSize` defines the size in bytes above which the
+// file is rotated.
+//
+// The `maxFiles` defines the number of rotated files kept
+// in addition to the current one.
+//
+// The `file` defines the file currently written.
+//
+// The `size` defines the number of bytes written in the
+// current file.
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// newRotatingFile :
+// Creates a new rotating file at the specified path. If
+// a file already exists it is appended to.
+//
+// The `path` defines the path to the log file.
+//
+// The `maxSize` defines the size in bytes of a file.
+//
+// The `maxFiles` defines the number of rotated files to
+// keep.
+//
+// Returns the created file along with any error.
+func newRotatingFile(path string, maxSize int64, maxFiles int) (*rotatingFile, error) {
+	rf := rotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+
+	err := rf.open()
+
+	return &rf, err
+}
+
+// open :
+// Used to open the current log file in append mode and
+// to retrieve its size.
+//
+// Returns any error.
+func (rf *rotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+
+	return nil
+}
+
+// rotate :
+// Used to close the current file and shift the existing
+// rotated files by one. The oldest file is removed if
+// needed and a new current file is created.
+//
+// Returns any error.
+func (rf *rotatingFile) rotate() error {
+	err := rf.file.Close()
+	if err != nil {
+		return err
+	}
+
+	if rf.maxFiles > 0 {
+		// Failures to remove or rename old files are ignored
+		// as they may not exist yet.
+		os.Remove(fmt.Sprintf("%s.%d", rf.path, rf.maxFiles))
+
+		for id := rf.maxFiles - 1; id > 0; id-- {
+			os.Rename(fmt.Sprintf("%s.%d", rf.path, id), fmt.Sprintf("%s.%d", rf.path, id+1))
+		}
+
+		err = os.Rename(rf.path, fmt.Sprintf("%s.1", rf.path))
+	} else {
+		err = os.Remove(rf.path)
+	}
+
+	// Reopen the file in any case so that the following
+	// logs are not lost.
+	oErr := rf.open()
+	if err != nil {
+		return err
+	}
+
+	return oErr
+}
+
+// Write :
+// Implementation of the `io.Writer` interface which rotates
+// the file when writing the input data would exceed the
+// maximum size.
+//
+// The `p` defines the data to write.
+//
+// Returns the number of bytes written along with any error.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		err := rf.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+
+	return n, err
+}
+
+// Close :
+// Used to close the current log file.
+//
+// Returns any error.
+func (rf *rotatingFile) Close() error {
+	return rf.file.Close()
+}
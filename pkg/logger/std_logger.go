@@ -1,7 +1,10 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"sync"
 	"time"
 
@@ -55,12 +58,40 @@ import (
 // absorb even more logs if needed. Note however that if the logger cannot
 // process messages fast enough this buffer is bound to
 // The default value is 500.
+//
+// The `format` defines how messages are written. The "text" value uses a
+// colored human readable display while "json" produces a single json
+// object per line which is easier to process by log aggregation tools.
+// The default value is "text".
+//
+// The `output` defines where messages are written. The "stdout" value
+// uses the standard output, "stderr" uses the standard error while
+// "file" writes to the file described by the `file` attribute.
+// The default value is "stdout".
+//
+// The `file` defines the path of the file to write logs to when the
+// `output` is set to "file".
+// The default value is "oglike_server.log".
+//
+// The `maxSize` defines the size in megabytes above which the log file
+// is rotated. The current file is renamed with a numerical suffix and a
+// new one is created.
+// The default value is 100.
+//
+// The `maxFiles` defines the number of rotated log files to keep. Older
+// files are removed.
+// The default value is 5.
 type configuration struct {
 	name        string
 	environment string
 	forceLocal  bool
 	level       string
 	buffer      int
+	format      string
+	output      string
+	file        string
+	maxSize     int
+	maxFiles    int
 }
 
 // traceMessage :
@@ -106,12 +137,16 @@ type configuration struct {
 // dumped with some context. The reason behind that is that we consider
 // that events should be self-explanatory while simple messages should
 // not.
+//
+// The `fields` defines additional key/value pairs attached to the message.
+// It might be empty.
 type traceMessage struct {
 	level   Severity
 	module  string
 	name    string
 	content string
 	isEvent bool
+	fields  Fields
 }
 
 // StdLogger :
@@ -166,6 +201,9 @@ type traceMessage struct {
 //
 // The `waiter` allows to wait for the proper termination of the logging
 // routine in order to allow the display of the last posted log messages.
+//
+// The `out` defines the device to which messages are written. It is only
+// accessed by the logging routine.
 type StdLogger struct {
 	config     configuration
 	instanceID string
@@ -176,6 +214,22 @@ type StdLogger struct {
 	closed     bool
 	locker     sync.Mutex
 	waiter     sync.WaitGroup
+	out        io.Writer
+}
+
+// jsonEntry :
+// Describes the structure of a message when logged in
+// the json format.
+type jsonEntry struct {
+	Timestamp   string `json:"timestamp"`
+	Level       string `json:"level"`
+	App         string `json:"app"`
+	Environment string `json:"environment"`
+	Instance    string `json:"instance"`
+	IP          string `json:"ip"`
+	Module      string `json:"module"`
+	Message     string `json:"message"`
+	Fields      Fields `json:"fields,omitempty"`
 }
 
 // parseConfiguration :
@@ -192,6 +246,11 @@ func parseConfiguration() configuration {
 		false,
 		"info",
 		500,
+		"text",
+		"stdout",
+		"oglike_server.log",
+		100,
+		5,
 	}
 
 	// Parse the description file if any.
@@ -210,6 +269,21 @@ func parseConfiguration() configuration {
 	if viper.IsSet("Logger.Buffer") {
 		config.buffer = viper.GetInt("Logger.Buffer")
 	}
+	if viper.IsSet("Logger.Format") {
+		config.format = viper.GetString("Logger.Format")
+	}
+	if viper.IsSet("Logger.Output") {
+		config.output = viper.GetString("Logger.Output")
+	}
+	if viper.IsSet("Logger.File") {
+		config.file = viper.GetString("Logger.File")
+	}
+	if viper.IsSet("Logger.MaxSize") {
+		config.maxSize = viper.GetInt("Logger.MaxSize")
+	}
+	if viper.IsSet("Logger.MaxFiles") {
+		config.maxFiles = viper.GetInt("Logger.MaxFiles")
+	}
 
 	// All is well
 	return config
@@ -246,6 +320,21 @@ func NewStdLogger(instanceID string, publicIP string) *StdLogger {
 		false,
 		sync.Mutex{},
 		sync.WaitGroup{},
+		os.Stdout,
+	}
+
+	// Use a file as output if requested. In case it cannot
+	// be opened we fall back to the standard output.
+	var fErr error
+
+	if config.output == "stderr" {
+		log.out = os.Stderr
+	}
+	if config.output == "file" {
+		log.out, fErr = newRotatingFile(config.file, int64(config.maxSize)*1024*1024, config.maxFiles)
+		if fErr != nil {
+			log.out = os.Stdout
+		}
 	}
 
 	// Update the public IP and instance ID in case no values
@@ -261,6 +350,10 @@ func NewStdLogger(instanceID string, publicIP string) *StdLogger {
 	log.waiter.Add(1)
 	go log.performLogging()
 
+	if fErr != nil {
+		log.Trace(Error, "logger", fmt.Sprintf("Could not open log file \"%s\" (err: %v)", config.file, fErr))
+	}
+
 	// Return the built-in logger.
 	return &log
 }
@@ -282,6 +375,11 @@ func (log *StdLogger) Release() {
 
 	// Wait for the routine termination.
 	log.waiter.Wait()
+
+	// Close the output device if needed.
+	if c, ok := log.out.(io.Closer); ok && log.out != os.Stdout {
+		c.Close()
+	}
 }
 
 // Trace :
@@ -298,6 +396,20 @@ func (log *StdLogger) Release() {
 //
 // The `message` describes the content of the message to log.
 func (log *StdLogger) Trace(level Severity, module string, message string) {
+	log.TraceWithFields(level, module, message, nil)
+}
+
+// TraceWithFields :
+// Used to perform the log of the input message with the
+// specified level and attached fields. It behaves in the
+// same way as the `Trace` method.
+//
+// The `level` describes the severity of the message to log.
+//
+// The `message` describes the content of the message to log.
+//
+// The `fields` describes the fields attached to the message.
+func (log *StdLogger) TraceWithFields(level Severity, module string, message string, fields Fields) {
 	// Create a trace object from the input element.
 	trace := traceMessage{
 		level,
@@ -305,6 +417,7 @@ func (log *StdLogger) Trace(level Severity, module string, message string) {
 		"",
 		message,
 		false,
+		fields,
 	}
 
 	// Enqueue the trace to the internal channel if it is not
@@ -362,6 +475,26 @@ func (log *StdLogger) performSingleLog(trace traceMessage) {
 		return
 	}
 
+	var out string
+
+	switch log.config.format {
+	case "json":
+		out = log.formatJSON(trace)
+	default:
+		out = log.formatText(trace)
+	}
+
+	fmt.Fprintln(log.out, out)
+}
+
+// formatText :
+// Used to format the input trace in a human readable way
+// with colors to highlight the context of the message.
+//
+// The `trace` describes the message to format.
+//
+// Returns the formatted message.
+func (log *StdLogger) formatText(trace traceMessage) string {
 	// Format the log to the standard output by providing some
 	// information about the message to log and the instance
 	// producing it.
@@ -375,5 +508,40 @@ func (log *StdLogger) performSingleLog(trace traceMessage) {
 
 	out += " " + trace.content
 
-	fmt.Println(out)
+	if len(trace.fields) > 0 {
+		out += " " + FormatWithBrackets(trace.fields.String(), Cyan)
+	}
+
+	return out
+}
+
+// formatJSON :
+// Used to format the input trace as a single line json
+// object which can easily be processed by tools.
+//
+// The `trace` describes the message to format.
+//
+// Returns the formatted message.
+func (log *StdLogger) formatJSON(trace traceMessage) string {
+	entry := jsonEntry{
+		Timestamp:   time.Now().Format(time.RFC3339Nano),
+		Level:       trace.level.Name(),
+		App:         log.config.name,
+		Environment: log.config.environment,
+		Instance:    log.instanceID,
+		IP:          log.publicIP,
+		Module:      trace.module,
+		Message:     trace.content,
+		Fields:      trace.fields,
+	}
+
+	out, err := json.Marshal(entry)
+	if err != nil {
+		// Fields might not be marshallable: drop them but
+		// keep the message.
+		entry.Fields = Fields{"error": err.Error()}
+		out, _ = json.Marshal(entry)
+	}
+
+	return string(out)
 }
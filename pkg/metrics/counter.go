@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"io"
+	"sort"
+	"sync"
+)
+
+// Counter :
+// Defines a metric which value can only increase. It is
+// described by a set of labels: each combination of the
+// values of the labels is a distinct series.
+//
+// The `id` defines the name of the metric.
+//
+// The `help` defines a description of the metric.
+//
+// The `labels` defines the names of the labels.
+//
+// The `series` defines the values of the counter keyed
+// by the values of the labels.
+//
+// The `values` defines the values of the labels for each
+// key of the `series`.
+//
+// The `locker` protects the series from concurrent use.
+type Counter struct {
+	id     string
+	help   string
+	labels []string
+	series map[string]float64
+	values map[string][]string
+	locker sync.Mutex
+}
+
+// NewCounter :
+// Creates a new counter registered in the default
+// registry.
+//
+// The `name` defines the name of the metric.
+//
+// The `help` defines a description of the metric.
+//
+// The `labels` defines the names of the labels.
+//
+// Returns the created counter.
+func NewCounter(name string, help string, labels ...string) *Counter {
+	c := &Counter{
+		id:     name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]float64),
+		values: make(map[string][]string),
+	}
+
+	DefaultRegistry.register(c)
+
+	return c
+}
+
+// Inc :
+// Used to increment the series of the counter defined
+// by the input values of the labels by one.
+//
+// The `values` defines the values of the labels.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1.0, values...)
+}
+
+// Add :
+// Used to increment the series of the counter defined
+// by the input values of the labels. Negative values
+// are ignored.
+//
+// The `v` defines the increment.
+//
+// The `values` defines the values of the labels.
+func (c *Counter) Add(v float64, values ...string) {
+	checkLabels(c.id, c.labels, values)
+
+	if v < 0.0 {
+		return
+	}
+
+	key := labelsKey(values)
+
+	c.locker.Lock()
+	defer c.locker.Unlock()
+
+	if _, ok := c.values[key]; !ok {
+		c.values[key] = append([]string{}, values...)
+	}
+
+	c.series[key] += v
+}
+
+// name :
+// Implementation of the `collector` interface.
+func (c *Counter) name() string {
+	return c.id
+}
+
+// write :
+// Implementation of the `collector` interface.
+//
+// The `w` defines the writer to use.
+//
+// Returns any error.
+func (c *Counter) write(w io.Writer) error {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+
+	err := writeHeader(w, c.id, c.help, "counter")
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(c.series))
+	for key := range c.series {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err = writeSample(w, c.id, c.labels, c.values[key], c.series[key])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
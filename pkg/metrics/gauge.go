@@ -0,0 +1,65 @@
+package metrics
+
+import "io"
+
+// GaugeFunc :
+// Defines a metric which value can go up and down. The
+// value is computed by a function each time the metric
+// is collected.
+//
+// The `id` defines the name of the metric.
+//
+// The `help` defines a description of the metric.
+//
+// The `value` defines the function computing the value
+// of the gauge.
+type GaugeFunc struct {
+	id    string
+	help  string
+	value func() float64
+}
+
+// NewGaugeFunc :
+// Creates a new gauge registered in the default registry.
+// Any gauge with the same name is replaced.
+//
+// The `name` defines the name of the metric.
+//
+// The `help` defines a description of the metric.
+//
+// The `value` defines the function computing the value
+// of the gauge.
+//
+// Returns the created gauge.
+func NewGaugeFunc(name string, help string, value func() float64) *GaugeFunc {
+	g := &GaugeFunc{
+		id:    name,
+		help:  help,
+		value: value,
+	}
+
+	DefaultRegistry.register(g)
+
+	return g
+}
+
+// name :
+// Implementation of the `collector` interface.
+func (g *GaugeFunc) name() string {
+	return g.id
+}
+
+// write :
+// Implementation of the `collector` interface.
+//
+// The `w` defines the writer to use.
+//
+// Returns any error.
+func (g *GaugeFunc) write(w io.Writer) error {
+	err := writeHeader(w, g.id, g.help, "gauge")
+	if err != nil {
+		return err
+	}
+
+	return writeSample(w, g.id, nil, nil, g.value())
+}
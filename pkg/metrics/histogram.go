@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"io"
+	"sort"
+	"sync"
+)
+
+// DefaultDurationBuckets :
+// Defines the default upper bounds of the buckets of a
+// histogram measuring durations in seconds.
+var DefaultDurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogramSeries :
+// Describes the samples of a single series of a histogram.
+//
+// The `values` defines the values of the labels.
+//
+// The `counts` defines the number of observations in each
+// bucket. The counts are not cumulative.
+//
+// The `sum` defines the sum of all the observations.
+//
+// The `count` defines the number of observations.
+type histogramSeries struct {
+	values []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Histogram :
+// Defines a metric counting observations in buckets. It
+// is described by a set of labels: each combination of
+// the values of the labels is a distinct series.
+//
+// The `id` defines the name of the metric.
+//
+// The `help` defines a description of the metric.
+//
+// The `labels` defines the names of the labels.
+//
+// The `buckets` defines the upper bounds of the buckets
+// sorted in ascending order.
+//
+// The `series` defines the observations keyed by the
+// values of the labels.
+//
+// The `locker` protects the series from concurrent use.
+type Histogram struct {
+	id      string
+	help    string
+	labels  []string
+	buckets []float64
+	series  map[string]*histogramSeries
+	locker  sync.Mutex
+}
+
+// NewHistogram :
+// Creates a new histogram registered in the default
+// registry.
+//
+// The `name` defines the name of the metric.
+//
+// The `help` defines a description of the metric.
+//
+// The `buckets` defines the upper bounds of buckets.
+//
+// The `labels` defines the names of the labels.
+//
+// Returns the created histogram.
+func NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	b := append([]float64{}, buckets...)
+	sort.Float64s(b)
+
+	h := &Histogram{
+		id:      name,
+		help:    help,
+		labels:  labels,
+		buckets: b,
+		series:  make(map[string]*histogramSeries),
+	}
+
+	DefaultRegistry.register(h)
+
+	return h
+}
+
+// Observe :
+// Used to add an observation to the series defined by
+// the input values of the labels.
+//
+// The `v` defines the observed value.
+//
+// The `values` defines the values of the labels.
+func (h *Histogram) Observe(v float64, values ...string) {
+	checkLabels(h.id, h.labels, values)
+
+	key := labelsKey(values)
+
+	h.locker.Lock()
+	defer h.locker.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			values: append([]string{}, values...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+
+		h.series[key] = s
+	}
+
+	// Observations larger than all the buckets are only
+	// accounted for in the `+Inf` bucket.
+	id := sort.SearchFloat64s(h.buckets, v)
+	if id < len(h.buckets) {
+		s.counts[id]++
+	}
+
+	s.sum += v
+	s.count++
+}
+
+// name :
+// Implementation of the `collector` interface.
+func (h *Histogram) name() string {
+	return h.id
+}
+
+// write :
+// Implementation of the `collector` interface.
+//
+// The `w` defines the writer to use.
+//
+// Returns any error.
+func (h *Histogram) write(w io.Writer) error {
+	h.locker.Lock()
+	defer h.locker.Unlock()
+
+	err := writeHeader(w, h.id, h.help, "histogram")
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	labels := append(append([]string{}, h.labels...), "le")
+
+	for _, key := range keys {
+		s := h.series[key]
+
+		cumulative := uint64(0)
+		for id, bound := range h.buckets {
+			cumulative += s.counts[id]
+
+			values := append(append([]string{}, s.values...), formatValue(bound))
+			err = writeSample(w, h.id+"_bucket", labels, values, float64(cumulative))
+			if err != nil {
+				return err
+			}
+		}
+
+		values := append(append([]string{}, s.values...), "+Inf")
+		err = writeSample(w, h.id+"_bucket", labels, values, float64(s.count))
+		if err != nil {
+			return err
+		}
+
+		err = writeSample(w, h.id+"_sum", h.labels, s.values, s.sum)
+		if err != nil {
+			return err
+		}
+
+		err = writeSample(w, h.id+"_count", h.labels, s.values, float64(s.count))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
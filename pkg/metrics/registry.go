@@ -0,0 +1,237 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector :
+// Describes an element able to write the samples of a
+// metric in the Prometheus text format.
+//
+// The `name` returns the name of the metric.
+//
+// The `write` allows to write the samples of the metric
+// to the input writer.
+type collector interface {
+	name() string
+	write(w io.Writer) error
+}
+
+// Registry :
+// Defines a set of metrics that can be exposed through
+// a single endpoint. Metrics are identified by their
+// name: registering a metric with the name of another
+// replaces it.
+//
+// The `metrics` defines the metrics registered so far
+// and keyed by their name.
+//
+// The `locker` protects the metrics from concurrent
+// accesses.
+type Registry struct {
+	metrics map[string]collector
+	locker  sync.Mutex
+}
+
+// DefaultRegistry :
+// Defines the registry used by the package level funcs
+// to create metrics. This is the registry served by the
+// `Handler` method.
+var DefaultRegistry = NewRegistry()
+
+// contentType :
+// Defines the content type of the Prometheus text format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// NewRegistry :
+// Creates a new registry with no metrics.
+//
+// Returns the created registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]collector),
+	}
+}
+
+// register :
+// Used to add the input metric to this registry. Any
+// metric with the same name is replaced.
+//
+// The `c` defines the metric to register.
+func (r *Registry) register(c collector) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	r.metrics[c.name()] = c
+}
+
+// Write :
+// Used to write all the metrics of this registry to the
+// input writer in the Prometheus text format. Metrics
+// are sorted by name.
+//
+// The `w` defines the writer to use.
+//
+// Returns any error.
+func (r *Registry) Write(w io.Writer) error {
+	r.locker.Lock()
+
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	all := make([]collector, len(names))
+	for id, name := range names {
+		all[id] = r.metrics[name]
+	}
+
+	r.locker.Unlock()
+
+	bw := bufio.NewWriter(w)
+
+	for _, c := range all {
+		err := c.write(bw)
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Handler :
+// Used to create a handler serving the metrics of the
+// default registry.
+//
+// Returns the created handler.
+func Handler() http.HandlerFunc {
+	return DefaultRegistry.Handler()
+}
+
+// Handler :
+// Used to create a handler serving the metrics of this
+// registry in the Prometheus text format.
+//
+// Returns the created handler.
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+
+		// Errors can only come from the connection to the
+		// client: nothing can be done about it.
+		r.Write(w)
+	}
+}
+
+// writeHeader :
+// Used to write the help and type lines of a metric.
+//
+// The `w` defines the writer to use.
+//
+// The `name` defines the name of the metric.
+//
+// The `help` defines the description of the metric.
+//
+// The `kind` defines the type of the metric.
+//
+// Returns any error.
+func writeHeader(w io.Writer, name string, help string, kind string) error {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+
+	return err
+}
+
+// writeSample :
+// Used to write a single sample of a metric.
+//
+// The `w` defines the writer to use.
+//
+// The `name` defines the name of the sample.
+//
+// The `labels` defines the names of the labels.
+//
+// The `values` defines the values of the labels. It
+// should have the same size as the `labels`.
+//
+// The `value` defines the value of the sample.
+//
+// Returns any error.
+func writeSample(w io.Writer, name string, labels []string, values []string, value float64) error {
+	_, err := fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(labels, values), formatValue(value))
+
+	return err
+}
+
+// formatLabels :
+// Used to format the input labels in the Prometheus
+// text format. Values are escaped as needed.
+//
+// The `labels` defines the names of the labels.
+//
+// The `values` defines the values of the labels.
+//
+// Returns the formatted labels.
+func formatLabels(labels []string, values []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	pairs := make([]string, len(labels))
+	for id, label := range labels {
+		pairs[id] = fmt.Sprintf("%s=\"%s\"", label, escaper.Replace(values[id]))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue :
+// Used to format the input value in the Prometheus text
+// format.
+//
+// The `value` defines the value to format.
+//
+// Returns the formatted value.
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// labelsKey :
+// Used to build a key identifying the input values of
+// labels so that they can be used in a map.
+//
+// The `values` defines the values of the labels.
+//
+// Returns the key.
+func labelsKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// checkLabels :
+// Used to verify that the number of values matches the
+// number of labels of a metric. It panics otherwise as
+// this indicates a programming error.
+//
+// The `name` defines the name of the metric.
+//
+// The `labels` defines the labels of the metric.
+//
+// The `values` defines the values provided.
+func checkLabels(name string, labels []string, values []string) {
+	if len(labels) != len(values) {
+		panic(fmt.Errorf("metric \"%s\" expects %d label(s) but got %d", name, len(labels), len(values)))
+	}
+}
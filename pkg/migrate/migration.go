@@ -0,0 +1,144 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration :
+// Describes a single step of the evolution of the schema
+// of the DB. Migrations follow the naming convention of
+// the `migrate` tool: `[version]_[name].up.sql` for the
+// statements applying the migration and the same name
+// with a `.down.sql` suffix to revert it.
+//
+// The `Version` defines the version of the schema once
+// the migration is applied. Migrations are applied in
+// increasing order of version.
+//
+// The `Name` defines a human readable name describing
+// the migration.
+//
+// The `Up` defines the statements to apply.
+//
+// The `Down` defines the statements reverting the ones
+// of the `Up` migration. It might be empty.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// ErrInvalidMigration :
+// Used to indicate that a migration file does not follow
+// the expected naming convention or is not consistent
+// with the other migrations.
+var ErrInvalidMigration = fmt.Errorf("invalid migration")
+
+// Load :
+// Used to read the migrations from the input directory
+// of the provided file system. Files not ending with the
+// `.up.sql` or `.down.sql` suffixes are ignored.
+//
+// The `fsys` defines the file system to read.
+//
+// The `dir` defines the directory holding the migrations
+// in the file system.
+//
+// Returns the migrations sorted by increasing version and
+// any error.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		file := entry.Name()
+
+		up := strings.HasSuffix(file, ".up.sql")
+		if !up && !strings.HasSuffix(file, ".down.sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(strings.TrimSuffix(file, ".up.sql"), ".down.sql")
+
+		tokens := strings.SplitN(base, "_", 2)
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("%v (file: \"%s\", err: missing name)", ErrInvalidMigration, file)
+		}
+
+		version, err := strconv.ParseUint(tokens[0], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("%v (file: \"%s\", err: invalid version)", ErrInvalidMigration, file)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{
+				Version: uint(version),
+				Name:    tokens[1],
+			}
+			byVersion[m.Version] = m
+		}
+
+		if m.Name != tokens[1] {
+			return nil, fmt.Errorf("%v (file: \"%s\", err: version %d already used by \"%s\")", ErrInvalidMigration, file, version, m.Name)
+		}
+
+		if up {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("%v (version: %d, err: missing up migration)", ErrInvalidMigration, m.Version)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest :
+// Used to determine the version of the schema once all
+// the input migrations are applied.
+//
+// The `migrations` defines the available migrations. They
+// are expected to be sorted by increasing version.
+//
+// Returns the latest version or `0` if there are no
+// migrations.
+func Latest(migrations []Migration) uint {
+	if len(migrations) == 0 {
+		return 0
+	}
+
+	return migrations[len(migrations)-1].Version
+}
@@ -0,0 +1,263 @@
+package migrate
+
+import (
+	"fmt"
+	"oglike_server/pkg/db"
+	"oglike_server/pkg/logger"
+
+	"github.com/jackc/pgx"
+)
+
+// Migrator :
+// Allows to bring the schema of a DB up to date with a
+// set of migrations. The version of the schema is kept
+// in the same table as the one used by the `migrate`
+// tool so that both can be used on the same DB.
+//
+// The `dbase` defines the DB to migrate.
+//
+// The `migrations` defines the available migrations,
+// sorted by increasing version.
+//
+// The `log` allows to notify information and errors.
+type Migrator struct {
+	dbase      *db.DB
+	migrations []Migration
+	log        logger.Logger
+}
+
+// versionTable :
+// Defines the name of the table holding the version of
+// the schema of the DB.
+const versionTable = "schema_migrations"
+
+// lockKey :
+// Defines the key of the advisory lock acquired while
+// migrations are applied. It prevents several servers
+// from migrating the same DB at once.
+const lockKey int64 = 0x6f676c696b65
+
+// undefinedTable :
+// Defines the code returned by the DB when a query uses
+// a table that does not exist.
+const undefinedTable = "42P01"
+
+// ErrDirtySchema :
+// Used to indicate that a previous migration failed and
+// left the schema in an unknown state. It should be fixed
+// manually before applying other migrations.
+var ErrDirtySchema = fmt.Errorf("schema of the DB is dirty")
+
+// ErrUnknownVersion :
+// Used to indicate that the version of the schema of the
+// DB is more recent than the available migrations.
+var ErrUnknownVersion = fmt.Errorf("schema of the DB is more recent than the migrations")
+
+// ErrMigrationFailed :
+// Used to indicate that a migration could not be applied.
+var ErrMigrationFailed = fmt.Errorf("failed to apply migration")
+
+// NewMigrator :
+// Creates a new migrator applying the input migrations
+// on the specified DB.
+//
+// The `dbase` defines the DB to migrate.
+//
+// The `migrations` defines the available migrations. They
+// are expected to be sorted by increasing version.
+//
+// The `log` allows to notify information and errors.
+//
+// Returns the created migrator.
+func NewMigrator(dbase *db.DB, migrations []Migration, log logger.Logger) *Migrator {
+	return &Migrator{
+		dbase:      dbase,
+		migrations: migrations,
+		log:        log,
+	}
+}
+
+// trace :
+// Wrapper around the internal logger to always use the
+// same module.
+//
+// The `level` defines the severity of the message.
+//
+// The `msg` defines the content of the message.
+func (m *Migrator) trace(level logger.Severity, msg string) {
+	m.log.Trace(level, "migrate", msg)
+}
+
+// Up :
+// Used to apply all the migrations which are more recent
+// than the current version of the schema. An advisory
+// lock is held during the process so that concurrent
+// calls, possibly from other servers, wait for each other
+// rather than applying the same migrations twice.
+// Each migration is applied in its own transaction along
+// with the update of the version: a failure leaves the
+// schema at the version of the last migration applied.
+//
+// Returns the number of migrations applied along with
+// any error.
+func (m *Migrator) Up() (int, error) {
+	conn, release, err := m.dbase.DBConnection()
+	defer release()
+
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = conn.Exec("select pg_advisory_lock($1)", lockKey)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_, err := conn.Exec("select pg_advisory_unlock($1)", lockKey)
+		if err != nil {
+			m.trace(logger.Error, fmt.Sprintf("Failed to release migrations lock (err: %v)", err))
+		}
+	}()
+
+	_, err = conn.Exec(fmt.Sprintf("create table if not exists %s (version bigint not null primary key, dirty boolean not null)", versionTable))
+	if err != nil {
+		return 0, err
+	}
+
+	version, dirty, err := fetchVersion(conn)
+	if err != nil {
+		return 0, err
+	}
+
+	if dirty {
+		return 0, fmt.Errorf("%v (version: %d)", ErrDirtySchema, version)
+	}
+	if version > Latest(m.migrations) {
+		return 0, fmt.Errorf("%v (version: %d, latest: %d)", ErrUnknownVersion, version, Latest(m.migrations))
+	}
+
+	applied := 0
+
+	for _, mig := range m.migrations {
+		if mig.Version <= version {
+			continue
+		}
+
+		m.trace(logger.Info, fmt.Sprintf("Applying migration %d \"%s\"", mig.Version, mig.Name))
+
+		err = apply(conn, mig)
+		if err != nil {
+			return applied, fmt.Errorf("%v (version: %d, err: %v)", ErrMigrationFailed, mig.Version, err)
+		}
+
+		applied++
+	}
+
+	return applied, nil
+}
+
+// apply :
+// Used to apply the input migration and to update the
+// version of the schema in a single transaction.
+//
+// The `conn` defines the connection to use.
+//
+// The `mig` defines the migration to apply.
+//
+// Returns any error.
+func apply(conn *pgx.Conn, mig Migration) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rolling back a committed transaction does nothing.
+	defer tx.Rollback()
+
+	_, err = tx.Exec(mig.Up)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("delete from %s", versionTable))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("insert into %s (version, dirty) values ($1, false)", versionTable), int64(mig.Version))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// fetchVersion :
+// Used to retrieve the version of the schema from the
+// input connection.
+//
+// The `conn` defines the connection to use.
+//
+// Returns the version, whether the schema is dirty and
+// any error.
+func fetchVersion(conn *pgx.Conn) (uint, bool, error) {
+	var version int64
+	var dirty bool
+
+	err := conn.QueryRow(fmt.Sprintf("select version, dirty from %s limit 1", versionTable)).Scan(&version, &dirty)
+	if err == pgx.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return uint(version), dirty, nil
+}
+
+// Version :
+// Used to retrieve the version of the schema of the DB
+// accessed by the input proxy. A DB where no migrations
+// were applied is at version `0`.
+//
+// The `proxy` defines the DB to query.
+//
+// Returns the version of the schema, whether it is dirty
+// and any error.
+func Version(proxy db.Proxy) (uint, bool, error) {
+	query := db.QueryDesc{
+		Props: []string{
+			"version",
+			"dirty",
+		},
+		Table:    versionTable,
+		Ordering: "limit 1",
+	}
+
+	dbRes, err := proxy.FetchFromDB(query)
+	if err != nil {
+		return 0, false, err
+	}
+	defer dbRes.Close()
+
+	if dbRes.Err != nil {
+		if pgErr, ok := dbRes.Err.(pgx.PgError); ok && pgErr.Code == undefinedTable {
+			return 0, false, nil
+		}
+
+		return 0, false, dbRes.Err
+	}
+
+	var version int64
+	var dirty bool
+
+	for dbRes.Next() {
+		err = dbRes.Scan(&version, &dirty)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	return uint(version), dirty, nil
+}
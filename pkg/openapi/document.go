@@ -0,0 +1,228 @@
+package openapi
+
+import "strings"
+
+// Version :
+// Defines the version of the OpenAPI specification that
+// the documents produced by this package follow.
+const Version = "3.0.3"
+
+// Document :
+// Defines an OpenAPI document describing an API. Only
+// the subset of the specification needed to describe
+// the routes of the server is supported.
+//
+// The `OpenAPI` defines the version of the specification.
+//
+// The `Info` describes the API.
+//
+// The `Paths` defines the operations available for each
+// path of the API.
+//
+// The `Components` defines the schemas referenced by the
+// operations.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info :
+// Provides general information about an API.
+//
+// The `Title` defines the name of the API.
+//
+// The `Description` briefly describes the API.
+//
+// The `Version` defines the version of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem :
+// Defines the operations available on a single path
+// keyed by their method in lower case.
+type PathItem map[string]*Operation
+
+// Operation :
+// Describes a single operation on a path.
+//
+// The `Summary` briefly describes the operation.
+//
+// The `Tags` allows to group operations.
+//
+// The `Parameters` defines the parameters accepted by
+// the operation either in the path or in the query.
+//
+// The `RequestBody` defines the body expected by the
+// operation if any.
+//
+// The `Responses` defines the possible responses keyed
+// by their status code.
+type Operation struct {
+	Summary     string              `json:"summary"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter :
+// Describes a parameter of an operation.
+//
+// The `Name` defines the name of the parameter.
+//
+// The `In` defines the location of the parameter, one of
+// `path`, `query` or `header`.
+//
+// The `Description` briefly describes the parameter.
+//
+// The `Required` defines whether the parameter must be
+// provided. Path parameters are always required.
+//
+// The `Explode` defines whether the parameter can be
+// repeated to provide several values.
+//
+// The `Schema` defines the type of the parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody :
+// Describes the body of a request.
+//
+// The `Required` defines whether the body is mandatory.
+//
+// The `Content` defines the format of the body for each
+// accepted media type.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response :
+// Describes a response of an operation.
+//
+// The `Description` briefly describes the response.
+//
+// The `Content` defines the format of the response for
+// each media type.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType :
+// Describes the content of a body in a media type.
+//
+// The `Schema` defines the format of the content.
+//
+// The `Encoding` defines how the properties of a form
+// content are encoded keyed by their name.
+type MediaType struct {
+	Schema   *Schema             `json:"schema,omitempty"`
+	Encoding map[string]Encoding `json:"encoding,omitempty"`
+}
+
+// Encoding :
+// Describes the encoding of a property of a form.
+//
+// The `ContentType` defines the media type used to
+// encode the property.
+type Encoding struct {
+	ContentType string `json:"contentType"`
+}
+
+// Components :
+// Holds the reusable elements of a document.
+//
+// The `Schemas` defines the schemas keyed by name.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema :
+// Describes the format of a value. It is either a
+// reference to a schema defined in the components of
+// the document or an inline description.
+//
+// The `Ref` defines the reference to a schema of the
+// components.
+//
+// The `Type` defines the type of the value.
+//
+// The `Format` refines the type of the value.
+//
+// The `Description` briefly describes the value.
+//
+// The `Items` defines the format of the elements of an
+// array.
+//
+// The `Properties` defines the format of the properties
+// of an object keyed by their name.
+//
+// The `AdditionalProperties` defines the format of the
+// values of a map.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// NewDocument :
+// Creates a new document with no operations.
+//
+// The `title` defines the name of the API.
+//
+// The `description` briefly describes the API.
+//
+// The `version` defines the version of the API.
+//
+// Returns the created document.
+func NewDocument(title string, description string, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       title,
+			Description: description,
+			Version:     version,
+		},
+		Paths: make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+		},
+	}
+}
+
+// AddOperation :
+// Registers the input operation for the specified path
+// and method. Any operation previously registered for
+// the same path and method is replaced.
+//
+// The `path` defines the path of the operation.
+//
+// The `method` defines the method of the operation. It
+// is converted to lower case.
+//
+// The `op` defines the operation to register.
+func (d *Document) AddOperation(path string, method string, op *Operation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+
+	item[strings.ToLower(method)] = op
+}
@@ -0,0 +1,258 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Modeler :
+// Describes a type whose JSON representation does not
+// follow its structure, typically because it provides
+// a custom `MarshalJSON` method. Such types can define
+// a model having the same JSON representation which is
+// then used to describe them.
+//
+// The `JSONModel` returns a value whose structure is the
+// one of the JSON representation of the type.
+type Modeler interface {
+	JSONModel() interface{}
+}
+
+// timeType :
+// Defines the reflected type of a time which is always
+// marshalled as a string.
+var timeType = reflect.TypeOf(time.Time{})
+
+// modelerType :
+// Defines the reflected type of the `Modeler` interface.
+var modelerType = reflect.TypeOf((*Modeler)(nil)).Elem()
+
+// marshalerType :
+// Defines the reflected type of the `json.Marshaler`
+// interface.
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// SchemaOf :
+// Used to produce the schema describing the JSON
+// representation of the input value. Named structures
+// are registered in the components of the document
+// and referenced by the returned schema.
+//
+// The `model` defines the value to describe. Only its
+// type is relevant.
+//
+// Returns the schema describing the value.
+func (d *Document) SchemaOf(model interface{}) *Schema {
+	if model == nil {
+		return &Schema{}
+	}
+
+	return d.schemaOf(reflect.TypeOf(model))
+}
+
+// schemaOf :
+// Used to produce the schema describing the input type.
+//
+// The `t` defines the type to describe.
+//
+// Returns the schema describing the type.
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	if m, ok := modelOf(t); ok {
+		mt := reflect.TypeOf(m)
+		if mt.Kind() == reflect.Struct {
+			return d.structSchema(mt, schemaName(t))
+		}
+
+		return d.schemaOf(mt)
+	}
+
+	if implements(t, marshalerType) {
+		return &Schema{
+			Description: fmt.Sprintf("custom representation of %s", t.String()),
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return d.structSchema(t, schemaName(t))
+	}
+
+	// Interfaces and other kinds can hold any value.
+	return &Schema{}
+}
+
+// structSchema :
+// Used to produce the schema describing the structure
+// defined by the input type. Named structures are added
+// to the components of the document the first time they
+// are met and referenced afterwards, which also allows
+// to describe recursive types.
+//
+// The `t` defines the structure to describe.
+//
+// The `name` defines the name under which the structure
+// is registered in the components. If it is empty the
+// structure is described inline.
+//
+// Returns the schema describing the structure.
+func (d *Document) structSchema(t reflect.Type, name string) *Schema {
+	if name != "" {
+		ref := &Schema{Ref: "#/components/schemas/" + name}
+
+		if _, ok := d.Components.Schemas[name]; ok {
+			return ref
+		}
+
+		// Register a placeholder before describing the
+		// fields in case the type references itself.
+		s := &Schema{Type: "object"}
+		d.Components.Schemas[name] = s
+		s.Properties = d.properties(t)
+
+		return ref
+	}
+
+	return &Schema{
+		Type:       "object",
+		Properties: d.properties(t),
+	}
+}
+
+// properties :
+// Used to describe the fields of the input structure as
+// they are marshalled by the `encoding/json` package.
+// Embedded structures without a name are flattened and
+// fields that are not exported or ignored are skipped.
+//
+// The `t` defines the structure to describe.
+//
+// Returns the schema of each field keyed by its name.
+func (d *Document) properties(t reflect.Type) map[string]*Schema {
+	props := make(map[string]*Schema)
+
+	for id := 0; id < t.NumField(); id++ {
+		f := t.Field(id)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				for n, s := range d.properties(ft) {
+					if _, ok := props[n]; !ok {
+						props[n] = s
+					}
+				}
+
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		props[name] = d.schemaOf(f.Type)
+	}
+
+	return props
+}
+
+// modelOf :
+// Used to retrieve the model describing the JSON format
+// of the input type if it implements `Modeler`.
+//
+// The `t` defines the type to check.
+//
+// Returns the model and whether one was found.
+func modelOf(t reflect.Type) (interface{}, bool) {
+	if t.Kind() == reflect.Interface || !implements(t, modelerType) {
+		return nil, false
+	}
+
+	v := reflect.New(t)
+	if !t.Implements(modelerType) {
+		return v.Interface().(Modeler).JSONModel(), true
+	}
+
+	return v.Elem().Interface().(Modeler).JSONModel(), true
+}
+
+// implements :
+// Used to determine whether the input type or a pointer
+// on it implements the input interface.
+//
+// The `t` defines the type to check.
+//
+// The `i` defines the interface.
+//
+// Returns `true` if the type implements the interface.
+func implements(t reflect.Type, i reflect.Type) bool {
+	return t.Implements(i) || reflect.PtrTo(t).Implements(i)
+}
+
+// schemaName :
+// Used to build the name under which the input type is
+// registered in the components. It is qualified by the
+// name of the package to avoid collisions. Types which
+// are not exported are described inline.
+//
+// The `t` defines the type to name.
+//
+// Returns the name of the schema or an empty string if
+// the type is anonymous or not exported.
+func schemaName(t reflect.Type) string {
+	if t.Name() == "" || !unicode.IsUpper([]rune(t.Name())[0]) {
+		return ""
+	}
+
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// bucket :
+// Describes the tokens available for a single client of
+// a limiter. Tokens are refilled continuously based on
+// the time elapsed since the last update.
+//
+// The `tokens` defines the number of tokens available.
+//
+// The `updated` defines the last time the number of tokens
+// was computed.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter :
+// Defines a token bucket rate limiter handling several
+// clients. Each client is identified by a key and has
+// its own bucket which allows to absorb bursts of at
+// most `burst` requests and is refilled at `rate` tokens
+// per second.
+//
+// The `rate` defines the number of tokens added to each
+// bucket per second.
+//
+// The `burst` defines the maximum number of tokens that
+// a bucket can hold.
+//
+// The `buckets` defines the buckets of the clients keyed
+// by their identifier.
+//
+// The `swept` defines the last time the buckets of the
+// idle clients were removed.
+//
+// The `locker` protects the buckets from concurrent use.
+type Limiter struct {
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+	swept   time.Time
+	locker  sync.Mutex
+}
+
+// sweepInterval :
+// Defines the interval between two removals of the
+// buckets of idle clients.
+var sweepInterval = time.Minute
+
+// NewLimiter :
+// Creates a new limiter with the specified rate and
+// burst. Buckets of new clients start full.
+//
+// The `rate` defines the number of requests allowed
+// per second in the long run.
+//
+// The `burst` defines the maximum number of requests
+// that can be performed at once.
+//
+// Returns the created limiter.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		swept:   time.Now(),
+	}
+}
+
+// Allow :
+// Used to determine whether the client identified by
+// the input key can perform a request. If this is the
+// case a token is consumed from its bucket. Otherwise
+// the time after which a token will be available is
+// returned.
+//
+// The `key` identifies the client.
+//
+// Returns whether the request is allowed and the time
+// to wait before retrying if it is not.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens:  l.burst,
+			updated: now,
+		}
+
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens >= 1.0 {
+		b.tokens--
+		return true, 0
+	}
+
+	if l.rate <= 0.0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+
+	wait := time.Duration((1.0 - b.tokens) / l.rate * float64(time.Second))
+
+	return false, wait
+}
+
+// sweep :
+// Used to remove the buckets of the clients that have
+// been idle long enough for their bucket to be full.
+// This is performed at most once per `sweepInterval`.
+//
+// The `now` defines the current time.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < sweepInterval {
+		return
+	}
+
+	l.swept = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}